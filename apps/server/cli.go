@@ -0,0 +1,177 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/goccy/go-yaml"
+	"github.com/grpc-bridge/server/internal/config"
+	"github.com/grpc-bridge/server/internal/proto"
+)
+
+// command is a subcommand of the server binary
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// commands lists the subcommands; serve runs when none is given
+var commands = []command{
+	{"serve", "Run the bridge (default)", runServe},
+	{"check-config", "Validate the configuration and exit", runCheckConfig},
+	{"export-stdlib", "Write the bundled standard proto library to a directory", runExportStdlib},
+	{"version", "Print the version", runVersion},
+}
+
+// runCommand dispatches the command line to a subcommand and exits with its
+// status
+func runCommand(args []string) {
+	name := "serve"
+	if len(args) > 0 && (args[0] == "-version" || args[0] == "--version") {
+		name, args = "version", args[1:]
+	} else if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		printUsage(os.Stdout)
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(cmd.run(args))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	os.Exit(2)
+}
+
+// printUsage lists the subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [command] [flags]\n\nCommands:\n", programName())
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun %s <command> -h for the flags of a command.\n", programName())
+}
+
+// programName is the name the binary was invoked as
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// newFlagSet creates the flag set of a subcommand; usage follows the command
+// name in its usage line
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s %s\n", programName(), name, usage)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// configFlags adds --config and a flag for every setting to fs, and returns a
+// function loading the configuration once fs is parsed. Flags override
+// environment variables, which override the config file.
+func configFlags(fs *flag.FlagSet) func() (*config.Config, string, error) {
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file, env CONFIG_FILE")
+	overrides := config.RegisterFlags(fs)
+	return func() (*config.Config, string, error) {
+		cfg, err := config.Load(*configFile, overrides)
+		return cfg, *configFile, err
+	}
+}
+
+// runServe loads the configuration and runs the bridge
+func runServe(args []string) int {
+	fs := newFlagSet("serve", "[flags]")
+	load := configFlags(fs)
+	_ = fs.Parse(args)
+
+	// Every invalid setting is reported before the server starts
+	cfg, configFile, err := load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if configFile != "" {
+		log.Printf("Configuration file: %s", configFile)
+	}
+	serve(cfg)
+	return 0
+}
+
+// runCheckConfig validates the configuration without starting the server,
+// optionally printing the resulting settings with secrets redacted
+func runCheckConfig(args []string) int {
+	fs := newFlagSet("check-config", "[flags]")
+	load := configFlags(fs)
+	printConfig := fs.Bool("print", false, "Print the resulting configuration as YAML, with secrets redacted")
+	_ = fs.Parse(args)
+
+	cfg, configFile, err := load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		return 1
+	}
+	if *printConfig {
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode configuration: %v\n", err)
+			return 1
+		}
+		os.Stdout.Write(out)
+		return 0
+	}
+	if configFile != "" {
+		fmt.Printf("Configuration OK (%s)\n", configFile)
+	} else {
+		fmt.Println("Configuration OK")
+	}
+	return 0
+}
+
+// runExportStdlib writes the embedded standard library protos to a directory,
+// e.g. to use as an include path for protoc
+func runExportStdlib(args []string) int {
+	fs := newFlagSet("export-stdlib", "<dir>")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	dir := fs.Arg(0)
+
+	stdlib := proto.NewStdlibManager()
+	if err := stdlib.ExtractToDirectory(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export standard library: %v\n", err)
+		return 1
+	}
+	files, err := stdlib.ListAvailableFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list standard library: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Exported %d proto files to %s\n", len(files), dir)
+	return 0
+}
+
+// runVersion prints the version the binary was built with
+func runVersion(args []string) int {
+	fs := newFlagSet("version", "")
+	_ = fs.Parse(args)
+	fmt.Printf("%s %s (%s %s/%s)\n", programName(), version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return 0
+}
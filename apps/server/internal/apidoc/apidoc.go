@@ -0,0 +1,405 @@
+// Package apidoc builds the OpenAPI document of the bridge's own REST API
+// from its Gin routes and the Go types of their request and response bodies.
+package apidoc
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+)
+
+// Operation annotates a route. Body types are given as values of the type,
+// e.g. CallRequest{} or []history.Entry{}; their schemas come from the json
+// (or form) struct tags, and binding:"required" marks required fields.
+type Operation struct {
+	Summary     string // Defaults to the handler's method name in words
+	Description string
+	Session     bool     // Takes the session in the X-Session-ID header
+	Query       []Param  // Query parameters
+	Request     any      // JSON request body
+	Form        any      // multipart/form-data request body, besides the files
+	File        []string // multipart fields taking one file
+	Files       []string // multipart fields taking several files
+	Status      int      // Success status; defaults to 200
+	Response    any      // JSON response body; nil for a free-form object
+	Produces    string   // Content type of a non-JSON response, e.g. application/zip
+}
+
+// Param is a query parameter
+type Param struct {
+	Name        string
+	Type        string // string (default), integer, number or boolean
+	Required    bool
+	Description string
+}
+
+// Object describes a JSON object built ad hoc by a handler (a gin.H), one
+// value per property. Values may be Objects themselves.
+type Object map[string]any
+
+// Info identifies the API in the document
+type Info struct {
+	Title   string
+	Version string
+	Auth    bool // The server requires credentials; adds the security schemes
+}
+
+// errorSchema is the component every error response uses
+const errorSchema = "Error"
+
+// errorBody describes apierror.Body
+func errorBody() *grpc.OpenAPISchema {
+	codes := make([]string, len(apierror.Codes))
+	for i, code := range apierror.Codes {
+		codes[i] = string(code)
+	}
+	return &grpc.OpenAPISchema{
+		Type: "object",
+		Properties: map[string]*grpc.OpenAPISchema{
+			"error":      {Type: "string", Description: "Same as message; kept for older clients"},
+			"code":       {Type: "string", Enum: codes},
+			"message":    {Type: "string"},
+			"details":    {Type: "object", AdditionalProperties: &grpc.OpenAPISchema{}},
+			"request_id": {Type: "string", Description: "Echoes the X-Request-ID response header"},
+		},
+		Required: []string{"error", "code", "message"},
+	}
+}
+
+// Build describes every route, annotated or not. ops maps "METHOD /path",
+// with Gin's path syntax, to the annotation of that route.
+func Build(info Info, routes gin.RoutesInfo, ops map[string]Operation) *grpc.OpenAPIDocument {
+	doc := &grpc.OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    grpc.OpenAPIInfo{Title: info.Title, Version: info.Version},
+		Paths:   make(map[string]map[string]*grpc.OpenAPIOperation),
+		Components: grpc.OpenAPIComponents{
+			Schemas: map[string]*grpc.OpenAPISchema{
+				errorSchema: errorBody(),
+			},
+		},
+	}
+	if info.Auth {
+		doc.Components.SecuritySchemes = map[string]*grpc.OpenAPISecurityScheme{
+			"bearer": {Type: "http", Scheme: "bearer"},
+			"apiKey": {Type: "apiKey", Name: "X-API-Key", In: "header"},
+		}
+		doc.Security = []map[string][]string{{"bearer": {}}, {"apiKey": {}}}
+	}
+
+	routes = append(gin.RoutesInfo(nil), routes...)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	ids := operationIDs(routes)
+
+	s := &schemas{doc: doc}
+	for i, route := range routes {
+		path, params := openAPIPath(route.Path)
+		op := ops[route.Method+" "+route.Path]
+		tag, method := handlerName(route.Handler)
+
+		summary := op.Summary
+		if summary == "" {
+			summary = words(method)
+		}
+		operation := &grpc.OpenAPIOperation{
+			OperationID: ids[i],
+			Summary:     summary,
+			Description: op.Description,
+			Tags:        []string{tag},
+			Responses:   map[string]grpc.OpenAPIResponse{},
+		}
+		for _, name := range params {
+			operation.Parameters = append(operation.Parameters, grpc.OpenAPIParameter{
+				Name: name, In: "path", Required: true, Schema: &grpc.OpenAPISchema{Type: "string"},
+			})
+		}
+		if op.Session {
+			operation.Parameters = append(operation.Parameters, grpc.OpenAPIParameter{
+				Name: "X-Session-ID", In: "header", Required: true, Schema: &grpc.OpenAPISchema{Type: "string"},
+			})
+		}
+		for _, q := range op.Query {
+			typ := q.Type
+			if typ == "" {
+				typ = "string"
+			}
+			operation.Parameters = append(operation.Parameters, grpc.OpenAPIParameter{
+				Name: q.Name, In: "query", Required: q.Required, Description: q.Description,
+				Schema: &grpc.OpenAPISchema{Type: typ},
+			})
+		}
+
+		switch {
+		case op.Request != nil:
+			operation.RequestBody = &grpc.OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]grpc.OpenAPIMediaType{"application/json": {Schema: s.of(op.Request, "json")}},
+			}
+		case op.Form != nil || len(op.File) > 0 || len(op.Files) > 0:
+			form := &grpc.OpenAPISchema{Type: "object", Properties: map[string]*grpc.OpenAPISchema{}}
+			if op.Form != nil {
+				form = s.of(op.Form, "form")
+			}
+			for _, name := range op.File {
+				form.Properties[name] = &grpc.OpenAPISchema{Type: "string", Format: "binary"}
+			}
+			for _, name := range op.Files {
+				form.Properties[name] = &grpc.OpenAPISchema{Type: "array", Items: &grpc.OpenAPISchema{Type: "string", Format: "binary"}}
+			}
+			operation.RequestBody = &grpc.OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]grpc.OpenAPIMediaType{"multipart/form-data": {Schema: form}},
+			}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := grpc.OpenAPIResponse{Description: http.StatusText(status)}
+		switch {
+		case op.Produces != "":
+			success.Content = map[string]grpc.OpenAPIMediaType{
+				op.Produces: {Schema: &grpc.OpenAPISchema{Type: "string", Format: "binary"}},
+			}
+		case op.Response != nil:
+			success.Content = map[string]grpc.OpenAPIMediaType{"application/json": {Schema: s.of(op.Response, "json")}}
+		default:
+			success.Content = map[string]grpc.OpenAPIMediaType{"application/json": {Schema: &grpc.OpenAPISchema{Type: "object"}}}
+		}
+		operation.Responses[strconv.Itoa(status)] = success
+		operation.Responses["default"] = grpc.OpenAPIResponse{
+			Description: "Error",
+			Content: map[string]grpc.OpenAPIMediaType{
+				"application/json": {Schema: &grpc.OpenAPISchema{Ref: "#/components/schemas/" + errorSchema}},
+			},
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*grpc.OpenAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+	return doc
+}
+
+// operationIDs names the operation of every route after its handler method,
+// prefixed with the handler when several handlers share a method name, and
+// numbered when one handler serves several routes
+func operationIDs(routes gin.RoutesInfo) []string {
+	base := make([]string, len(routes))
+	methods := make(map[string]map[string]bool)
+	for i, route := range routes {
+		tag, method := handlerName(route.Handler)
+		base[i] = method
+		if methods[method] == nil {
+			methods[method] = make(map[string]bool)
+		}
+		methods[method][tag] = true
+	}
+	for i, route := range routes {
+		if tag, method := handlerName(route.Handler); len(methods[method]) > 1 {
+			base[i] = tag + method
+		}
+	}
+
+	ids := make([]string, len(routes))
+	seen := make(map[string]int)
+	for i, name := range base {
+		id := lowerFirst(name)
+		if seen[id]++; seen[id] > 1 {
+			id += strconv.Itoa(seen[id])
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+// handlerName splits a handler's function name, such as
+// "github.com/grpc-bridge/server/internal/handler.(*HistoryHandler).ListHistory-fm",
+// into a tag ("History") and a method ("ListHistory")
+func handlerName(fn string) (tag, method string) {
+	fn = strings.TrimSuffix(fn, "-fm")
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	parts := strings.Split(fn, ".")
+	method = parts[len(parts)-1]
+	tag = "Misc"
+	if len(parts) >= 3 {
+		tag = strings.TrimSuffix(strings.Trim(parts[len(parts)-2], "(*)"), "Handler")
+	}
+	return tag, method
+}
+
+// openAPIPath converts a Gin path to an OpenAPI path and returns its
+// parameters, e.g. "/sessions/:sessionId" becomes "/sessions/{sessionId}"
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "" && (segment[0] == ':' || segment[0] == '*') {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// words spells out a method name: "ListHistory" becomes "List history"
+func words(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte(' ')
+			if i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				r = unicode.ToLower(r)
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lowerFirst lowercases the leading capitals of a name: "GRPCCall" becomes "grpcCall"
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) || i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+	objectType  = reflect.TypeOf(Object{})
+)
+
+// schemas converts Go types to schemas, collecting named structs under
+// components.schemas
+type schemas struct {
+	doc *grpc.OpenAPIDocument
+}
+
+// of returns the schema of a value: an Object, or any other value standing
+// for its type
+func (s *schemas) of(v any, tag string) *grpc.OpenAPISchema {
+	if obj, ok := v.(Object); ok {
+		schema := &grpc.OpenAPISchema{Type: "object", Properties: make(map[string]*grpc.OpenAPISchema, len(obj))}
+		for name, value := range obj {
+			if value == nil {
+				schema.Properties[name] = &grpc.OpenAPISchema{}
+				continue
+			}
+			schema.Properties[name] = s.of(value, tag)
+		}
+		return schema
+	}
+	return s.typ(reflect.TypeOf(v), tag)
+}
+
+// typ returns the schema of t, a reference for named structs
+func (s *schemas) typ(t reflect.Type, tag string) *grpc.OpenAPISchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &grpc.OpenAPISchema{Type: "string", Format: "date-time"}
+	case t == rawJSONType, t == objectType:
+		return &grpc.OpenAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &grpc.OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &grpc.OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &grpc.OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &grpc.OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &grpc.OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &grpc.OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &grpc.OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &grpc.OpenAPISchema{Type: "array", Items: s.typ(t.Elem(), tag)}
+	case reflect.Map:
+		return &grpc.OpenAPISchema{Type: "object", AdditionalProperties: s.typ(t.Elem(), tag)}
+	case reflect.Struct:
+		if t.Name() == "" || tag != "json" {
+			return s.inline(t, tag)
+		}
+		name := t.Name()
+		if pkg := t.PkgPath(); pkg != "" {
+			name = pkg[strings.LastIndexByte(pkg, '/')+1:] + "." + name
+		}
+		if _, ok := s.doc.Components.Schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate
+			s.doc.Components.Schemas[name] = &grpc.OpenAPISchema{}
+			*s.doc.Components.Schemas[name] = *s.inline(t, tag)
+		}
+		return &grpc.OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	// Interfaces and anything else can hold any JSON value
+	return &grpc.OpenAPISchema{}
+}
+
+// inline returns the object schema of a struct's fields, named by the json
+// or form tag. Embedded structs without a name contribute their fields.
+func (s *schemas) inline(t reflect.Type, tag string) *grpc.OpenAPISchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	schema := &grpc.OpenAPISchema{Type: "object", Properties: map[string]*grpc.OpenAPISchema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if name == "" && field.Anonymous {
+			embedded := s.inline(field.Type, tag)
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			if tag == "form" {
+				continue
+			}
+			name = field.Name
+		}
+		schema.Properties[name] = s.typ(field.Type, tag)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
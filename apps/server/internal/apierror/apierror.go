@@ -0,0 +1,212 @@
+// Package apierror defines the error envelope returned by every REST endpoint
+// and the stable, machine-readable codes carried by it and by WebSocket error
+// events. Clients should branch on the code; messages are for humans and may
+// change.
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code identifies a class of error. Codes are part of the API and never change
+// meaning once released.
+type Code string
+
+const (
+	// Request problems
+	InvalidRequest       Code = "INVALID_REQUEST"        // Malformed body, query or path parameter
+	ValidationFailed     Code = "VALIDATION_FAILED"      // Well-formed request with invalid field values
+	SessionRequired      Code = "SESSION_REQUIRED"       // No session ID in the request
+	PayloadTooLarge      Code = "PAYLOAD_TOO_LARGE"      // Body, file or archive over a size limit
+	UnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE" // Wrong Content-Type
+	NoProtoFiles         Code = "NO_PROTO_FILES"         // The session has no protos to work with
+	TemplateError        Code = "TEMPLATE_ERROR"         // A {{var}} placeholder could not be resolved
+
+	// Missing resources
+	NotFound        Code = "NOT_FOUND"
+	SessionNotFound Code = "SESSION_NOT_FOUND"
+	ServiceNotFound Code = "SERVICE_NOT_FOUND"
+	MethodNotFound  Code = "METHOD_NOT_FOUND"
+	MessageNotFound Code = "MESSAGE_NOT_FOUND"
+
+	// Access
+	Unauthenticated  Code = "UNAUTHENTICATED"
+	PermissionDenied Code = "PERMISSION_DENIED"
+
+	// State and limits
+	Conflict        Code = "CONFLICT"
+	RateLimited     Code = "RATE_LIMITED"
+	QuotaExceeded   Code = "QUOTA_EXCEEDED"
+	FeatureDisabled Code = "FEATURE_DISABLED" // The server is not configured for this
+
+	// Protos and targets
+	ParseError         Code = "PARSE_ERROR"         // Protos or descriptors failed to compile or decode
+	TargetUnreachable  Code = "TARGET_UNREACHABLE"  // The gRPC target could not be dialed or reflected
+	TargetDenied       Code = "TARGET_DENIED"       // The target policy rejects the target
+	PlaintextForbidden Code = "PLAINTEXT_FORBIDDEN" // Plaintext connections are disabled
+	UpstreamFailed     Code = "UPSTREAM_FAILED"     // Another external dependency (git, OIDC provider) failed
+
+	// Call outcomes (grpc://error)
+	Timeout      Code = "TIMEOUT"
+	Unavailable  Code = "UNAVAILABLE"
+	CallFailed   Code = "CALL_FAILED" // The target answered with an error status
+	ScriptFailed Code = "SCRIPT_FAILED"
+
+	Internal Code = "INTERNAL"
+)
+
+// Codes lists every code, for API documentation
+var Codes = []Code{
+	InvalidRequest, ValidationFailed, SessionRequired, PayloadTooLarge, UnsupportedMediaType, NoProtoFiles, TemplateError,
+	NotFound, SessionNotFound, ServiceNotFound, MethodNotFound, MessageNotFound,
+	Unauthenticated, PermissionDenied,
+	Conflict, RateLimited, QuotaExceeded, FeatureDisabled,
+	ParseError, TargetUnreachable, TargetDenied, PlaintextForbidden, UpstreamFailed,
+	Timeout, Unavailable, CallFailed, ScriptFailed,
+	Internal,
+}
+
+// Body is the JSON body of an error response. Error repeats Message for
+// clients written against the earlier {"error": "..."} responses.
+type Body struct {
+	Error     string         `json:"error"`
+	Code      Code           `json:"code"`
+	Message   string         `json:"message"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+// New builds the error body for the request
+func New(c *gin.Context, code Code, message string, details map[string]any) Body {
+	return Body{
+		Error:     message,
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: RequestID(c),
+	}
+}
+
+// Respond writes an error response
+func Respond(c *gin.Context, status int, code Code, message string) {
+	c.JSON(status, New(c, code, message, nil))
+}
+
+// RespondDetails writes an error response with structured details
+func RespondDetails(c *gin.Context, status int, code Code, message string, details map[string]any) {
+	c.JSON(status, New(c, code, message, details))
+}
+
+// Abort writes an error response and stops the handler chain; for middleware
+func Abort(c *gin.Context, status int, code Code, message string, details map[string]any) {
+	c.AbortWithStatusJSON(status, New(c, code, message, details))
+}
+
+// FromStatus returns the default code for an HTTP error status
+func FromStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return InvalidRequest
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return PermissionDenied
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusRequestEntityTooLarge:
+		return PayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return UnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return ValidationFailed
+	case http.StatusTooManyRequests:
+		return RateLimited
+	case http.StatusNotImplemented:
+		return FeatureDisabled
+	case http.StatusBadGateway:
+		return UpstreamFailed
+	case http.StatusServiceUnavailable:
+		return Unavailable
+	case http.StatusGatewayTimeout:
+		return Timeout
+	default:
+		return Internal
+	}
+}
+
+// Error is an error that carries its code and details, for paths (such as
+// WebSocket commands) that report errors away from the code that raised them
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]any
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError returns an error with the given code. Wrapping it with %w keeps
+// the code visible to CodeOf.
+func NewError(code Code, message string) error {
+	return &Error{Code: code, Message: message}
+}
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"` // JSON path, e.g. "steps[0].service"
+	Rule    string `json:"rule"`  // The check that failed: required, type, grpc_target, ...
+	Message string `json:"message"`
+}
+
+// Invalid returns a VALIDATION_FAILED error listing the invalid fields under
+// details.fields
+func Invalid(fields ...FieldError) error {
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Message
+	}
+	return &Error{
+		Code:    ValidationFailed,
+		Message: "invalid request: " + strings.Join(messages, "; "),
+		Details: map[string]any{"fields": fields},
+	}
+}
+
+// RespondError writes an error response for err, with the code and details it
+// carries or fallback when it has none
+func RespondError(c *gin.Context, status int, err error, fallback Code) {
+	var e *Error
+	if errors.As(err, &e) {
+		c.JSON(status, New(c, e.Code, err.Error(), e.Details))
+		return
+	}
+	c.JSON(status, New(c, fallback, err.Error(), nil))
+}
+
+// CodeOf returns the code carried by err, or fallback when it has none
+func CodeOf(err error, fallback Code) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return fallback
+}
+
+const requestIDKey = "apierror.request_id"
+
+// SetRequestID records the request's ID so error bodies can echo it
+func SetRequestID(c *gin.Context, id string) {
+	c.Set(requestIDKey, id)
+}
+
+// RequestID returns the ID recorded by SetRequestID, or ""
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
@@ -0,0 +1,238 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Limits guarding against zip bombs and oversized archives
+const (
+	MaxArchiveSize int64 = 100 << 20 // 100MB compressed upload
+	MaxEntries           = 10000
+	MaxFileSize    int64 = 10 << 20  // 10MB per extracted file
+	MaxTotalSize   int64 = 200 << 20 // 200MB uncompressed in total
+)
+
+// Format identifies a supported archive container
+type Format string
+
+const (
+	FormatZip   Format = "zip"
+	FormatTarGz Format = "tar.gz"
+)
+
+// ArchiveError reports a malformed or disallowed archive
+type ArchiveError struct {
+	msg string
+}
+
+func (e *ArchiveError) Error() string {
+	return e.msg
+}
+
+var (
+	ErrUnsupportedFormat = &ArchiveError{"unsupported archive format (expected .zip, .tar.gz or .tgz)"}
+	ErrTooManyEntries    = &ArchiveError{"archive contains too many entries"}
+	ErrTooLarge          = &ArchiveError{"archive exceeds the maximum uncompressed size"}
+)
+
+// File is a single proto file extracted from an archive
+type File struct {
+	Path    string // sanitized, slash-separated relative path
+	Content []byte
+}
+
+// Result describes the outcome of an extraction
+type Result struct {
+	Files          []File
+	Skipped        []string // entries ignored because they are not .proto files
+	Rejected       []string // entries refused because their path is unsafe
+	StrippedPrefix string   // common top-level directory removed from all paths
+}
+
+// DetectFormat guesses the archive format from the filename, falling back to magic bytes
+func DetectFormat(filename string, head []byte) (Format, error) {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return FormatTarGz, nil
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return FormatZip, nil
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return FormatTarGz, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Extract reads a zip or tar.gz archive and returns its .proto files.
+// Nothing is written to disk; callers store the returned files themselves.
+func Extract(format Format, r io.ReaderAt, size int64) (*Result, error) {
+	var (
+		res *Result
+		err error
+	)
+	switch format {
+	case FormatZip:
+		res, err = extractZip(r, size)
+	case FormatTarGz:
+		res, err = extractTarGz(io.NewSectionReader(r, 0, size))
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	stripCommonPrefix(res)
+	return res, nil
+}
+
+// collector applies the entry/size limits and path checks shared by both formats
+type collector struct {
+	res     *Result
+	entries int
+	total   int64
+}
+
+func (c *collector) add(name string, isRegular bool, declared int64, open func() (io.Reader, error)) error {
+	c.entries++
+	if c.entries > MaxEntries {
+		return ErrTooManyEntries
+	}
+	if !isRegular {
+		return nil
+	}
+
+	clean, ok := SanitizePath(name)
+	if !ok {
+		c.res.Rejected = append(c.res.Rejected, name)
+		return nil
+	}
+	if !strings.HasSuffix(strings.ToLower(clean), ".proto") {
+		c.res.Skipped = append(c.res.Skipped, clean)
+		return nil
+	}
+	if declared > MaxFileSize {
+		return &ArchiveError{fmt.Sprintf("file %s exceeds the maximum size of %d bytes", clean, MaxFileSize)}
+	}
+
+	src, err := open()
+	if err != nil {
+		return &ArchiveError{fmt.Sprintf("failed to read %s: %v", clean, err)}
+	}
+	// Never trust the declared size: read at most one byte past the limit
+	content, err := io.ReadAll(io.LimitReader(src, MaxFileSize+1))
+	if closer, ok := src.(io.Closer); ok {
+		closer.Close()
+	}
+	if err != nil {
+		return &ArchiveError{fmt.Sprintf("failed to read %s: %v", clean, err)}
+	}
+	if int64(len(content)) > MaxFileSize {
+		return &ArchiveError{fmt.Sprintf("file %s exceeds the maximum size of %d bytes", clean, MaxFileSize)}
+	}
+	c.total += int64(len(content))
+	if c.total > MaxTotalSize {
+		return ErrTooLarge
+	}
+
+	c.res.Files = append(c.res.Files, File{Path: clean, Content: content})
+	return nil
+}
+
+func extractZip(r io.ReaderAt, size int64) (*Result, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, &ArchiveError{"invalid zip archive: " + err.Error()}
+	}
+	c := &collector{res: &Result{}}
+	for _, f := range zr.File {
+		f := f
+		if err := c.add(f.Name, f.Mode().IsRegular(), int64(f.UncompressedSize64), func() (io.Reader, error) {
+			return f.Open()
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return c.res, nil
+}
+
+func extractTarGz(r io.Reader) (*Result, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, &ArchiveError{"invalid gzip stream: " + err.Error()}
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	c := &collector{res: &Result{}}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &ArchiveError{"invalid tar archive: " + err.Error()}
+		}
+		if err := c.add(hdr.Name, hdr.Typeflag == tar.TypeReg, hdr.Size, func() (io.Reader, error) {
+			return tr, nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return c.res, nil
+}
+
+// SanitizePath normalizes an archive entry name into a safe relative path.
+// Absolute paths, drive letters, parent-directory traversal and NUL bytes are
+// rejected.
+func SanitizePath(name string) (string, bool) {
+	p := strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(p, "/") || (len(p) >= 2 && p[1] == ':') || strings.ContainsRune(p, 0) {
+		return "", false
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == ".." {
+			return "", false
+		}
+	}
+	p = path.Clean(p)
+	if p == "." || p == "" || strings.HasPrefix(p, "../") {
+		return "", false
+	}
+	return p, true
+}
+
+// stripCommonPrefix removes a single top-level directory shared by every
+// extracted file, mirroring how directory uploads drop the chosen root folder.
+func stripCommonPrefix(res *Result) {
+	if len(res.Files) == 0 {
+		return
+	}
+	prefix := ""
+	for i, f := range res.Files {
+		idx := strings.Index(f.Path, "/")
+		if idx < 0 {
+			return
+		}
+		top := f.Path[:idx]
+		if i == 0 {
+			prefix = top
+		} else if top != prefix {
+			return
+		}
+	}
+	for i := range res.Files {
+		res.Files[i].Path = strings.TrimPrefix(res.Files[i].Path, prefix+"/")
+	}
+	res.StrippedPrefix = prefix
+}
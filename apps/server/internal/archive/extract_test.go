@@ -0,0 +1,40 @@
+package archive
+
+import "testing"
+
+func TestSanitizePath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{name: "a.proto", want: "a.proto", ok: true},
+		{name: "api/v1/a.proto", want: "api/v1/a.proto", ok: true},
+		{name: "./api//v1/./a.proto", want: "api/v1/a.proto", ok: true},
+		{name: `api\v1\a.proto`, want: "api/v1/a.proto", ok: true},
+		{name: "api/..a.proto", want: "api/..a.proto", ok: true},
+		{name: "../a.proto"},
+		{name: "api/../../a.proto"},
+		{name: "api/../a.proto"},
+		{name: ".."},
+		{name: `..\a.proto`},
+		{name: `api\..\..\a.proto`},
+		{name: "/etc/passwd"},
+		{name: `\\server\share\a.proto`},
+		{name: `\a.proto`},
+		{name: "C:/a.proto"},
+		{name: `C:\a.proto`},
+		{name: "c:a.proto"},
+		{name: "a.proto\x00.txt"},
+		{name: "api/\x00/a.proto"},
+		{name: ""},
+		{name: "."},
+		{name: "./"},
+	}
+	for _, tt := range tests {
+		got, ok := SanitizePath(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("SanitizePath(%q) = %q, %t, want %q, %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package audit
+
+import "github.com/gin-gonic/gin"
+
+// Context keys for request-scoped audit information set by handlers
+const (
+	detailsKey = "audit.details"
+	sessionKey = "audit.session"
+)
+
+// AddDetail attaches a detail (e.g. the called method) to the audit entry of
+// the current request
+func AddDetail(c *gin.Context, key, value string) {
+	if value == "" {
+		return
+	}
+	details, _ := c.Get(detailsKey)
+	m, _ := details.(map[string]string)
+	if m == nil {
+		m = map[string]string{}
+		c.Set(detailsKey, m)
+	}
+	m[key] = value
+}
+
+// Details returns the details attached to the current request
+func Details(c *gin.Context) map[string]string {
+	details, _ := c.Get(detailsKey)
+	m, _ := details.(map[string]string)
+	return m
+}
+
+// SetSession records the session a request created, for requests that do not
+// name their session up front
+func SetSession(c *gin.Context, sessionID string) {
+	c.Set(sessionKey, sessionID)
+}
+
+// Session returns the session set with SetSession, if any
+func Session(c *gin.Context) string {
+	return c.GetString(sessionKey)
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actions recorded in the audit trail
+const (
+	ActionCreate = "create" // Session created, imported or restored
+	ActionUpload = "upload" // Protos or descriptor sets added
+	ActionEdit   = "edit"   // Session contents or settings changed
+	ActionDelete = "delete" // Something removed from the session, or the session itself
+	ActionCall   = "call"   // gRPC call, replay, chain or load test executed
+)
+
+// Entry is a single recorded operation on a session
+type Entry struct {
+	ID        string            `json:"id"`
+	SessionID string            `json:"session_id"`
+	CreatedAt time.Time         `json:"created_at"`
+	Action    string            `json:"action"`
+	Method    string            `json:"method"` // HTTP method
+	Route     string            `json:"route"`  // Matched route pattern (e.g. /api/sessions/:sessionId/file-content)
+	Status    int               `json:"status"` // HTTP response status
+	User      string            `json:"user,omitempty"`
+	IP        string            `json:"ip"`
+	Details   map[string]string `json:"details,omitempty"` // Route parameters and handler-provided details
+}
+
+// Filter narrows down audit queries. Empty fields match everything.
+type Filter struct {
+	Action string
+	User   string
+	Offset int
+	Limit  int
+}
+
+// Matches reports whether an entry satisfies the filter criteria (ignores pagination)
+func (f Filter) Matches(e *Entry) bool {
+	if f.Action != "" && e.Action != f.Action {
+		return false
+	}
+	if f.User != "" && e.User != f.User {
+		return false
+	}
+	return true
+}
+
+// Store persists the audit trail per session
+type Store interface {
+	// Add records an entry, assigning ID and timestamp when empty
+	Add(entry *Entry) (*Entry, error)
+	// List returns entries newest first along with the total count matching the filter
+	List(sessionID string, filter Filter) ([]*Entry, int, error)
+	// Clear removes all entries of a session
+	Clear(sessionID string) error
+}
+
+// MemoryStore keeps the audit trail in memory, bounded per session
+type MemoryStore struct {
+	entries    map[string][]*Entry // sessionID -> entries (oldest first)
+	maxEntries int
+	mu         sync.RWMutex
+}
+
+// NewMemoryStore creates an in-memory store keeping at most maxEntries per session
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryStore{
+		entries:    make(map[string][]*Entry),
+		maxEntries: maxEntries,
+	}
+}
+
+// Add records an entry, evicting the oldest ones past the per-session limit
+func (s *MemoryStore) Add(entry *Entry) (*Entry, error) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := append(s.entries[entry.SessionID], entry)
+	if len(list) > s.maxEntries {
+		list = list[len(list)-s.maxEntries:]
+	}
+	s.entries[entry.SessionID] = list
+	return entry, nil
+}
+
+// List returns matching entries newest first
+func (s *MemoryStore) List(sessionID string, filter Filter) ([]*Entry, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := []*Entry{}
+	for _, e := range s.entries[sessionID] {
+		if filter.Matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	offset, end := filter.Offset, len(matched)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(matched) {
+		return []*Entry{}, len(matched), nil
+	}
+	if filter.Limit > 0 && offset+filter.Limit < end {
+		end = offset + filter.Limit
+	}
+	return matched[offset:end], len(matched), nil
+}
+
+// Clear removes all entries of a session
+func (s *MemoryStore) Clear(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, sessionID)
+	return nil
+}
@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mode selects how API requests are authenticated
+type Mode string
+
+const (
+	ModeNone  Mode = "none"  // Every request is anonymous; sessions have no owner
+	ModeBasic Mode = "basic" // HTTP Basic credentials checked against a user list
+	ModeToken Mode = "token" // Bearer tokens mapped to user names
+	ModeOIDC  Mode = "oidc"  // Browser login through an OpenID Connect provider, or its ID tokens as bearer tokens
+)
+
+// userKey is the gin context key holding the authenticated user name
+const userKey = "auth.user"
+
+// APIKeyHeader carries API keys for programmatic access
+const APIKeyHeader = "X-API-Key"
+
+// Realm is announced in WWW-Authenticate challenges
+const Realm = "grpc-bridge"
+
+// Authenticator resolves the user behind a request
+type Authenticator struct {
+	mode   Mode
+	users  map[string]string // user name -> password (basic)
+	tokens map[string]string // token -> user name (token)
+
+	apiKeys map[string]string // API key -> user name ("" for anonymous keys)
+
+	oidc     *OIDCProvider // OIDC mode
+	cookies  *CookieSigner
+	loginTTL time.Duration
+
+	urls *URLSigner // Signed download URLs; nil disables them
+}
+
+// New creates an authenticator. Basic mode needs at least one user and token
+// mode at least one token; OIDC mode needs a provider set with SetOIDC.
+func New(mode Mode, users, tokens map[string]string) (*Authenticator, error) {
+	switch mode {
+	case "", ModeNone:
+		return &Authenticator{mode: ModeNone}, nil
+	case ModeBasic:
+		if len(users) == 0 {
+			return nil, fmt.Errorf("basic auth requires at least one user")
+		}
+	case ModeToken:
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("token auth requires at least one token")
+		}
+	case ModeOIDC:
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q (expected none, basic, token or oidc)", mode)
+	}
+	return &Authenticator{mode: mode, users: users, tokens: tokens}, nil
+}
+
+// Mode returns the configured authentication mode
+func (a *Authenticator) Mode() Mode {
+	return a.mode
+}
+
+// SetAPIKeys configures keys accepted in the X-API-Key header in addition to
+// the mode's credentials. Once keys are set, requests must be authenticated
+// even in ModeNone.
+func (a *Authenticator) SetAPIKeys(keys map[string]string) {
+	a.apiKeys = keys
+}
+
+// SetOIDC configures the provider for OIDC mode. Signed-in browsers get a
+// login cookie signed by cookies and valid for ttl.
+func (a *Authenticator) SetOIDC(provider *OIDCProvider, cookies *CookieSigner, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultOIDCSessionTTL
+	}
+	a.oidc = provider
+	a.cookies = cookies
+	a.loginTTL = ttl
+}
+
+// OIDC returns the OIDC provider, or nil outside OIDC mode
+func (a *Authenticator) OIDC() *OIDCProvider {
+	if a.mode != ModeOIDC {
+		return nil
+	}
+	return a.oidc
+}
+
+// Cookies returns the signer of OIDC login and state cookies
+func (a *Authenticator) Cookies() *CookieSigner {
+	return a.cookies
+}
+
+// SetURLSigner accepts URLs signed by urls in place of credentials
+func (a *Authenticator) SetURLSigner(urls *URLSigner) {
+	a.urls = urls
+}
+
+// URLSigner returns the signer of download URLs, or nil
+func (a *Authenticator) URLSigner() *URLSigner {
+	return a.urls
+}
+
+// SignedURL reports whether r carries a valid URL signature and returns the
+// user it was signed for
+func (a *Authenticator) SignedURL(r *http.Request) (string, bool) {
+	if a.urls == nil {
+		return "", false
+	}
+	return a.urls.Verify(r)
+}
+
+// Login sets the login cookie of a browser that signed in as id
+func (a *Authenticator) Login(w http.ResponseWriter, r *http.Request, id *Identity) error {
+	value, err := a.cookies.Seal(id, a.loginTTL)
+	if err != nil {
+		return err
+	}
+	SetCookie(w, r, LoginCookie, value, "/", int(a.loginTTL.Seconds()))
+	return nil
+}
+
+// Logout clears the login cookie
+func (a *Authenticator) Logout(w http.ResponseWriter, r *http.Request) {
+	SetCookie(w, r, LoginCookie, "", "/", -1)
+}
+
+// Identity returns the OIDC identity behind a request: its login cookie or an
+// ID token sent as "Authorization: Bearer <token>"
+func (a *Authenticator) Identity(r *http.Request) (*Identity, bool) {
+	if a.oidc == nil {
+		return nil, false
+	}
+	if cookie, err := r.Cookie(LoginCookie); err == nil {
+		var id Identity
+		if a.cookies.Open(cookie.Value, &id) && id.User != "" {
+			return &id, true
+		}
+	}
+	if token := bearerToken(r); token != "" {
+		if id, err := a.oidc.VerifyIdentity(r.Context(), token); err == nil {
+			return id, true
+		}
+	}
+	return nil, false
+}
+
+// ValidAPIKey reports whether key is one of the configured API keys
+func (a *Authenticator) ValidAPIKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	_, ok := lookup(a.apiKeys, key)
+	return ok
+}
+
+// Enabled reports whether requests must be authenticated
+func (a *Authenticator) Enabled() bool {
+	return a.mode != ModeNone || len(a.apiKeys) > 0
+}
+
+// Authenticate returns the user making the request. A request carrying an API
+// key is judged on the key alone. Token mode accepts "Authorization: Bearer
+// <token>" and, for WebSocket upgrades where browsers cannot set headers, an
+// access_token query parameter.
+func (a *Authenticator) Authenticate(r *http.Request) (string, bool) {
+	if key := r.Header.Get(APIKeyHeader); key != "" && len(a.apiKeys) > 0 {
+		return lookup(a.apiKeys, key)
+	}
+
+	switch a.mode {
+	case ModeBasic:
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			return "", false
+		}
+		expected, exists := a.users[user]
+		if !exists || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+			return "", false
+		}
+		return user, true
+	case ModeToken:
+		token := bearerToken(r)
+		if token == "" {
+			token = r.URL.Query().Get("access_token")
+		}
+		if token == "" {
+			return "", false
+		}
+		return lookup(a.tokens, token)
+	case ModeOIDC:
+		id, ok := a.Identity(r)
+		if !ok {
+			return "", false
+		}
+		return id.User, true
+	default:
+		// Only reached with API keys configured but none presented
+		return "", !a.Enabled()
+	}
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// lookup finds the user of a secret, comparing in constant time
+func lookup(secrets map[string]string, secret string) (string, bool) {
+	for candidate, user := range secrets {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(candidate)) == 1 {
+			return user, true
+		}
+	}
+	return "", false
+}
+
+// Challenge returns the WWW-Authenticate header value for rejected requests,
+// or "" when only API keys or OIDC logins are accepted
+func (a *Authenticator) Challenge() string {
+	switch a.mode {
+	case ModeBasic:
+		return fmt.Sprintf("Basic realm=%q", Realm)
+	case ModeToken:
+		return fmt.Sprintf("Bearer realm=%q", Realm)
+	default:
+		return ""
+	}
+}
+
+// ParseAPIKeys parses a comma-separated list of API keys, each optionally bound
+// to a user as "key=user"
+func ParseAPIKeys(spec string) map[string]string {
+	keys := map[string]string{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, user, _ := strings.Cut(entry, "=")
+		if key = strings.TrimSpace(key); key != "" {
+			keys[key] = strings.TrimSpace(user)
+		}
+	}
+	return keys
+}
+
+// ParseCredentials parses a comma-separated list of key/value pairs joined by
+// sep, e.g. "alice:secret,bob:hunter2" with sep ":"
+func ParseCredentials(spec, sep string) (map[string]string, error) {
+	creds := map[string]string{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, found := strings.Cut(entry, sep)
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found || key == "" || value == "" {
+			return nil, fmt.Errorf("invalid entry %q (expected key%svalue)", entry, sep)
+		}
+		creds[key] = value
+	}
+	return creds, nil
+}
+
+// SetUser records the authenticated user on the request context. Anonymous
+// API keys are recorded with an empty user.
+func SetUser(c *gin.Context, user string) {
+	c.Set(userKey, user)
+}
+
+// User returns the authenticated user of the request, or "" when auth is
+// disabled or the request used an anonymous API key
+func User(c *gin.Context) string {
+	return c.GetString(userKey)
+}
+
+// Authenticated reports whether the request passed authentication
+func Authenticated(c *gin.Context) bool {
+	_, ok := c.Get(userKey)
+	return ok
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cookies used by OIDC login
+const (
+	LoginCookie = "grpc_bridge_login" // Signed Identity of a signed-in browser
+	StateCookie = "grpc_bridge_oidc"  // Signed LoginState while the provider round trip is in progress
+)
+
+// OIDC login routes, served outside /api
+const (
+	LoginPath    = "/auth/login"
+	CallbackPath = "/auth/callback"
+	LogoutPath   = "/auth/logout"
+)
+
+// LoginState is what the callback needs to finish a login started by a redirect
+type LoginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to"`
+}
+
+// CookieSigner seals values into tamper-proof, expiring cookie values:
+// "<base64 JSON>.<HMAC>". The value is signed, not encrypted, so it must not
+// hold secrets beyond what the browser may see.
+type CookieSigner struct {
+	secret []byte
+}
+
+// NewCookieSigner creates a signer. With an empty secret a random one is
+// generated, and cookies do not survive a restart.
+func NewCookieSigner(secret []byte) *CookieSigner {
+	if len(secret) == 0 {
+		secret = []byte(RandomString(32))
+	}
+	return &CookieSigner{secret: secret}
+}
+
+// sealed wraps a payload with its expiry
+type sealed struct {
+	Exp     int64           `json:"exp"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Seal encodes v into a cookie value valid for ttl
+func (s *CookieSigner) Seal(v any, ttl time.Duration) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(sealed{Exp: time.Now().Add(ttl).Unix(), Payload: payload})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + s.sign(encoded), nil
+}
+
+// Open decodes a cookie value sealed by Seal into v. It reports false for
+// tampered or expired values.
+func (s *CookieSigner) Open(value string, v any) bool {
+	encoded, sig, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(s.sign(encoded))) {
+		return false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	var env sealed
+	if json.Unmarshal(data, &env) != nil || time.Now().Unix() >= env.Exp {
+		return false
+	}
+	return json.Unmarshal(env.Payload, v) == nil
+}
+
+func (s *CookieSigner) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SetCookie writes an HttpOnly cookie, Secure when the request arrived over
+// HTTPS (directly or through a proxy). A negative maxAge deletes it.
+func SetCookie(w http.ResponseWriter, r *http.Request, name, value, path string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,446 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDC defaults; see OIDCConfig
+const (
+	DefaultOIDCScopes     = "openid email profile"
+	DefaultOIDCUserClaim  = "email"
+	DefaultOIDCSessionTTL = 12 * time.Hour
+)
+
+// oidcClockSkew is tolerated when checking token expiry
+const oidcClockSkew = time.Minute
+
+// jwksRefreshInterval bounds how often unknown key IDs trigger a JWKS refetch
+const jwksRefreshInterval = time.Minute
+
+// OIDCConfig configures login through an OpenID Connect provider using the
+// authorization code flow
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // This server's callback, e.g. https://bridge.example.com/auth/callback
+	Scopes       []string // Defaults to DefaultOIDCScopes
+	UserClaim    string   // ID token claim used as the user name; defaults to email, falling back to sub
+}
+
+// Identity is a user signed in through OIDC
+type Identity struct {
+	User  string `json:"user"`
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// OIDCProvider talks to an OpenID Connect provider: it builds login
+// redirects, exchanges authorization codes and verifies ID tokens against
+// the provider's published keys
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	authEndpoint  string
+	tokenEndpoint string
+	jwksURI       string
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey // kid -> key
+	fetchedAt time.Time
+}
+
+// NewOIDCProvider fetches the provider's discovery document and signing keys
+func NewOIDCProvider(ctx context.Context, config OIDCConfig) (*OIDCProvider, error) {
+	if config.IssuerURL == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDC requires an issuer URL, a client ID and a redirect URL")
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = strings.Fields(DefaultOIDCScopes)
+	}
+	if config.UserClaim == "" {
+		config.UserClaim = DefaultOIDCUserClaim
+	}
+	p := &OIDCProvider{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+
+	var discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+	wellKnown := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &discovery); err != nil {
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+	if discovery.Issuer != config.IssuerURL {
+		return nil, fmt.Errorf("OIDC discovery: issuer %q does not match %q", discovery.Issuer, config.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery: incomplete provider metadata")
+	}
+	p.authEndpoint = discovery.AuthorizationEndpoint
+	p.tokenEndpoint = discovery.TokenEndpoint
+	p.jwksURI = discovery.JWKSURI
+
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Issuer returns the provider's issuer URL
+func (p *OIDCProvider) Issuer() string {
+	return p.config.IssuerURL
+}
+
+// AuthCodeURL returns the provider URL a browser is sent to for login. The
+// verifier is the PKCE code verifier to pass to Exchange.
+func (p *OIDCProvider) AuthCodeURL(state, nonce, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.authEndpoint, "?") {
+		sep = "&"
+	}
+	return p.authEndpoint + sep + q.Encode()
+}
+
+// Exchange trades an authorization code for an ID token and returns the
+// identity it carries. nonce must match the one sent with the login redirect.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC token exchange: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.IDToken == "" {
+		return nil, fmt.Errorf("OIDC token exchange: response has no id_token")
+	}
+
+	claims, err := p.Verify(ctx, token.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("OIDC: ID token nonce mismatch")
+	}
+	return p.identity(claims)
+}
+
+// VerifyIdentity checks an ID token presented as a bearer token and returns
+// its identity
+func (p *OIDCProvider) VerifyIdentity(ctx context.Context, rawToken string) (*Identity, error) {
+	claims, err := p.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, err
+	}
+	return p.identity(claims)
+}
+
+// identity maps ID token claims to an Identity
+func (p *OIDCProvider) identity(claims map[string]any) (*Identity, error) {
+	user, _ := claims[p.config.UserClaim].(string)
+	if user == "" {
+		user, _ = claims["sub"].(string)
+	}
+	if user == "" {
+		return nil, fmt.Errorf("OIDC: ID token has no %s or sub claim", p.config.UserClaim)
+	}
+	id := &Identity{User: user}
+	id.Name, _ = claims["name"].(string)
+	id.Email, _ = claims["email"].(string)
+	return id, nil
+}
+
+// Verify checks an ID token's signature, issuer, audience and expiry and
+// returns its claims
+func (p *OIDCProvider) Verify(ctx context.Context, rawToken string) (map[string]any, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("OIDC: malformed ID token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errors.New("OIDC: malformed ID token header")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("OIDC: malformed ID token signature")
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.New("OIDC: malformed ID token claims")
+	}
+	if iss, _ := claims["iss"].(string); iss != p.config.IssuerURL {
+		return nil, fmt.Errorf("OIDC: ID token issued by %q", iss)
+	}
+	if !audienceContains(claims["aud"], p.config.ClientID) {
+		return nil, errors.New("OIDC: ID token not issued for this client")
+	}
+	exp, _ := claims["exp"].(float64)
+	if time.Now().After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return nil, errors.New("OIDC: ID token expired")
+	}
+	return claims, nil
+}
+
+// key returns the signing key with the given ID, refetching the key set
+// (at most once per jwksRefreshInterval) when it is unknown, e.g. after the
+// provider rotated its keys
+func (p *OIDCProvider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.lookupKey(kid)
+	stale := time.Since(p.fetchedAt) >= jwksRefreshInterval
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if stale {
+		if err := p.refreshKeys(ctx); err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		key, ok = p.lookupKey(kid)
+		p.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("OIDC: unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by ID; tokens without one match a sole key. Callers hold p.mu.
+func (p *OIDCProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// refreshKeys fetches the provider's JSON Web Key Set. RSA and EC (P-256,
+// P-384, P-521) signing keys are kept; others are ignored.
+func (p *OIDCProvider) refreshKeys(ctx context.Context) error {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.jwksURI, &set); err != nil {
+		return fmt.Errorf("OIDC keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	if len(keys) == 0 {
+		return errors.New("OIDC keys: no usable signing keys")
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.fetchedAt = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// getJSON fetches and decodes a JSON document from the provider
+func (p *OIDCProvider) getJSON(ctx context.Context, rawURL string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// verifySignature checks a JWS signature for the RS*, PS* and ES* algorithms
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("OIDC: unsupported signing algorithm %q", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("OIDC: unsupported signing algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		var err error
+		switch alg[:2] {
+		case "RS":
+			err = rsa.VerifyPKCS1v15(k, hash, digest, sig)
+		case "PS":
+			err = rsa.VerifyPSS(k, hash, digest, sig, nil)
+		default:
+			return fmt.Errorf("OIDC: algorithm %q does not match an RSA key", alg)
+		}
+		if err != nil {
+			return errors.New("OIDC: invalid ID token signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(sig) != 2*size {
+			return errors.New("OIDC: invalid ID token signature")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("OIDC: invalid ID token signature")
+		}
+		return nil
+	}
+	return errors.New("OIDC: unsupported key type")
+}
+
+// audienceContains reports whether the aud claim (a string or an array) names the client
+func audienceContains(aud any, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []any:
+		for _, a := range v {
+			if s, _ := a.(string); s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT
+func decodeSegment(segment string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// RandomString returns n random bytes, base64url-encoded, for OIDC state,
+// nonce and PKCE verifier values
+func RandomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("auth: failed to read random bytes: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
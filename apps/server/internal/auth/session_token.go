@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSessionTokenTTL is how long a session token stays valid
+const DefaultSessionTokenTTL = 5 * time.Minute
+
+// SessionTokens mints and checks short-lived tokens that let a client attach
+// to one session's event stream (WebSocket or Server-Sent Events). Browsers
+// cannot set headers on those requests, so the token travels in the query
+// string; being bound to a session and expiring quickly limits what a leaked
+// URL exposes. Tokens are stateless: "<expiry unix>.<HMAC of session and
+// expiry>", so every instance sharing the secret accepts them.
+type SessionTokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSessionTokens creates a token issuer. With an empty secret a random one
+// is generated, and tokens do not survive a restart.
+func NewSessionTokens(secret []byte, ttl time.Duration) *SessionTokens {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic("auth: failed to generate session token secret: " + err.Error())
+		}
+	}
+	if ttl <= 0 {
+		ttl = DefaultSessionTokenTTL
+	}
+	return &SessionTokens{secret: secret, ttl: ttl}
+}
+
+// Issue mints a token for the session and returns it with its expiry
+func (t *SessionTokens) Issue(sessionID string) (string, time.Time) {
+	expires := time.Now().Add(t.ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + t.sign(sessionID, exp), expires
+}
+
+// Verify reports whether token was issued for the session and has not expired
+func (t *SessionTokens) Verify(token, sessionID string) bool {
+	exp, sig, found := strings.Cut(token, ".")
+	if !found {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() >= unix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(t.sign(sessionID, exp)))
+}
+
+// sign returns the signature of a session and expiry
+func (t *SessionTokens) sign(sessionID, exp string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(sessionID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,258 @@
+// Package callstats aggregates rolling latency and error-rate statistics of
+// calls per session, keyed by target and method.
+package callstats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grpc-bridge/server/internal/loadtest"
+)
+
+// Defaults for the rolling window and how often changes are published
+const (
+	DefaultWindow   = 5 * time.Minute
+	DefaultInterval = 10 * time.Second
+)
+
+// Bounds on memory per session: the oldest samples of an endpoint and the
+// least recently called endpoints are dropped first
+const (
+	maxSamples   = 2000
+	maxEndpoints = 200
+)
+
+// Key identifies an endpoint: a method on a target
+type Key struct {
+	Target  string
+	Service string
+	Method  string
+}
+
+// EndpointStats summarizes the calls of one endpoint within the window
+type EndpointStats struct {
+	Target     string                `json:"target"`
+	Service    string                `json:"service"`
+	Method     string                `json:"method"`
+	Calls      int                   `json:"calls"`
+	Errors     int                   `json:"errors"`
+	ErrorRate  float64               `json:"error_rate"`            // Errors / Calls
+	ErrorKinds map[string]int        `json:"error_kinds,omitempty"` // Error kind -> count
+	Latency    loadtest.LatencyStats `json:"latency"`
+	LastCallAt time.Time             `json:"last_call_at"`
+}
+
+// Snapshot is the statistics of a session's endpoints, sorted by target,
+// service and method
+type Snapshot struct {
+	WindowSeconds int64           `json:"window_seconds"`
+	Endpoints     []EndpointStats `json:"endpoints"`
+}
+
+// sample is one finished call
+type sample struct {
+	at   time.Time
+	ms   float64
+	kind string // Error kind; empty for successful calls
+}
+
+// endpoint holds the samples of one key, oldest first
+type endpoint struct {
+	key     Key
+	samples []sample
+}
+
+// sessionStats holds the endpoints of one session
+type sessionStats struct {
+	endpoints map[Key]*endpoint
+	dirty     bool // Calls were recorded since the last publish
+}
+
+// Tracker records finished calls and keeps those within the window
+type Tracker struct {
+	window   time.Duration
+	sessions map[string]*sessionStats
+	mu       sync.Mutex
+}
+
+// NewTracker creates a tracker with a rolling window (DefaultWindow when 0)
+func NewTracker(window time.Duration) *Tracker {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Tracker{
+		window:   window,
+		sessions: make(map[string]*sessionStats),
+	}
+}
+
+// Window returns the length of the rolling window
+func (t *Tracker) Window() time.Duration {
+	return t.window
+}
+
+// Record adds a finished call of a session. errKind classifies a failed call
+// and is empty for a successful one.
+func (t *Tracker) Record(sessionID string, key Key, took time.Duration, errKind string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sessions[sessionID]
+	if !ok {
+		s = &sessionStats{endpoints: make(map[Key]*endpoint)}
+		t.sessions[sessionID] = s
+	}
+	e, ok := s.endpoints[key]
+	if !ok {
+		if len(s.endpoints) >= maxEndpoints {
+			s.evictLeastRecent()
+		}
+		e = &endpoint{key: key}
+		s.endpoints[key] = e
+	}
+	e.samples = append(e.samples, sample{
+		at:   time.Now(),
+		ms:   float64(took.Microseconds()) / 1000,
+		kind: errKind,
+	})
+	if len(e.samples) > maxSamples {
+		e.samples = append(e.samples[:0], e.samples[len(e.samples)-maxSamples:]...)
+	}
+	s.dirty = true
+}
+
+// Snapshot returns the statistics of a session's calls within the window
+func (t *Tracker) Snapshot(sessionID string) Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snap := Snapshot{WindowSeconds: int64(t.window.Seconds()), Endpoints: []EndpointStats{}}
+	if s, ok := t.sessions[sessionID]; ok {
+		// Aged samples are left for Run to prune, so it still sees the change
+		snap.Endpoints = s.stats(time.Now().Add(-t.window))
+	}
+	return snap
+}
+
+// Forget drops the statistics of a session
+func (t *Tracker) Forget(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sessions, sessionID)
+}
+
+// Run publishes, every interval, the snapshot of each session whose
+// statistics changed: calls were recorded or aged out of the window. A session
+// whose last call leaves the window gets a final, empty snapshot.
+func (t *Tracker) Run(interval time.Duration, publish func(sessionID string, snap Snapshot)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			for sessionID, snap := range t.changed() {
+				publish(sessionID, snap)
+			}
+		}
+	}()
+}
+
+// changed prunes every session and returns snapshots of those that changed
+func (t *Tracker) changed() map[string]Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := time.Now().Add(-t.window)
+	out := make(map[string]Snapshot)
+	for sessionID, s := range t.sessions {
+		if !s.prune(cutoff) && !s.dirty {
+			continue
+		}
+		s.dirty = false
+		out[sessionID] = Snapshot{WindowSeconds: int64(t.window.Seconds()), Endpoints: s.stats(cutoff)}
+		if len(s.endpoints) == 0 {
+			delete(t.sessions, sessionID)
+		}
+	}
+	return out
+}
+
+// prune drops samples older than cutoff and endpoints left without samples.
+// It reports whether anything was dropped.
+func (s *sessionStats) prune(cutoff time.Time) bool {
+	dropped := false
+	for key, e := range s.endpoints {
+		keep := sort.Search(len(e.samples), func(i int) bool {
+			return e.samples[i].at.After(cutoff)
+		})
+		if keep == 0 {
+			continue
+		}
+		dropped = true
+		if keep == len(e.samples) {
+			delete(s.endpoints, key)
+			continue
+		}
+		e.samples = append(e.samples[:0], e.samples[keep:]...)
+	}
+	return dropped
+}
+
+// evictLeastRecent drops the endpoint whose last call is the oldest
+func (s *sessionStats) evictLeastRecent() {
+	var oldest *endpoint
+	for _, e := range s.endpoints {
+		if oldest == nil || e.samples[len(e.samples)-1].at.Before(oldest.samples[len(oldest.samples)-1].at) {
+			oldest = e
+		}
+	}
+	if oldest != nil {
+		delete(s.endpoints, oldest.key)
+	}
+}
+
+// stats summarizes the samples after cutoff of every endpoint of the session
+func (s *sessionStats) stats(cutoff time.Time) []EndpointStats {
+	out := make([]EndpointStats, 0, len(s.endpoints))
+	for _, e := range s.endpoints {
+		samples := e.samples[sort.Search(len(e.samples), func(i int) bool {
+			return e.samples[i].at.After(cutoff)
+		}):]
+		if len(samples) == 0 {
+			continue
+		}
+		st := EndpointStats{
+			Target:     e.key.Target,
+			Service:    e.key.Service,
+			Method:     e.key.Method,
+			Calls:      len(samples),
+			LastCallAt: samples[len(samples)-1].at,
+		}
+		latencies := make([]float64, len(samples))
+		for i, smp := range samples {
+			latencies[i] = smp.ms
+			if smp.kind != "" {
+				if st.ErrorKinds == nil {
+					st.ErrorKinds = make(map[string]int)
+				}
+				st.ErrorKinds[smp.kind]++
+				st.Errors++
+			}
+		}
+		st.ErrorRate = float64(st.Errors) / float64(st.Calls)
+		st.Latency = loadtest.ComputeLatencyStats(latencies)
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		return a.Method < b.Method
+	})
+	return out
+}
@@ -0,0 +1,312 @@
+package collection
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/secret"
+)
+
+// SQLStore keeps collections in the SQL database opened by the database
+// package. Saved requests are stored as JSON, in collection order.
+type SQLStore struct {
+	db     *sql.DB
+	cipher *secret.Cipher
+}
+
+// NewSQLStore creates a database-backed collection store
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// SetCipher encrypts the sensitive metadata values (see secret.IsSensitive) of
+// saved requests written from now on. Requests written with a cipher cannot
+// be read without it.
+func (s *SQLStore) SetCipher(c *secret.Cipher) {
+	s.cipher = c
+}
+
+// List returns collections visible to a session, sorted by name
+func (s *SQLStore) List(sessionID string) ([]*Collection, error) {
+	rows, err := s.db.Query(`SELECT id, name, description, scope, session_id, owner, created_at, updated_at FROM collections
+		WHERE scope = ? OR (session_id = ? AND ? <> '') ORDER BY name`, ScopeGlobal, sessionID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	out := []*Collection{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range out {
+		if c.Requests, err = s.requests(c.ID); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Get returns a collection by ID
+func (s *SQLStore) Get(id string) (*Collection, error) {
+	c, err := scanCollection(s.db.QueryRow(`SELECT id, name, description, scope, session_id, owner, created_at, updated_at FROM collections WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Requests, err = s.requests(id); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Create stores a new collection
+func (s *SQLStore) Create(c *Collection) (*Collection, error) {
+	now := time.Now()
+	stored := c.clone()
+	stored.ID = uuid.New().String()
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+	if stored.Requests == nil {
+		stored.Requests = []SavedRequest{}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO collections (id, name, description, scope, session_id, owner, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		stored.ID, stored.Name, stored.Description, stored.Scope, stored.SessionID, stored.Owner, now.UnixNano(), now.UnixNano()); err != nil {
+		return nil, err
+	}
+	for i := range stored.Requests {
+		r := &stored.Requests[i]
+		if r.ID == "" {
+			r.ID = uuid.New().String()
+		}
+		if err := s.insertRequest(tx, stored.ID, i, *r); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// Update changes collection name and description
+func (s *SQLStore) Update(id string, name, description string) (*Collection, error) {
+	result, err := s.db.Exec(`UPDATE collections SET name = ?, description = ?, updated_at = ? WHERE id = ?`,
+		name, description, time.Now().UnixNano(), id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrCollectionNotFound
+	}
+	return s.Get(id)
+}
+
+// Delete removes a collection with its saved requests
+func (s *SQLStore) Delete(id string) error {
+	result, err := s.db.Exec(`DELETE FROM collections WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+// DeleteSession removes all collections owned by a session
+func (s *SQLStore) DeleteSession(sessionID string) error {
+	_, err := s.db.Exec(`DELETE FROM collections WHERE scope = ? AND session_id = ?`, ScopeSession, sessionID)
+	return err
+}
+
+// AddRequest appends a saved request to a collection
+func (s *SQLStore) AddRequest(collectionID string, r SavedRequest) (*SavedRequest, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := touchCollection(tx, collectionID, now); err != nil {
+		return nil, err
+	}
+	var position int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(position), -1) + 1 FROM saved_requests WHERE collection_id = ?`, collectionID).Scan(&position); err != nil {
+		return nil, err
+	}
+
+	r.ID = uuid.New().String()
+	r.CreatedAt = now
+	r.UpdatedAt = now
+	if err := s.insertRequest(tx, collectionID, position, r); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UpdateRequest replaces a saved request (matched by r.ID)
+func (s *SQLStore) UpdateRequest(collectionID string, r SavedRequest) (*SavedRequest, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := touchCollection(tx, collectionID, now); err != nil {
+		return nil, err
+	}
+	existing, err := s.scanRequest(tx.QueryRow(`SELECT request FROM saved_requests WHERE collection_id = ? AND id = ?`, collectionID, r.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.CreatedAt = existing.CreatedAt
+	r.UpdatedAt = now
+	data, err := s.encodeRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE saved_requests SET request = ? WHERE id = ?`, data, r.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// DeleteRequest removes a saved request
+func (s *SQLStore) DeleteRequest(collectionID, requestID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := touchCollection(tx, collectionID, time.Now()); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM saved_requests WHERE collection_id = ? AND id = ?`, collectionID, requestID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrRequestNotFound
+	}
+	return tx.Commit()
+}
+
+// requests loads the saved requests of a collection in order
+func (s *SQLStore) requests(collectionID string) ([]SavedRequest, error) {
+	rows, err := s.db.Query(`SELECT request FROM saved_requests WHERE collection_id = ? ORDER BY position`, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []SavedRequest{}
+	for rows.Next() {
+		r, err := s.scanRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// touchCollection bumps the update time of a collection, reporting
+// ErrCollectionNotFound when it does not exist
+func touchCollection(tx *sql.Tx, id string, now time.Time) error {
+	result, err := tx.Exec(`UPDATE collections SET updated_at = ? WHERE id = ?`, now.UnixNano(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) insertRequest(tx *sql.Tx, collectionID string, position int, r SavedRequest) error {
+	data, err := s.encodeRequest(r)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO saved_requests (id, collection_id, position, request) VALUES (?, ?, ?, ?)`,
+		r.ID, collectionID, position, data)
+	return err
+}
+
+// encodeRequest encodes a saved request for storage with its secrets sealed
+func (s *SQLStore) encodeRequest(r SavedRequest) (string, error) {
+	metadata, err := s.cipher.Seal(r.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("saved request metadata %w", err)
+	}
+	r.Metadata = metadata
+	data, err := json.Marshal(r)
+	return string(data), err
+}
+
+// scanner is satisfied by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCollection(row scanner) (*Collection, error) {
+	var c Collection
+	var created, updated int64
+	if err := row.Scan(&c.ID, &c.Name, &c.Description, &c.Scope, &c.SessionID, &c.Owner, &created, &updated); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = time.Unix(0, created)
+	c.UpdatedAt = time.Unix(0, updated)
+	return &c, nil
+}
+
+// scanRequest decodes a stored saved request, opening its sealed secrets
+func (s *SQLStore) scanRequest(row scanner) (*SavedRequest, error) {
+	var data string
+	if err := row.Scan(&data); err != nil {
+		return nil, err
+	}
+	var r SavedRequest
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		return nil, err
+	}
+	if err := s.cipher.Open(r.Metadata); err != nil {
+		return nil, fmt.Errorf("saved request metadata %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,146 @@
+package collection
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grpc-bridge/server/internal/database"
+	"github.com/grpc-bridge/server/internal/secret"
+)
+
+func TestSQLStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.db")
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	store := NewSQLStore(db)
+	created, err := store.Create(&Collection{Name: "smoke", Scope: ScopeSession, SessionID: "s1", Requests: []SavedRequest{
+		{Name: "hello", Service: "pkg.Greeter", Method: "SayHello", Folder: "a/b"},
+	}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := store.AddRequest(created.ID, SavedRequest{Name: "bye", Method: "SayBye"}); err != nil {
+		t.Fatalf("AddRequest: %v", err)
+	}
+	db.Close()
+
+	db, err = database.Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	store = NewSQLStore(db)
+
+	got, err := store.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Get after reopen: %v", err)
+	}
+	if got.Name != "smoke" || len(got.Requests) != 2 || got.Requests[0].Name != "hello" || got.Requests[1].Name != "bye" {
+		t.Errorf("Get after reopen = %+v", got)
+	}
+	if folders := got.Folders(); len(folders) != 2 || folders[1] != "a/b" {
+		t.Errorf("Folders = %v", folders)
+	}
+}
+
+func TestSQLStoreScopesAndCascade(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "bridge.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	store := NewSQLStore(db)
+
+	global, err := store.Create(&Collection{Name: "shared", Scope: ScopeGlobal, Owner: "ada"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	own, err := store.Create(&Collection{Name: "mine", Scope: ScopeSession, SessionID: "s1", Requests: []SavedRequest{{Name: "r"}}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if list, _ := store.List("s1"); len(list) != 2 {
+		t.Errorf("List(s1) = %d collections, want 2", len(list))
+	}
+	if list, _ := store.List("s2"); len(list) != 1 || list[0].ID != global.ID {
+		t.Errorf("List(s2) = %v, want only the global collection", list)
+	}
+
+	if err := store.DeleteSession("s1"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if _, err := store.Get(own.ID); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("Get after DeleteSession = %v, want ErrCollectionNotFound", err)
+	}
+	var orphans int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM saved_requests WHERE collection_id = ?`, own.ID).Scan(&orphans); err != nil || orphans != 0 {
+		t.Errorf("saved requests left after delete: %d (%v)", orphans, err)
+	}
+	if got, err := store.Get(global.ID); err != nil {
+		t.Errorf("global collection removed with the session: %v", err)
+	} else if got.Owner != "ada" {
+		t.Errorf("global collection owner = %q, want ada", got.Owner)
+	}
+}
+
+func TestSQLStoreSealsMetadata(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "bridge.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	c, err := secret.NewCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	store := NewSQLStore(db)
+	store.SetCipher(c)
+
+	created, err := store.Create(&Collection{Name: "smoke", Scope: ScopeSession, SessionID: "s1", Requests: []SavedRequest{
+		{Name: "hello", Metadata: map[string]string{"authorization": "Bearer one"}},
+	}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	added, err := store.AddRequest(created.ID, SavedRequest{Name: "bye", Metadata: map[string]string{"x-api-key": "key-two"}})
+	if err != nil {
+		t.Fatalf("AddRequest: %v", err)
+	}
+	updated := created.Requests[0]
+	updated.Metadata = map[string]string{"authorization": "Bearer three"}
+	if _, err := store.UpdateRequest(created.ID, updated); err != nil {
+		t.Fatalf("UpdateRequest: %v", err)
+	}
+	if added.Metadata["x-api-key"] != "key-two" {
+		t.Errorf("AddRequest returned metadata %v, want plaintext", added.Metadata)
+	}
+
+	rows, err := db.Query(`SELECT request FROM saved_requests`)
+	if err != nil {
+		t.Fatalf("raw rows: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			t.Fatalf("raw row: %v", err)
+		}
+		if strings.Contains(raw, "Bearer") || strings.Contains(raw, "key-two") || !strings.Contains(raw, "enc:v1:") {
+			t.Errorf("raw row = %s, want metadata encrypted", raw)
+		}
+	}
+
+	got, err := store.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Requests[0].Metadata["authorization"] != "Bearer three" || got.Requests[1].Metadata["x-api-key"] != "key-two" {
+		t.Errorf("Get requests = %+v, want plaintext metadata", got.Requests)
+	}
+}
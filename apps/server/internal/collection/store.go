@@ -0,0 +1,267 @@
+package collection
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Collection scopes
+const (
+	ScopeSession = "session" // Visible only to the owning session
+	ScopeGlobal  = "global"  // Shared by all sessions on this server
+)
+
+// SavedRequest is a reusable call definition stored in a collection
+type SavedRequest struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Folder    string            `json:"folder"` // Slash separated folder path (e.g. "users/admin"), empty for root
+	Target    string            `json:"target"`
+	Service   string            `json:"service"`
+	Method    string            `json:"method"`
+	Data      interface{}       `json:"data"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Plaintext bool              `json:"plaintext"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Collection groups saved requests
+type Collection struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Scope       string         `json:"scope"`
+	SessionID   string         `json:"session_id,omitempty"` // Owner session for session-scoped collections
+	Owner       string         `json:"owner,omitempty"`      // Authenticated user who created a global collection
+	Requests    []SavedRequest `json:"requests"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Folders returns the distinct folder paths used by the collection, including parents
+func (c *Collection) Folders() []string {
+	set := map[string]struct{}{}
+	for _, r := range c.Requests {
+		if r.Folder == "" {
+			continue
+		}
+		parts := strings.Split(r.Folder, "/")
+		for i := range parts {
+			set[strings.Join(parts[:i+1], "/")] = struct{}{}
+		}
+	}
+	folders := make([]string, 0, len(set))
+	for f := range set {
+		folders = append(folders, f)
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+// VisibleTo reports whether a session may access the collection
+func (c *Collection) VisibleTo(sessionID string) bool {
+	return c.Scope == ScopeGlobal || (sessionID != "" && c.SessionID == sessionID)
+}
+
+// Store persists collections
+type Store interface {
+	// List returns global collections plus those owned by sessionID
+	List(sessionID string) ([]*Collection, error)
+	Get(id string) (*Collection, error)
+	Create(c *Collection) (*Collection, error)
+	Update(id string, name, description string) (*Collection, error)
+	Delete(id string) error
+	// DeleteSession removes every collection owned by a session
+	DeleteSession(sessionID string) error
+
+	AddRequest(collectionID string, r SavedRequest) (*SavedRequest, error)
+	UpdateRequest(collectionID string, r SavedRequest) (*SavedRequest, error)
+	DeleteRequest(collectionID, requestID string) error
+}
+
+// MemoryStore keeps collections in memory
+type MemoryStore struct {
+	collections map[string]*Collection
+	mu          sync.RWMutex
+}
+
+// NewMemoryStore creates an in-memory collection store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		collections: make(map[string]*Collection),
+	}
+}
+
+// List returns collections visible to a session, sorted by name
+func (s *MemoryStore) List(sessionID string) ([]*Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []*Collection{}
+	for _, c := range s.collections {
+		if c.VisibleTo(sessionID) {
+			out = append(out, c.clone())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// Get returns a collection by ID
+func (s *MemoryStore) Get(id string) (*Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, exists := s.collections[id]
+	if !exists {
+		return nil, ErrCollectionNotFound
+	}
+	return c.clone(), nil
+}
+
+// Create stores a new collection
+func (s *MemoryStore) Create(c *Collection) (*Collection, error) {
+	now := time.Now()
+	stored := c.clone()
+	stored.ID = uuid.New().String()
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+	if stored.Requests == nil {
+		stored.Requests = []SavedRequest{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.collections[stored.ID] = stored
+	return stored.clone(), nil
+}
+
+// Update changes collection name and description
+func (s *MemoryStore) Update(id string, name, description string) (*Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, exists := s.collections[id]
+	if !exists {
+		return nil, ErrCollectionNotFound
+	}
+	c.Name = name
+	c.Description = description
+	c.UpdatedAt = time.Now()
+	return c.clone(), nil
+}
+
+// Delete removes a collection
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.collections[id]; !exists {
+		return ErrCollectionNotFound
+	}
+	delete(s.collections, id)
+	return nil
+}
+
+// DeleteSession removes all collections owned by a session
+func (s *MemoryStore) DeleteSession(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, c := range s.collections {
+		if c.Scope == ScopeSession && c.SessionID == sessionID {
+			delete(s.collections, id)
+		}
+	}
+	return nil
+}
+
+// AddRequest appends a saved request to a collection
+func (s *MemoryStore) AddRequest(collectionID string, r SavedRequest) (*SavedRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, exists := s.collections[collectionID]
+	if !exists {
+		return nil, ErrCollectionNotFound
+	}
+
+	now := time.Now()
+	r.ID = uuid.New().String()
+	r.CreatedAt = now
+	r.UpdatedAt = now
+	c.Requests = append(c.Requests, r)
+	c.UpdatedAt = now
+	return &r, nil
+}
+
+// UpdateRequest replaces a saved request (matched by r.ID)
+func (s *MemoryStore) UpdateRequest(collectionID string, r SavedRequest) (*SavedRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, exists := s.collections[collectionID]
+	if !exists {
+		return nil, ErrCollectionNotFound
+	}
+
+	for i := range c.Requests {
+		if c.Requests[i].ID == r.ID {
+			r.CreatedAt = c.Requests[i].CreatedAt
+			r.UpdatedAt = time.Now()
+			c.Requests[i] = r
+			c.UpdatedAt = r.UpdatedAt
+			return &r, nil
+		}
+	}
+	return nil, ErrRequestNotFound
+}
+
+// DeleteRequest removes a saved request
+func (s *MemoryStore) DeleteRequest(collectionID, requestID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, exists := s.collections[collectionID]
+	if !exists {
+		return ErrCollectionNotFound
+	}
+
+	for i := range c.Requests {
+		if c.Requests[i].ID == requestID {
+			c.Requests = append(c.Requests[:i:i], c.Requests[i+1:]...)
+			c.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return ErrRequestNotFound
+}
+
+// clone returns a copy safe to hand out without holding the lock
+func (c *Collection) clone() *Collection {
+	out := *c
+	out.Requests = append([]SavedRequest(nil), c.Requests...)
+	return &out
+}
+
+// Errors
+var (
+	ErrCollectionNotFound = &CollectionError{"collection not found"}
+	ErrRequestNotFound    = &CollectionError{"saved request not found"}
+)
+
+type CollectionError struct {
+	Message string
+}
+
+func (e *CollectionError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,343 @@
+// Package config loads the server configuration: built-in defaults, then an
+// optional YAML or TOML file, then environment variables, which override the
+// file. Every problem found along the way is collected into one report.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/callstats"
+	"github.com/grpc-bridge/server/internal/gitsource"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/handler"
+	"github.com/grpc-bridge/server/internal/middleware"
+	"github.com/grpc-bridge/server/internal/payload"
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/grpc-bridge/server/internal/websocket"
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+// Config is the complete server configuration. Each setting has a key in the
+// config file (e.g. websocket.ping_interval) and an environment variable
+// (WS_PING_INTERVAL) that overrides it. Empty paths derived from the upload
+// directory, like the session store, are filled in by the caller.
+type Config struct {
+	Server    Server    `yaml:"server" toml:"server"`
+	TLS       TLS       `yaml:"tls" toml:"tls"`
+	CORS      CORS      `yaml:"cors" toml:"cors"`
+	Uploads   Uploads   `yaml:"uploads" toml:"uploads"`
+	Sessions  Sessions  `yaml:"sessions" toml:"sessions"`
+	Storage   Storage   `yaml:"storage" toml:"storage"`
+	GRPC      GRPC      `yaml:"grpc" toml:"grpc"`
+	Git       Git       `yaml:"git" toml:"git"`
+	Auth      Auth      `yaml:"auth" toml:"auth"`
+	WebSocket WebSocket `yaml:"websocket" toml:"websocket"`
+	RateLimit RateLimit `yaml:"rate_limit" toml:"rate_limit"`
+	AccessLog AccessLog `yaml:"access_log" toml:"access_log"`
+}
+
+// Server holds the HTTP listener settings
+type Server struct {
+	Port           int  `yaml:"port" toml:"port" env:"PORT"`
+	DebugEndpoints bool `yaml:"debug_endpoints" toml:"debug_endpoints" env:"DEBUG_ENDPOINTS"` // Serve /debug/pprof and /debug/runtime
+}
+
+// TLS configures native HTTPS, from certificate files or Let's Encrypt
+type TLS struct {
+	CertFile         string   `yaml:"cert_file" toml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile          string   `yaml:"key_file" toml:"key_file" env:"TLS_KEY_FILE"`
+	AutocertDomains  []string `yaml:"autocert_domains" toml:"autocert_domains" env:"TLS_AUTOCERT_DOMAINS"`
+	AutocertCache    string   `yaml:"autocert_cache" toml:"autocert_cache" env:"TLS_AUTOCERT_CACHE"` // Default: <uploads.dir>/.autocert
+	AutocertEmail    string   `yaml:"autocert_email" toml:"autocert_email" env:"TLS_AUTOCERT_EMAIL"`
+	HTTPRedirectPort int      `yaml:"http_redirect_port" toml:"http_redirect_port" env:"HTTP_REDIRECT_PORT"` // 0 disables the redirect listener
+}
+
+// CORS lists the browser origins allowed to call the API
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"` // "*" allows any origin
+}
+
+// Uploads holds the upload directory and upload size limits
+type Uploads struct {
+	Dir            string   `yaml:"dir" toml:"dir" env:"UPLOAD_DIR"`
+	MaxFileSize    int64    `yaml:"max_file_size" toml:"max_file_size" env:"UPLOAD_MAX_FILE_SIZE"`
+	MaxRequestSize int64    `yaml:"max_request_size" toml:"max_request_size" env:"UPLOAD_MAX_REQUEST_SIZE"`
+	MaxMemory      int64    `yaml:"max_memory" toml:"max_memory" env:"UPLOAD_MAX_MEMORY"`
+	ChunkTTL       Duration `yaml:"chunk_ttl" toml:"chunk_ttl" env:"UPLOAD_CHUNK_TTL"`
+}
+
+// Sessions holds session lifetime, quota and persistence settings
+type Sessions struct {
+	StoreFile         string   `yaml:"store_file" toml:"store_file" env:"SESSION_STORE_FILE"` // Default: <uploads.dir>/sessions.json; "off" keeps sessions in memory
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SESSION_IDLE_TIMEOUT"`
+	MaxFiles          int      `yaml:"max_files" toml:"max_files" env:"SESSION_MAX_FILES"`
+	MaxBytes          int64    `yaml:"max_bytes" toml:"max_bytes" env:"SESSION_MAX_BYTES"`
+	OrphanGracePeriod Duration `yaml:"orphan_grace_period" toml:"orphan_grace_period" env:"ORPHAN_GRACE_PERIOD"`
+	SecretKey         string   `yaml:"secret_key" toml:"secret_key" env:"SECRET_KEY" secret:"true"`
+	SecretKeyFile     string   `yaml:"secret_key_file" toml:"secret_key_file" env:"SECRET_KEY_FILE"` // Overrides secret_key
+}
+
+// Storage selects where sessions, history and collections are kept
+type Storage struct {
+	MaxBytes      int64    `yaml:"max_bytes" toml:"max_bytes" env:"STORAGE_MAX_BYTES"`
+	CheckInterval Duration `yaml:"check_interval" toml:"check_interval" env:"STORAGE_CHECK_INTERVAL"`
+	DatabaseFile  string   `yaml:"database_file" toml:"database_file" env:"DATABASE_FILE"` // Default: <uploads.dir>/grpc-bridge.db; "off" keeps data in memory
+	StdlibDir     string   `yaml:"stdlib_dir" toml:"stdlib_dir" env:"STDLIB_DIR"`          // Default: <uploads.dir>/.stdlib; "off" copies the stdlib into sessions
+	Archive       Archive  `yaml:"archive" toml:"archive"`
+}
+
+// Archive configures the object store expired sessions are archived to
+type Archive struct {
+	URL       string `yaml:"url" toml:"url" env:"ARCHIVE_URL"`
+	Endpoint  string `yaml:"endpoint" toml:"endpoint" env:"ARCHIVE_ENDPOINT"`
+	Region    string `yaml:"region" toml:"region" env:"ARCHIVE_REGION"`
+	AccessKey string `yaml:"access_key" toml:"access_key" env:"ARCHIVE_ACCESS_KEY" secret:"true"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key" env:"ARCHIVE_SECRET_KEY" secret:"true"`
+}
+
+// GRPC holds the gRPC backend, target restrictions and proto sources
+type GRPC struct {
+	Backend              string   `yaml:"backend" toml:"backend" env:"GRPC_BACKEND"`
+	TargetAllow          []string `yaml:"target_allow" toml:"target_allow" env:"GRPC_TARGET_ALLOW"`
+	TargetDeny           []string `yaml:"target_deny" toml:"target_deny" env:"GRPC_TARGET_DENY"`
+	BlockPrivateTargets  bool     `yaml:"block_private_targets" toml:"block_private_targets" env:"GRPC_TARGET_BLOCK_PRIVATE"`
+	ForbidPlaintext      bool     `yaml:"forbid_plaintext" toml:"forbid_plaintext" env:"GRPC_FORBID_PLAINTEXT"`
+	CommonProtoDirs      []string `yaml:"common_proto_dirs" toml:"common_proto_dirs" env:"COMMON_PROTO_DIRS"`          // "dir" or "name=dir"
+	CommonProtoRoots     []string `yaml:"common_proto_roots" toml:"common_proto_roots" env:"COMMON_PROTO_ROOTS"`       // Where the admin API may register libraries
+	RemoteProtoSources   []string `yaml:"remote_proto_sources" toml:"remote_proto_sources" env:"REMOTE_PROTO_SOURCES"` // "prefix=baseURL"
+	CallStatsWindow      Duration `yaml:"call_stats_window" toml:"call_stats_window" env:"CALL_STATS_WINDOW"`
+	CallStatsInterval    Duration `yaml:"call_stats_interval" toml:"call_stats_interval" env:"CALL_STATS_INTERVAL"`
+	DescriptorCacheSize  int      `yaml:"descriptor_cache_size" toml:"descriptor_cache_size" env:"DESCRIPTOR_CACHE_SIZE"`       // Sessions; 0 for unlimited
+	ConnIdleTimeout      Duration `yaml:"conn_idle_timeout" toml:"conn_idle_timeout" env:"GRPC_CONN_IDLE_TIMEOUT"`              // 0 closes connections after each call
+	ConnCacheSize        int      `yaml:"conn_cache_size" toml:"conn_cache_size" env:"GRPC_CONN_CACHE_SIZE"`                    // Connections; 0 for unlimited
+	CallWorkers          int      `yaml:"call_workers" toml:"call_workers" env:"CALL_WORKERS"`                                  // Unary calls running at once
+	CallQueueSize        int      `yaml:"call_queue_size" toml:"call_queue_size" env:"CALL_QUEUE_SIZE"`                         // Calls waiting for a worker; 0 for none
+	CallQueueSessionSize int      `yaml:"call_queue_session_size" toml:"call_queue_session_size" env:"CALL_QUEUE_SESSION_SIZE"` // Per session; 0 for no per-session limit
+}
+
+// Git restricts the repositories proto imports may clone
+type Git struct {
+	AllowSSH        bool     `yaml:"allow_ssh" toml:"allow_ssh" env:"GIT_ALLOW_SSH"`
+	HostAllow       []string `yaml:"host_allow" toml:"host_allow" env:"GIT_HOST_ALLOW"`
+	HostDeny        []string `yaml:"host_deny" toml:"host_deny" env:"GIT_HOST_DENY"`
+	BlockPrivate    bool     `yaml:"block_private" toml:"block_private" env:"GIT_BLOCK_PRIVATE"`
+	MaxCheckoutSize int64    `yaml:"max_checkout_size" toml:"max_checkout_size" env:"GIT_MAX_CHECKOUT_SIZE"` // Bytes; 0 for unlimited
+}
+
+// Auth holds API authentication and the secrets that sign tokens and URLs
+type Auth struct {
+	Mode              string   `yaml:"mode" toml:"mode" env:"AUTH_MODE"`
+	Users             []string `yaml:"users" toml:"users" env:"AUTH_USERS" secret:"true"`     // "user:password"
+	Tokens            []string `yaml:"tokens" toml:"tokens" env:"AUTH_TOKENS" secret:"true"`  // "token=user"
+	APIKeys           []string `yaml:"api_keys" toml:"api_keys" env:"API_KEYS" secret:"true"` // "key" or "key=user"
+	AdminUsers        []string `yaml:"admin_users" toml:"admin_users" env:"ADMIN_USERS"`
+	WSTokenSecret     string   `yaml:"ws_token_secret" toml:"ws_token_secret" env:"WS_TOKEN_SECRET" secret:"true"`
+	WSTokenTTL        Duration `yaml:"ws_token_ttl" toml:"ws_token_ttl" env:"WS_TOKEN_TTL"`
+	DownloadURLSecret string   `yaml:"download_url_secret" toml:"download_url_secret" env:"DOWNLOAD_URL_SECRET" secret:"true"`
+	DownloadURLTTL    Duration `yaml:"download_url_ttl" toml:"download_url_ttl" env:"DOWNLOAD_URL_TTL"`
+	OIDC              OIDC     `yaml:"oidc" toml:"oidc"`
+}
+
+// OIDC configures browser login through an OpenID Connect provider
+type OIDC struct {
+	IssuerURL    string   `yaml:"issuer_url" toml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	ClientID     string   `yaml:"client_id" toml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret" env:"OIDC_CLIENT_SECRET" secret:"true"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes       []string `yaml:"scopes" toml:"scopes" env:"OIDC_SCOPES"`
+	UserClaim    string   `yaml:"user_claim" toml:"user_claim" env:"OIDC_USER_CLAIM"`
+	SessionTTL   Duration `yaml:"session_ttl" toml:"session_ttl" env:"OIDC_SESSION_TTL"`
+	CookieSecret string   `yaml:"cookie_secret" toml:"cookie_secret" env:"OIDC_COOKIE_SECRET" secret:"true"`
+}
+
+// WebSocket holds keepalive, replay, compression and delivery settings
+type WebSocket struct {
+	PingInterval       Duration `yaml:"ping_interval" toml:"ping_interval" env:"WS_PING_INTERVAL"`
+	PongTimeout        Duration `yaml:"pong_timeout" toml:"pong_timeout" env:"WS_PONG_TIMEOUT"`
+	ReplayBuffer       int      `yaml:"replay_buffer" toml:"replay_buffer" env:"WS_REPLAY_BUFFER"`
+	ReplayWindow       Duration `yaml:"replay_window" toml:"replay_window" env:"WS_REPLAY_WINDOW"`
+	Compression        bool     `yaml:"compression" toml:"compression" env:"WS_COMPRESSION"`
+	CompressionLevel   int      `yaml:"compression_level" toml:"compression_level" env:"WS_COMPRESSION_LEVEL"`
+	CompressionMinSize int      `yaml:"compression_min_size" toml:"compression_min_size" env:"WS_COMPRESSION_MIN_SIZE"`
+	AckTimeout         Duration `yaml:"ack_timeout" toml:"ack_timeout" env:"WS_ACK_TIMEOUT"`
+	AckRetries         int      `yaml:"ack_retries" toml:"ack_retries" env:"WS_ACK_RETRIES"`
+	Backpressure       string   `yaml:"backpressure" toml:"backpressure" env:"WS_BACKPRESSURE"`
+	PreviewSize        int      `yaml:"preview_size" toml:"preview_size" env:"WS_PREVIEW_SIZE"` // Bytes; 0 sends responses in full
+	PreviewRetention   Duration `yaml:"preview_retention" toml:"preview_retention" env:"WS_PREVIEW_RETENTION"`
+}
+
+// RateLimit caps calls and uploads per session and per client IP
+type RateLimit struct {
+	Session float64 `yaml:"session" toml:"session" env:"RATE_LIMIT_SESSION"` // Requests per second; 0 disables the limit
+	IP      float64 `yaml:"ip" toml:"ip" env:"RATE_LIMIT_IP"`
+	Burst   int     `yaml:"burst" toml:"burst" env:"RATE_LIMIT_BURST"` // 0 uses the rate
+}
+
+// AccessLog controls the request log
+type AccessLog struct {
+	Format     string   `yaml:"format" toml:"format" env:"ACCESS_LOG_FORMAT"`
+	Fields     []string `yaml:"fields" toml:"fields" env:"ACCESS_LOG_FIELDS"`
+	SampleRate float64  `yaml:"sample_rate" toml:"sample_rate" env:"ACCESS_LOG_SAMPLE_RATE"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("30s", "24h")
+// in config files and environment variables
+type Duration time.Duration
+
+// UnmarshalText parses a Go duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration as a Go duration string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Std returns the duration as a time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// Default returns the configuration used when nothing is set
+func Default() *Config {
+	return &Config{
+		Server: Server{Port: 8800},
+		CORS:   CORS{AllowedOrigins: []string{"*"}},
+		Uploads: Uploads{
+			Dir:            "./uploads",
+			MaxFileSize:    handler.DefaultMaxUploadFileSize,
+			MaxRequestSize: handler.DefaultMaxUploadRequestSize,
+			MaxMemory:      8 << 20,
+			ChunkTTL:       Duration(handler.DefaultChunkedUploadTTL),
+		},
+		Sessions: Sessions{
+			IdleTimeout:       Duration(24 * time.Hour),
+			MaxFiles:          session.DefaultMaxFiles,
+			MaxBytes:          session.DefaultMaxBytes,
+			OrphanGracePeriod: Duration(session.DefaultOrphanGrace),
+		},
+		Storage: Storage{CheckInterval: Duration(5 * time.Minute)},
+		GRPC: GRPC{
+			CallStatsWindow:      Duration(callstats.DefaultWindow),
+			CallStatsInterval:    Duration(callstats.DefaultInterval),
+			DescriptorCacheSize:  grpc.DefaultDescriptorCacheSize,
+			ConnIdleTimeout:      Duration(grpc.DefaultConnIdleTimeout),
+			ConnCacheSize:        grpc.DefaultConnCacheSize,
+			CallWorkers:          handler.DefaultCallWorkers,
+			CallQueueSize:        handler.DefaultCallQueueSize,
+			CallQueueSessionSize: handler.DefaultCallQueueSessionSize,
+		},
+		Git: Git{MaxCheckoutSize: gitsource.DefaultMaxCheckoutSize},
+		Auth: Auth{
+			Mode:           string(auth.ModeNone),
+			WSTokenTTL:     Duration(auth.DefaultSessionTokenTTL),
+			DownloadURLTTL: Duration(auth.DefaultSignedURLTTL),
+			OIDC:           OIDC{SessionTTL: Duration(auth.DefaultOIDCSessionTTL)},
+		},
+		WebSocket: WebSocket{
+			PingInterval:       Duration(websocket.DefaultPingInterval),
+			PongTimeout:        Duration(websocket.DefaultPongTimeout),
+			ReplayBuffer:       websocket.DefaultReplaySize,
+			ReplayWindow:       Duration(websocket.DefaultReplayWindow),
+			Compression:        true,
+			CompressionLevel:   websocket.DefaultCompressionLevel,
+			CompressionMinSize: websocket.DefaultCompressionMinSize,
+			AckTimeout:         Duration(websocket.DefaultAckTimeout),
+			AckRetries:         websocket.DefaultAckRetries,
+			Backpressure:       string(websocket.DefaultBackpressurePolicy),
+			PreviewSize:        payload.DefaultPreviewSize,
+			PreviewRetention:   Duration(payload.DefaultRetention),
+		},
+		AccessLog: AccessLog{Format: middleware.AccessLogText, SampleRate: 1},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML (.yaml, .yml) or
+// TOML (.toml) file at path when path is not empty, the environment, and then
+// overrides such as command-line flags, each taking precedence over the ones
+// before. A file that cannot be read or parsed is reported on its own;
+// otherwise every invalid setting is listed in the returned *Error.
+func Load(path string, overrides ...Source) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.decodeFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only the value that takes precedence is parsed, so an invalid
+	// environment variable overridden by a flag is not reported
+	sources := append([]Source{os.LookupEnv}, overrides...)
+	r := &report{}
+	cfg.apply(func(env string) (string, bool) {
+		for i := len(sources) - 1; i >= 0; i-- {
+			if v, ok := sources[i](env); ok && v != "" {
+				return v, true
+			}
+		}
+		return "", false
+	}, r)
+	cfg.applyFallbacks(os.LookupEnv)
+	cfg.validate(r)
+	if len(r.problems) > 0 {
+		return cfg, &Error{Problems: r.problems}
+	}
+	return cfg, nil
+}
+
+// decodeFile reads the config file over the current values. Unknown keys are
+// rejected so that typos do not silently fall back to defaults.
+func (c *Config) decodeFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.UnmarshalWithOptions(data, c, yaml.DisallowUnknownField()); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return fmt.Errorf("parse %s: %s", path, tomlError(err))
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format (expected .yaml, .yml or .toml)", path)
+	}
+	return nil
+}
+
+// tomlError names the line and keys a TOML decoding error is about
+func tomlError(err error) string {
+	var strict *toml.StrictMissingError
+	if errors.As(err, &strict) {
+		keys := make([]string, 0, len(strict.Errors))
+		for _, e := range strict.Errors {
+			row, _ := e.Position()
+			keys = append(keys, fmt.Sprintf("%s (line %d)", strings.Join(e.Key(), "."), row))
+		}
+		return "unknown key(s) " + strings.Join(keys, ", ")
+	}
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, _ := decodeErr.Position()
+		return fmt.Sprintf("line %d: %s", row, decodeErr.Error())
+	}
+	return err.Error()
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// setting is one configurable value: its config file key, its environment
+// variable and where it is stored
+type setting struct {
+	key    string
+	env    string
+	secret bool // Credentials and signing keys, hidden by Redacted
+	value  reflect.Value
+}
+
+// settings lists every setting of c with an environment variable, in
+// declaration order
+func (c *Config) settings() []setting {
+	var out []setting
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key := prefix + f.Tag.Get("yaml")
+			if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			if env := f.Tag.Get("env"); env != "" {
+				out = append(out, setting{key: key, env: env, secret: f.Tag.Get("secret") == "true", value: v.Field(i)})
+			}
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+	return out
+}
+
+// envName returns the environment variable of the setting at key
+func (c *Config) envName(key string) string {
+	for _, s := range c.settings() {
+		if s.key == key {
+			return s.env
+		}
+	}
+	return ""
+}
+
+// Source looks up the value of a setting by its environment variable name,
+// like os.LookupEnv
+type Source func(env string) (string, bool)
+
+// apply overrides settings with the values lookup finds that are not empty.
+// Values that do not parse are reported and leave the setting as is.
+func (c *Config) apply(lookup Source, r *report) {
+	for _, s := range c.settings() {
+		v, ok := lookup(s.env)
+		if !ok || v == "" {
+			continue
+		}
+		if msg := parseInto(s.value, v); msg != "" {
+			r.add(s.key, s.env, msg)
+		}
+	}
+}
+
+// applyFallbacks fills the archive credentials and region left unset from the
+// standard AWS variables
+func (c *Config) applyFallbacks(lookup Source) {
+	archive := &c.Storage.Archive
+	if archive.AccessKey == "" {
+		archive.AccessKey, _ = lookup("AWS_ACCESS_KEY_ID")
+		archive.SecretKey, _ = lookup("AWS_SECRET_ACCESS_KEY")
+	}
+	if archive.Region == "" {
+		archive.Region, _ = lookup("AWS_REGION")
+	}
+}
+
+// parseInto stores the environment value v in dst, returning what is wrong
+// with v when it does not parse. Lists are comma-separated.
+func parseInto(dst reflect.Value, v string) string {
+	if dst.Type() == durationType {
+		var d Duration
+		if err := d.UnmarshalText([]byte(v)); err != nil {
+			return "must be a Go duration such as 30s or 5m, got " + strconv.Quote(v)
+		}
+		dst.Set(reflect.ValueOf(d))
+		return ""
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(v)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return "must be true or false, got " + strconv.Quote(v)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "must be a whole number, got " + strconv.Quote(v)
+		}
+		dst.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "must be a number, got " + strconv.Quote(v)
+		}
+		dst.SetFloat(f)
+	case reflect.Slice:
+		dst.Set(reflect.ValueOf(splitList(v)))
+	}
+	return ""
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FlagName returns the command-line flag of the setting with environment
+// variable env: WS_PING_INTERVAL is set with --ws-ping-interval
+func FlagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// RegisterFlags defines a flag on fs for every setting, named by FlagName and
+// taking the same values as the environment variable, and returns a Source of
+// the flags given on the command line. Pass it to Load once fs is parsed.
+func RegisterFlags(fs *flag.FlagSet) Source {
+	given := map[string]string{}
+	for _, s := range Default().settings() {
+		fs.Var(&flagValue{
+			env:    s.env,
+			given:  given,
+			isBool: s.value.Kind() == reflect.Bool,
+			def:    formatValue(s.value),
+		}, FlagName(s.env), flagUsage(s))
+	}
+	return func(env string) (string, bool) {
+		v, ok := given[env]
+		return v, ok
+	}
+}
+
+// flagUsage describes the flag of s, naming the kind of value it takes in
+// backquotes for the flag package's usage message
+func flagUsage(s setting) string {
+	kind := ""
+	switch {
+	case s.value.Type() == durationType:
+		kind = " (a `duration`)"
+	case s.value.Kind() == reflect.Slice:
+		kind = " (a comma-separated `list`)"
+	case s.value.Kind() == reflect.String:
+		kind = " (a `string`)"
+	case s.value.Kind() == reflect.Int, s.value.Kind() == reflect.Int64:
+		kind = " (an `integer`)"
+	case s.value.Kind() == reflect.Float64:
+		kind = " (a `number`)"
+	}
+	return fmt.Sprintf("Sets %s%s, env %s", s.key, kind, s.env)
+}
+
+// flagValue records a flag given on the command line; it is parsed by Load
+// like the environment variable it mirrors
+type flagValue struct {
+	env    string
+	given  map[string]string
+	isBool bool
+	def    string // Shown in the usage message
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.def
+}
+
+func (v *flagValue) Set(s string) error {
+	v.given[v.env] = s
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// formatValue writes a setting the way it is given in an environment variable
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return v.Interface().(Duration).Std().String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	case v.Kind() == reflect.String:
+		return v.String()
+	case v.Kind() == reflect.Bool && !v.Bool():
+		return "" // The flag package omits false defaults of boolean flags
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Redacted returns a copy of the configuration with credentials and signing
+// keys replaced, for display
+func (c *Config) Redacted() *Config {
+	out := *c
+	for _, s := range out.settings() {
+		if !s.secret || s.value.IsZero() {
+			continue
+		}
+		switch s.value.Kind() {
+		case reflect.String:
+			s.value.SetString("REDACTED")
+		case reflect.Slice:
+			redacted := make([]string, s.value.Len())
+			for i := range redacted {
+				redacted[i] = "REDACTED"
+			}
+			s.value.Set(reflect.ValueOf(redacted))
+		}
+	}
+	return &out
+}
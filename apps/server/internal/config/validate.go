@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/middleware"
+	"github.com/grpc-bridge/server/internal/secret"
+	"github.com/grpc-bridge/server/internal/websocket"
+)
+
+// Problem is one invalid setting
+type Problem struct {
+	Key     string // Config file key, e.g. "websocket.pong_timeout"
+	Env     string // Environment variable, e.g. "WS_PONG_TIMEOUT"
+	Message string // What is wrong, as a predicate of the setting
+}
+
+func (p Problem) String() string {
+	if p.Env == "" {
+		return p.Key + ": " + p.Message
+	}
+	return fmt.Sprintf("%s (%s): %s", p.Key, p.Env, p.Message)
+}
+
+// Error lists every invalid setting found while loading the configuration
+type Error struct {
+	Problems []Problem
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problem(s)", len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+// report collects problems, keeping only the first one of each setting
+type report struct {
+	problems []Problem
+	seen     map[string]bool
+}
+
+func (r *report) add(key, env, msg string) {
+	if r.seen == nil {
+		r.seen = map[string]bool{}
+	}
+	if r.seen[key] {
+		return
+	}
+	r.seen[key] = true
+	r.problems = append(r.problems, Problem{Key: key, Env: env, Message: msg})
+}
+
+// validate checks the settings against each other and against what the
+// server accepts, adding every violation to r
+func (c *Config) validate(r *report) {
+	check := func(key string, ok bool, format string, args ...any) {
+		if !ok {
+			r.add(key, c.envName(key), fmt.Sprintf(format, args...))
+		}
+	}
+	positive := func(key string, d Duration) {
+		check(key, d > 0, "must be a positive duration")
+	}
+	notNegative := func(key string, n int64) {
+		check(key, n >= 0, "must not be negative")
+	}
+
+	check("server.port", c.Server.Port >= 1 && c.Server.Port <= 65535, "must be a port between 1 and 65535")
+	check("tls.http_redirect_port", c.TLS.HTTPRedirectPort >= 0 && c.TLS.HTTPRedirectPort <= 65535, "must be a port between 1 and 65535, or 0 for none")
+	check("tls.key_file", (c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "must be set together with tls.cert_file")
+	check("tls.autocert_domains", len(c.TLS.AutocertDomains) == 0 || c.TLS.CertFile == "", "cannot be combined with tls.cert_file")
+	for _, origin := range c.CORS.AllowedOrigins {
+		check("cors.allowed_origins", origin == "*" || validOrigin(origin), "has an invalid origin %q (expected scheme://host[:port] or *)", origin)
+	}
+
+	check("uploads.dir", c.Uploads.Dir != "", "is required")
+	notNegative("uploads.max_file_size", c.Uploads.MaxFileSize)
+	notNegative("uploads.max_request_size", c.Uploads.MaxRequestSize)
+	notNegative("uploads.max_memory", c.Uploads.MaxMemory)
+	positive("uploads.chunk_ttl", c.Uploads.ChunkTTL)
+
+	positive("sessions.idle_timeout", c.Sessions.IdleTimeout)
+	notNegative("sessions.max_files", int64(c.Sessions.MaxFiles))
+	notNegative("sessions.max_bytes", c.Sessions.MaxBytes)
+	check("sessions.orphan_grace_period", c.Sessions.OrphanGracePeriod >= 0, "must not be negative")
+	if c.Sessions.SecretKey != "" && c.Sessions.SecretKeyFile == "" {
+		_, err := secret.ParseKey(c.Sessions.SecretKey)
+		check("sessions.secret_key", err == nil, "%v", err)
+	}
+
+	notNegative("storage.max_bytes", c.Storage.MaxBytes)
+	positive("storage.check_interval", c.Storage.CheckInterval)
+
+	switch strings.ToLower(strings.TrimSpace(c.GRPC.Backend)) {
+	case "", grpc.BackendNative, grpc.BackendGrpcurl:
+	default:
+		check("grpc.backend", false, "must be %s or %s", grpc.BackendNative, grpc.BackendGrpcurl)
+	}
+	if _, err := grpc.NewTargetPolicy(c.GRPC.TargetAllow, nil, false); err != nil {
+		check("grpc.target_allow", false, "%v", err)
+	}
+	if _, err := grpc.NewTargetPolicy(nil, c.GRPC.TargetDeny, false); err != nil {
+		check("grpc.target_deny", false, "%v", err)
+	}
+	for _, entry := range c.GRPC.CommonProtoDirs {
+		_, dir, found := strings.Cut(entry, "=")
+		check("grpc.common_proto_dirs", !found || strings.TrimSpace(dir) != "", "has an entry %q without a directory", entry)
+	}
+	for _, entry := range c.GRPC.RemoteProtoSources {
+		prefix, baseURL, found := strings.Cut(entry, "=")
+		check("grpc.remote_proto_sources", found && prefix != "" && baseURL != "", "has an invalid entry %q (expected prefix=baseURL)", entry)
+	}
+	positive("grpc.call_stats_window", c.GRPC.CallStatsWindow)
+	positive("grpc.call_stats_interval", c.GRPC.CallStatsInterval)
+	notNegative("grpc.descriptor_cache_size", int64(c.GRPC.DescriptorCacheSize))
+	check("grpc.conn_idle_timeout", c.GRPC.ConnIdleTimeout >= 0, "must not be negative")
+	notNegative("grpc.conn_cache_size", int64(c.GRPC.ConnCacheSize))
+	check("grpc.call_workers", c.GRPC.CallWorkers > 0, "must be positive")
+	notNegative("grpc.call_queue_size", int64(c.GRPC.CallQueueSize))
+	notNegative("grpc.call_queue_session_size", int64(c.GRPC.CallQueueSessionSize))
+
+	if _, err := grpc.NewTargetPolicy(c.Git.HostAllow, nil, false); err != nil {
+		check("git.host_allow", false, "%v", err)
+	}
+	if _, err := grpc.NewTargetPolicy(nil, c.Git.HostDeny, false); err != nil {
+		check("git.host_deny", false, "%v", err)
+	}
+	notNegative("git.max_checkout_size", c.Git.MaxCheckoutSize)
+
+	users, err := auth.ParseCredentials(strings.Join(c.Auth.Users, ","), ":")
+	check("auth.users", err == nil, "%v", err)
+	tokens, err := auth.ParseCredentials(strings.Join(c.Auth.Tokens, ","), "=")
+	check("auth.tokens", err == nil, "%v", err)
+	switch auth.Mode(strings.ToLower(c.Auth.Mode)) {
+	case "", auth.ModeNone:
+	case auth.ModeBasic:
+		check("auth.users", len(users) > 0, "needs at least one user in basic mode")
+	case auth.ModeToken:
+		check("auth.tokens", len(tokens) > 0, "needs at least one token in token mode")
+	case auth.ModeOIDC:
+		check("auth.oidc.issuer_url", c.Auth.OIDC.IssuerURL != "", "is required in oidc mode")
+		check("auth.oidc.client_id", c.Auth.OIDC.ClientID != "", "is required in oidc mode")
+		check("auth.oidc.redirect_url", c.Auth.OIDC.RedirectURL != "", "is required in oidc mode")
+	default:
+		check("auth.mode", false, "must be none, basic, token or oidc")
+	}
+	positive("auth.ws_token_ttl", c.Auth.WSTokenTTL)
+	positive("auth.download_url_ttl", c.Auth.DownloadURLTTL)
+	positive("auth.oidc.session_ttl", c.Auth.OIDC.SessionTTL)
+
+	ws := c.WebSocket
+	positive("websocket.ping_interval", ws.PingInterval)
+	positive("websocket.pong_timeout", ws.PongTimeout)
+	check("websocket.pong_timeout", ws.PongTimeout > ws.PingInterval, "must be longer than websocket.ping_interval (%s)", ws.PingInterval.Std())
+	check("websocket.replay_buffer", ws.ReplayBuffer > 0, "must be positive")
+	positive("websocket.replay_window", ws.ReplayWindow)
+	check("websocket.compression_level", ws.CompressionLevel >= 1 && ws.CompressionLevel <= 9, "must be between 1 and 9")
+	notNegative("websocket.compression_min_size", int64(ws.CompressionMinSize))
+	positive("websocket.ack_timeout", ws.AckTimeout)
+	check("websocket.ack_retries", ws.AckRetries > 0, "must be positive")
+	if _, err := websocket.ParseBackpressurePolicy(ws.Backpressure); err != nil {
+		check("websocket.backpressure", false, "must be %s or %s", websocket.DropOldest, websocket.Disconnect)
+	}
+	notNegative("websocket.preview_size", int64(ws.PreviewSize))
+	positive("websocket.preview_retention", ws.PreviewRetention)
+
+	check("rate_limit.session", c.RateLimit.Session >= 0, "must not be negative")
+	check("rate_limit.ip", c.RateLimit.IP >= 0, "must not be negative")
+	notNegative("rate_limit.burst", int64(c.RateLimit.Burst))
+
+	switch c.AccessLog.Format {
+	case middleware.AccessLogText, middleware.AccessLogJSON, middleware.AccessLogCombined, middleware.AccessLogOff:
+	default:
+		check("access_log.format", false, "must be text, json, combined or off")
+	}
+	if _, err := middleware.ParseAccessLogFields(strings.Join(c.AccessLog.Fields, ",")); err != nil {
+		check("access_log.fields", false, "%v", err)
+	}
+	check("access_log.sample_rate", c.AccessLog.SampleRate >= 0 && c.AccessLog.SampleRate <= 1, "must be between 0 and 1")
+}
+
+// validOrigin reports whether origin is a browser origin: an http or https
+// URL with a host and nothing after it
+func validOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && (u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
@@ -0,0 +1,43 @@
+// Package database opens the embedded SQLite database that persists call
+// history and collections, and keeps its schema up to date.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DriverName is the database/sql driver used for SQLite. It is registered by
+// the pure-Go modernc.org/sqlite driver (see driver_sqlite.go), so binaries
+// stay free of cgo.
+const DriverName = "sqlite"
+
+// Open opens (creating if needed) the database file at path and applies any
+// pending migrations
+func Open(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	// WAL lets readers proceed during writes; the busy timeout covers the
+	// remaining lock contention between connections
+	dsn := "file:" + path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	// SQLite serializes writers anyway; one connection avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database: failed to open %s: %w", path, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
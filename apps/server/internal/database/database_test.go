@@ -0,0 +1,70 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenAppliesMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "bridge.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	var version, count int
+	if err := db.QueryRow(`SELECT MAX(version), COUNT(*) FROM schema_migrations`).Scan(&version, &count); err != nil {
+		t.Fatalf("reading schema_migrations: %v", err)
+	}
+	if version != len(migrations) || count != len(migrations) {
+		t.Errorf("schema at version %d with %d rows, want %d", version, count, len(migrations))
+	}
+	for _, table := range []string{"history", "collections", "saved_requests"} {
+		var name string
+		if err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&name); err != nil {
+			t.Errorf("table %s missing: %v", table, err)
+		}
+	}
+}
+
+func TestOpenReopenKeepsSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	db.Close()
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatalf("reading schema_migrations: %v", err)
+	}
+	if count != len(migrations) {
+		t.Errorf("reopening applied migrations again: %d rows, want %d", count, len(migrations))
+	}
+}
+
+func TestOpenRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, 0)`, len(migrations)+1); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	db.Close()
+
+	_, err = Open(path)
+	if err == nil || !strings.Contains(err.Error(), "newer than this server supports") {
+		t.Fatalf("Open = %v, want a newer schema error", err)
+	}
+}
@@ -0,0 +1,4 @@
+package database
+
+// The pure-Go SQLite driver; it registers itself as DriverName
+import _ "modernc.org/sqlite"
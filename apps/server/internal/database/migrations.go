@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations are applied in order, each once; version N is migrations[N-1].
+// Never edit a released migration: append a new one instead.
+var migrations = []string{
+	// 1: call history
+	`CREATE TABLE history (
+		id         TEXT PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		created_at INTEGER NOT NULL, -- unix nanoseconds
+		service    TEXT NOT NULL,
+		method     TEXT NOT NULL,
+		status     TEXT NOT NULL,
+		entry      TEXT NOT NULL     -- JSON encoded history.Entry
+	);
+	CREATE INDEX history_session_created ON history (session_id, created_at);`,
+
+	// 2: collections and their saved requests
+	`CREATE TABLE collections (
+		id          TEXT PRIMARY KEY,
+		name        TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		scope       TEXT NOT NULL,
+		session_id  TEXT NOT NULL DEFAULT '',
+		created_at  INTEGER NOT NULL, -- unix nanoseconds
+		updated_at  INTEGER NOT NULL
+	);
+	CREATE INDEX collections_session ON collections (session_id);
+	CREATE TABLE saved_requests (
+		id            TEXT PRIMARY KEY,
+		collection_id TEXT NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+		position      INTEGER NOT NULL,
+		request       TEXT NOT NULL -- JSON encoded collection.SavedRequest
+	);
+	CREATE INDEX saved_requests_collection ON saved_requests (collection_id, position);`,
+
+	// 3: the user who created a global collection
+	`ALTER TABLE collections ADD COLUMN owner TEXT NOT NULL DEFAULT '';`,
+}
+
+// migrate brings the schema up to the latest version
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("database: failed to create migrations table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("database: failed to read schema version: %w", err)
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database: schema version %d is newer than this server supports (%d)", current, len(migrations))
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("database: migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec(migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("database: migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().Unix()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("database: migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("database: migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
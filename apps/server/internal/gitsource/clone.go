@@ -0,0 +1,316 @@
+package gitsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxCheckoutSize bounds the bytes a clone may fetch and check out
+const DefaultMaxCheckoutSize int64 = 256 << 20
+
+const (
+	// hostLookupTimeout bounds the DNS lookup of the repository host
+	hostLookupTimeout = 5 * time.Second
+	// sizePollInterval is how often a running clone is measured against the
+	// checkout size limit
+	sizePollInterval = 200 * time.Millisecond
+)
+
+// CloneOptions describes the repository revision to fetch
+type CloneOptions struct {
+	URL      string
+	Ref      string // branch, tag or commit SHA; empty means the remote HEAD
+	Username string // defaults to "x-access-token" when Token is set
+	Token    string
+}
+
+// HostPolicy checks a repository host ("host:port") before it is contacted
+// and returns the addresses git may connect to, or none to let git resolve
+// the host itself. *grpc.TargetPolicy implements it.
+type HostPolicy interface {
+	Resolve(ctx context.Context, addr string) ([]net.IP, error)
+}
+
+// Policy restricts the repositories Clone may fetch
+type Policy struct {
+	AllowSSH        bool       // Accept ssh:// urls; their host is checked but not pinned
+	Hosts           HostPolicy // nil allows any host
+	MaxCheckoutSize int64      // Bytes; 0 for unlimited
+}
+
+var policy atomic.Pointer[Policy]
+
+// SetPolicy restricts the repositories every Clone may fetch. Without it,
+// ssh:// urls are refused, any host is allowed and checkouts are limited to
+// DefaultMaxCheckoutSize.
+func SetPolicy(p Policy) {
+	policy.Store(&p)
+}
+
+func currentPolicy() Policy {
+	if p := policy.Load(); p != nil {
+		return *p
+	}
+	return Policy{MaxCheckoutSize: DefaultMaxCheckoutSize}
+}
+
+// GitError reports a failed git invocation or an invalid request
+type GitError struct {
+	msg string
+}
+
+func (e *GitError) Error() string {
+	return e.msg
+}
+
+var (
+	ErrGitNotFound = &GitError{"git executable not found in PATH"}
+	ErrInvalidURL  = &GitError{"repository url must be an https:// or http:// url with a host"}
+	ErrSSHDisabled = &GitError{"ssh:// repository urls are disabled on this server"}
+	ErrInvalidRef  = &GitError{"invalid git ref"}
+
+	ErrInsecureToken = &GitError{"a token can only be sent to an https:// repository url"}
+)
+
+// CheckoutTooLargeError reports a clone stopped at the checkout size limit
+type CheckoutTooLargeError struct {
+	Limit int64
+}
+
+func (e *CheckoutTooLargeError) Error() string {
+	return fmt.Sprintf("repository exceeds the maximum checkout size of %d bytes", e.Limit)
+}
+
+// ValidateURL only allows network transports so requests cannot read local
+// repositories (file://, plain paths) or trigger ext:: helpers on the server.
+// ssh:// is only accepted when the Policy allows it.
+func ValidateURL(raw string) error {
+	_, err := parseURL(raw)
+	return err
+}
+
+func parseURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.ContainsAny(raw, " \t\r\n") {
+		return nil, ErrInvalidURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" || u.Opaque != "" {
+		return nil, ErrInvalidURL
+	}
+	switch u.Scheme = strings.ToLower(u.Scheme); u.Scheme {
+	case "https", "http":
+	case "ssh":
+		if !currentPolicy().AllowSSH {
+			return nil, ErrSSHDisabled
+		}
+	default:
+		return nil, ErrInvalidURL
+	}
+	return u, nil
+}
+
+// defaultPorts are the ports git connects to when a url has none
+var defaultPorts = map[string]string{"https": "443", "http": "80", "ssh": "22"}
+
+// ValidateToken refuses to send a token over plain http://, where anyone on
+// the path could read it
+func ValidateToken(rawURL, token string) error {
+	if token != "" && strings.HasPrefix(strings.ToLower(strings.TrimSpace(rawURL)), "http://") {
+		return ErrInsecureToken
+	}
+	return nil
+}
+
+// RedactURL drops the user name and password of a repository url, for events
+// and responses
+func RedactURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	if u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+// Clone performs a shallow fetch of opts.Ref into a new temporary directory.
+// The caller must call cleanup once it is done reading the checkout.
+func Clone(ctx context.Context, opts CloneOptions) (dir string, cleanup func(), err error) {
+	p := currentPolicy()
+	u, err := parseURL(opts.URL)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := ValidateToken(opts.URL, opts.Token); err != nil {
+		return "", nil, err
+	}
+	if strings.HasPrefix(opts.Ref, "-") || strings.ContainsAny(opts.Ref, " \t\n") {
+		return "", nil, ErrInvalidRef
+	}
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return "", nil, ErrGitNotFound
+	}
+
+	// Redirects could lead git to a host the policy was never asked about
+	config := []string{"http.followRedirects", "false"}
+	if p.Hosts != nil {
+		host, port := u.Hostname(), u.Port()
+		if port == "" {
+			port = defaultPorts[u.Scheme]
+		}
+		lookupCtx, cancel := context.WithTimeout(ctx, hostLookupTimeout)
+		ips, err := p.Hosts.Resolve(lookupCtx, net.JoinHostPort(host, port))
+		cancel()
+		if err != nil {
+			return "", nil, err
+		}
+		// Pin the checked address so a DNS answer changing before git
+		// connects cannot bypass the policy
+		if len(ips) > 0 && u.Scheme != "ssh" && net.ParseIP(host) == nil {
+			addr := ips[0].String()
+			if ips[0].To4() == nil {
+				addr = "[" + addr + "]"
+			}
+			config = append(config, "http.curloptResolve", host+":"+port+":"+addr)
+		}
+	}
+	// Pass credentials through environment config so the token never shows up
+	// in the process list or in the stored remote URL.
+	redact := []string{}
+	if opts.Token != "" {
+		user := opts.Username
+		if user == "" {
+			user = "x-access-token"
+		}
+		basic := base64.StdEncoding.EncodeToString([]byte(user + ":" + opts.Token))
+		config = append(config, "http.extraHeader", "Authorization: Basic "+basic)
+		redact = append(redact, opts.Token)
+	}
+	if password, ok := u.User.Password(); ok && password != "" {
+		redact = append(redact, password)
+	}
+	env := append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_ASKPASS=",
+		"GIT_CONFIG_NOSYSTEM=1",
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(config)/2),
+	)
+	for i := 0; i < len(config); i += 2 {
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i/2, config[i]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i/2, config[i+1]),
+		)
+	}
+
+	dir, err = os.MkdirTemp("", "grpc-bridge-git-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var tooLarge atomic.Bool
+	if p.MaxCheckoutSize > 0 {
+		go watchSize(ctx, dir, p.MaxCheckoutSize, func() {
+			tooLarge.Store(true)
+			cancel()
+		})
+	}
+
+	ref := opts.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", u.String()},
+		{"fetch", "--quiet", "--depth", "1", "origin", ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		if err := run(ctx, gitPath, dir, env, redact, args); err != nil {
+			cleanup()
+			if tooLarge.Load() {
+				return "", nil, &CheckoutTooLargeError{Limit: p.MaxCheckoutSize}
+			}
+			return "", nil, err
+		}
+	}
+	// The last poll may predate the end of the checkout
+	if p.MaxCheckoutSize > 0 && dirSize(dir) > p.MaxCheckoutSize {
+		cleanup()
+		return "", nil, &CheckoutTooLargeError{Limit: p.MaxCheckoutSize}
+	}
+	return dir, cleanup, nil
+}
+
+// watchSize calls exceeded once dir grows past limit bytes, until ctx is done
+func watchSize(ctx context.Context, dir string, limit int64, exceeded func()) {
+	ticker := time.NewTicker(sizePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if dirSize(dir) > limit {
+				exceeded()
+				return
+			}
+		}
+	}
+}
+
+// dirSize sums the sizes of the regular files below dir
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Files come and go while git runs
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+func run(ctx context.Context, gitPath, dir string, env, redact []string, args []string) error {
+	cmd := exec.CommandContext(ctx, gitPath, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return &GitError{"git " + args[0] + " timed out"}
+		}
+		msg := strings.TrimSpace(stderr.String())
+		for _, s := range redact {
+			msg = strings.ReplaceAll(msg, s, "***")
+		}
+		return &GitError{fmt.Sprintf("git %s failed: %s", args[0], msg)}
+	}
+	return nil
+}
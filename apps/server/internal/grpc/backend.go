@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/jhump/protoreflect/desc"
+)
+
+// Backend modes selectable via GRPC_BACKEND
+const (
+	BackendNative  = "native"  // In-process gRPC client (default)
+	BackendGrpcurl = "grpcurl" // Legacy grpcurl shell-out (requires the binary on PATH)
+)
+
+// Client is the abstraction used by handlers to execute gRPC operations.
+// NativeClient is the default implementation; GrpcurlClient is kept as an
+// optional fallback for environments that prefer the external binary.
+type Client interface {
+	// Call executes a unary RPC
+	Call(ctx context.Context, opts NativeCallOptions) (*NativeCallResult, error)
+	// ListServices lists service names exposed via server reflection
+	ListServices(ctx context.Context, target string, plaintext bool) ([]string, error)
+	// DescribeService returns a textual description of a service
+	DescribeService(ctx context.Context, opts DescribeOptions) (interface{}, error)
+	// ClearCache drops any cached state for a session
+	ClearCache(sessionID string)
+	// GetMethodDescriptor resolves a method offline from the session's proto files and descriptor sets
+	GetMethodDescriptor(sessionID, sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping, fqService, method string) (*desc.MethodDescriptor, error)
+	// FileDescriptors compiles the session's proto files and descriptor sets, keyed by file name
+	FileDescriptors(sessionID, sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping) (map[string]*desc.FileDescriptor, error)
+}
+
+// NewClient creates the gRPC client backend for the given mode.
+// An empty mode selects the native client.
+func NewClient(mode string) (Client, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", BackendNative:
+		return NewNativeClient(), nil
+	case BackendGrpcurl:
+		return NewGrpcurlClient(NewProxy()), nil
+	default:
+		return nil, fmt.Errorf("unknown gRPC backend %q (expected %q or %q)", mode, BackendNative, BackendGrpcurl)
+	}
+}
@@ -2,55 +2,68 @@ package grpc
 
 import (
 	"context"
-	"crypto/sha256"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"os"
-	"sort"
-	"sync"
+	"net"
 	"time"
 
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/grpc-bridge/server/internal/tracing"
 	"github.com/jhump/protoreflect/desc"
-	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/desc/protoprint"
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	encodingproto "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/mem"
 	"google.golang.org/grpc/metadata"
 	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
 )
 
 // NativeClient implements gRPC calls using native Go gRPC client
 type NativeClient struct {
-	// Cache for file descriptors by session
-	descriptorCache  map[string]map[string]*desc.FileDescriptor
-	cacheFingerprint map[string]string
-	mu               sync.RWMutex
+	// Compiled file descriptors by session, least recently used evicted first
+	descriptors *descriptorCache
+	// Compilations in progress, by session and proto set hash
+	compiling singleflight.Group
 }
 
 // NewNativeClient creates a new native gRPC client
 func NewNativeClient() *NativeClient {
 	return &NativeClient{
-		descriptorCache:  make(map[string]map[string]*desc.FileDescriptor),
-		cacheFingerprint: make(map[string]string),
+		descriptors: newDescriptorCache(),
 	}
 }
 
 // NativeCallOptions represents options for a native gRPC call
 type NativeCallOptions struct {
-	SessionID   string
-	SessionRoot string            // Root directory for proto files
-	ProtoFiles  []string          // Proto file paths
-	Target      string            // gRPC server address
-	Service     string            // Fully qualified service name
-	Method      string            // Method name
-	Data        interface{}       // Request data (JSON or map)
-	Metadata    map[string]string // gRPC metadata headers
-	Plaintext   bool              // Use insecure connection
-	Timeout     time.Duration     // Call timeout
+	SessionID      string
+	SessionRoot    string            // Root directory for proto files
+	ProtoFiles     []string          // Proto file paths
+	DescriptorSets []string          // Compiled descriptor set (.protoset) paths
+	Target         string            // gRPC server address
+	Service        string            // Fully qualified service name
+	Method         string            // Method name
+	Data           interface{}       // Request data (JSON or map)
+	Metadata       map[string]string // gRPC metadata headers
+	Plaintext      bool              // Use insecure connection
+	Timeout        time.Duration     // Call timeout
+	CaptureWire    bool              // Record the request/response bytes as sent and received
+
+	ImportMappings []session.ImportMapping // Import path prefix remapping
+	TLS            *session.TLSSettings    // TLS customization when not plaintext
 }
 
 // NativeCallResult represents the result of a native gRPC call
@@ -59,53 +72,130 @@ type NativeCallResult struct {
 	Headers  map[string][]string `json:"headers,omitempty"`
 	Trailers map[string][]string `json:"trailers,omitempty"`
 	Status   string              `json:"status"`
+	Wire     *WireCapture        `json:"wire,omitempty"`    // Present when CaptureWire was requested; see WireError for failed calls
+	Metrics  *CallMetrics        `json:"metrics,omitempty"` // Size and timing breakdown (native backend only)
 }
 
-// Call executes a gRPC call using native Go gRPC client
-func (c *NativeClient) Call(ctx context.Context, opts NativeCallOptions) (*NativeCallResult, error) {
-	// Apply timeout
-	if opts.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
-		defer cancel()
-	}
+// CallMetrics is a timing and size breakdown of a single call, similar to a browser devtools timing view.
+// Durations are in milliseconds with microsecond precision.
+type CallMetrics struct {
+	RequestBytes      int     `json:"request_bytes"`      // Encoded protobuf request size
+	ResponseBytes     int     `json:"response_bytes"`     // Encoded protobuf response size
+	DescriptorMs      float64 `json:"descriptor_ms"`      // Loading/parsing proto descriptors
+	SerializationMs   float64 `json:"serialization_ms"`   // JSON -> protobuf request encoding
+	DialMs            float64 `json:"dial_ms"`            // Connection establishment
+	RPCMs             float64 `json:"rpc_ms"`             // Time from sending the request to receiving the response
+	DeserializationMs float64 `json:"deserialization_ms"` // Protobuf -> JSON response decoding
+	TotalMs           float64 `json:"total_ms"`
+}
 
-	// Load file descriptors for this session
-	fileDescs, err := c.loadFileDescriptors(opts.SessionID, opts.SessionRoot, opts.ProtoFiles)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load file descriptors: %w", err)
+// msSince returns the elapsed time since start in fractional milliseconds
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
+
+// waitForConnection eagerly connects and blocks until the connection is ready,
+// has failed, or ctx is done. Failures are left for the RPC itself to report.
+func waitForConnection(ctx context.Context, conn *grpc.ClientConn) {
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready || state == connectivity.TransientFailure || state == connectivity.Shutdown {
+			return
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return
+		}
 	}
+}
 
-	// Find service descriptor
-	serviceDesc, err := c.findServiceDescriptor(fileDescs, opts.Service)
-	if err != nil {
-		return nil, fmt.Errorf("service not found: %w", err)
+// WireCapture holds the protobuf bytes of a request/response pair as they
+// were written to and read from the gRPC stream (before compression)
+type WireCapture struct {
+	RequestHex     string `json:"request_hex"`
+	RequestBase64  string `json:"request_base64"`
+	RequestBytes   int    `json:"request_bytes"`
+	ResponseHex    string `json:"response_hex"`
+	ResponseBase64 string `json:"response_base64"`
+	ResponseBytes  int    `json:"response_bytes"`
+}
+
+// newWireCapture encodes raw request/response bytes for display
+func newWireCapture(reqBytes, respBytes []byte) *WireCapture {
+	return &WireCapture{
+		RequestHex:     hex.EncodeToString(reqBytes),
+		RequestBase64:  base64.StdEncoding.EncodeToString(reqBytes),
+		RequestBytes:   len(reqBytes),
+		ResponseHex:    hex.EncodeToString(respBytes),
+		ResponseBase64: base64.StdEncoding.EncodeToString(respBytes),
+		ResponseBytes:  len(respBytes),
 	}
+}
 
-	// Find method descriptor
-	methodDesc := serviceDesc.FindMethodByName(opts.Method)
-	if methodDesc == nil {
-		return nil, fmt.Errorf("method %s not found in service %s", opts.Method, opts.Service)
+// WireError is returned by a failed call made with CaptureWire. Wire holds the
+// bytes exchanged before the failure: the request, and the response if one
+// was received.
+type WireError struct {
+	Err  error
+	Wire *WireCapture
+}
+
+func (e *WireError) Error() string { return e.Err.Error() }
+func (e *WireError) Unwrap() error { return e.Err }
+
+// recordingCodec is the standard proto codec, keeping a copy of the last
+// message it marshalled and unmarshalled
+type recordingCodec struct {
+	encoding.CodecV2
+	request, response []byte
+}
+
+func newRecordingCodec() *recordingCodec {
+	return &recordingCodec{CodecV2: encoding.GetCodecV2(encodingproto.Name)}
+}
+
+func (r *recordingCodec) Marshal(v any) (mem.BufferSlice, error) {
+	out, err := r.CodecV2.Marshal(v)
+	if err == nil {
+		r.request = out.Materialize()
 	}
+	return out, err
+}
 
-	// Create gRPC connection
-	dialOpts := []grpc.DialOption{}
-	if opts.Plaintext {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	} else {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+func (r *recordingCodec) Unmarshal(data mem.BufferSlice, v any) error {
+	r.response = data.Materialize()
+	return r.CodecV2.Unmarshal(data, v)
+}
+
+// Call executes a gRPC call using native Go gRPC client. The parse, dial and
+// invoke phases are traced as children of a span for the whole call, and the
+// trace context is sent to the target in the request metadata.
+func (c *NativeClient) Call(ctx context.Context, opts NativeCallOptions) (result *NativeCallResult, err error) {
+	callStart := time.Now()
+	metrics := &CallMetrics{}
+
+	ctx, span := tracing.Start(ctx, "grpc-bridge.call", trace.WithAttributes(callAttributes(opts)...))
+	defer func() { tracing.End(span, err) }()
+
+	// Apply timeout
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
 	}
 
-	conn, err := grpc.NewClient(opts.Target, dialOpts...)
+	// Load file descriptors for this session
+	phaseStart := time.Now()
+	_, parseSpan := tracing.Start(ctx, "parse")
+	methodDesc, err := c.resolveCallMethod(opts)
+	tracing.End(parseSpan, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to %s: %w", opts.Target, err)
+		return nil, err
 	}
-	defer conn.Close()
-
-	// Create dynamic stub
-	stub := grpcdynamic.NewStub(conn)
+	metrics.DescriptorMs = msSince(phaseStart)
 
 	// Create request message
+	phaseStart = time.Now()
 	reqMsg := dynamic.NewMessage(methodDesc.GetInputType())
 	if opts.Data != nil {
 		// Convert data to JSON bytes
@@ -115,38 +205,88 @@ func (c *NativeClient) Call(ctx context.Context, opts NativeCallOptions) (*Nativ
 		}
 
 		// Unmarshal JSON into dynamic message
-		if err := reqMsg.UnmarshalJSON(dataBytes); err != nil {
+		if err := unmarshalMessageJSON(reqMsg, dataBytes); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal request: %w", err)
 		}
 	}
+	reqBytes, err := reqMsg.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	metrics.RequestBytes = len(reqBytes)
+	metrics.SerializationMs = msSince(phaseStart)
 
-	// Add metadata to context
-	if len(opts.Metadata) > 0 {
-		md := metadata.New(opts.Metadata)
-		ctx = metadata.NewOutgoingContext(ctx, md)
+	// Reuse or create a gRPC connection and wait for the transport to come up
+	// so dial latency (DNS, TCP, TLS, HTTP/2 handshake) is measured separately
+	// from the RPC; it is near zero when a cached connection is reused
+	phaseStart = time.Now()
+	dialCtx, dialSpan := tracing.Start(ctx, "dial", trace.WithAttributes(attribute.String("server.address", opts.Target)))
+	conn, release, err := acquireConn(opts.Target, opts.Plaintext, opts.TLS)
+	if err != nil {
+		tracing.End(dialSpan, err)
+		return nil, fmt.Errorf("failed to connect to %s: %w", opts.Target, err)
 	}
+	defer release()
+	waitForConnection(dialCtx, conn)
+	dialSpan.SetAttributes(attribute.String("grpc.connectivity_state", conn.GetState().String()))
+	dialSpan.End()
+	metrics.DialMs = msSince(phaseStart)
+
+	// Create dynamic stub
+	stub := grpcdynamic.NewStub(conn)
+
+	// Add metadata to context, with the trace context of the invoke span
+	invokeCtx, invokeSpan := tracing.Start(ctx, "invoke", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(callAttributes(opts)...))
+	md := metadata.New(opts.Metadata)
+	tracing.InjectMetadata(invokeCtx, md)
+	invokeCtx = metadata.NewOutgoingContext(invokeCtx, md)
 
 	// Capture headers and trailers
 	var respHeaders, respTrailers metadata.MD
-
-	// Execute RPC call
-	respMsg, err := stub.InvokeRpc(ctx, methodDesc, reqMsg,
+	callOpts := []grpc.CallOption{
 		grpc.Header(&respHeaders),
 		grpc.Trailer(&respTrailers),
-	)
+	}
+
+	// With CaptureWire the codec records the bytes gRPC actually sends and
+	// receives, so the capture is exact and survives a failed call
+	var codec *recordingCodec
+	if opts.CaptureWire {
+		codec = newRecordingCodec()
+		callOpts = append(callOpts, grpc.ForceCodecV2(codec))
+		defer func() {
+			if err != nil && codec.request != nil {
+				err = &WireError{Err: err, Wire: newWireCapture(codec.request, codec.response)}
+			}
+		}()
+	}
+
+	// Execute RPC call
+	phaseStart = time.Now()
+	respMsg, err := stub.InvokeRpc(invokeCtx, methodDesc, reqMsg, callOpts...)
+	metrics.RPCMs = msSince(phaseStart)
+	invokeSpan.SetAttributes(attribute.Int("rpc.grpc.status_code", int(status.Code(err))))
+	tracing.End(invokeSpan, err)
 
 	if err != nil {
 		return nil, fmt.Errorf("RPC call failed: %w", err)
 	}
 
 	// Convert response to JSON-compatible format
-	// Cast to dynamic.Message to access MarshalJSON
+	// Cast to dynamic.Message so well-known types render in canonical JSON
+	phaseStart = time.Now()
 	dynamicResp, ok := respMsg.(*dynamic.Message)
 	if !ok {
 		return nil, fmt.Errorf("unexpected response type")
 	}
 
-	respJSON, err := dynamicResp.MarshalJSON()
+	respBytes, err := dynamicResp.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	metrics.ResponseBytes = len(respBytes)
+
+	respJSON, err := marshalMessageJSON(dynamicResp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal response: %w", err)
 	}
@@ -155,30 +295,32 @@ func (c *NativeClient) Call(ctx context.Context, opts NativeCallOptions) (*Nativ
 	if err := json.Unmarshal(respJSON, &respData); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	metrics.DeserializationMs = msSince(phaseStart)
+	metrics.TotalMs = msSince(callStart)
 
-	return &NativeCallResult{
+	result = &NativeCallResult{
 		Response: respData,
 		Headers:  metadataToMap(respHeaders),
 		Trailers: metadataToMap(respTrailers),
 		Status:   "OK",
-	}, nil
+		Metrics:  metrics,
+	}
+
+	if codec != nil {
+		result.Wire = newWireCapture(codec.request, codec.response)
+	}
+
+	return result, nil
 }
 
 // ListServices lists available services using gRPC reflection
 func (c *NativeClient) ListServices(ctx context.Context, target string, plaintext bool) ([]string, error) {
 	// Create connection
-	dialOpts := []grpc.DialOption{}
-	if plaintext {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	} else {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
-	}
-
-	conn, err := grpc.NewClient(target, dialOpts...)
+	conn, release, err := acquireConn(target, plaintext, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
-	defer conn.Close()
+	defer release()
 
 	// Create reflection client
 	refClient := reflectionpb.NewServerReflectionClient(conn)
@@ -215,53 +357,103 @@ func (c *NativeClient) ListServices(ctx context.Context, target string, plaintex
 	return services, nil
 }
 
-// loadFileDescriptors loads and parses proto files for a session
-func (c *NativeClient) loadFileDescriptors(sessionID, sessionRoot string, protoFiles []string) (map[string]*desc.FileDescriptor, error) {
-	fingerprint := buildDescriptorFingerprint(sessionRoot, protoFiles)
+// DescribeService renders a service definition as proto source.
+// Uploaded proto files are used when available; otherwise the target is queried via reflection.
+func (c *NativeClient) DescribeService(ctx context.Context, opts DescribeOptions) (interface{}, error) {
+	var serviceDesc *desc.ServiceDescriptor
 
-	// Check cache
-	c.mu.RLock()
-	cached, exists := c.descriptorCache[sessionID]
-	cachedFingerprint, fpExists := c.cacheFingerprint[sessionID]
-	c.mu.RUnlock()
-	if exists && fpExists && cachedFingerprint == fingerprint {
-		return cached, nil
+	if len(opts.ProtoFiles) > 0 || len(opts.DescriptorSets) > 0 {
+		fileDescs, err := c.loadFileDescriptors(opts.SessionID, opts.SessionRoot, opts.ProtoFiles, opts.DescriptorSets, opts.ImportMappings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load file descriptors: %w", err)
+		}
+		serviceDesc, _ = c.findServiceDescriptor(fileDescs, opts.Service)
 	}
 
-	// Parse proto files
-	parser := protoparse.Parser{
-		ImportPaths: []string{sessionRoot},
-	}
+	if serviceDesc == nil {
+		if opts.Target == "" {
+			return nil, fmt.Errorf("service %s not found in proto files", opts.Service)
+		}
+
+		conn, release, err := acquireConn(opts.Target, opts.Plaintext, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect: %w", err)
+		}
+		defer release()
+
+		refClient := grpcreflect.NewClientAuto(ctx, conn)
+		defer refClient.Reset()
 
-	// Extract relative paths from absolute paths
-	relativePaths := make([]string, len(protoFiles))
-	for i, absPath := range protoFiles {
-		// Remove sessionRoot prefix to get relative path
-		if len(absPath) > len(sessionRoot) {
-			relativePaths[i] = absPath[len(sessionRoot)+1:]
-		} else {
-			relativePaths[i] = absPath
+		serviceDesc, err = refClient.ResolveService(opts.Service)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve service via reflection: %w", err)
 		}
 	}
 
-	fileDescs, err := parser.ParseFiles(relativePaths...)
+	printer := &protoprint.Printer{}
+	text, err := printer.PrintProtoToString(serviceDesc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse proto files: %w", err)
+		return nil, fmt.Errorf("failed to render service: %w", err)
 	}
 
-	// Build map
-	descMap := make(map[string]*desc.FileDescriptor)
-	for _, fd := range fileDescs {
-		descMap[fd.GetName()] = fd
+	return map[string]string{
+		"description": text,
+	}, nil
+}
+
+// dial creates a client connection using plaintext or TLS transport credentials.
+// tlsSettings may be nil for default TLS verification. The target is checked
+// against ForbidPlaintext and the TargetPolicy first, and with a policy every
+// connection is dialed through it.
+func dial(target string, plaintext bool, tlsSettings *session.TLSSettings) (*grpc.ClientConn, error) {
+	dialOpts := []grpc.DialOption{grpc.WithStatsHandler(statsCounter{})}
+	if err := checkTarget(context.Background(), target, plaintext); err != nil {
+		return nil, err
+	}
+	if policy := targetPolicy.Load(); policy.Restricted() {
+		host, port, _ := targetHostPort(target)
+		target = "passthrough:///" + net.JoinHostPort(host, port)
+		dialOpts = append(dialOpts, grpc.WithContextDialer(policy.dialContext))
+	}
+	if plaintext {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		cfg := &tls.Config{}
+		if tlsSettings != nil {
+			cfg.InsecureSkipVerify = tlsSettings.InsecureSkipVerify
+			cfg.ServerName = tlsSettings.ServerName
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
 	}
 
-	// Cache for this session
-	c.mu.Lock()
-	c.descriptorCache[sessionID] = descMap
-	c.cacheFingerprint[sessionID] = fingerprint
-	c.mu.Unlock()
+	return grpc.NewClient(target, dialOpts...)
+}
+
+// loadFileDescriptors returns the session's compiled descriptors from the
+// cache, compiling them on a miss. Simultaneous misses for the same proto set
+// share one compilation. The returned map is shared and must not be modified.
+func (c *NativeClient) loadFileDescriptors(sessionID, sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping) (map[string]*desc.FileDescriptor, error) {
+	hash := c.descriptors.hash(sessionID, sessionRoot, append(append([]string(nil), protoFiles...), descriptorSets...), mappings)
+	if cached, ok := c.descriptors.get(sessionID, hash); ok {
+		return cached, nil
+	}
 
-	return descMap, nil
+	v, err, _ := c.compiling.Do(sessionID+"\x00"+hash, func() (interface{}, error) {
+		// A compilation that finished while this caller waited to start is reused
+		if cached, ok := c.descriptors.get(sessionID, hash); ok {
+			return cached, nil
+		}
+		descMap, err := compileDescriptors(sessionRoot, protoFiles, descriptorSets, mappings, sessionParseProgress(sessionID))
+		if err != nil {
+			return nil, err
+		}
+		c.descriptors.put(sessionID, hash, descMap)
+		return descMap, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]*desc.FileDescriptor), nil
 }
 
 // findServiceDescriptor finds a service descriptor by fully qualified name
@@ -285,9 +477,40 @@ func metadataToMap(md metadata.MD) map[string][]string {
 	return result
 }
 
+// resolveCallMethod loads the session's descriptors and finds the method a
+// call targets
+func (c *NativeClient) resolveCallMethod(opts NativeCallOptions) (*desc.MethodDescriptor, error) {
+	fileDescs, err := c.loadFileDescriptors(opts.SessionID, opts.SessionRoot, opts.ProtoFiles, opts.DescriptorSets, opts.ImportMappings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load file descriptors: %w", err)
+	}
+
+	serviceDesc, err := c.findServiceDescriptor(fileDescs, opts.Service)
+	if err != nil {
+		return nil, fmt.Errorf("service not found: %w", err)
+	}
+
+	methodDesc := serviceDesc.FindMethodByName(opts.Method)
+	if methodDesc == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", opts.Method, opts.Service)
+	}
+	return methodDesc, nil
+}
+
+// callAttributes describes a call on its spans
+func callAttributes(opts NativeCallOptions) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", opts.Service),
+		attribute.String("rpc.method", opts.Method),
+		attribute.String("server.address", opts.Target),
+		attribute.String("grpc_bridge.session_id", opts.SessionID),
+	}
+}
+
 // GetMethodDescriptor returns the input type descriptor for a method (for generating skeleton)
-func (c *NativeClient) GetMethodDescriptor(sessionID, sessionRoot string, protoFiles []string, fqService, method string) (*desc.MethodDescriptor, error) {
-	fileDescs, err := c.loadFileDescriptors(sessionID, sessionRoot, protoFiles)
+func (c *NativeClient) GetMethodDescriptor(sessionID, sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping, fqService, method string) (*desc.MethodDescriptor, error) {
+	fileDescs, err := c.loadFileDescriptors(sessionID, sessionRoot, protoFiles, descriptorSets, mappings)
 	if err != nil {
 		return nil, err
 	}
@@ -305,9 +528,14 @@ func (c *NativeClient) GetMethodDescriptor(sessionID, sessionRoot string, protoF
 	return methodDesc, nil
 }
 
+// FileDescriptors returns the session's compiled descriptors keyed by file name
+func (c *NativeClient) FileDescriptors(sessionID, sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping) (map[string]*desc.FileDescriptor, error) {
+	return c.loadFileDescriptors(sessionID, sessionRoot, protoFiles, descriptorSets, mappings)
+}
+
 // ListServicesFromProto lists services from proto files (no server connection needed)
-func (c *NativeClient) ListServicesFromProto(sessionID, sessionRoot string, protoFiles []string) ([]string, error) {
-	fileDescs, err := c.loadFileDescriptors(sessionID, sessionRoot, protoFiles)
+func (c *NativeClient) ListServicesFromProto(sessionID, sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping) ([]string, error) {
+	fileDescs, err := c.loadFileDescriptors(sessionID, sessionRoot, protoFiles, descriptorSets, mappings)
 	if err != nil {
 		return nil, err
 	}
@@ -322,28 +550,70 @@ func (c *NativeClient) ListServicesFromProto(sessionID, sessionRoot string, prot
 	return services, nil
 }
 
-// ClearCache clears the descriptor cache for a session (call on session delete)
+// ClearCache drops the session's cached descriptors (call on session delete
+// and when its proto files change)
 func (c *NativeClient) ClearCache(sessionID string) {
-	c.mu.Lock()
-	delete(c.descriptorCache, sessionID)
-	delete(c.cacheFingerprint, sessionID)
-	c.mu.Unlock()
+	c.descriptors.invalidate(sessionID)
+}
+
+// PreparedCall is a unary call with descriptors, request message and connection
+// resolved up front, so it can be invoked repeatedly (e.g. for load testing).
+type PreparedCall struct {
+	release  func()
+	stub     grpcdynamic.Stub
+	method   *desc.MethodDescriptor
+	request  *dynamic.Message
+	metadata metadata.MD
 }
 
-func buildDescriptorFingerprint(sessionRoot string, protoFiles []string) string {
-	hasher := sha256.New()
-	_, _ = hasher.Write([]byte(sessionRoot))
-
-	files := append([]string(nil), protoFiles...)
-	sort.Strings(files)
-	for _, file := range files {
-		_, _ = hasher.Write([]byte(file))
-		if st, err := os.Stat(file); err == nil {
-			_, _ = hasher.Write([]byte(fmt.Sprintf("|%d|%d|", st.Size(), st.ModTime().UnixNano())))
-		} else {
-			_, _ = hasher.Write([]byte("|missing|"))
+// Prepare resolves the method and request once and opens a shared connection
+func (c *NativeClient) Prepare(opts NativeCallOptions) (*PreparedCall, error) {
+	methodDesc, err := c.GetMethodDescriptor(opts.SessionID, opts.SessionRoot, opts.ProtoFiles, opts.DescriptorSets, opts.ImportMappings, opts.Service, opts.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	reqMsg := dynamic.NewMessage(methodDesc.GetInputType())
+	if opts.Data != nil {
+		dataBytes, err := json.Marshal(opts.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request data: %w", err)
+		}
+		if err := unmarshalMessageJSON(reqMsg, dataBytes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal request: %w", err)
 		}
 	}
 
-	return hex.EncodeToString(hasher.Sum(nil))
+	conn, release, err := acquireConn(opts.Target, opts.Plaintext, opts.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", opts.Target, err)
+	}
+
+	return &PreparedCall{
+		release:  release,
+		stub:     grpcdynamic.NewStub(conn),
+		method:   methodDesc,
+		request:  reqMsg,
+		metadata: metadata.New(opts.Metadata),
+	}, nil
+}
+
+// Invoke sends the prepared request once
+func (p *PreparedCall) Invoke(ctx context.Context) error {
+	md := p.metadata
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		md = md.Copy()
+		tracing.InjectMetadata(ctx, md)
+	}
+	if len(md) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+	_, err := p.stub.InvokeRpc(ctx, p.method, p.request)
+	return err
+}
+
+// Close releases the underlying connection
+func (p *PreparedCall) Close() error {
+	p.release()
+	return nil
 }
@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/mem"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestRecordingCodec(t *testing.T) {
+	codec := newRecordingCodec()
+	req := wrapperspb.String("hello")
+	want, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := codec.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !bytes.Equal(out.Materialize(), want) || !bytes.Equal(codec.request, want) {
+		t.Errorf("recorded request %x, sent %x, want %x", codec.request, out.Materialize(), want)
+	}
+
+	resp := &wrapperspb.StringValue{}
+	if err := codec.Unmarshal(mem.BufferSlice{mem.SliceBuffer(want)}, resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if resp.GetValue() != "hello" || !bytes.Equal(codec.response, want) {
+		t.Errorf("Unmarshal decoded %q and recorded %x, want hello and %x", resp.GetValue(), codec.response, want)
+	}
+}
+
+func TestWireError(t *testing.T) {
+	cause := errors.New("RPC call failed: unavailable")
+	var err error = &WireError{Err: cause, Wire: newWireCapture([]byte{0x0a, 0x01, 0x61}, nil)}
+	var wireErr *WireError
+	if !errors.As(err, &wireErr) || !errors.Is(err, cause) || err.Error() != cause.Error() {
+		t.Fatalf("WireError does not wrap its cause: %v", err)
+	}
+	if wireErr.Wire.RequestHex != "0a0161" || wireErr.Wire.ResponseBytes != 0 {
+		t.Errorf("Wire = %+v, want the request only", wireErr.Wire)
+	}
+}
@@ -0,0 +1,298 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+	pproto "github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Stages of a descriptor compilation reported by ParseProgress
+const (
+	ParseStageParse = "parse" // Proto files are being parsed
+	ParseStageLink  = "link"  // Every file is parsed; imports are being linked
+	ParseStageDone  = "done"  // Compilation finished, or failed with Error
+	ParseStageIndex = "index" // Dependency analysis is parsing imports
+)
+
+// ParseProgressInterval is the minimum time between two progress reports of
+// the parse stage; the first and last file are always reported
+const ParseProgressInterval = 100 * time.Millisecond
+
+// ParseProgress reports how far the compilation of a session's proto files has
+// got
+type ParseProgress struct {
+	Stage     string `json:"stage"`
+	Parsed    int    `json:"parsed"`
+	Total     int    `json:"total"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+	Error     string `json:"error,omitempty"` // Set on a failed "done" report
+}
+
+// parseProgressHandler receives the progress of every descriptor compilation
+var parseProgressHandler atomic.Pointer[func(sessionID string, p ParseProgress)]
+
+// OnParseProgress registers fn to receive the progress of descriptor
+// compilations, which run when a session's proto set is first used after it
+// changed. fn is called from the compiling goroutine and must not block.
+func OnParseProgress(fn func(sessionID string, p ParseProgress)) {
+	parseProgressHandler.Store(&fn)
+}
+
+// sessionParseProgress returns the progress callback of a session's
+// compilation, or nil when nobody listens
+func sessionParseProgress(sessionID string) func(ParseProgress) {
+	fn := parseProgressHandler.Load()
+	if fn == nil {
+		return nil
+	}
+	return func(p ParseProgress) { (*fn)(sessionID, p) }
+}
+
+// compileDescriptors loads descriptor sets and compiles proto files.
+// Files parsed from source take precedence over same-named files from a descriptor set.
+// With import mappings, files under a mapped directory are compiled under their import name.
+// Proto files are parsed and linked by parallel workers; progress, if not nil,
+// is told how many have been parsed.
+func compileDescriptors(sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping, progress func(ParseProgress)) (map[string]*desc.FileDescriptor, error) {
+	descMap := make(map[string]*desc.FileDescriptor)
+
+	// Descriptor sets are already compiled, no parsing needed
+	for _, setPath := range descriptorSets {
+		files, err := pproto.LoadDescriptorSet(setPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load descriptor set %s: %w", setPath, err)
+		}
+		for name, fd := range files {
+			descMap[name] = fd
+		}
+	}
+
+	if len(protoFiles) > 0 {
+		started := time.Now()
+		report := func(stage string, parsed int, err error) {
+			if progress == nil {
+				return
+			}
+			p := ParseProgress{Stage: stage, Parsed: parsed, Total: len(protoFiles), ElapsedMs: time.Since(started).Milliseconds()}
+			if err != nil {
+				p.Error = err.Error()
+			}
+			progress(p)
+		}
+
+		fileDescs, err := compileProtoFiles(sessionRoot, protoFiles, mappings, report)
+		if err != nil {
+			err = fmt.Errorf("failed to parse proto files: %w", err)
+			report(ParseStageDone, 0, err)
+			return nil, err
+		}
+		report(ParseStageDone, len(protoFiles), nil)
+
+		for name, fd := range fileDescs {
+			descMap[name] = fd
+		}
+	}
+
+	return descMap, nil
+}
+
+// compileProtoFiles parses the session's proto files on GOMAXPROCS workers,
+// then links them with protocompile, which resolves imports outside the set
+// from the session root, the import mappings, the shared stdlib tree and the
+// built-in well-known types. The result holds the given files by name.
+func compileProtoFiles(sessionRoot string, protoFiles []string, mappings []session.ImportMapping, report func(stage string, parsed int, err error)) (map[string]*desc.FileDescriptor, error) {
+	// Files are compiled under their path relative to the session root, or
+	// their import name when it is mapped
+	names := make([]string, len(protoFiles))
+	for i, absPath := range protoFiles {
+		if len(absPath) > len(sessionRoot) {
+			names[i] = absPath[len(sessionRoot)+1:]
+		} else {
+			names[i] = absPath
+		}
+		if len(mappings) > 0 {
+			names[i] = pproto.ImportName(names[i], mappings)
+		}
+	}
+
+	asts, err := parseProtoFiles(protoFiles, names, report)
+	if err != nil {
+		return nil, err
+	}
+	report(ParseStageLink, len(protoFiles), nil)
+
+	source := &protocompile.SourceResolver{ImportPaths: pproto.ImportPaths(sessionRoot)}
+	if len(mappings) > 0 {
+		source = &protocompile.SourceResolver{Accessor: mappedAccessor(sessionRoot, mappings)}
+	}
+	fallback := protocompile.WithStandardImports(protocompile.CompositeResolver{})
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.CompositeResolver{
+			protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+				if node, ok := asts[path]; ok {
+					return protocompile.SearchResult{AST: node}, nil
+				}
+				return protocompile.SearchResult{}, os.ErrNotExist
+			}),
+			source,
+			fallback,
+		},
+		MaxParallelism: runtime.GOMAXPROCS(0),
+		SourceInfoMode: protocompile.SourceInfoExtraComments, // Keeps comments for describe/type docs
+	}
+	linked, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		return nil, err
+	}
+	return wrapLinkedFiles(linked, names)
+}
+
+// parseProtoFiles parses files into ASTs named by names, using one worker per
+// available CPU. The first syntax error, in file order, fails the parse.
+func parseProtoFiles(files, names []string, report func(stage string, parsed int, err error)) (map[string]*ast.FileNode, error) {
+	nodes := make([]*ast.FileNode, len(files))
+	errs := make([]error, len(files))
+
+	var (
+		mu         sync.Mutex
+		parsed     int
+		lastReport time.Time
+	)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				nodes[i], errs[i] = parseProtoFile(files[i], names[i])
+
+				mu.Lock()
+				parsed++
+				if parsed == 1 || parsed == len(files) || time.Since(lastReport) >= ParseProgressInterval {
+					lastReport = time.Now()
+					report(ParseStageParse, parsed, nil)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	asts := make(map[string]*ast.FileNode, len(files))
+	for i, node := range nodes {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		asts[names[i]] = node
+	}
+	return asts, nil
+}
+
+// parseProtoFile parses one proto file into an AST, failing on the first
+// syntax error
+func parseProtoFile(path, name string) (*ast.FileNode, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer f.Close()
+	return parser.Parse(name, f, reporter.NewHandler(nil))
+}
+
+// wrapLinkedFiles converts linked files to descriptors keyed by name.
+// protocompile represents custom options as dynamic extensions; they are
+// decoded again in place so that options look as they did with protoparse:
+// known extensions typed, the rest as unrecognized fields.
+func wrapLinkedFiles(linked linker.Files, names []string) (map[string]*desc.FileDescriptor, error) {
+	seen := map[string]bool{}
+	var retype func(fd protoreflect.FileDescriptor)
+	retype = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		if res, ok := fd.(linker.Result); ok {
+			retypeOptions(res.FileDescriptorProto().ProtoReflect())
+		}
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			retype(imports.Get(i).FileDescriptor)
+		}
+	}
+
+	fds := make([]protoreflect.FileDescriptor, len(linked))
+	for i, fd := range linked {
+		retype(fd)
+		fds[i] = fd
+	}
+	wrapped, err := desc.WrapFiles(fds)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*desc.FileDescriptor, len(wrapped))
+	for i, fd := range wrapped {
+		files[names[i]] = fd
+	}
+	return files, nil
+}
+
+// retypeOptions re-decodes every options message with extensions found in a
+// descriptor proto, so that dynamic extensions become typed or unrecognized
+// fields. Source info is skipped: it holds no options.
+func retypeOptions(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil || fd.Name() == "source_code_info" {
+			return true
+		}
+		if fd.IsList() {
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				retypeOptions(list.Get(i).Message())
+			}
+			return true
+		}
+		if msg := v.Message(); strings.HasSuffix(string(fd.Message().FullName()), "Options") {
+			if hasExtensions(msg) {
+				data, err := proto.MarshalOptions{AllowPartial: true}.Marshal(msg.Interface())
+				if err == nil {
+					proto.Reset(msg.Interface())
+					_ = proto.UnmarshalOptions{AllowPartial: true}.Unmarshal(data, msg.Interface())
+				}
+			}
+		} else {
+			retypeOptions(msg)
+		}
+		return true
+	})
+}
+
+// hasExtensions reports whether a message has an extension field set
+func hasExtensions(m protoreflect.Message) bool {
+	found := false
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		found = fd.IsExtension()
+		return !found
+	})
+	return found
+}
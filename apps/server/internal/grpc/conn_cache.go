@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grpc-bridge/server/internal/session"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// DefaultConnIdleTimeout is how long an unused target connection is kept open
+// when GRPC_CONN_IDLE_TIMEOUT is not set
+const DefaultConnIdleTimeout = 5 * time.Minute
+
+// DefaultConnCacheSize is the number of target connections kept open when
+// GRPC_CONN_CACHE_SIZE is not set
+const DefaultConnCacheSize = 256
+
+// maxConnSweepInterval bounds how long an idle or broken connection outlives
+// its idle timeout
+const maxConnSweepInterval = 30 * time.Second
+
+// connIdleTimeout and connCacheSize configure the target connection cache;
+// an idle timeout of 0 disables it and a size of 0 removes the limit
+var (
+	connIdleTimeout atomic.Int64
+	connCacheSize   atomic.Int64
+)
+
+func init() {
+	connIdleTimeout.Store(int64(DefaultConnIdleTimeout))
+	connCacheSize.Store(DefaultConnCacheSize)
+}
+
+// SetConnIdleTimeout sets how long a target connection is kept open after its
+// last call. 0 closes every connection when its call finishes.
+func SetConnIdleTimeout(d time.Duration) {
+	connIdleTimeout.Store(int64(d))
+	if d <= 0 {
+		targetConns.closeIdle(true)
+	}
+}
+
+// SetConnCacheSize limits how many target connections are kept open; the
+// least recently used idle ones are closed beyond it. 0 removes the limit.
+func SetConnCacheSize(n int) {
+	connCacheSize.Store(int64(n))
+}
+
+// ConnCacheStats counts the target connection cache's activity since startup
+type ConnCacheStats struct {
+	Cached    int   `json:"cached"`    // Connections kept open
+	InUse     int   `json:"in_use"`    // Cached connections with calls in progress
+	Hits      int64 `json:"hits"`      // Calls that reused a connection
+	Misses    int64 `json:"misses"`    // Calls that dialed a new connection
+	Evictions int64 `json:"evictions"` // Connections closed for being idle, broken or over the limit
+}
+
+// connKey identifies connections that can be shared: the same target reached
+// with the same transport security
+type connKey struct {
+	target    string
+	plaintext bool
+	tls       session.TLSSettings
+}
+
+// cachedConn is a shared target connection and the calls using it
+type cachedConn struct {
+	key      connKey
+	conn     *grpc.ClientConn
+	refs     int
+	lastUsed time.Time
+	retired  bool // Replaced in the cache; closed when its last call releases it
+}
+
+// connCache shares client connections between calls to the same target, so
+// only the first call pays for the TCP, TLS and HTTP/2 handshakes. Unused
+// connections are closed after the idle timeout, and connections whose
+// transport failed are replaced on their next use.
+type connCache struct {
+	mu       sync.Mutex
+	conns    map[connKey]*cachedConn
+	sweeping bool
+
+	hits, misses, evictions int64
+}
+
+// targetConns is the connection cache every dial of the native client goes through
+var targetConns = &connCache{conns: make(map[connKey]*cachedConn)}
+
+// acquireConn returns a connection to target, shared with other calls to it
+// when the cache is enabled. release must be called once the connection is no
+// longer used; it never closes a connection another call holds.
+func acquireConn(target string, plaintext bool, tlsSettings *session.TLSSettings) (*grpc.ClientConn, func(), error) {
+	if connIdleTimeout.Load() <= 0 {
+		conn, err := dial(target, plaintext, tlsSettings)
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn, func() { _ = conn.Close() }, nil
+	}
+	return targetConns.acquire(target, plaintext, tlsSettings)
+}
+
+func (cc *connCache) acquire(target string, plaintext bool, tlsSettings *session.TLSSettings) (*grpc.ClientConn, func(), error) {
+	key := connKey{target: target, plaintext: plaintext}
+	if tlsSettings != nil && !plaintext {
+		key.tls = *tlsSettings
+	}
+
+	cc.mu.Lock()
+	entry, ok := cc.conns[key]
+	if ok && healthy(entry.conn) {
+		cc.mu.Unlock()
+		// The target policy may have changed its verdict since the dial
+		if err := checkTarget(context.Background(), target, plaintext); err != nil {
+			return nil, nil, err
+		}
+		cc.mu.Lock()
+		if cc.conns[key] == entry {
+			entry.refs++
+			entry.lastUsed = time.Now()
+			cc.hits++
+			cc.mu.Unlock()
+			return entry.conn, cc.releaser(entry), nil
+		}
+	}
+	cc.mu.Unlock()
+
+	conn, err := dial(target, plaintext, tlsSettings)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry = &cachedConn{key: key, conn: conn, refs: 1, lastUsed: time.Now()}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.misses++
+	if old, ok := cc.conns[key]; ok {
+		// A broken connection, or one dialed by a concurrent miss
+		cc.retire(old)
+	}
+	cc.conns[key] = entry
+	cc.trim()
+	if !cc.sweeping {
+		cc.sweeping = true
+		go cc.sweep()
+	}
+	return conn, cc.releaser(entry), nil
+}
+
+// releaser returns the release function of one use of entry
+func (cc *connCache) releaser(entry *cachedConn) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cc.mu.Lock()
+			defer cc.mu.Unlock()
+			entry.refs--
+			entry.lastUsed = time.Now()
+			if entry.refs > 0 {
+				return
+			}
+			if entry.retired {
+				_ = entry.conn.Close()
+			} else {
+				// Connections over the limit that were in use can go now
+				cc.trim()
+			}
+		})
+	}
+}
+
+// retire removes entry from the cache and closes it once unused. The caller
+// holds cc.mu.
+func (cc *connCache) retire(entry *cachedConn) {
+	if cc.conns[entry.key] == entry {
+		delete(cc.conns, entry.key)
+	}
+	entry.retired = true
+	cc.evictions++
+	if entry.refs == 0 {
+		_ = entry.conn.Close()
+	}
+}
+
+// trim closes the least recently used idle connections beyond the size
+// limit. Connections in use are never closed. The caller holds cc.mu.
+func (cc *connCache) trim() {
+	limit := int(connCacheSize.Load())
+	for limit > 0 && len(cc.conns) > limit {
+		var oldest *cachedConn
+		for _, entry := range cc.conns {
+			if entry.refs == 0 && (oldest == nil || entry.lastUsed.Before(oldest.lastUsed)) {
+				oldest = entry
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		cc.retire(oldest)
+	}
+}
+
+// sweep periodically closes connections idle for longer than the idle
+// timeout and idle connections whose transport failed
+func (cc *connCache) sweep() {
+	for {
+		interval := time.Duration(connIdleTimeout.Load()) / 2
+		if interval <= 0 || interval > maxConnSweepInterval {
+			interval = maxConnSweepInterval
+		}
+		time.Sleep(interval)
+		cc.closeIdle(false)
+	}
+}
+
+// closeIdle closes unused connections that are broken or past the idle
+// timeout, or every unused connection with all set
+func (cc *connCache) closeIdle(all bool) {
+	timeout := time.Duration(connIdleTimeout.Load())
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	for _, entry := range cc.conns {
+		if entry.refs > 0 {
+			continue
+		}
+		if all || time.Since(entry.lastUsed) >= timeout || !healthy(entry.conn) {
+			cc.retire(entry)
+		}
+	}
+}
+
+// stats returns the cache's counts
+func (cc *connCache) stats() ConnCacheStats {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	stats := ConnCacheStats{Cached: len(cc.conns), Hits: cc.hits, Misses: cc.misses, Evictions: cc.evictions}
+	for _, entry := range cc.conns {
+		if entry.refs > 0 {
+			stats.InUse++
+		}
+	}
+	return stats
+}
+
+// healthy reports whether a connection can carry new calls without being
+// dialed again: it is not shut down and its transport has not failed
+func healthy(conn *grpc.ClientConn) bool {
+	state := conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc/stats"
+)
+
+// Live counts of target connections, RPCs and streams, for spotting leaks
+var (
+	openConns   atomic.Int64
+	activeRPCs  atomic.Int64
+	openStreams atomic.Int64
+)
+
+// ConnStats is a snapshot of the client's traffic to gRPC targets
+type ConnStats struct {
+	Connections int64 `json:"connections"` // Open transports to targets
+	RPCs        int64 `json:"rpcs"`        // RPCs started and not yet finished, streams included
+	Streams     int64 `json:"streams"`     // Streams opened with OpenStream and not yet closed
+
+	Cache ConnCacheStats `json:"cache"`
+}
+
+// Stats returns the current connection, RPC and stream counts and the
+// connection cache's activity
+func Stats() ConnStats {
+	return ConnStats{
+		Connections: openConns.Load(),
+		RPCs:        activeRPCs.Load(),
+		Streams:     openStreams.Load(),
+		Cache:       targetConns.stats(),
+	}
+}
+
+// statsCounter is installed on every dialed connection to maintain the counts
+type statsCounter struct{}
+
+func (statsCounter) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (statsCounter) HandleRPC(_ context.Context, s stats.RPCStats) {
+	switch s.(type) {
+	case *stats.Begin:
+		activeRPCs.Add(1)
+	case *stats.End:
+		activeRPCs.Add(-1)
+	}
+}
+
+func (statsCounter) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (statsCounter) HandleConn(_ context.Context, s stats.ConnStats) {
+	switch s.(type) {
+	case *stats.ConnBegin:
+		openConns.Add(1)
+	case *stats.ConnEnd:
+		openConns.Add(-1)
+	}
+}
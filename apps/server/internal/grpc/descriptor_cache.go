@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/jhump/protoreflect/desc"
+)
+
+// DefaultDescriptorCacheSize is the number of sessions whose compiled
+// descriptors are kept when DESCRIPTOR_CACHE_SIZE is not set
+const DefaultDescriptorCacheSize = 64
+
+// descriptorCacheSize bounds every descriptor cache; 0 means unlimited
+var descriptorCacheSize atomic.Int64
+
+func init() {
+	descriptorCacheSize.Store(DefaultDescriptorCacheSize)
+}
+
+// SetDescriptorCacheSize limits how many sessions' compiled descriptors the
+// clients keep; the least recently used are evicted beyond it. 0 removes the
+// limit.
+func SetDescriptorCacheSize(n int) {
+	descriptorCacheSize.Store(int64(n))
+}
+
+// descriptorCache is an LRU of compiled descriptors keyed by session and the
+// content hash of the session's proto set. A session holds at most one entry:
+// storing a new proto set replaces the previous one, and a re-upload of
+// identical content keeps hitting the cache.
+type descriptorCache struct {
+	mu      sync.Mutex
+	order   *list.List               // Front is the most recently used
+	entries map[string]*list.Element // By session ID
+	stamps  map[string]map[string]fileStamp
+}
+
+// cachedDescriptors is the value of a descriptorCache entry
+type cachedDescriptors struct {
+	sessionID string
+	hash      string
+	files     map[string]*desc.FileDescriptor
+}
+
+// fileStamp remembers a file's content hash for as long as its size and
+// modification time stay the same, so unchanged files are not read again
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+func newDescriptorCache() *descriptorCache {
+	return &descriptorCache{
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		stamps:  make(map[string]map[string]fileStamp),
+	}
+}
+
+// get returns the session's descriptors if they were compiled from the proto
+// set with the given hash
+func (dc *descriptorCache) get(sessionID, hash string) (map[string]*desc.FileDescriptor, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	el, ok := dc.entries[sessionID]
+	if !ok || el.Value.(*cachedDescriptors).hash != hash {
+		return nil, false
+	}
+	dc.order.MoveToFront(el)
+	return el.Value.(*cachedDescriptors).files, true
+}
+
+// put stores the session's descriptors, replacing its previous proto set, and
+// evicts the least recently used sessions beyond the size limit
+func (dc *descriptorCache) put(sessionID, hash string, files map[string]*desc.FileDescriptor) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	entry := &cachedDescriptors{sessionID: sessionID, hash: hash, files: files}
+	if el, ok := dc.entries[sessionID]; ok {
+		el.Value = entry
+		dc.order.MoveToFront(el)
+	} else {
+		dc.entries[sessionID] = dc.order.PushFront(entry)
+	}
+
+	limit := int(descriptorCacheSize.Load())
+	for limit > 0 && dc.order.Len() > limit {
+		oldest := dc.order.Back()
+		evicted := oldest.Value.(*cachedDescriptors).sessionID
+		dc.order.Remove(oldest)
+		delete(dc.entries, evicted)
+		delete(dc.stamps, evicted)
+	}
+}
+
+// invalidate drops the session's descriptors and remembered file hashes
+func (dc *descriptorCache) invalidate(sessionID string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if el, ok := dc.entries[sessionID]; ok {
+		dc.order.Remove(el)
+		delete(dc.entries, sessionID)
+	}
+	delete(dc.stamps, sessionID)
+}
+
+// hash returns the content hash of a session's proto set: the root, the
+// import mappings and the path and SHA-256 of every file. Files whose size and
+// modification time are unchanged since the last call are not read again.
+func (dc *descriptorCache) hash(sessionID, sessionRoot string, files []string, mappings []session.ImportMapping) string {
+	dc.mu.Lock()
+	previous := dc.stamps[sessionID]
+	dc.mu.Unlock()
+
+	hasher := sha256.New()
+	_, _ = hasher.Write([]byte(sessionRoot))
+	for _, m := range mappings {
+		_, _ = hasher.Write([]byte(fmt.Sprintf("|map:%s=%s|", m.Prefix, m.Directory)))
+	}
+
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+	stamps := make(map[string]fileStamp, len(sorted))
+	for _, file := range sorted {
+		_, _ = hasher.Write([]byte(file))
+		st, err := os.Stat(file)
+		if err != nil {
+			_, _ = hasher.Write([]byte("|missing|"))
+			continue
+		}
+		stamp, ok := previous[file]
+		if !ok || stamp.size != st.Size() || !stamp.modTime.Equal(st.ModTime()) {
+			sum, err := fileSum(file)
+			if err != nil {
+				_, _ = hasher.Write([]byte("|unreadable|"))
+				continue
+			}
+			stamp = fileStamp{size: st.Size(), modTime: st.ModTime(), sum: sum}
+		}
+		stamps[file] = stamp
+		_, _ = hasher.Write([]byte("|" + stamp.sum + "|"))
+	}
+
+	dc.mu.Lock()
+	dc.stamps[sessionID] = stamps
+	dc.mu.Unlock()
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// fileSum returns the hex SHA-256 of a file's content
+func fileSum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/grpc-bridge/server/internal/tracing"
+	"github.com/jhump/protoreflect/desc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GrpcurlClient adapts the grpcurl-based Proxy to the Client interface
+type GrpcurlClient struct {
+	proxy *Proxy
+
+	// Offline descriptor resolution (skeletons, type lookups) is shared with the native client
+	descriptors *NativeClient
+}
+
+// NewGrpcurlClient creates a new grpcurl-backed client
+func NewGrpcurlClient(proxy *Proxy) *GrpcurlClient {
+	return &GrpcurlClient{
+		proxy:       proxy,
+		descriptors: NewNativeClient(),
+	}
+}
+
+// Call executes a gRPC call by shelling out to grpcurl. grpcurl parses, dials
+// and invokes in one process, so the trace has a single invoke span whose
+// context is passed on as -H headers.
+func (g *GrpcurlClient) Call(ctx context.Context, opts NativeCallOptions) (*NativeCallResult, error) {
+	return g.call(ctx, opts, nil)
+}
+
+// CallStream executes a server-streaming call through grpcurl and passes each
+// response to onMessage as soon as grpcurl prints it. grpcurl reads its
+// request before it dials, so opts.Data is the only request sent. The result
+// carries no response; the messages have already been delivered.
+func (g *GrpcurlClient) CallStream(ctx context.Context, opts NativeCallOptions, onMessage func(msg any)) (*NativeCallResult, error) {
+	return g.call(ctx, opts, onMessage)
+}
+
+// call runs grpcurl, collecting the response or, with onMessage set,
+// streaming it
+func (g *GrpcurlClient) call(ctx context.Context, opts NativeCallOptions, onMessage func(msg any)) (result *NativeCallResult, err error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	ctx, span := tracing.Start(ctx, "grpc-bridge.call", trace.WithAttributes(callAttributes(opts)...))
+	defer func() { tracing.End(span, err) }()
+
+	// grpcurl resolves the target itself, so it is handed the address the
+	// policy was checked against
+	target, authority, err := pinTarget(ctx, opts.Target, opts.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	importPaths, protoFiles, err := MapGrpcurlSchema(opts.SessionRoot, opts.ImportMappings, opts.ProtoFiles, false)
+	if err != nil {
+		return nil, err
+	}
+
+	invokeCtx, invokeSpan := tracing.Start(ctx, "invoke", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(callAttributes(opts), attribute.String("grpc_bridge.client", "grpcurl"))...))
+	callOpts := CallOptions{
+		SessionID:   opts.SessionID,
+		ProtoFiles:  protoFiles,
+		Target:      target,
+		Authority:   authority,
+		Service:     opts.Service,
+		Method:      opts.Method,
+		Data:        opts.Data,
+		Metadata:    tracing.Headers(invokeCtx, opts.Metadata),
+		Plaintext:   opts.Plaintext,
+		ImportPaths: importPaths,
+		SessionRoot: opts.SessionRoot,
+		ProtoSets:   opts.DescriptorSets,
+		TLS:         opts.TLS,
+	}
+	if onMessage != nil {
+		var raw string
+		raw, err = g.proxy.Stream(invokeCtx, callOpts, onMessage)
+		tracing.End(invokeSpan, err)
+		if err != nil {
+			return nil, err
+		}
+		if raw != "" {
+			// Output that is not JSON is delivered as one text message
+			onMessage(raw)
+		}
+		return &NativeCallResult{Status: "OK"}, nil
+	}
+
+	callResult, err := g.proxy.Call(invokeCtx, callOpts)
+	tracing.End(invokeSpan, err)
+	if err != nil {
+		return nil, err
+	}
+
+	// grpcurl output does not carry structured headers/trailers
+	return &NativeCallResult{
+		Response: callResult.Response,
+		Status:   callResult.Status,
+	}, nil
+}
+
+// Ready checks that the grpcurl binary can be found
+func (g *GrpcurlClient) Ready() error {
+	return g.proxy.Available()
+}
+
+// ListServices lists services via grpcurl reflection
+func (g *GrpcurlClient) ListServices(ctx context.Context, target string, plaintext bool) ([]string, error) {
+	addr, authority, err := pinTarget(ctx, target, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return g.proxy.ListServices(ctx, ListOptions{
+		Target:    addr,
+		Authority: authority,
+		Plaintext: plaintext,
+	})
+}
+
+// DescribeService describes a service via grpcurl
+func (g *GrpcurlClient) DescribeService(ctx context.Context, opts DescribeOptions) (interface{}, error) {
+	if opts.Target != "" {
+		addr, authority, err := pinTarget(ctx, opts.Target, opts.Plaintext)
+		if err != nil {
+			return nil, err
+		}
+		opts.Target, opts.Authority = addr, authority
+	}
+	return g.proxy.DescribeService(ctx, opts)
+}
+
+// ClearCache drops cached offline descriptors; grpcurl itself re-reads proto files on every invocation
+func (g *GrpcurlClient) ClearCache(sessionID string) {
+	g.descriptors.ClearCache(sessionID)
+}
+
+// GetMethodDescriptor resolves a method from the session's proto files without grpcurl
+func (g *GrpcurlClient) GetMethodDescriptor(sessionID, sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping, fqService, method string) (*desc.MethodDescriptor, error) {
+	return g.descriptors.GetMethodDescriptor(sessionID, sessionRoot, protoFiles, descriptorSets, mappings, fqService, method)
+}
+
+// FileDescriptors compiles the session's proto files without grpcurl
+func (g *GrpcurlClient) FileDescriptors(sessionID, sessionRoot string, protoFiles, descriptorSets []string, mappings []session.ImportMapping) (map[string]*desc.FileDescriptor, error) {
+	return g.descriptors.FileDescriptors(sessionID, sessionRoot, protoFiles, descriptorSets, mappings)
+}
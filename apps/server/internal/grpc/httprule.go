@@ -0,0 +1,180 @@
+package grpc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// httpRuleExtension is the field number of the google.api.http method option
+const httpRuleExtension = 72295728
+
+// HTTPRule is the REST mapping declared by a google.api.http method option
+type HTTPRule struct {
+	Method             string     `json:"method"` // GET, POST, ... or the custom verb
+	Path               string     `json:"path"`
+	Body               string     `json:"body,omitempty"`
+	ResponseBody       string     `json:"response_body,omitempty"`
+	AdditionalBindings []HTTPRule `json:"additional_bindings,omitempty"`
+}
+
+// MethodHTTPMapping is the REST mapping of a single RPC
+type MethodHTTPMapping struct {
+	Service    string   `json:"service"`
+	Method     string   `json:"method"`
+	FullMethod string   `json:"full_method"`
+	Rule       HTTPRule `json:"rule"`
+}
+
+// MethodHTTPRule returns the google.api.http rule of a method, or nil when the
+// method has none. The option is decoded from its wire form, so it works for
+// protos parsed from source and for descriptor sets alike.
+func MethodHTTPRule(md *desc.MethodDescriptor) *HTTPRule {
+	opts := md.GetMethodOptions()
+	if opts == nil {
+		return nil
+	}
+	raw, err := proto.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+
+	var rule *HTTPRule
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return rule
+		}
+		raw = raw[n:]
+		if num == httpRuleExtension && typ == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(raw)
+			if m < 0 {
+				return rule
+			}
+			if parsed, ok := parseHTTPRule(v); ok {
+				rule = &parsed
+			}
+			raw = raw[m:]
+			continue
+		}
+		m := protowire.ConsumeFieldValue(num, typ, raw)
+		if m < 0 {
+			return rule
+		}
+		raw = raw[m:]
+	}
+	return rule
+}
+
+// ListHTTPRules returns the REST mappings of every annotated method, sorted by
+// full method name
+func ListHTTPRules(files map[string]*desc.FileDescriptor) []MethodHTTPMapping {
+	mappings := []MethodHTTPMapping{}
+	for _, fd := range files {
+		for _, sd := range fd.GetServices() {
+			for _, md := range sd.GetMethods() {
+				rule := MethodHTTPRule(md)
+				if rule == nil {
+					continue
+				}
+				mappings = append(mappings, MethodHTTPMapping{
+					Service:    sd.GetFullyQualifiedName(),
+					Method:     md.GetName(),
+					FullMethod: "/" + sd.GetFullyQualifiedName() + "/" + md.GetName(),
+					Rule:       *rule,
+				})
+			}
+		}
+	}
+
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].FullMethod < mappings[j].FullMethod
+	})
+	return mappings
+}
+
+// parseHTTPRule decodes a google.api.HttpRule message
+func parseHTTPRule(b []byte) (HTTPRule, bool) {
+	var rule HTTPRule
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return rule, false
+		}
+		b = b[n:]
+
+		if typ != protowire.BytesType {
+			m := protowire.ConsumeFieldValue(num, typ, b)
+			if m < 0 {
+				return rule, false
+			}
+			b = b[m:]
+			continue
+		}
+
+		v, m := protowire.ConsumeBytes(b)
+		if m < 0 {
+			return rule, false
+		}
+		b = b[m:]
+
+		switch num {
+		case 2:
+			rule.Method, rule.Path = "GET", string(v)
+		case 3:
+			rule.Method, rule.Path = "PUT", string(v)
+		case 4:
+			rule.Method, rule.Path = "POST", string(v)
+		case 5:
+			rule.Method, rule.Path = "DELETE", string(v)
+		case 6:
+			rule.Method, rule.Path = "PATCH", string(v)
+		case 7:
+			rule.Body = string(v)
+		case 8:
+			rule.Method, rule.Path = parseCustomHTTPPattern(v)
+		case 11:
+			if binding, ok := parseHTTPRule(v); ok {
+				rule.AdditionalBindings = append(rule.AdditionalBindings, binding)
+			}
+		case 12:
+			rule.ResponseBody = string(v)
+		}
+	}
+	return rule, true
+}
+
+// parseCustomHTTPPattern decodes a google.api.CustomHttpPattern (kind, path)
+func parseCustomHTTPPattern(b []byte) (string, string) {
+	var kind, path string
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			m := protowire.ConsumeFieldValue(num, typ, b)
+			if m < 0 {
+				break
+			}
+			b = b[m:]
+			continue
+		}
+		v, m := protowire.ConsumeBytes(b)
+		if m < 0 {
+			break
+		}
+		b = b[m:]
+		switch num {
+		case 1:
+			kind = strings.ToUpper(string(v))
+		case 2:
+			path = string(v)
+		}
+	}
+	return kind, path
+}
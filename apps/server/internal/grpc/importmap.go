@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	pproto "github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// MapGrpcurlSchema adapts a session's proto inputs to its import mappings for
+// grpcurl, which only understands -import-path. It returns the extra import
+// paths to pass and the proto files to list, where files under a mapped
+// directory are named by their import name so they are not compiled twice.
+// protoFiles may be absolute or relative to the session root; with relative
+// set, import paths are returned relative to the session root as well.
+func MapGrpcurlSchema(sessionRoot string, mappings []session.ImportMapping, protoFiles []string, relative bool) ([]string, []string, error) {
+	if len(mappings) == 0 {
+		return nil, protoFiles, nil
+	}
+
+	importPaths, err := pproto.MaterializeImportMappings(sessionRoot, mappings)
+	if err != nil {
+		return nil, nil, err
+	}
+	if relative {
+		for i, p := range importPaths {
+			if rel, err := filepath.Rel(sessionRoot, p); err == nil {
+				importPaths[i] = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	files := make([]string, len(protoFiles))
+	for i, f := range protoFiles {
+		files[i] = f
+		rel := f
+		if filepath.IsAbs(f) {
+			r, err := filepath.Rel(sessionRoot, f)
+			if err != nil {
+				continue
+			}
+			rel = r
+		}
+		rel = filepath.ToSlash(rel)
+		if name := pproto.ImportName(rel, mappings); name != rel {
+			files[i] = name
+		}
+	}
+
+	return importPaths, files, nil
+}
+
+// mappedAccessor opens files for protoparse, resolving imports through the
+// session's import mappings before falling back to the session root and the
+// shared stdlib tree
+func mappedAccessor(sessionRoot string, mappings []session.ImportMapping) func(string) (io.ReadCloser, error) {
+	return func(name string) (io.ReadCloser, error) {
+		if mapped := pproto.MapImport(sessionRoot, mappings, name); mapped != "" {
+			return os.Open(mapped)
+		}
+		f, err := os.Open(filepath.Join(sessionRoot, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			if stdlib := pproto.StdlibFile(name); stdlib != "" {
+				return os.Open(stdlib)
+			}
+		}
+		return f, err
+	}
+}
@@ -0,0 +1,410 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ServiceDescription is a structured, network-free description of a service
+type ServiceDescription struct {
+	FQService string              `json:"fq_service"`
+	File      string              `json:"file"`
+	Methods   []MethodDescription `json:"methods"`
+
+	Comment string `json:"comment,omitempty"` // Doc comment from the proto source
+}
+
+// MethodDescription describes a single RPC and the fields of its messages
+type MethodDescription struct {
+	Name            string             `json:"name"`
+	FullMethod      string             `json:"full_method"` // e.g. /acme.v1.UserService/GetUser
+	InputType       string             `json:"input_type"`
+	OutputType      string             `json:"output_type"`
+	ClientStreaming bool               `json:"client_streaming"`
+	ServerStreaming bool               `json:"server_streaming"`
+	InputFields     []FieldDescription `json:"input_fields"`
+	OutputFields    []FieldDescription `json:"output_fields"`
+	HTTPRule        *HTTPRule          `json:"http_rule,omitempty"` // google.api.http REST mapping
+
+	Comment string `json:"comment,omitempty"` // Doc comment from the proto source
+}
+
+// MethodSchema describes a single RPC in full: its streaming flags and
+// options, the schemas of its request and response messages, and every other
+// message and enum type those reference
+type MethodSchema struct {
+	Service         string               `json:"service"`
+	Name            string               `json:"name"`
+	FullMethod      string               `json:"full_method"` // e.g. /acme.v1.UserService/GetUser
+	File            string               `json:"file"`
+	ClientStreaming bool                 `json:"client_streaming"`
+	ServerStreaming bool                 `json:"server_streaming"`
+	Input           MessageDescription   `json:"input"`
+	Output          MessageDescription   `json:"output"`
+	Messages        []MessageDescription `json:"messages"`          // Referenced message types, sorted by name
+	Enums           []EnumDescription    `json:"enums"`             // Referenced enum types, sorted by name
+	Options         json.RawMessage      `json:"options,omitempty"` // MethodOptions in protobuf JSON form
+	HTTPRule        *HTTPRule            `json:"http_rule,omitempty"`
+
+	Comment string `json:"comment,omitempty"` // Doc comment from the proto source
+}
+
+// MessageSchema describes a message and every other message and enum type it
+// references, enough to compose a request without the proto source
+type MessageSchema struct {
+	Message  MessageDescription   `json:"message"`
+	Messages []MessageDescription `json:"messages"` // Referenced message types, sorted by name
+	Enums    []EnumDescription    `json:"enums"`    // Referenced enum types, sorted by name
+}
+
+// FieldDescription describes a message field
+type FieldDescription struct {
+	Name        string `json:"name"`
+	JSONName    string `json:"json_name"`
+	Number      int32  `json:"number"`
+	Type        string `json:"type"`                // Scalar name, or "message" / "enum"
+	TypeName    string `json:"type_name,omitempty"` // Fully qualified message or enum name
+	Label       string `json:"label"`               // optional, required or repeated
+	HasPresence bool   `json:"has_presence"`        // Whether unset can be told apart from the zero value
+	OneOf       string `json:"oneof,omitempty"`
+	MapKey      string `json:"map_key,omitempty"`   // Key type for map fields
+	MapValue    string `json:"map_value,omitempty"` // Value type for map fields
+
+	Default string          `json:"default,omitempty"` // proto2 default value
+	Options json.RawMessage `json:"options,omitempty"` // FieldOptions in protobuf JSON form
+	Comment string          `json:"comment,omitempty"` // Doc comment from the proto source
+}
+
+// MessageDescription is a structured description of a message type, suitable
+// for generating request forms
+type MessageDescription struct {
+	FullName       string               `json:"full_name"`
+	Name           string               `json:"name"`
+	File           string               `json:"file"`
+	Fields         []FieldDescription   `json:"fields"`
+	OneOfs         []OneOfDescription   `json:"oneofs"`
+	NestedMessages []MessageDescription `json:"nested_messages"`
+	NestedEnums    []EnumDescription    `json:"nested_enums"`
+	Options        json.RawMessage      `json:"options,omitempty"`
+
+	Comment string `json:"comment,omitempty"` // Doc comment from the proto source
+}
+
+// OneOfDescription lists the fields of a oneof group
+type OneOfDescription struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+// EnumDescription describes an enum and its values
+type EnumDescription struct {
+	FullName string                 `json:"full_name"`
+	Name     string                 `json:"name"`
+	File     string                 `json:"file"`
+	Values   []EnumValueDescription `json:"values"`
+	Options  json.RawMessage        `json:"options,omitempty"`
+
+	Comment string `json:"comment,omitempty"` // Doc comment from the proto source
+}
+
+// EnumValueDescription describes a single enum value
+type EnumValueDescription struct {
+	Name   string `json:"name"`
+	Number int32  `json:"number"`
+
+	Comment string `json:"comment,omitempty"` // Doc comment from the proto source
+}
+
+// FindService looks up a service by fully qualified name across descriptors
+func FindService(files map[string]*desc.FileDescriptor, fqService string) (*desc.ServiceDescriptor, error) {
+	for _, fd := range files {
+		if sd := fd.FindService(fqService); sd != nil {
+			return sd, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s not found in proto files", fqService)
+}
+
+// FindMessage looks up a message type by fully qualified name across descriptors
+func FindMessage(files map[string]*desc.FileDescriptor, fqmn string) (*desc.MessageDescriptor, error) {
+	fqmn = strings.TrimPrefix(fqmn, ".")
+	for _, fd := range files {
+		if md := fd.FindMessage(fqmn); md != nil {
+			return md, nil
+		}
+	}
+	return nil, fmt.Errorf("message type %s not found in proto files", fqmn)
+}
+
+// DescribeMessage builds a structured description of a message, including
+// nested types and options
+func DescribeMessage(md *desc.MessageDescriptor) MessageDescription {
+	out := MessageDescription{
+		FullName:       md.GetFullyQualifiedName(),
+		Name:           md.GetName(),
+		File:           md.GetFile().GetName(),
+		Fields:         DescribeFields(md),
+		OneOfs:         []OneOfDescription{},
+		NestedMessages: []MessageDescription{},
+		NestedEnums:    []EnumDescription{},
+		Options:        optionsJSON(md.GetMessageOptions()),
+		Comment:        docComment(md),
+	}
+	for _, oo := range md.GetOneOfs() {
+		if oo.IsSynthetic() {
+			continue
+		}
+		names := make([]string, 0, len(oo.GetChoices()))
+		for _, f := range oo.GetChoices() {
+			names = append(names, f.GetName())
+		}
+		out.OneOfs = append(out.OneOfs, OneOfDescription{Name: oo.GetName(), Fields: names})
+	}
+	for _, nested := range md.GetNestedMessageTypes() {
+		// Synthesized map entry types are described via the map field instead
+		if nested.IsMapEntry() {
+			continue
+		}
+		out.NestedMessages = append(out.NestedMessages, DescribeMessage(nested))
+	}
+	for _, ed := range md.GetNestedEnumTypes() {
+		out.NestedEnums = append(out.NestedEnums, DescribeEnum(ed))
+	}
+	return out
+}
+
+// DescribeEnum builds a structured description of an enum
+func DescribeEnum(ed *desc.EnumDescriptor) EnumDescription {
+	out := EnumDescription{
+		FullName: ed.GetFullyQualifiedName(),
+		Name:     ed.GetName(),
+		File:     ed.GetFile().GetName(),
+		Values:   make([]EnumValueDescription, 0, len(ed.GetValues())),
+		Options:  optionsJSON(ed.GetEnumOptions()),
+		Comment:  docComment(ed),
+	}
+	for _, v := range ed.GetValues() {
+		out.Values = append(out.Values, EnumValueDescription{Name: v.GetName(), Number: v.GetNumber(), Comment: docComment(v)})
+	}
+	return out
+}
+
+// ListEnums returns every enum (including nested ones) sorted by full name.
+// Enums from google/protobuf well-known files are skipped.
+func ListEnums(files map[string]*desc.FileDescriptor) []EnumDescription {
+	enums := []EnumDescription{}
+	var walk func(md *desc.MessageDescriptor)
+	walk = func(md *desc.MessageDescriptor) {
+		for _, ed := range md.GetNestedEnumTypes() {
+			enums = append(enums, DescribeEnum(ed))
+		}
+		for _, nested := range md.GetNestedMessageTypes() {
+			walk(nested)
+		}
+	}
+
+	for name, fd := range files {
+		if strings.HasPrefix(name, "google/protobuf/") {
+			continue
+		}
+		for _, ed := range fd.GetEnumTypes() {
+			enums = append(enums, DescribeEnum(ed))
+		}
+		for _, md := range fd.GetMessageTypes() {
+			walk(md)
+		}
+	}
+
+	sort.Slice(enums, func(i, j int) bool {
+		return enums[i].FullName < enums[j].FullName
+	})
+	return enums
+}
+
+// optionsJSON renders descriptor options in protobuf JSON form, or nil when unset
+func optionsJSON(opts proto.Message) json.RawMessage {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return nil
+	}
+	data, err := protojson.Marshal(opts)
+	if err != nil || string(data) == "{}" {
+		return nil
+	}
+	return data
+}
+
+// DescribeServiceOffline builds a structured description from parsed descriptors
+func DescribeServiceOffline(sd *desc.ServiceDescriptor) *ServiceDescription {
+	out := &ServiceDescription{
+		FQService: sd.GetFullyQualifiedName(),
+		File:      sd.GetFile().GetName(),
+		Methods:   []MethodDescription{},
+		Comment:   docComment(sd),
+	}
+	for _, m := range sd.GetMethods() {
+		out.Methods = append(out.Methods, MethodDescription{
+			Name:            m.GetName(),
+			FullMethod:      fmt.Sprintf("/%s/%s", sd.GetFullyQualifiedName(), m.GetName()),
+			InputType:       m.GetInputType().GetFullyQualifiedName(),
+			OutputType:      m.GetOutputType().GetFullyQualifiedName(),
+			ClientStreaming: m.IsClientStreaming(),
+			ServerStreaming: m.IsServerStreaming(),
+			InputFields:     DescribeFields(m.GetInputType()),
+			OutputFields:    DescribeFields(m.GetOutputType()),
+			HTTPRule:        MethodHTTPRule(m),
+			Comment:         docComment(m),
+		})
+	}
+	return out
+}
+
+// DescribeMethod builds the full schema of a method, including the types
+// referenced from its input and output messages
+func DescribeMethod(md *desc.MethodDescriptor) *MethodSchema {
+	sd := md.GetService()
+	out := &MethodSchema{
+		Service:         sd.GetFullyQualifiedName(),
+		Name:            md.GetName(),
+		FullMethod:      fmt.Sprintf("/%s/%s", sd.GetFullyQualifiedName(), md.GetName()),
+		File:            md.GetFile().GetName(),
+		ClientStreaming: md.IsClientStreaming(),
+		ServerStreaming: md.IsServerStreaming(),
+		Input:           DescribeMessage(md.GetInputType()),
+		Output:          DescribeMessage(md.GetOutputType()),
+		Options:         optionsJSON(md.GetMethodOptions()),
+		HTTPRule:        MethodHTTPRule(md),
+		Comment:         docComment(md),
+	}
+	out.Messages, out.Enums = ReferencedTypes(md.GetInputType(), md.GetOutputType())
+	return out
+}
+
+// DescribeMessageSchema builds the schema of a message with the types it
+// references
+func DescribeMessageSchema(md *desc.MessageDescriptor) *MessageSchema {
+	out := &MessageSchema{Message: DescribeMessage(md)}
+	out.Messages, out.Enums = ReferencedTypes(md)
+	return out
+}
+
+// ReferencedTypes describes the message and enum types reachable from the
+// fields of roots, directly or through other messages, each once and sorted
+// by name. The roots themselves are left out, and map entries are described
+// through their map fields instead.
+func ReferencedTypes(roots ...*desc.MessageDescriptor) ([]MessageDescription, []EnumDescription) {
+	messages := []MessageDescription{}
+	enums := []EnumDescription{}
+	seen := map[string]bool{}
+	for _, root := range roots {
+		seen[root.GetFullyQualifiedName()] = true
+	}
+	var walk func(msg *desc.MessageDescriptor)
+	walk = func(msg *desc.MessageDescriptor) {
+		for _, f := range msg.GetFields() {
+			if et := f.GetEnumType(); et != nil && !seen[et.GetFullyQualifiedName()] {
+				seen[et.GetFullyQualifiedName()] = true
+				enums = append(enums, DescribeEnum(et))
+			}
+			mt := f.GetMessageType()
+			if mt == nil || seen[mt.GetFullyQualifiedName()] {
+				continue
+			}
+			seen[mt.GetFullyQualifiedName()] = true
+			if !mt.IsMapEntry() {
+				messages = append(messages, DescribeMessage(mt))
+			}
+			walk(mt)
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].FullName < messages[j].FullName
+	})
+	sort.Slice(enums, func(i, j int) bool {
+		return enums[i].FullName < enums[j].FullName
+	})
+	return messages, enums
+}
+
+// DescribeFields lists a message's fields in declaration order
+func DescribeFields(md *desc.MessageDescriptor) []FieldDescription {
+	fields := make([]FieldDescription, 0, len(md.GetFields()))
+	for _, f := range md.GetFields() {
+		fields = append(fields, describeField(f))
+	}
+	return fields
+}
+
+func describeField(f *desc.FieldDescriptor) FieldDescription {
+	fd := FieldDescription{
+		Name:        f.GetName(),
+		JSONName:    f.GetJSONName(),
+		Number:      f.GetNumber(),
+		Type:        fieldKind(f),
+		TypeName:    fieldTypeName(f),
+		Label:       f.UnwrapField().Cardinality().String(),
+		HasPresence: f.UnwrapField().HasPresence(),
+	}
+	if oo := f.GetOneOf(); oo != nil && !oo.IsSynthetic() {
+		fd.OneOf = oo.GetName()
+	}
+	if f.AsFieldDescriptorProto().DefaultValue != nil {
+		fd.Default = f.AsFieldDescriptorProto().GetDefaultValue()
+	}
+	fd.Options = optionsJSON(f.GetFieldOptions())
+	fd.Comment = docComment(f)
+	if f.IsMap() {
+		fd.Type = "map"
+		fd.TypeName = ""
+		fd.MapKey = fieldKind(f.GetMapKeyType())
+		fd.MapValue = fieldKind(f.GetMapValueType())
+		if name := fieldTypeName(f.GetMapValueType()); name != "" {
+			fd.MapValue = name
+		}
+	}
+	return fd
+}
+
+func fieldKind(f *desc.FieldDescriptor) string {
+	return strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+}
+
+func fieldTypeName(f *desc.FieldDescriptor) string {
+	if mt := f.GetMessageType(); mt != nil {
+		return mt.GetFullyQualifiedName()
+	}
+	if et := f.GetEnumType(); et != nil {
+		return et.GetFullyQualifiedName()
+	}
+	return ""
+}
+
+// docComment returns the leading comment of a declaration, falling back to its
+// trailing comment (e.g. "int32 id = 1; // ..."). Comment markers' leading
+// space is stripped from each line. Descriptors without source info (such as
+// most descriptor sets) have no comments.
+func docComment(d desc.Descriptor) string {
+	info := d.GetSourceInfo()
+	if info == nil {
+		return ""
+	}
+	comment := info.GetLeadingComments()
+	if strings.TrimSpace(comment) == "" {
+		comment = info.GetTrailingComments()
+	}
+
+	lines := strings.Split(strings.TrimRight(comment, "\n "), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,434 @@
+package grpc
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// OpenAPIDocument is an OpenAPI 3.0 document describing the REST mappings of
+// google.api.http annotated methods
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+	Security   []map[string][]string                   `json:"security,omitempty"` // Alternative security requirements
+}
+
+// OpenAPIInfo is the document's info object
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIOperation describes a single HTTP operation
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a path, query or header parameter
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"` // "path", "query" or "header"
+	Required    bool           `json:"required,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody is an operation's request body
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is an operation response
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType wraps the schema of a request or response body
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIComponents holds the message schemas referenced by operations
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPISecurityScheme describes how requests authenticate
+type OpenAPISecurityScheme struct {
+	Type   string `json:"type"`             // "http" or "apiKey"
+	Scheme string `json:"scheme,omitempty"` // For http, e.g. "bearer"
+	Name   string `json:"name,omitempty"`   // For apiKey, the header name
+	In     string `json:"in,omitempty"`     // For apiKey, e.g. "header"
+}
+
+// OpenAPISchema is the subset of the OpenAPI schema object needed to describe
+// messages in their protobuf JSON form
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+}
+
+// statusSchemaName is the component used for error responses
+const statusSchemaName = "google.rpc.Status"
+
+// GenerateOpenAPI builds an OpenAPI 3.0 document from the google.api.http
+// rules of every annotated method. Messages are described in their protobuf
+// JSON form (lowerCamelCase names, 64-bit integers as strings) and collected
+// under components.schemas by fully qualified name.
+func GenerateOpenAPI(files map[string]*desc.FileDescriptor, title, version string) *OpenAPIDocument {
+	g := &openAPIGenerator{
+		doc: &OpenAPIDocument{
+			OpenAPI: "3.0.3",
+			Info:    OpenAPIInfo{Title: title, Version: version},
+			Paths:   make(map[string]map[string]*OpenAPIOperation),
+			Components: OpenAPIComponents{
+				Schemas: make(map[string]*OpenAPISchema),
+			},
+		},
+		operationIDs: make(map[string]int),
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, sd := range files[name].GetServices() {
+			for _, md := range sd.GetMethods() {
+				rule := MethodHTTPRule(md)
+				if rule == nil {
+					continue
+				}
+				g.addOperation(sd, md, *rule)
+				for _, binding := range rule.AdditionalBindings {
+					g.addOperation(sd, md, binding)
+				}
+			}
+		}
+	}
+
+	if len(g.doc.Paths) > 0 {
+		g.doc.Components.Schemas[statusSchemaName] = &OpenAPISchema{
+			Type:        "object",
+			Description: "gRPC error status",
+			Properties: map[string]*OpenAPISchema{
+				"code":    {Type: "integer", Format: "int32"},
+				"message": {Type: "string"},
+				"details": {Type: "array", Items: anySchema()},
+			},
+		}
+	}
+	return g.doc
+}
+
+type openAPIGenerator struct {
+	doc          *OpenAPIDocument
+	operationIDs map[string]int
+}
+
+// addOperation adds the operation for one binding of a method
+func (g *openAPIGenerator) addOperation(sd *desc.ServiceDescriptor, md *desc.MethodDescriptor, rule HTTPRule) {
+	path, pathParams := openAPIPath(rule.Path)
+
+	id := sd.GetName() + "_" + md.GetName()
+	if n := g.operationIDs[id]; n > 0 {
+		g.operationIDs[id] = n + 1
+		id = fmt.Sprintf("%s%d", id, n)
+	} else {
+		g.operationIDs[id] = 1
+	}
+
+	op := &OpenAPIOperation{
+		OperationID: id,
+		Tags:        []string{sd.GetFullyQualifiedName()},
+		Responses:   make(map[string]OpenAPIResponse),
+	}
+	if comment := docComment(md); comment != "" {
+		summary, rest, _ := strings.Cut(comment, "\n")
+		op.Summary = summary
+		op.Description = strings.TrimSpace(rest)
+	}
+
+	input := md.GetInputType()
+	bound := make(map[string]bool)
+	for _, param := range pathParams {
+		bound[param] = true
+		p := OpenAPIParameter{Name: param, In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"}}
+		if f := findFieldPath(input, param); f != nil {
+			p.Schema = g.fieldSchema(f)
+			p.Description = docComment(f)
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	if rule.Body == "*" {
+		op.RequestBody = jsonBody(g.messageSchema(input))
+	} else if rule.Body != "" {
+		bound[rule.Body] = true
+		if f := findFieldPath(input, rule.Body); f != nil {
+			op.RequestBody = jsonBody(g.fieldSchema(f))
+		}
+	}
+
+	// Scalar fields not bound to the path or body become query parameters
+	if rule.Body != "*" {
+		for _, f := range input.GetFields() {
+			if bound[f.GetName()] || f.GetMessageType() != nil {
+				continue
+			}
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:        f.GetJSONName(),
+				In:          "query",
+				Description: docComment(f),
+				Schema:      g.fieldSchema(f),
+			})
+		}
+	}
+
+	var response *OpenAPISchema
+	if f := findFieldPath(md.GetOutputType(), rule.ResponseBody); rule.ResponseBody != "" && f != nil {
+		response = g.fieldSchema(f)
+	} else {
+		response = g.messageSchema(md.GetOutputType())
+	}
+	op.Responses["200"] = OpenAPIResponse{
+		Description: "A successful response.",
+		Content:     map[string]OpenAPIMediaType{"application/json": {Schema: response}},
+	}
+	op.Responses["default"] = OpenAPIResponse{
+		Description: "An unexpected error response.",
+		Content: map[string]OpenAPIMediaType{
+			"application/json": {Schema: &OpenAPISchema{Ref: "#/components/schemas/" + statusSchemaName}},
+		},
+	}
+
+	method := strings.ToLower(rule.Method)
+	if !isOpenAPIMethod(method) {
+		// Custom verbs have no OpenAPI equivalent; grpc-gateway serves them as POST
+		method = "post"
+	}
+	if g.doc.Paths[path] == nil {
+		g.doc.Paths[path] = make(map[string]*OpenAPIOperation)
+	}
+	g.doc.Paths[path][method] = op
+}
+
+// messageSchema returns a schema for md, registering it as a component
+func (g *openAPIGenerator) messageSchema(md *desc.MessageDescriptor) *OpenAPISchema {
+	if s := wellKnownSchema(md.GetFullyQualifiedName()); s != nil {
+		return s
+	}
+
+	name := md.GetFullyQualifiedName()
+	ref := &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	if _, exists := g.doc.Components.Schemas[name]; exists {
+		return ref
+	}
+
+	schema := &OpenAPISchema{
+		Type:        "object",
+		Description: docComment(md),
+		Properties:  make(map[string]*OpenAPISchema),
+	}
+	// Register before walking fields so recursive messages terminate
+	g.doc.Components.Schemas[name] = schema
+	for _, f := range md.GetFields() {
+		fs := g.fieldSchema(f)
+		if comment := docComment(f); comment != "" && fs.Ref == "" {
+			fs.Description = comment
+		}
+		schema.Properties[f.GetJSONName()] = fs
+	}
+	return ref
+}
+
+// fieldSchema returns the schema of a field's JSON value
+func (g *openAPIGenerator) fieldSchema(f *desc.FieldDescriptor) *OpenAPISchema {
+	if f.IsMap() {
+		return &OpenAPISchema{
+			Type:                 "object",
+			AdditionalProperties: g.singularSchema(f.GetMapValueType()),
+		}
+	}
+	if f.IsRepeated() {
+		return &OpenAPISchema{Type: "array", Items: g.singularSchema(f)}
+	}
+	return g.singularSchema(f)
+}
+
+func (g *openAPIGenerator) singularSchema(f *desc.FieldDescriptor) *OpenAPISchema {
+	if mt := f.GetMessageType(); mt != nil {
+		return g.messageSchema(mt)
+	}
+	if et := f.GetEnumType(); et != nil {
+		values := make([]string, 0, len(et.GetValues()))
+		for _, v := range et.GetValues() {
+			values = append(values, v.GetName())
+		}
+		return &OpenAPISchema{Type: "string", Enum: values}
+	}
+	return scalarSchema(f.GetType())
+}
+
+// scalarSchema maps a scalar field type to its protobuf JSON representation
+func scalarSchema(t descriptorpb.FieldDescriptorProto_Type) *OpenAPISchema {
+	switch t {
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+		descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		return &OpenAPISchema{Type: "string", Format: "int64"}
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
+		return &OpenAPISchema{Type: "string", Format: "uint64"}
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return &OpenAPISchema{Type: "boolean"}
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return &OpenAPISchema{Type: "string", Format: "byte"}
+	default:
+		return &OpenAPISchema{Type: "string"}
+	}
+}
+
+// wellKnownSchema returns the JSON schema of a well-known type, which has a
+// special JSON form, or nil for other messages
+func wellKnownSchema(fqn string) *OpenAPISchema {
+	switch fqn {
+	case "google.protobuf.Timestamp":
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return &OpenAPISchema{Type: "string"}
+	case "google.protobuf.Struct", "google.protobuf.Empty":
+		return &OpenAPISchema{Type: "object"}
+	case "google.protobuf.ListValue":
+		return &OpenAPISchema{Type: "array", Items: &OpenAPISchema{}}
+	case "google.protobuf.Value":
+		return &OpenAPISchema{}
+	case "google.protobuf.Any":
+		return anySchema()
+	case "google.protobuf.DoubleValue":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_DOUBLE)
+	case "google.protobuf.FloatValue":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_FLOAT)
+	case "google.protobuf.Int64Value":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_INT64)
+	case "google.protobuf.UInt64Value":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_UINT64)
+	case "google.protobuf.Int32Value":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_INT32)
+	case "google.protobuf.UInt32Value":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_UINT32)
+	case "google.protobuf.BoolValue":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_BOOL)
+	case "google.protobuf.StringValue":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_STRING)
+	case "google.protobuf.BytesValue":
+		return scalarSchema(descriptorpb.FieldDescriptorProto_TYPE_BYTES)
+	}
+	return nil
+}
+
+// anySchema describes google.protobuf.Any in JSON form
+func anySchema() *OpenAPISchema {
+	return &OpenAPISchema{
+		Type:                 "object",
+		Properties:           map[string]*OpenAPISchema{"@type": {Type: "string"}},
+		AdditionalProperties: &OpenAPISchema{},
+	}
+}
+
+// jsonBody wraps a schema as a required JSON request body
+func jsonBody(schema *OpenAPISchema) *OpenAPIRequestBody {
+	return &OpenAPIRequestBody{
+		Required: true,
+		Content:  map[string]OpenAPIMediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// openAPIPath converts an HTTP rule path template to an OpenAPI path and
+// returns its variables, e.g. "/v1/{name=shelves/*}:get" becomes "/v1/{name}:get"
+func openAPIPath(template string) (string, []string) {
+	var b strings.Builder
+	var params []string
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			b.WriteString(template)
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			b.WriteString(template)
+			break
+		}
+		end += start
+
+		name, _, _ := strings.Cut(template[start+1:end], "=")
+		params = append(params, name)
+		b.WriteString(template[:start])
+		b.WriteString("{" + name + "}")
+		template = template[end+1:]
+	}
+	return b.String(), params
+}
+
+// findFieldPath resolves a dotted field path (e.g. "book.name") in md
+func findFieldPath(md *desc.MessageDescriptor, fieldPath string) *desc.FieldDescriptor {
+	var field *desc.FieldDescriptor
+	for _, name := range strings.Split(fieldPath, ".") {
+		if md == nil {
+			return nil
+		}
+		field = md.FindFieldByName(name)
+		if field == nil {
+			return nil
+		}
+		md = field.GetMessageType()
+	}
+	return field
+}
+
+// isOpenAPIMethod reports whether method is an operation key in a path item
+func isOpenAPIMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+		http.MethodPatch, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
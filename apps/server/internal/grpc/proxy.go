@@ -5,8 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
+	"sort"
 	"strings"
+
+	pproto "github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/session"
 )
 
 // Proxy handles gRPC communication using grpcurl
@@ -28,18 +33,30 @@ func NewProxy() *Proxy {
 	}
 }
 
+// Available reports an error when the grpcurl binary cannot be found
+func (p *Proxy) Available() error {
+	if _, err := exec.LookPath(p.grpcurlPath); err != nil {
+		return fmt.Errorf("grpcurl not found: %w", err)
+	}
+	return nil
+}
+
 // CallOptions represents options for a gRPC call
 type CallOptions struct {
-	SessionID    string
-	ProtoFiles   []string // Absolute paths to proto files
-	Target       string
-	Service      string
-	Method       string
-	Data         interface{}
-	Metadata     map[string]string
-	Plaintext    bool
-	ImportPaths  []string // Additional import paths
-	SessionRoot  string   // Session root directory (used as primary import path)
+	SessionID   string
+	ProtoFiles  []string // Absolute paths to proto files
+	Target      string
+	Service     string
+	Method      string
+	Data        interface{}
+	Metadata    map[string]string
+	Plaintext   bool
+	ImportPaths []string // Additional import paths
+	SessionRoot string   // Session root directory (used as primary import path)
+	ProtoSets   []string // Compiled descriptor sets; grpcurl uses these instead of proto sources
+	Authority   string   // Host name of a Target pinned to an IP address (see pinTarget)
+
+	TLS *session.TLSSettings // TLS customization when not plaintext
 }
 
 // CallResult represents the result of a gRPC call
@@ -50,34 +67,193 @@ type CallResult struct {
 	Status   string      `json:"status"`
 }
 
-// Call executes a gRPC call using grpcurl
+// Call executes a gRPC call using grpcurl. A server-streaming call's
+// responses are returned as an array; output that is not JSON is returned as
+// text.
 func (p *Proxy) Call(ctx context.Context, opts CallOptions) (*CallResult, error) {
+	var messages []interface{}
+	raw, err := p.Stream(ctx, opts, func(msg interface{}) {
+		messages = append(messages, msg)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response interface{}
+	switch {
+	case raw != "":
+		response = raw
+	case len(messages) == 1:
+		response = messages[0]
+	case len(messages) > 1:
+		response = messages
+	}
+
+	result := &CallResult{
+		Response: response,
+		Status:   "OK",
+	}
+
+	return result, nil
+}
+
+// Stream executes a gRPC call using grpcurl and passes each response message
+// to onMessage as soon as grpcurl prints it, so the responses of a long
+// server-streaming call arrive while it runs. grpcurl prints one JSON document
+// per message; they are split by decoding stdout incrementally. If the output
+// is not JSON, decoding stops and the output (at most maxRawOutput bytes) is
+// returned as raw text once the process exits.
+func (p *Proxy) Stream(ctx context.Context, opts CallOptions, onMessage func(msg interface{})) (string, error) {
+	args, err := BuildCallArgs(opts)
+	if err != nil {
+		return "", err
+	}
+
+	// Execute grpcurl command
+	cmd := exec.CommandContext(ctx, p.grpcurlPath, args...)
+
+	var output rawOutput
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("grpcurl execution failed: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("grpcurl execution failed: %w", err)
+	}
+
+	// Keep a copy of the output in case it turns out not to be JSON; once a
+	// document decodes the output is JSON and the copy is dropped
+	decoder := json.NewDecoder(io.TeeReader(stdout, &output))
+	var decodeErr error
+	messages := 0
+	for {
+		var msg interface{}
+		if err := decoder.Decode(&msg); err != nil {
+			if err != io.EOF {
+				decodeErr = err
+			}
+			break
+		}
+		if messages == 0 {
+			output.discard()
+		}
+		messages++
+		onMessage(msg)
+	}
+	// Drain the rest so grpcurl is not blocked writing to a full pipe
+	_, _ = io.Copy(&output, stdout)
+
+	if err := cmd.Wait(); err != nil {
+		return "", fmt.Errorf("grpcurl execution failed: %s\nstderr: %s", err.Error(), stderr.String())
+	}
+	switch {
+	case decodeErr == nil:
+		return "", nil
+	case messages > 0:
+		return "", fmt.Errorf("grpcurl printed invalid JSON after %d messages: %w", messages, decodeErr)
+	default:
+		return output.String(), nil
+	}
+}
+
+// maxRawOutput bounds how much non-JSON grpcurl output is kept
+const maxRawOutput = 1 << 20
+
+// rawOutput is the copy of grpcurl's stdout used when it is not JSON. It keeps
+// at most maxRawOutput bytes and nothing after discard.
+type rawOutput struct {
+	buf       bytes.Buffer
+	dropped   bool
+	truncated bool
+}
+
+func (r *rawOutput) Write(p []byte) (int, error) {
+	if r.dropped {
+		return len(p), nil
+	}
+	keep := min(len(p), maxRawOutput-r.buf.Len())
+	r.buf.Write(p[:keep])
+	if keep < len(p) {
+		r.truncated = true
+	}
+	return len(p), nil
+}
+
+// discard drops the copy kept so far and ignores further writes
+func (r *rawOutput) discard() {
+	r.dropped, r.truncated = true, false
+	r.buf = bytes.Buffer{}
+}
+
+func (r *rawOutput) String() string {
+	if r.truncated {
+		return r.buf.String() + fmt.Sprintf("\n[output truncated at %d bytes]", maxRawOutput)
+	}
+	return r.buf.String()
+}
+
+// schemaArgs builds the -protoset or -import-path/-proto flags. grpcurl cannot
+// mix both sources, so descriptor sets win when the session has any.
+func schemaArgs(sessionRoot string, importPaths, protoFiles, protoSets []string) []string {
 	args := []string{}
 
+	if len(protoSets) > 0 {
+		for _, protoSet := range protoSets {
+			args = append(args, "-protoset", protoSet)
+		}
+		return args
+	}
+
 	// Add session root as primary import path (MUST come first for proper resolution)
-	if opts.SessionRoot != "" {
-		args = append(args, "-import-path", opts.SessionRoot)
+	if sessionRoot != "" {
+		args = append(args, "-import-path", sessionRoot)
 	}
 
 	// Add additional import paths
-	for _, importPath := range opts.ImportPaths {
+	for _, importPath := range importPaths {
 		args = append(args, "-import-path", importPath)
 	}
 
+	// The shared stdlib tree resolves well-known imports last
+	if stdlib := pproto.StdlibDir(); stdlib != "" && sessionRoot != "" {
+		args = append(args, "-import-path", stdlib)
+	}
+
 	// Add proto files
-	for _, protoFile := range opts.ProtoFiles {
+	for _, protoFile := range protoFiles {
 		args = append(args, "-proto", protoFile)
 	}
 
-	// Add metadata headers
-	for key, value := range opts.Metadata {
-		args = append(args, "-H", fmt.Sprintf("%s: %s", key, value))
+	return args
+}
+
+// BuildCallArgs builds the grpcurl argument list for a unary call
+func BuildCallArgs(opts CallOptions) ([]string, error) {
+	args := schemaArgs(opts.SessionRoot, opts.ImportPaths, opts.ProtoFiles, opts.ProtoSets)
+
+	// Add metadata headers (sorted for deterministic output)
+	keys := make([]string, 0, len(opts.Metadata))
+	for key := range opts.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-H", fmt.Sprintf("%s: %s", key, opts.Metadata[key]))
 	}
 
 	// Add plaintext flag if needed
+	serverName := ""
 	if opts.Plaintext {
 		args = append(args, "-plaintext")
+	} else if opts.TLS != nil {
+		if opts.TLS.InsecureSkipVerify {
+			args = append(args, "-insecure")
+		}
+		serverName = opts.TLS.ServerName
 	}
+	args = append(args, connectionArgs(opts.Authority, serverName)...)
 
 	// Add format flags for better output
 	args = append(args, "-format", "json")
@@ -98,33 +274,50 @@ func (p *Proxy) Call(ctx context.Context, opts CallOptions) (*CallResult, error)
 	fullMethod := fmt.Sprintf("%s/%s", opts.Service, opts.Method)
 	args = append(args, opts.Target, fullMethod)
 
-	// Execute grpcurl command
-	cmd := exec.CommandContext(ctx, p.grpcurlPath, args...)
+	return args, nil
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// connectionArgs returns the flags naming the server a pinned target belongs
+// to. grpcurl refuses -authority and -servername with different values, so
+// an explicit server name wins; it is then what the certificate is checked
+// against.
+func connectionArgs(authority, serverName string) []string {
+	switch {
+	case serverName != "":
+		return []string{"-servername", serverName}
+	case authority != "":
+		// Sent as :authority and, over TLS, verified against the certificate
+		return []string{"-authority", authority}
+	}
+	return nil
+}
 
-	err := cmd.Run()
-	if err != nil {
-		return nil, fmt.Errorf("grpcurl execution failed: %s\nstderr: %s", err.Error(), stderr.String())
+// FormatCommand renders a grpcurl invocation as a POSIX shell command line
+func FormatCommand(args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, "grpcurl")
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
 	}
+	return strings.Join(parts, " ")
+}
 
-	// Parse output
-	var response interface{}
-	if stdout.Len() > 0 {
-		if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
-			// If not valid JSON, return raw output
-			response = stdout.String()
+// shellQuote single-quotes an argument when it contains shell metacharacters
+func shellQuote(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range arg {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+", r)) {
+			safe = false
+			break
 		}
 	}
-
-	result := &CallResult{
-		Response: response,
-		Status:   "OK",
+	if safe {
+		return arg
 	}
-
-	return result, nil
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
 }
 
 // ListOptions represents options for listing services
@@ -132,6 +325,7 @@ type ListOptions struct {
 	SessionID   string
 	ProtoFiles  []string // Absolute paths to proto files
 	Target      string
+	Authority   string // Host name of a Target pinned to an IP address
 	Plaintext   bool
 	SessionRoot string // Session root directory (used as import path)
 }
@@ -144,6 +338,9 @@ func (p *Proxy) ListServices(ctx context.Context, opts ListOptions) ([]string, e
 	if opts.SessionRoot != "" {
 		args = append(args, "-import-path", opts.SessionRoot)
 		fmt.Printf("[grpcurl] Adding import path: %s\n", opts.SessionRoot)
+		if stdlib := pproto.StdlibDir(); stdlib != "" {
+			args = append(args, "-import-path", stdlib)
+		}
 	}
 
 	// Add proto files
@@ -155,6 +352,7 @@ func (p *Proxy) ListServices(ctx context.Context, opts ListOptions) ([]string, e
 	if opts.Plaintext {
 		args = append(args, "-plaintext")
 	}
+	args = append(args, connectionArgs(opts.Authority, "")...)
 
 	// List services
 	args = append(args, opts.Target, "list")
@@ -187,32 +385,31 @@ func (p *Proxy) ListServices(ctx context.Context, opts ListOptions) ([]string, e
 
 // DescribeOptions represents options for describing a service
 type DescribeOptions struct {
-	SessionID   string
-	ProtoFiles  []string // Absolute paths to proto files
-	Target      string
-	Service     string
-	Plaintext   bool
-	SessionRoot string // Session root directory (used as import path)
+	SessionID      string
+	ProtoFiles     []string // Absolute paths to proto files
+	Target         string
+	Authority      string // Host name of a Target pinned to an IP address
+	Service        string
+	Plaintext      bool
+	SessionRoot    string   // Session root directory (used as import path)
+	DescriptorSets []string // Compiled descriptor sets (.protoset)
+
+	ImportMappings []session.ImportMapping // Import path prefix remapping
 }
 
 // DescribeService describes a gRPC service
 func (p *Proxy) DescribeService(ctx context.Context, opts DescribeOptions) (interface{}, error) {
-	args := []string{}
-
-	// Add session root as import path
-	if opts.SessionRoot != "" {
-		args = append(args, "-import-path", opts.SessionRoot)
-	}
-
-	// Add proto files
-	for _, protoFile := range opts.ProtoFiles {
-		args = append(args, "-proto", protoFile)
+	importPaths, protoFiles, err := MapGrpcurlSchema(opts.SessionRoot, opts.ImportMappings, opts.ProtoFiles, false)
+	if err != nil {
+		return nil, err
 	}
+	args := schemaArgs(opts.SessionRoot, importPaths, protoFiles, opts.DescriptorSets)
 
 	// Add plaintext flag if needed
 	if opts.Plaintext {
 		args = append(args, "-plaintext")
 	}
+	args = append(args, connectionArgs(opts.Authority, "")...)
 
 	// Describe service
 	args = append(args, opts.Target, "describe", opts.Service)
@@ -224,7 +421,7 @@ func (p *Proxy) DescribeService(ctx context.Context, opts DescribeOptions) (inte
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		return nil, fmt.Errorf("grpcurl execution failed: %s\nstderr: %s", err.Error(), stderr.String())
 	}
@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeGrpcurl writes a shell script that stands in for grpcurl
+func fakeGrpcurl(t *testing.T, body string) *Proxy {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "grpcurl")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return &Proxy{grpcurlPath: path}
+}
+
+func TestProxyStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		messages []interface{}
+		raw      string
+		wantErr  string
+	}{
+		{
+			name:     "json documents",
+			script:   `printf '{"n": 1}\n{"n": 2}\n'`,
+			messages: []interface{}{map[string]interface{}{"n": 1.0}, map[string]interface{}{"n": 2.0}},
+		},
+		{
+			name:   "text output",
+			script: `printf 'hello world\n'`,
+			raw:    "hello world\n",
+		},
+		{
+			name:     "text after json",
+			script:   `printf '{"n": 1}\nnot json\n'`,
+			messages: []interface{}{map[string]interface{}{"n": 1.0}},
+			wantErr:  "invalid JSON after 1 messages",
+		},
+		{
+			name:    "process failure",
+			script:  `echo boom >&2; exit 1`,
+			wantErr: "stderr: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := fakeGrpcurl(t, tt.script)
+			var messages []interface{}
+			raw, err := p.Stream(context.Background(), CallOptions{Target: "localhost:50051", Service: "a.S", Method: "M"}, func(msg interface{}) {
+				messages = append(messages, msg)
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Stream error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Stream: %v", err)
+			}
+			if raw != tt.raw {
+				t.Errorf("Stream raw = %q, want %q", raw, tt.raw)
+			}
+			if !reflect.DeepEqual(messages, tt.messages) {
+				t.Errorf("Stream messages = %v, want %v", messages, tt.messages)
+			}
+		})
+	}
+}
+
+func TestRawOutput(t *testing.T) {
+	var out rawOutput
+	chunk := strings.Repeat("x", maxRawOutput/2+1)
+	for range 3 {
+		if n, err := out.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write = %d, %v, want %d, nil", n, err, len(chunk))
+		}
+	}
+	if got := out.buf.Len(); got != maxRawOutput {
+		t.Errorf("kept %d bytes, want %d", got, maxRawOutput)
+	}
+	if !strings.HasSuffix(out.String(), "[output truncated at 1048576 bytes]") {
+		t.Errorf("String() does not mark the truncation")
+	}
+
+	out.discard()
+	_, _ = out.Write([]byte("more"))
+	if out.buf.Len() != 0 {
+		t.Errorf("discarded output kept %d bytes", out.buf.Len())
+	}
+}
@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// FetchDescriptorSet downloads the file descriptors of every service exposed via
+// server reflection (plus their transitive imports) as a FileDescriptorSet.
+// Files are ordered dependencies-first, like protoc --include_imports output.
+func FetchDescriptorSet(ctx context.Context, target string, plaintext bool) (*descriptorpb.FileDescriptorSet, []string, error) {
+	conn, release, err := acquireConn(target, plaintext, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer release()
+
+	refClient := grpcreflect.NewClientAuto(ctx, conn)
+	defer refClient.Reset()
+
+	names, err := refClient.ListServices()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list services via reflection: %w", err)
+	}
+	sort.Strings(names)
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if seen[fd.GetName()] {
+			return
+		}
+		seen[fd.GetName()] = true
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+
+	services := make([]string, 0, len(names))
+	for _, name := range names {
+		// The reflection service itself is not useful to call
+		if strings.HasPrefix(name, "grpc.reflection.") {
+			continue
+		}
+		svc, err := refClient.ResolveService(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve service %s: %w", name, err)
+		}
+		add(svc.GetFile())
+		services = append(services, name)
+	}
+
+	if len(set.File) == 0 {
+		return nil, nil, fmt.Errorf("target exposes no services via reflection")
+	}
+	return set, services, nil
+}
+
+// ErrNotFoundViaReflection is returned when the target's reflection service
+// does not know the requested element
+var ErrNotFoundViaReflection = errors.New("not found via reflection")
+
+// ResolveServiceViaReflection fetches a service's descriptor, with the files
+// it depends on, from the target's reflection service
+func ResolveServiceViaReflection(ctx context.Context, target string, plaintext bool, fqService string) (*desc.ServiceDescriptor, error) {
+	conn, release, err := acquireConn(target, plaintext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer release()
+
+	refClient := grpcreflect.NewClientAuto(ctx, conn)
+	defer refClient.Reset()
+
+	sd, err := refClient.ResolveService(fqService)
+	if grpcreflect.IsElementNotFoundError(err) {
+		return nil, fmt.Errorf("service %s %w", fqService, ErrNotFoundViaReflection)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve service via reflection: %w", err)
+	}
+	return sd, nil
+}
+
+// ReflectedService is a service and its methods as reported by a target's
+// reflection service
+type ReflectedService struct {
+	FQService string               `json:"fq_service"`
+	File      string               `json:"file"`
+	Methods   []session.MethodInfo `json:"methods"`
+	Error     string               `json:"error,omitempty"` // Why the methods could not be resolved
+}
+
+// ResolveServicesViaReflection looks up the methods of each named service with
+// file_containing_symbol requests over a single reflection stream. A service
+// that fails to resolve is returned without methods and with its error, so
+// one broken descriptor does not hide the rest.
+func ResolveServicesViaReflection(ctx context.Context, target string, plaintext bool, names []string) ([]ReflectedService, error) {
+	conn, release, err := acquireConn(target, plaintext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer release()
+
+	refClient := grpcreflect.NewClientAuto(ctx, conn)
+	defer refClient.Reset()
+
+	out := make([]ReflectedService, 0, len(names))
+	for _, name := range names {
+		svc := ReflectedService{FQService: name, File: "(reflection)", Methods: []session.MethodInfo{}}
+		sd, err := refClient.ResolveService(name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("failed to resolve service %s: %w", name, err)
+			}
+			svc.Error = err.Error()
+			out = append(out, svc)
+			continue
+		}
+		svc.File = sd.GetFile().GetName()
+		for _, m := range sd.GetMethods() {
+			svc.Methods = append(svc.Methods, session.MethodInfo{
+				Name:       m.GetName(),
+				InputType:  m.GetInputType().GetFullyQualifiedName(),
+				OutputType: m.GetOutputType().GetFullyQualifiedName(),
+				Streaming:  m.IsClientStreaming() || m.IsServerStreaming(),
+			})
+		}
+		out = append(out, svc)
+	}
+	return out, nil
+}
+
+// ResolveMessageViaReflection fetches a message type's descriptor, with the
+// files it depends on, from the target's reflection service
+func ResolveMessageViaReflection(ctx context.Context, target string, plaintext bool, fqmn string) (*desc.MessageDescriptor, error) {
+	conn, release, err := acquireConn(target, plaintext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer release()
+
+	refClient := grpcreflect.NewClientAuto(ctx, conn)
+	defer refClient.Reset()
+
+	fqmn = strings.TrimPrefix(fqmn, ".")
+	md, err := refClient.ResolveMessage(fqmn)
+	if grpcreflect.IsElementNotFoundError(err) {
+		return nil, fmt.Errorf("message type %s %w", fqmn, ErrNotFoundViaReflection)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve message type via reflection: %w", err)
+	}
+	return md, nil
+}
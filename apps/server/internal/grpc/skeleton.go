@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// BuildSkeleton renders a sample JSON payload for a message type: nested
+// messages are expanded, enums use their first value, repeated fields hold one
+// element, and only the first field of each oneof is included. Well-known
+// types use their canonical JSON form (RFC 3339 timestamps, "0s" durations,
+// plain values for wrappers). Recursive message references are rendered as
+// empty objects.
+func BuildSkeleton(md *desc.MessageDescriptor) map[string]interface{} {
+	return buildMessageSkeleton(md, map[string]bool{})
+}
+
+func buildMessageSkeleton(md *desc.MessageDescriptor, visiting map[string]bool) map[string]interface{} {
+	out := map[string]interface{}{}
+	name := md.GetFullyQualifiedName()
+	if visiting[name] {
+		return out
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	seenOneofs := map[string]bool{}
+	for _, f := range md.GetFields() {
+		if oo := f.GetOneOf(); oo != nil && !oo.IsSynthetic() {
+			if seenOneofs[oo.GetName()] {
+				continue
+			}
+			seenOneofs[oo.GetName()] = true
+		}
+
+		switch {
+		case f.IsMap():
+			out[f.GetJSONName()] = map[string]interface{}{
+				skeletonMapKey(f.GetMapKeyType()): skeletonValue(f.GetMapValueType(), visiting),
+			}
+		case f.IsRepeated():
+			out[f.GetJSONName()] = []interface{}{skeletonValue(f, visiting)}
+		default:
+			out[f.GetJSONName()] = skeletonValue(f, visiting)
+		}
+	}
+	return out
+}
+
+func skeletonValue(f *desc.FieldDescriptor, visiting map[string]bool) interface{} {
+	if mt := f.GetMessageType(); mt != nil {
+		if v, ok := wellKnownSkeleton(mt); ok {
+			return v
+		}
+		return buildMessageSkeleton(mt, visiting)
+	}
+	return skeletonScalar(f)
+}
+
+// skeletonScalar returns a placeholder following the protobuf JSON mapping
+// (64-bit integers are strings, bytes are base64)
+func skeletonScalar(f *desc.FieldDescriptor) interface{} {
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return ""
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return ""
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return false
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+		descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+		descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		return "0"
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+		descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return 0.0
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		if values := f.GetEnumType().GetValues(); len(values) > 0 {
+			return values[0].GetName()
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+// skeletonMapKey returns a placeholder JSON object key for a map key field
+func skeletonMapKey(f *desc.FieldDescriptor) string {
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return "key"
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return "false"
+	default:
+		return "0"
+	}
+}
@@ -0,0 +1,175 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/grpc-bridge/server/internal/tracing"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// StreamCall is an open streaming RPC. Requests are sent with Send and
+// CloseSend; responses are read with Recv until it returns io.EOF.
+// Send and CloseSend may be called concurrently with Recv, not with each other.
+type StreamCall struct {
+	release func() // Returns the connection to the cache
+	stream  grpc.ClientStream
+	method  *desc.MethodDescriptor
+	cancel  context.CancelFunc
+	span    trace.Span // Covers the stream from dial until Close
+
+	sendMu    sync.Mutex
+	closeOnce sync.Once
+}
+
+// OpenStream dials the target and starts a streaming call of any kind
+// (client, server or bidirectional). opts.Data is ignored; send requests with
+// Send. The stream ends when ctx is canceled or Close is called.
+func (c *NativeClient) OpenStream(ctx context.Context, opts NativeCallOptions) (*StreamCall, error) {
+	ctx, span := tracing.Start(ctx, "grpc-bridge.stream", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(callAttributes(opts)...))
+
+	_, parseSpan := tracing.Start(ctx, "parse")
+	methodDesc, err := c.GetMethodDescriptor(opts.SessionID, opts.SessionRoot, opts.ProtoFiles, opts.DescriptorSets, opts.ImportMappings, opts.Service, opts.Method)
+	tracing.End(parseSpan, err)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+
+	_, dialSpan := tracing.Start(ctx, "dial", trace.WithAttributes(attribute.String("server.address", opts.Target)))
+	conn, release, err := acquireConn(opts.Target, opts.Plaintext, opts.TLS)
+	tracing.End(dialSpan, err)
+	if err != nil {
+		err = fmt.Errorf("failed to connect to %s: %w", opts.Target, err)
+		tracing.End(span, err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	md := metadata.New(opts.Metadata)
+	tracing.InjectMetadata(ctx, md)
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	streamDesc := &grpc.StreamDesc{
+		StreamName:    methodDesc.GetName(),
+		ClientStreams: methodDesc.IsClientStreaming(),
+		ServerStreams: methodDesc.IsServerStreaming(),
+	}
+	fullMethod := "/" + methodDesc.GetService().GetFullyQualifiedName() + "/" + methodDesc.GetName()
+	stream, err := conn.NewStream(ctx, streamDesc, fullMethod)
+	if err != nil {
+		cancel()
+		release()
+		tracing.End(span, err)
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+
+	openStreams.Add(1)
+	return &StreamCall{
+		release: release,
+		stream:  stream,
+		method:  methodDesc,
+		cancel:  cancel,
+		span:    span,
+	}, nil
+}
+
+// ClientStreaming reports whether the call accepts more than one request
+func (s *StreamCall) ClientStreaming() bool {
+	return s.method.IsClientStreaming()
+}
+
+// ServerStreaming reports whether the call returns more than one response
+func (s *StreamCall) ServerStreaming() bool {
+	return s.method.IsServerStreaming()
+}
+
+// Send encodes a JSON-compatible value as a request message and sends it
+func (s *StreamCall) Send(data any) error {
+	msg := dynamic.NewMessage(s.method.GetInputType())
+	if data != nil {
+		dataBytes, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request data: %w", err)
+		}
+		if err := unmarshalMessageJSON(msg, dataBytes); err != nil {
+			return fmt.Errorf("failed to unmarshal request: %w", err)
+		}
+	}
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.stream.SendMsg(msg)
+}
+
+// CloseSend tells the server no more requests will be sent
+func (s *StreamCall) CloseSend() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.stream.CloseSend()
+}
+
+// Recv waits for the next response and returns it as a JSON-compatible value.
+// It returns io.EOF once the server has finished successfully.
+func (s *StreamCall) Recv() (any, error) {
+	msg := dynamic.NewMessage(s.method.GetOutputType())
+	if err := s.stream.RecvMsg(msg); err != nil {
+		s.recordEnd(err)
+		return nil, err
+	}
+
+	respJSON, err := marshalMessageJSON(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	var respData any
+	if err := json.Unmarshal(respJSON, &respData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return respData, nil
+}
+
+// Header returns the response headers, waiting for them if necessary
+func (s *StreamCall) Header() map[string][]string {
+	md, err := s.stream.Header()
+	if err != nil {
+		return nil
+	}
+	return metadataToMap(md)
+}
+
+// Trailer returns the response trailers; only valid after Recv has returned an error
+func (s *StreamCall) Trailer() map[string][]string {
+	return metadataToMap(s.stream.Trailer())
+}
+
+// Close cancels the call if still running and releases the connection
+func (s *StreamCall) Close() error {
+	s.cancel()
+	s.closeOnce.Do(func() {
+		openStreams.Add(-1)
+		s.span.End()
+	})
+	s.release()
+	return nil
+}
+
+// recordEnd records the final status of the stream on its span
+func (s *StreamCall) recordEnd(err error) {
+	if err == io.EOF {
+		s.span.SetAttributes(attribute.Int("rpc.grpc.status_code", 0))
+		return
+	}
+	s.span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(status.Code(err))))
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hostnamePattern accepts DNS names, plus the underscores of container and
+// service names
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_.-]*[A-Za-z0-9])?$`)
+
+// ValidateTarget checks that target is an address the bridge can dial:
+// "host:port", a bare host (gRPC defaults the port to 443), "dns:///host:port",
+// "passthrough:///host:port" or a unix socket ("unix:/path", "unix:///path",
+// "unix-abstract:name"). It catches typos before a call waits on a connection
+// that cannot succeed; whether the target may be dialed is up to the
+// TargetPolicy. Errors read as a predicate of the field holding the target,
+// e.g. "must be host:port".
+func ValidateTarget(target string) error {
+	if target == "" {
+		return errors.New("is required")
+	}
+	if strings.ContainsAny(target, " \t\r\n") {
+		return errors.New("must not contain whitespace")
+	}
+
+	if scheme, rest, found := strings.Cut(target, ":"); found {
+		switch scheme {
+		case "unix", "unix-abstract":
+			if strings.TrimLeft(rest, "/") == "" {
+				return errors.New("needs a socket path")
+			}
+			return nil
+		case "dns", "passthrough":
+			if strings.HasPrefix(rest, "//") {
+				u, err := url.Parse(target)
+				if err != nil {
+					return errors.New("is not a valid URL")
+				}
+				return validateHostPort(strings.TrimPrefix(u.Path, "/"))
+			}
+		default:
+			if strings.HasPrefix(rest, "//") {
+				return fmt.Errorf("uses unsupported scheme %q (use host:port)", scheme)
+			}
+		}
+	}
+	return validateHostPort(target)
+}
+
+// validateHostPort checks "host:port" or a bare host
+func validateHostPort(endpoint string) error {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		var addrErr *net.AddrError
+		if !errors.As(err, &addrErr) || addrErr.Err != "missing port in address" {
+			return errors.New("must be host:port")
+		}
+		host, port = endpoint, ""
+	}
+	if host == "" {
+		return errors.New("is missing a host")
+	}
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("has an invalid port %q", port)
+		}
+	}
+	if net.ParseIP(host) != nil || hostnamePattern.MatchString(host) {
+		return nil
+	}
+	return fmt.Errorf("has an invalid host %q", host)
+}
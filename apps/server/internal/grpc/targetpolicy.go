@@ -0,0 +1,383 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// targetLookupTimeout bounds the DNS lookup of a target checked before dialing
+const targetLookupTimeout = 5 * time.Second
+
+// TargetPolicy restricts the addresses the bridge may dial, so it cannot be
+// used to reach internal infrastructure (SSRF). Rules are host names
+// ("api.example.com", "*.example.com"), IP addresses or CIDRs ("10.0.0.0/8"),
+// each optionally with a port ("api.example.com:443", "*:443"). Deny rules
+// win over allow rules; with allow rules, anything unmatched is denied.
+type TargetPolicy struct {
+	allow        []targetRule
+	deny         []targetRule
+	blockPrivate bool
+
+	resolver *net.Resolver
+}
+
+// targetRule matches a host name pattern or an IP network, and a port
+type targetRule struct {
+	host    string     // Lower-cased name, "*.suffix" or "*"; empty for networks
+	network *net.IPNet // IP or CIDR rule
+	port    string     // Empty matches any port
+}
+
+// TargetDeniedError reports a target rejected by the TargetPolicy
+type TargetDeniedError struct {
+	Target string
+	Reason string
+}
+
+func (e *TargetDeniedError) Error() string {
+	return fmt.Sprintf("target %q is not allowed: %s", e.Target, e.Reason)
+}
+
+// ErrPlaintextForbidden rejects plaintext connections when ForbidPlaintext is on
+var ErrPlaintextForbidden = errors.New("plaintext connections are disabled on this server; use TLS")
+
+// IsTargetDenied reports whether err was caused by the TargetPolicy or by
+// ForbidPlaintext
+func IsTargetDenied(err error) bool {
+	var denied *TargetDeniedError
+	return errors.As(err, &denied) || errors.Is(err, ErrPlaintextForbidden)
+}
+
+// NewTargetPolicy parses allow and deny rules. blockPrivate also denies
+// loopback, private, link-local and unspecified addresses unless an allow
+// rule names the host or its network explicitly.
+func NewTargetPolicy(allow, deny []string, blockPrivate bool) (*TargetPolicy, error) {
+	p := &TargetPolicy{blockPrivate: blockPrivate, resolver: net.DefaultResolver}
+	var err error
+	if p.allow, err = parseTargetRules(allow); err != nil {
+		return nil, err
+	}
+	if p.deny, err = parseTargetRules(deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Restricted reports whether the policy denies anything
+func (p *TargetPolicy) Restricted() bool {
+	return p != nil && (len(p.allow) > 0 || len(p.deny) > 0 || p.blockPrivate)
+}
+
+func parseTargetRules(entries []string) ([]targetRule, error) {
+	var rules []targetRule
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rule, err := parseTargetRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseTargetRule(entry string) (targetRule, error) {
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		return targetRule{network: network}, nil
+	}
+	host, port := entry, ""
+	if h, p, err := net.SplitHostPort(entry); err == nil {
+		host, port = h, p
+	}
+	if port != "" && !isPort(port) {
+		return targetRule{}, fmt.Errorf("invalid target rule %q: bad port", entry)
+	}
+	if _, network, err := net.ParseCIDR(host); err == nil {
+		return targetRule{network: network, port: port}, nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return targetRule{network: hostNetwork(ip), port: port}, nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" || strings.Contains(strings.TrimPrefix(host, "*."), "*") && host != "*" {
+		return targetRule{}, fmt.Errorf("invalid target rule %q", entry)
+	}
+	return targetRule{host: host, port: port}, nil
+}
+
+func isPort(s string) bool {
+	if s == "" || len(s) > 5 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hostNetwork returns the single-address network of ip
+func hostNetwork(ip net.IP) *net.IPNet {
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// matchesHost reports whether the rule matches a host name (not an IP)
+func (r targetRule) matchesHost(host, port string) bool {
+	if r.host == "" || (r.port != "" && r.port != port) {
+		return false
+	}
+	switch {
+	case r.host == "*":
+		return true
+	case strings.HasPrefix(r.host, "*."):
+		return strings.HasSuffix(host, r.host[1:])
+	default:
+		return host == r.host
+	}
+}
+
+// matchesIP reports whether the rule's network contains ip
+func (r targetRule) matchesIP(ip net.IP, port string) bool {
+	return r.network != nil && (r.port == "" || r.port == port) && r.network.Contains(ip)
+}
+
+// explicit reports whether the rule names a host or network, as opposed to
+// the catch-all "*"
+func (r targetRule) explicit() bool {
+	return r.host != "*"
+}
+
+// Check resolves a gRPC target ("host:port", "dns:///host:port") and reports
+// a *TargetDeniedError when it, or any address it resolves to, is not allowed
+func (p *TargetPolicy) Check(ctx context.Context, target string) error {
+	if !p.Restricted() {
+		return nil
+	}
+	host, port, err := targetHostPort(target)
+	if err != nil {
+		return &TargetDeniedError{Target: target, Reason: err.Error()}
+	}
+	_, err = p.resolve(ctx, target, host, port)
+	return err
+}
+
+// Resolve checks a "host:port" address like Check and returns the addresses
+// it may be connected to, for a caller that pins them. A policy that does not
+// restrict anything returns no addresses.
+func (p *TargetPolicy) Resolve(ctx context.Context, addr string) ([]net.IP, error) {
+	if !p.Restricted() {
+		return nil, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, &TargetDeniedError{Target: addr, Reason: err.Error()}
+	}
+	return p.resolve(ctx, addr, host, port)
+}
+
+// resolve checks host and port against the policy and returns the addresses
+// that may be dialed
+func (p *TargetPolicy) resolve(ctx context.Context, target, host, port string) ([]net.IP, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, rule := range p.deny {
+		if rule.matchesHost(host, port) {
+			return nil, &TargetDeniedError{Target: target, Reason: "host is on the deny list"}
+		}
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+		host = ""
+	} else {
+		addrs, err := p.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+
+	for _, rule := range p.deny {
+		for _, ip := range ips {
+			if rule.matchesIP(ip, port) {
+				return nil, &TargetDeniedError{Target: target, Reason: fmt.Sprintf("address %s is on the deny list", ip)}
+			}
+		}
+	}
+
+	hostAllowed, hostExplicit := false, false
+	for _, rule := range p.allow {
+		if host != "" && rule.matchesHost(host, port) {
+			hostAllowed = true
+			hostExplicit = hostExplicit || rule.explicit()
+		}
+	}
+	for _, ip := range ips {
+		ipAllowed := false
+		for _, rule := range p.allow {
+			if rule.matchesIP(ip, port) {
+				ipAllowed = true
+				break
+			}
+		}
+		if len(p.allow) > 0 && !hostAllowed && !ipAllowed {
+			return nil, &TargetDeniedError{Target: target, Reason: "not on the allow list"}
+		}
+		if p.blockPrivate && isPrivateIP(ip) && !hostExplicit && !ipAllowed {
+			return nil, &TargetDeniedError{Target: target, Reason: fmt.Sprintf("address %s is in a private range", ip)}
+		}
+	}
+	return ips, nil
+}
+
+// isPrivateIP reports loopback, private, link-local, unspecified and other
+// non-public addresses (including 100.64.0.0/10 carrier-grade NAT)
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return true
+	}
+	return sharedAddressSpace.Contains(ip)
+}
+
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)}
+
+// targetHostPort extracts host and port from a gRPC target. Only TCP targets
+// ("host:port", "dns:///host:port", "passthrough:///host:port") can be
+// checked; unix sockets and other schemes are rejected.
+func targetHostPort(target string) (string, string, error) {
+	endpoint := target
+	if scheme, rest, found := strings.Cut(target, ":"); found && strings.HasPrefix(rest, "//") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid target")
+		}
+		switch scheme {
+		case "dns", "passthrough":
+			endpoint = strings.TrimPrefix(u.Path, "/")
+		default:
+			return "", "", fmt.Errorf("scheme %q is not allowed", scheme)
+		}
+	} else if found && (scheme == "unix" || scheme == "unix-abstract") {
+		return "", "", fmt.Errorf("scheme %q is not allowed", scheme)
+	}
+
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		// gRPC defaults the port to 443
+		host, port = endpoint, "443"
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("missing host")
+	}
+	return strings.Trim(host, "[]"), port, nil
+}
+
+// dialContext dials addr ("host:port", as passed through by the passthrough
+// resolver) only at an address the policy allows. Resolving here rather than
+// in gRPC's resolver keeps a DNS answer changing after Check from bypassing it.
+func (p *TargetPolicy) dialContext(ctx context.Context, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := p.resolve(ctx, addr, host, port)
+	if err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// targetPolicy applies to every connection dialed by this package
+var targetPolicy atomic.Pointer[TargetPolicy]
+
+// SetTargetPolicy restricts the targets every backend may dial; nil removes
+// the restriction
+func SetTargetPolicy(p *TargetPolicy) {
+	targetPolicy.Store(p)
+}
+
+// plaintextForbidden makes every backend refuse plaintext connections
+var plaintextForbidden atomic.Bool
+
+// ForbidPlaintext makes every backend refuse plaintext connections (and
+// grpcurl's -plaintext) with ErrPlaintextForbidden, so only TLS targets are
+// contacted
+func ForbidPlaintext(forbid bool) {
+	plaintextForbidden.Store(forbid)
+}
+
+// PlaintextForbidden reports whether plaintext connections are refused
+func PlaintextForbidden() bool {
+	return plaintextForbidden.Load()
+}
+
+// checkTarget checks a connection about to be made against ForbidPlaintext
+// and the configured TargetPolicy
+func checkTarget(ctx context.Context, target string, plaintext bool) error {
+	if plaintext && plaintextForbidden.Load() {
+		return ErrPlaintextForbidden
+	}
+	ctx, cancel := context.WithTimeout(ctx, targetLookupTimeout)
+	defer cancel()
+	return targetPolicy.Load().Check(ctx, target)
+}
+
+// pinTarget checks a target like checkTarget for a process that dials it
+// itself (grpcurl). With a restrictive policy, a host name is resolved once
+// and the returned address is the first allowed IP with the target's port,
+// so a DNS answer changing between the check and the dial cannot bypass the
+// policy; authority is then the host name, to be sent as :authority and
+// verified against the server's certificate. Otherwise target is returned
+// unchanged with an empty authority.
+func pinTarget(ctx context.Context, target string, plaintext bool) (addr, authority string, err error) {
+	if plaintext && plaintextForbidden.Load() {
+		return "", "", ErrPlaintextForbidden
+	}
+	p := targetPolicy.Load()
+	if !p.Restricted() {
+		return target, "", nil
+	}
+	host, port, err := targetHostPort(target)
+	if err != nil {
+		return "", "", &TargetDeniedError{Target: target, Reason: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, targetLookupTimeout)
+	defer cancel()
+	ips, err := p.resolve(ctx, target, host, port)
+	if err != nil {
+		return "", "", err
+	}
+	if len(ips) == 0 {
+		return "", "", fmt.Errorf("failed to resolve %s: no addresses", host)
+	}
+	if net.ParseIP(host) != nil {
+		return net.JoinHostPort(host, port), "", nil
+	}
+	return net.JoinHostPort(ips[0].String(), port), host, nil
+}
@@ -0,0 +1,187 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestParseTargetRule(t *testing.T) {
+	tests := []struct {
+		entry   string
+		host    string
+		network string
+		port    string
+		wantErr bool
+	}{
+		{entry: "api.example.com", host: "api.example.com"},
+		{entry: "API.Example.com.", host: "api.example.com"},
+		{entry: "*.example.com:443", host: "*.example.com", port: "443"},
+		{entry: "*", host: "*"},
+		{entry: "*:443", host: "*", port: "443"},
+		{entry: "10.0.0.0/8", network: "10.0.0.0/8"},
+		{entry: "10.1.2.3", network: "10.1.2.3/32"},
+		{entry: "10.1.2.3:50051", network: "10.1.2.3/32", port: "50051"},
+		{entry: "[::1]:443", network: "::1/128", port: "443"},
+		{entry: "api.example.com:http", wantErr: true},
+		{entry: "api.*.com", wantErr: true},
+		{entry: "**.example.com", wantErr: true},
+		{entry: ":443", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			rule, err := parseTargetRule(tt.entry)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseTargetRule(%q) = %+v, want an error", tt.entry, rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTargetRule(%q): %v", tt.entry, err)
+			}
+			network := ""
+			if rule.network != nil {
+				network = rule.network.String()
+			}
+			if rule.host != tt.host || network != tt.network || rule.port != tt.port {
+				t.Errorf("parseTargetRule(%q) = host %q network %q port %q, want %q %q %q",
+					tt.entry, rule.host, network, rule.port, tt.host, tt.network, tt.port)
+			}
+		})
+	}
+}
+
+func TestTargetHostPort(t *testing.T) {
+	tests := []struct {
+		target  string
+		host    string
+		port    string
+		wantErr bool
+	}{
+		{target: "api.example.com:50051", host: "api.example.com", port: "50051"},
+		{target: "api.example.com", host: "api.example.com", port: "443"},
+		{target: "dns:///api.example.com:8443", host: "api.example.com", port: "8443"},
+		{target: "passthrough:///10.0.0.1:80", host: "10.0.0.1", port: "80"},
+		{target: "[::1]:50051", host: "::1", port: "50051"},
+		{target: "unix:///var/run/grpc.sock", wantErr: true},
+		{target: "unix:grpc.sock", wantErr: true},
+		{target: "xds:///service", wantErr: true},
+		{target: ":50051", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			host, port, err := targetHostPort(tt.target)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("targetHostPort(%q) = %q, %q, want an error", tt.target, host, port)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("targetHostPort(%q): %v", tt.target, err)
+			}
+			if host != tt.host || port != tt.port {
+				t.Errorf("targetHostPort(%q) = %q, %q, want %q, %q", tt.target, host, port, tt.host, tt.port)
+			}
+		})
+	}
+}
+
+func TestTargetPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		allow, deny  []string
+		blockPrivate bool
+		target       string
+		denied       bool
+	}{
+		{name: "no rules", target: "10.0.0.1:80"},
+		{name: "allowed network", allow: []string{"203.0.113.0/24"}, target: "203.0.113.7:443"},
+		{name: "outside allow list", allow: []string{"203.0.113.0/24"}, target: "198.51.100.1:443", denied: true},
+		{name: "allow rule port", allow: []string{"203.0.113.7:443"}, target: "203.0.113.7:80", denied: true},
+		{name: "deny wins over allow", allow: []string{"203.0.113.0/24"}, deny: []string{"203.0.113.7"}, target: "203.0.113.7:443", denied: true},
+		{name: "deny host name", deny: []string{"*.internal"}, target: "db.internal:5432", denied: true},
+		{name: "private blocked", blockPrivate: true, target: "10.0.0.1:80", denied: true},
+		{name: "loopback blocked", blockPrivate: true, target: "[::1]:80", denied: true},
+		{name: "carrier-grade NAT blocked", blockPrivate: true, target: "100.64.1.1:80", denied: true},
+		{name: "public allowed with private blocked", blockPrivate: true, target: "203.0.113.7:443"},
+		{name: "private allowed explicitly", blockPrivate: true, allow: []string{"10.0.0.0/8"}, target: "10.0.0.1:80"},
+		{name: "catch-all does not allow private", blockPrivate: true, allow: []string{"*"}, target: "10.0.0.1:80", denied: true},
+		{name: "explicit host allows private", blockPrivate: true, allow: []string{"localhost"}, target: "localhost:50051"},
+		{name: "unix socket", deny: []string{"*.internal"}, target: "unix:///tmp/grpc.sock", denied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewTargetPolicy(tt.allow, tt.deny, tt.blockPrivate)
+			if err != nil {
+				t.Fatalf("NewTargetPolicy: %v", err)
+			}
+			err = p.Check(context.Background(), tt.target)
+			var denied *TargetDeniedError
+			if got := errors.As(err, &denied); got != tt.denied {
+				t.Errorf("Check(%q) = %v, denied %v, want denied %v", tt.target, err, got, tt.denied)
+			}
+		})
+	}
+}
+
+func TestPinTarget(t *testing.T) {
+	t.Cleanup(func() {
+		SetTargetPolicy(nil)
+		ForbidPlaintext(false)
+	})
+
+	SetTargetPolicy(nil)
+	addr, authority, err := pinTarget(context.Background(), "localhost:50051", true)
+	if err != nil || addr != "localhost:50051" || authority != "" {
+		t.Errorf("without a policy: pinTarget = %q, %q, %v, want the target unchanged", addr, authority, err)
+	}
+
+	p, err := NewTargetPolicy([]string{"localhost"}, nil, true)
+	if err != nil {
+		t.Fatalf("NewTargetPolicy: %v", err)
+	}
+	SetTargetPolicy(p)
+
+	addr, authority, err = pinTarget(context.Background(), "dns:///localhost:50051", true)
+	if err != nil {
+		t.Fatalf("pinTarget: %v", err)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || port != "50051" || net.ParseIP(host) == nil || !net.ParseIP(host).IsLoopback() {
+		t.Errorf("pinTarget address = %q, want a loopback IP with port 50051", addr)
+	}
+	if authority != "localhost" {
+		t.Errorf("pinTarget authority = %q, want localhost", authority)
+	}
+
+	addr, authority, err = pinTarget(context.Background(), "127.0.0.1:50051", true)
+	if err == nil {
+		t.Errorf("pinTarget(127.0.0.1) = %q, %q, want denied: only the host name is allowed", addr, authority)
+	}
+
+	ForbidPlaintext(true)
+	if _, _, err := pinTarget(context.Background(), "localhost:50051", true); !errors.Is(err, ErrPlaintextForbidden) {
+		t.Errorf("plaintext with ForbidPlaintext: pinTarget = %v, want ErrPlaintextForbidden", err)
+	}
+}
+
+func TestConnectionArgs(t *testing.T) {
+	tests := []struct {
+		authority, serverName string
+		want                  []string
+	}{
+		{},
+		{authority: "api.example.com", want: []string{"-authority", "api.example.com"}},
+		{serverName: "tls.example.com", want: []string{"-servername", "tls.example.com"}},
+		{authority: "api.example.com", serverName: "tls.example.com", want: []string{"-servername", "tls.example.com"}},
+	}
+	for _, tt := range tests {
+		got := connectionArgs(tt.authority, tt.serverName)
+		if len(got) != len(tt.want) || (len(got) == 2 && (got[0] != tt.want[0] || got[1] != tt.want[1])) {
+			t.Errorf("connectionArgs(%q, %q) = %v, want %v", tt.authority, tt.serverName, got, tt.want)
+		}
+	}
+}
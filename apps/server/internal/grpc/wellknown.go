@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// wellKnownSkeleton returns a placeholder in the canonical JSON form of a
+// well-known type, or false when the message is not one
+func wellKnownSkeleton(md *desc.MessageDescriptor) (interface{}, bool) {
+	switch md.GetFullyQualifiedName() {
+	case "google.protobuf.Timestamp":
+		return "1970-01-01T00:00:00Z", true
+	case "google.protobuf.Duration":
+		return "0s", true
+	case "google.protobuf.FieldMask":
+		return "", true
+	case "google.protobuf.Struct":
+		return map[string]interface{}{}, true
+	case "google.protobuf.ListValue":
+		return []interface{}{}, true
+	case "google.protobuf.Value":
+		return nil, true
+	case "google.protobuf.Empty":
+		return map[string]interface{}{}, true
+	case "google.protobuf.Any":
+		return map[string]interface{}{"@type": ""}, true
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return "", true
+	case "google.protobuf.BoolValue":
+		return false, true
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return "0", true
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return 0, true
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return 0.0, true
+	}
+	return nil, false
+}
+
+// unmarshalMessageJSON decodes a JSON payload into msg using the protobuf JSON
+// mapping, so well-known types such as FieldMask accept their canonical string
+// form. Payloads protojson cannot handle (e.g. Any holding a session-defined
+// type) fall back to the dynamic message decoder.
+func unmarshalMessageJSON(msg *dynamic.Message, data []byte) error {
+	pm := dynamicpb.NewMessage(msg.GetMessageDescriptor().UnwrapMessage())
+	if err := protojson.Unmarshal(data, pm); err == nil {
+		if raw, err := proto.Marshal(pm); err == nil {
+			return msg.Unmarshal(raw)
+		}
+	}
+	return msg.UnmarshalJSON(data)
+}
+
+// marshalMessageJSON renders msg using the protobuf JSON mapping, falling
+// back to the dynamic message encoder when protojson cannot render it
+func marshalMessageJSON(msg *dynamic.Message) ([]byte, error) {
+	raw, err := msg.Marshal()
+	if err == nil {
+		pm := dynamicpb.NewMessage(msg.GetMessageDescriptor().UnwrapMessage())
+		if err := proto.Unmarshal(raw, pm); err == nil {
+			if data, err := protojson.Marshal(pm); err == nil {
+				return data, nil
+			}
+		}
+	}
+	return msg.MarshalJSON()
+}
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+	"github.com/grpc-bridge/server/internal/apidoc"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/audit"
+	"github.com/grpc-bridge/server/internal/callstats"
+	"github.com/grpc-bridge/server/internal/collection"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/history"
+	"github.com/grpc-bridge/server/internal/loadtest"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// APIDocHandler serves the OpenAPI document of the bridge's own REST API
+type APIDocHandler struct {
+	info   apidoc.Info
+	routes func() gin.RoutesInfo
+	once   sync.Once
+	json   []byte
+	yaml   []byte
+	err    error
+}
+
+// NewAPIDocHandler creates a handler documenting the routes returned by
+// routes. The document is built on the first request, once every route is
+// registered.
+func NewAPIDocHandler(version string, authEnabled bool, routes func() gin.RoutesInfo) *APIDocHandler {
+	return &APIDocHandler{
+		info:   apidoc.Info{Title: "gRPC Bridge API", Version: version, Auth: authEnabled},
+		routes: routes,
+	}
+}
+
+// GetOpenAPIJSON returns the document as JSON
+func (h *APIDocHandler) GetOpenAPIJSON(c *gin.Context) {
+	h.serve(c, "application/json", func() []byte { return h.json })
+}
+
+// GetOpenAPIYAML returns the document as YAML
+func (h *APIDocHandler) GetOpenAPIYAML(c *gin.Context) {
+	h.serve(c, "application/yaml", func() []byte { return h.yaml })
+}
+
+func (h *APIDocHandler) serve(c *gin.Context, contentType string, data func() []byte) {
+	h.once.Do(func() {
+		doc := apidoc.Build(h.info, h.routes(), apiOperations)
+		if h.json, h.err = json.MarshalIndent(doc, "", "  "); h.err != nil {
+			return
+		}
+		// Converting from JSON keeps the document's key order
+		h.yaml, h.err = yaml.JSONToYAML(h.json)
+	})
+	if h.err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to encode document: "+h.err.Error())
+		return
+	}
+	c.Data(http.StatusOK, contentType, data())
+}
+
+// Query parameters shared by several routes
+var (
+	pageParams = []apidoc.Param{
+		{Name: "offset", Type: "integer"},
+		{Name: "limit", Type: "integer"},
+	}
+	historyFilterParams = []apidoc.Param{
+		{Name: "service", Description: "Fully qualified service name"},
+		{Name: "method"},
+		{Name: "status", Description: "Status code name (OK, Unavailable, ...), ok or error"},
+	}
+	fileParam   = []apidoc.Param{{Name: "file", Required: true, Description: "Path relative to the session's proto root"}}
+	eventParams = []apidoc.Param{
+		{Name: "sessionId", Required: true},
+		{Name: "token", Description: "Event stream token from POST /api/sessions/{sessionId}/ws-token"},
+		{Name: "since", Type: "integer", Description: "Replay buffered events after this sequence number"},
+		{Name: "events", Description: "Comma-separated event name prefixes to receive"},
+	}
+)
+
+// messageResponse is the body of routes that only confirm an action
+var messageResponse = apidoc.Object{"message": ""}
+
+// apiOperations annotates routes, keyed by "METHOD /path" as registered.
+// Routes without an entry are still documented, with free-form bodies.
+var apiOperations = map[string]apidoc.Operation{
+	// Health
+	"GET /api/health":       {Summary: "Liveness probe"},
+	"GET /api/health/live":  {Summary: "Liveness probe"},
+	"GET /api/health/ready": {Summary: "Readiness probe", Response: apidoc.Object{"status": "", "version": "", "checks": map[string]CheckResult{}}},
+
+	// Event streams
+	"GET /api/ws":     {Summary: "Open the session's WebSocket event stream", Query: append(eventParams, apidoc.Param{Name: "acks", Type: "boolean"})},
+	"GET /api/events": {Summary: "Open the session's Server-Sent Events stream", Query: eventParams, Produces: "text/event-stream"},
+
+	// Sessions
+	"POST /api/sessions": {
+		Request:  CreateSessionRequest{},
+		Status:   http.StatusCreated,
+		Response: apidoc.Object{"session": session.Session{}, "ws_token": WSToken{}},
+	},
+	"GET /api/sessions/:sessionId": {
+		Response: apidoc.Object{"session": session.Session{}, "usage": session.Usage{}, "quota": session.Quota{}},
+	},
+	"PATCH /api/sessions/:sessionId": {
+		Request:  UpdateSessionRequest{},
+		Response: apidoc.Object{"session": session.Session{}},
+	},
+	"DELETE /api/sessions/:sessionId":            {Response: apidoc.Object{"message": "", "cancelled_operations": 0}},
+	"POST /api/sessions/:sessionId/restore":      {Response: apidoc.Object{"session": session.Session{}}},
+	"POST /api/sessions/bulk-delete":             {Summary: "Delete many sessions", Request: BulkDeleteRequest{}, Response: session.BulkDeleteResult{}},
+	"POST /api/sessions/:sessionId/ws-token":     {Summary: "Issue an event stream token", Response: WSToken{}},
+	"POST /api/sessions/:sessionId/download-url": {Request: DownloadURLRequest{}, Response: apidoc.Object{"url": "", "expires_at": time.Time{}}},
+
+	// Variables, metadata and import mappings
+	"GET /api/sessions/:sessionId/variables":          {Response: apidoc.Object{"session_id": "", "variables": map[string]string{}}},
+	"PUT /api/sessions/:sessionId/variables":          {Request: ReplaceVariablesRequest{}, Response: apidoc.Object{"session_id": "", "variables": map[string]string{}}},
+	"PUT /api/sessions/:sessionId/variables/:name":    {Request: SetVariableRequest{}, Response: apidoc.Object{"name": "", "value": ""}},
+	"DELETE /api/sessions/:sessionId/variables/:name": {Response: messageResponse},
+	"GET /api/sessions/:sessionId/metadata":           {Response: apidoc.Object{"session_id": "", "metadata": map[string]string{}}},
+	"PUT /api/sessions/:sessionId/metadata":           {Request: ReplaceMetadataRequest{}, Response: apidoc.Object{"session_id": "", "metadata": map[string]string{}}},
+	"PUT /api/sessions/:sessionId/metadata/:key":      {Request: SetMetadataRequest{}, Response: apidoc.Object{"key": "", "value": ""}},
+	"DELETE /api/sessions/:sessionId/metadata/:key":   {Response: messageResponse},
+	"GET /api/sessions/:sessionId/import-mappings":    {Response: apidoc.Object{"session_id": "", "mappings": []session.ImportMapping{}}},
+	"PUT /api/sessions/:sessionId/import-mappings":    {Request: ReplaceImportMappingsRequest{}, Response: apidoc.Object{"session_id": "", "mappings": []session.ImportMapping{}}},
+
+	// Environments and targets
+	"GET /api/sessions/:sessionId/environments":          {Response: apidoc.Object{"session_id": "", "environments": []session.Environment{}}},
+	"GET /api/sessions/:sessionId/environments/:name":    {Response: apidoc.Object{"environment": session.Environment{}}},
+	"PUT /api/sessions/:sessionId/environments/:name":    {Request: PutEnvironmentRequest{}, Response: apidoc.Object{"environment": session.Environment{}, "created": false}},
+	"DELETE /api/sessions/:sessionId/environments/:name": {Response: apidoc.Object{"message": "", "name": ""}},
+	"GET /api/sessions/:sessionId/targets":               {Response: apidoc.Object{"session_id": "", "targets": []session.Target{}}},
+	"POST /api/sessions/:sessionId/targets":              {Request: TargetRequest{}, Status: http.StatusCreated, Response: apidoc.Object{"target": session.Target{}}},
+	"GET /api/sessions/:sessionId/targets/:targetId":     {Response: apidoc.Object{"target": session.Target{}}},
+	"PUT /api/sessions/:sessionId/targets/:targetId":     {Request: TargetRequest{}, Response: apidoc.Object{"target": session.Target{}}},
+	"DELETE /api/sessions/:sessionId/targets/:targetId":  {Response: apidoc.Object{"message": "", "id": ""}},
+
+	// History, audit and statistics
+	"GET /api/sessions/:sessionId/history": {
+		Query:    append(historyFilterParams, pageParams...),
+		Response: apidoc.Object{"session_id": "", "entries": []history.Entry{}, "total": 0, "offset": 0, "limit": 0},
+	},
+	"GET /api/sessions/:sessionId/history/export": {
+		Summary:  "Export the call history as JSONL or HAR",
+		Query:    append([]apidoc.Param{{Name: "format", Description: "jsonl (default) or har"}}, historyFilterParams...),
+		Produces: "application/x-ndjson",
+	},
+	"GET /api/sessions/:sessionId/history/:historyId":         {Response: apidoc.Object{"entry": history.Entry{}}},
+	"GET /api/sessions/:sessionId/history/:historyId/grpcurl": {Summary: "Export a history entry as a grpcurl command", Query: []apidoc.Param{{Name: "absolute_paths", Type: "boolean"}}},
+	"DELETE /api/sessions/:sessionId/history":                 {Response: messageResponse},
+	"DELETE /api/sessions/:sessionId/history/:historyId":      {Response: messageResponse},
+	"GET /api/sessions/:sessionId/audit": {
+		Query:    append([]apidoc.Param{{Name: "action"}, {Name: "user"}}, pageParams...),
+		Response: apidoc.Object{"session_id": "", "entries": []audit.Entry{}, "total": 0, "offset": 0, "limit": 0},
+	},
+	"GET /api/sessions/:sessionId/stats": {Summary: "Rolling per-endpoint call statistics", Response: callstats.Snapshot{}},
+	"GET /api/sessions/:sessionId/responses/:responseId": {
+		Summary: "Fetch the full JSON of a response sent as a preview",
+		Query: []apidoc.Param{
+			{Name: "offset", Type: "integer", Description: "First byte of the JSON to return"},
+			{Name: "limit", Type: "integer", Description: "Bytes of JSON to return; 0 for 1 MiB"},
+			{Name: "raw", Type: "boolean", Description: "Send the whole JSON as application/json, honoring Range requests"},
+		},
+		Response: apidoc.Object{"response_id": "", "content": "", "offset": 0, "size": 0, "truncated": false, "next_offset": 0},
+	},
+
+	// Collections
+	"GET /api/collections":                                      {Response: apidoc.Object{"collections": []collection.Collection{}, "count": 0}},
+	"POST /api/collections":                                     {Request: CollectionRequest{}, Status: http.StatusCreated, Response: apidoc.Object{"collection": collection.Collection{}, "folders": []string{}}},
+	"GET /api/collections/:collectionId":                        {Response: apidoc.Object{"collection": collection.Collection{}, "folders": []string{}}},
+	"PUT /api/collections/:collectionId":                        {Request: CollectionRequest{}, Response: apidoc.Object{"collection": collection.Collection{}, "folders": []string{}}},
+	"DELETE /api/collections/:collectionId":                     {Response: messageResponse},
+	"POST /api/collections/:collectionId/requests":              {Request: SavedRequestBody{}, Status: http.StatusCreated, Response: apidoc.Object{"request": collection.SavedRequest{}}},
+	"PUT /api/collections/:collectionId/requests/:requestId":    {Request: SavedRequestBody{}, Response: apidoc.Object{"request": collection.SavedRequest{}}},
+	"DELETE /api/collections/:collectionId/requests/:requestId": {Response: messageResponse},
+
+	// Proto files
+	"POST /api/proto/upload-structure": {
+		Summary: "Upload a proto directory",
+		Form:    apidoc.Object{"sessionId": "", "mode": "", "relative_paths": []string{}, "clientStripped": false},
+		Files:   []string{"files"},
+	},
+	"POST /api/proto/upload-archive":  {Summary: "Upload a .zip or .tar.gz of proto files", Form: UploadStructureRequest{}, File: []string{"archive"}},
+	"POST /api/proto/upload-protoset": {Summary: "Upload compiled descriptor sets", Form: UploadStructureRequest{}, Files: []string{"protoset"}},
+	"POST /api/proto/import-git":      {Summary: "Import proto files from a Git repository", Request: ImportGitRequest{}},
+	"POST /api/proto/save-reflection": {Summary: "Save a server's reflected descriptors to the session", Request: SaveReflectionRequest{}},
+	"GET /api/sessions/:sessionId/files": {
+		Query: append([]apidoc.Param{
+			{Name: "q", Description: "Case-insensitive substring of the relative path"},
+			{Name: "dir", Description: "Only files below this directory"},
+			{Name: "sort", Description: "path (default), name or size"},
+			{Name: "order", Description: "asc (default) or desc"},
+			{Name: "view", Description: "list (default) or tree"},
+			{Name: "depth", Type: "integer", Description: "Directory levels of the tree view; 0 for all"},
+		}, pageParams...),
+		Response: apidoc.Object{
+			"session_id": "", "files": []listedProtoFile{}, "count": 0, "total": 0, "offset": 0, "limit": 0,
+			"tree": FileTreeNode{},
+		},
+	},
+	"GET /api/sessions/:sessionId/file-content": {
+		Query: append([]apidoc.Param{
+			{Name: "offset", Type: "integer", Description: "First byte of the content to return"},
+			{Name: "limit", Type: "integer", Description: "Bytes of content to return; 0 for the rest of the file"},
+			{Name: "raw", Type: "boolean", Description: "Send the file as text/plain, honoring Range requests"},
+		}, fileParam...),
+		Response: apidoc.Object{"file": session.ProtoFile{}, "content": "", "offset": 0, "size": 0, "truncated": false},
+	},
+	"PUT /api/sessions/:sessionId/file-content":  {Request: UpdateFileContentRequest{}},
+	"GET /api/sessions/:sessionId/file-download": {Query: fileParam, Produces: "application/octet-stream"},
+	"GET /api/sessions/:sessionId/download": {
+		Summary:  "Download the session's proto files as a zip",
+		Query:    []apidoc.Param{{Name: "stdlib", Type: "boolean"}},
+		Produces: "application/zip",
+	},
+	"GET /api/sessions/:sessionId/search": {
+		Query: []apidoc.Param{
+			{Name: "q", Required: true},
+			{Name: "regex", Type: "boolean"},
+			{Name: "case_sensitive", Type: "boolean"},
+			{Name: "whole_word", Type: "boolean"},
+			{Name: "stdlib", Type: "boolean"},
+			{Name: "limit", Type: "integer"},
+		},
+	},
+	"GET /api/sessions/:sessionId/breaking":             {Query: []apidoc.Param{{Name: "except", Description: "Comma-separated change kinds to ignore"}}},
+	"GET /api/proto/stdlib":                             {Response: apidoc.Object{"files": []string{}, "count": 0}},
+	"GET /api/proto/stdlib-content":                     {Query: fileParam, Response: apidoc.Object{"file": "", "content": ""}},
+	"POST /api/sessions/:sessionId/uploads":             {Request: ChunkedUploadRequest{}, Status: http.StatusCreated, Response: ChunkedUploadStatus{}},
+	"GET /api/sessions/:sessionId/uploads/:uploadId":    {Response: ChunkedUploadStatus{}},
+	"PUT /api/sessions/:sessionId/uploads/:uploadId":    {Summary: "Upload a chunk", Response: ChunkedUploadStatus{}},
+	"DELETE /api/sessions/:sessionId/uploads/:uploadId": {Response: messageResponse},
+	"GET /api/sessions/:sessionId/bundle":               {Summary: "Export the session as a bundle", Produces: "application/zip"},
+	"POST /api/sessions/import":                         {Summary: "Import a session bundle", Form: apidoc.Object{"sessionId": ""}, File: []string{"bundle"}},
+
+	// Schemas
+	"GET /api/sessions/:sessionId/describe":        {Query: []apidoc.Param{{Name: "service", Required: true}}, Response: grpc.ServiceDescription{}},
+	"GET /api/sessions/:sessionId/types/:typeName": {Response: grpc.MessageDescription{}},
+	"GET /api/sessions/:sessionId/openapi": {
+		Summary: "Generate OpenAPI for the session's HTTP-annotated methods",
+		Query: []apidoc.Param{
+			{Name: "format", Description: "json (default) or yaml"},
+			{Name: "title"},
+			{Name: "version"},
+			{Name: "download", Type: "boolean"},
+		},
+		Response: grpc.OpenAPIDocument{},
+	},
+
+	// Calls
+	"POST /api/grpc/call":              {Summary: "Call a unary method", Session: true, Request: CallRequest{}, Response: CallGRPCResponse{}},
+	"POST /api/grpc/replay/:historyId": {Summary: "Replay a call from the history", Session: true, Request: ReplayRequest{}, Response: CallGRPCResponse{}},
+	"POST /api/grpc/chain": {
+		Summary:  "Run calls in sequence",
+		Session:  true,
+		Request:  ChainRequest{},
+		Response: apidoc.Object{"chain_id": "", "ok": false, "steps": []ChainStepResult{}},
+	},
+	"POST /api/grpc/export/grpcurl": {Summary: "Render a call as a grpcurl command", Session: true, Request: CallRequest{}},
+	"POST /api/grpc/loadtest": {
+		Summary:  "Run a load test",
+		Session:  true,
+		Request:  LoadTestRequest{},
+		Response: apidoc.Object{"test_id": "", "summary": loadtest.Summary{}},
+	},
+	"POST /api/grpc/services":         {Summary: "List services", Session: true, Request: ListServicesRequest{}},
+	"POST /api/grpc/describe":         {Summary: "Describe a service over reflection", Session: true, Request: DescribeServiceRequest{}, Response: grpc.ServiceDescription{}},
+	"POST /api/grpc/describe-method":  {Summary: "Describe a single method", Session: true, Request: DescribeMethodRequest{}, Response: DescribeMethodResponse{}},
+	"POST /api/grpc/describe-message": {Summary: "Fetch a message schema over reflection", Session: true, Request: DescribeMessageRequest{}, Response: DescribeMessageResponse{}},
+	"GET /api/grpc/skeleton": {
+		Summary: "Build a request skeleton for a method",
+		Session: true,
+		Query:   []apidoc.Param{{Name: "service", Required: true}, {Name: "method", Required: true}},
+	},
+
+	// Administration
+	"POST /api/admin/common-protos": {Request: RegisterCommonProtosRequest{}},
+	"GET /api/admin/status":         {Summary: "Server status and connected clients"},
+	"PUT /api/admin/maintenance":    {Request: MaintenanceRequest{}, Response: Maintenance{}},
+	"POST /api/admin/notices":       {Request: NoticeRequest{}, Response: apidoc.Object{"notice": Notice{}, "clients": 0}},
+	"GET /api/admin/storage":        {Response: session.StorageReport{}},
+	"POST /api/admin/storage/cleanup": {
+		Request:  StorageCleanupRequest{},
+		Response: session.StorageCleanup{},
+	},
+	"POST /api/admin/orphans/reconcile": {Response: session.OrphanReport{}},
+	"GET /debug/runtime":                {Response: RuntimeStats{}},
+}
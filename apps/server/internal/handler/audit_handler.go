@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/audit"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditHandler exposes the per-session audit trail
+type AuditHandler struct {
+	sessionManager *session.Manager
+	store          audit.Store
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(sm *session.Manager, store audit.Store) *AuditHandler {
+	return &AuditHandler{
+		sessionManager: sm,
+		store:          store,
+	}
+}
+
+// ListAudit returns recorded operations, newest first.
+// Query params: action, user, offset, limit.
+func (h *AuditHandler) ListAudit(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAuditLimit)))
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	if limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := h.store.List(sessionID, audit.Filter{
+		Action: c.Query("action"),
+		User:   c.Query("user"),
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to list audit trail: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"entries":    entries,
+		"total":      total,
+		"offset":     offset,
+		"limit":      limit,
+	})
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/auth"
+)
+
+// loginStateTTL bounds how long a user may take to sign in at the provider
+const loginStateTTL = 10 * time.Minute
+
+// AuthHandler serves the OIDC login flow and the current user endpoint
+type AuthHandler struct {
+	auth *auth.Authenticator
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(authenticator *auth.Authenticator) *AuthHandler {
+	return &AuthHandler{auth: authenticator}
+}
+
+// Login redirects the browser to the OIDC provider. ?return= is the local
+// path to come back to after signing in (default /).
+func (h *AuthHandler) Login(c *gin.Context) {
+	provider := h.auth.OIDC()
+	if provider == nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.FeatureDisabled, "OIDC login is not enabled")
+		return
+	}
+
+	state := auth.LoginState{
+		State:    auth.RandomString(24),
+		Nonce:    auth.RandomString(24),
+		Verifier: auth.RandomString(48),
+		ReturnTo: localPath(c.Query("return")),
+	}
+	value, err := h.auth.Cookies().Seal(state, loginStateTTL)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to start login")
+		return
+	}
+	auth.SetCookie(c.Writer, c.Request, auth.StateCookie, value, auth.CallbackPath, int(loginStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state.State, state.Nonce, state.Verifier))
+}
+
+// Callback finishes a login: it checks the state, exchanges the code for an
+// ID token, sets the login cookie and sends the browser back where it started
+func (h *AuthHandler) Callback(c *gin.Context) {
+	provider := h.auth.OIDC()
+	if provider == nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.FeatureDisabled, "OIDC login is not enabled")
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		apierror.RespondDetails(c, http.StatusUnauthorized, apierror.Unauthenticated, "login failed: "+e, gin.H{
+			"description": c.Query("error_description"),
+		})
+		return
+	}
+
+	var state auth.LoginState
+	cookie, err := c.Cookie(auth.StateCookie)
+	if err != nil || !h.auth.Cookies().Open(cookie, &state) || state.State != c.Query("state") {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "login expired or was not started here; try again")
+		return
+	}
+	auth.SetCookie(c.Writer, c.Request, auth.StateCookie, "", auth.CallbackPath, -1)
+
+	code := c.Query("code")
+	if code == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "code is required")
+		return
+	}
+	id, err := provider.Exchange(c.Request.Context(), code, state.Verifier, state.Nonce)
+	if err != nil {
+		log.Printf("[Auth] OIDC login failed: %v", err)
+		apierror.Respond(c, http.StatusUnauthorized, apierror.Unauthenticated, "login failed")
+		return
+	}
+	if err := h.auth.Login(c.Writer, c.Request, id); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to complete login")
+		return
+	}
+	log.Printf("[Auth] %s signed in", id.User)
+	c.Redirect(http.StatusFound, state.ReturnTo)
+}
+
+// Logout clears the login cookie. The provider session is left alone, so the
+// next login may complete without a prompt.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	h.auth.Logout(c.Writer, c.Request)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// Me returns the user behind the request. With authentication disabled the
+// caller is anonymous.
+func (h *AuthHandler) Me(c *gin.Context) {
+	response := gin.H{
+		"authenticated": auth.Authenticated(c),
+		"user":          auth.User(c),
+		"mode":          h.auth.Mode(),
+	}
+	if id, ok := h.auth.Identity(c.Request); ok {
+		response["name"] = id.Name
+		response["email"] = id.Email
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// localPath keeps post-login redirects on this server: anything but an
+// absolute local path (including protocol-relative "//host") becomes "/"
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return "/"
+	}
+	return p
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+)
+
+// Request structs are validated by Gin's validator through binding tags. Its
+// errors name fields by their JSON names, and the grpc_target rule checks
+// gRPC addresses with grpc.ValidateTarget. Values holding {{var}}
+// placeholders pass grpc_target; they are checked once resolved, before the
+// call is made (see resolveTemplates).
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return f.Name
+	})
+	v.RegisterValidation("grpc_target", func(fl validator.FieldLevel) bool {
+		target := strings.TrimSpace(fl.Field().String())
+		return strings.Contains(target, "{{") || grpc.ValidateTarget(target) == nil
+	})
+}
+
+// bindJSON decodes and validates the JSON body into req. On failure it writes
+// the error response, naming the invalid fields, and returns false.
+func bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		respondBindError(c, err)
+		return false
+	}
+	return true
+}
+
+// respondBindError answers a request whose body could not be bound: 413 for
+// an oversized body, VALIDATION_FAILED with details.fields when fields are
+// missing or invalid, and INVALID_REQUEST when the body is not JSON at all
+func respondBindError(c *gin.Context, err error) {
+	if bodyTooLarge(c, err) {
+		return
+	}
+	if fields := fieldErrors(err); len(fields) > 0 {
+		apierror.RespondError(c, http.StatusBadRequest, apierror.Invalid(fields...), apierror.ValidationFailed)
+		return
+	}
+	msg := err.Error()
+	if errors.Is(err, io.EOF) {
+		msg = "request body is required"
+	}
+	apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid request: "+msg)
+}
+
+// fieldErrors describes the fields a binding error complains about
+func fieldErrors(err error) []apierror.FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []apierror.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be %s, not %s", typeErr.Field, jsonKind(typeErr.Type), typeErr.Value),
+		}}
+	}
+
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+	fields := make([]apierror.FieldError, 0, len(invalid))
+	for _, fe := range invalid {
+		// Drop the struct name: "CallRequest.service" -> "service"
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		fields = append(fields, apierror.FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: field + " " + ruleMessage(fe),
+		})
+	}
+	return fields
+}
+
+// ruleMessage phrases a failed validation rule as a predicate of the field
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "grpc_target":
+		if err := grpc.ValidateTarget(strings.TrimSpace(fmt.Sprint(fe.Value()))); err != nil {
+			return err.Error()
+		}
+		return "must be host:port"
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		switch fe.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return fmt.Sprintf("must have %s %s item(s)", bound, fe.Param())
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters", bound, fe.Param())
+		default:
+			return fmt.Sprintf("must be %s %s", bound, fe.Param())
+		}
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return fmt.Sprintf("fails the %s rule", fe.Tag())
+	}
+}
+
+// jsonKind names the JSON type a Go type decodes from
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
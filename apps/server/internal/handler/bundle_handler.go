@@ -0,0 +1,487 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/archive"
+	"github.com/grpc-bridge/server/internal/audit"
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/collection"
+	"github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/secret"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// Session bundle layout: a zip with a JSON manifest, the uploaded proto files
+// under protos/ and the descriptor sets under descriptors/
+const (
+	bundleManifestName  = "bundle.json"
+	bundleProtoDir      = "protos/"
+	bundleDescriptorDir = "descriptors/"
+	bundleVersion       = 1
+)
+
+// sessionBundle is the manifest of an exported session
+type sessionBundle struct {
+	Version        int                      `json:"version"`
+	ExportedAt     time.Time                `json:"exported_at"`
+	Name           string                   `json:"name"`
+	Description    string                   `json:"description,omitempty"`
+	Tags           []string                 `json:"tags,omitempty"`
+	Variables      map[string]string        `json:"variables,omitempty"`
+	Metadata       map[string]string        `json:"metadata,omitempty"` // Default call metadata
+	Environments   []session.Environment    `json:"environments,omitempty"`
+	Targets        []session.Target         `json:"targets,omitempty"`
+	ImportMappings []session.ImportMapping  `json:"import_mappings,omitempty"`
+	DescriptorSets []bundleDescriptorSet    `json:"descriptor_sets,omitempty"`
+	Collections    []*collection.Collection `json:"collections,omitempty"`
+}
+
+// bundleDescriptorSet records where a bundled descriptor set came from
+type bundleDescriptorSet struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// BundleHandler exports a whole session (protos, descriptor sets, variables,
+// default metadata, environments, target presets, import mappings and session
+// collections) as one archive and imports it again
+type BundleHandler struct {
+	protoHandler    *ProtoHandler
+	sessionManager  *session.Manager
+	collectionStore collection.Store
+}
+
+// NewBundleHandler creates a new session bundle handler. Imported protos are
+// stored through the proto handler's upload pipeline.
+func NewBundleHandler(ph *ProtoHandler, store collection.Store) *BundleHandler {
+	return &BundleHandler{
+		protoHandler:    ph,
+		sessionManager:  ph.sessionManager,
+		collectionStore: store,
+	}
+}
+
+// ExportBundle streams the session bundle as a zip archive. Credentials
+// (sensitive variables and metadata) are redacted like in API responses.
+func (h *BundleHandler) ExportBundle(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	variables, _ := h.sessionManager.GetVariables(sessionID)
+	defaultMetadata, _ := h.sessionManager.GetDefaultMetadata(sessionID)
+	environments, _ := h.sessionManager.ListEnvironments(sessionID)
+	targets, _ := h.sessionManager.ListTargets(sessionID)
+	manifest := sessionBundle{
+		Version:        bundleVersion,
+		ExportedAt:     time.Now(),
+		Name:           sess.Name,
+		Description:    sess.Description,
+		Tags:           sess.Tags,
+		Variables:      secret.RedactMap(variables),
+		Metadata:       secret.RedactMap(defaultMetadata),
+		Environments:   session.RedactEnvironments(environments),
+		Targets:        session.RedactTargets(targets),
+		ImportMappings: sess.ImportMappings,
+	}
+	for _, set := range sess.DescriptorSets {
+		manifest.DescriptorSets = append(manifest.DescriptorSets, bundleDescriptorSet{Name: set.Name, Source: set.Source})
+	}
+
+	// Only collections owned by the session travel with it; global ones belong to the server
+	collections, err := h.collectionStore.List(sessionID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to list collections: "+err.Error())
+		return
+	}
+	for _, col := range collections {
+		if col.Scope == collection.ScopeSession {
+			manifest.Collections = append(manifest.Collections, redactCollection(col))
+		}
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to encode bundle manifest: "+err.Error())
+		return
+	}
+
+	shortID := sessionID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="session-%s-bundle.zip"`, shortID))
+	c.Status(http.StatusOK)
+
+	// Headers are already sent, so failures past this point can only be logged
+	zw := zip.NewWriter(c.Writer)
+	if w, err := zw.Create(bundleManifestName); err == nil {
+		_, _ = w.Write(manifestJSON)
+	}
+	for _, pf := range sess.ProtoFiles {
+		if err := addZipEntry(zw, bundleProtoDir+filepath.ToSlash(pf.RelativePath), pf.AbsolutePath); err != nil {
+			fmt.Printf("[ExportBundle] Skipping %s: %v\n", pf.RelativePath, err)
+		}
+	}
+	for _, set := range sess.DescriptorSets {
+		if err := addZipEntry(zw, bundleDescriptorDir+set.Name, set.AbsolutePath); err != nil {
+			fmt.Printf("[ExportBundle] Skipping %s: %v\n", set.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		fmt.Printf("[ExportBundle] Failed to finish bundle for session %s: %v\n", sessionID, err)
+	}
+}
+
+// ImportBundle restores a session bundle. Without a sessionId form field a new
+// session is created; otherwise the given session's files, variables, import
+// mappings and session collections are replaced.
+func (h *BundleHandler) ImportBundle(c *gin.Context) {
+	fileHeader, err := c.FormFile("bundle")
+	if err != nil {
+		if bodyTooLarge(c, err) {
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "bundle file is required")
+		return
+	}
+	if fileHeader.Size > archive.MaxArchiveSize {
+		apierror.Respond(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("bundle exceeds the maximum size of %d bytes", archive.MaxArchiveSize))
+		return
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to open uploaded bundle")
+		return
+	}
+	defer src.Close()
+
+	contents, err := readBundle(src, fileHeader.Size)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	var sess *session.Session
+	if sessionID := c.PostForm("sessionId"); sessionID != "" {
+		existing, exists := h.sessionManager.Get(sessionID)
+		if !exists {
+			apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+			return
+		}
+		sess = existing
+	} else {
+		sess = h.sessionManager.Create(contents.manifest.Name)
+		if user := auth.User(c); user != "" {
+			_ = h.sessionManager.SetOwner(sess.ID, user)
+		}
+		audit.SetSession(c, sess.ID)
+	}
+
+	h.protoHandler.hub.EmitToSession(sess.ID, UploadStart{
+		SessionID: sess.ID,
+		Bundle:    fileHeader.Filename,
+	})
+
+	batch, ok := h.protoHandler.resetSessionDir(c, sess.ID)
+	if !ok {
+		return
+	}
+	for _, f := range contents.protos {
+		h.protoHandler.store(batch, f.Path, bytes.NewReader(f.Content))
+	}
+	batch.errorFiles = append(batch.errorFiles, contents.rejected...)
+
+	sources := make(map[string]string, len(contents.manifest.DescriptorSets))
+	for _, set := range contents.manifest.DescriptorSets {
+		sources[set.Name] = set.Source
+	}
+	for _, f := range contents.descriptors {
+		source := sources[f.Path]
+		if source == "" {
+			source = "upload"
+		}
+		if _, err := h.protoHandler.storeDescriptorSet(sess.ID, f.Path, f.Content, source); err != nil {
+			if qe, ok := asQuotaError(err); ok && batch.quotaErr == nil {
+				batch.quotaErr = qe
+			}
+			batch.errorFiles = append(batch.errorFiles, bundleDescriptorDir+f.Path)
+		}
+	}
+
+	warnings := h.restoreSettings(sess.ID, contents.manifest)
+	collections := h.restoreCollections(sess.ID, contents.manifest.Collections)
+
+	updated, _ := h.sessionManager.Get(sess.ID)
+	if updated == nil {
+		updated = sess
+	}
+	h.protoHandler.finishUpload(c, updated, batch, UploadSource{
+		Bundle:              fileHeader.Filename,
+		DescriptorSetCount:  len(contents.descriptors),
+		CollectionsImported: collections,
+		VariablesImported:   len(contents.manifest.Variables),
+		BundleExportedAt:    &contents.manifest.ExportedAt,
+		Warnings:            warnings,
+	})
+}
+
+// restoreSettings applies the bundle's session details, variables, default
+// metadata, environments, target presets and import mappings.
+// Invalid parts are skipped and reported as warnings. Redacted credentials
+// keep the session's current value, or are left empty.
+func (h *BundleHandler) restoreSettings(sessionID string, manifest sessionBundle) []string {
+	var warnings []string
+	currentVars, _ := h.sessionManager.GetVariables(sessionID)
+	currentMetadata, _ := h.sessionManager.GetDefaultMetadata(sessionID)
+	currentEnvs, _ := h.sessionManager.ListEnvironments(sessionID)
+	currentTargets, _ := h.sessionManager.ListTargets(sessionID)
+
+	name, description := manifest.Name, manifest.Description
+	tags, err := normalizeTags(manifest.Tags)
+	if err != nil {
+		warnings = append(warnings, "tags: "+err.Error())
+		tags = []string{}
+	}
+	if _, err := h.sessionManager.UpdateMetadata(sessionID, session.MetadataUpdate{
+		Name:        &name,
+		Description: &description,
+		Tags:        tags,
+	}); err != nil {
+		warnings = append(warnings, "metadata: "+err.Error())
+	}
+
+	vars := make(map[string]string, len(manifest.Variables))
+	for k, v := range manifest.Variables {
+		if !isValidVariableName(k) {
+			warnings = append(warnings, "invalid variable name: "+k)
+			continue
+		}
+		vars[k] = v
+	}
+	restoreRedacted(vars, currentVars)
+	if err := h.sessionManager.ReplaceVariables(sessionID, vars); err != nil {
+		warnings = append(warnings, "variables: "+err.Error())
+	}
+
+	md := make(map[string]string, len(manifest.Metadata))
+	for k, v := range manifest.Metadata {
+		key, ok := normalizeMetadataKey(k)
+		if !ok {
+			warnings = append(warnings, "invalid metadata key: "+k)
+			continue
+		}
+		md[key] = v
+	}
+	restoreRedacted(md, currentMetadata)
+	if err := h.sessionManager.ReplaceDefaultMetadata(sessionID, md); err != nil {
+		warnings = append(warnings, "default metadata: "+err.Error())
+	}
+
+	envs := make([]session.Environment, 0, len(manifest.Environments))
+	for _, env := range manifest.Environments {
+		normalized, err := normalizeEnvironment(env)
+		if err != nil {
+			warnings = append(warnings, "environments: "+err.Error())
+			continue
+		}
+		var current session.Environment
+		for _, e := range currentEnvs {
+			if e.Name == normalized.Name {
+				current = e
+			}
+		}
+		restoreRedacted(normalized.Metadata, current.Metadata)
+		restoreRedacted(normalized.Variables, current.Variables)
+		envs = append(envs, normalized)
+	}
+	if err := h.sessionManager.ReplaceEnvironments(sessionID, envs); err != nil {
+		warnings = append(warnings, "environments: "+err.Error())
+	}
+
+	// Target IDs are kept so saved requests referencing them keep working
+	targets := make([]session.Target, 0, len(manifest.Targets))
+	for _, target := range manifest.Targets {
+		normalized, err := normalizeTarget(target)
+		if err != nil {
+			warnings = append(warnings, "targets: "+err.Error())
+			continue
+		}
+		var current session.Target
+		for _, t := range currentTargets {
+			if t.ID == normalized.ID {
+				current = t
+			}
+		}
+		restoreRedacted(normalized.Metadata, current.Metadata)
+		targets = append(targets, normalized)
+	}
+	if err := h.sessionManager.ReplaceTargets(sessionID, targets); err != nil {
+		warnings = append(warnings, "targets: "+err.Error())
+	}
+
+	mappings, err := proto.NormalizeImportMappings(manifest.ImportMappings)
+	if err != nil {
+		warnings = append(warnings, "import mappings: "+err.Error())
+		mappings = []session.ImportMapping{}
+	}
+	if err := h.sessionManager.SetImportMappings(sessionID, mappings); err != nil {
+		warnings = append(warnings, "import mappings: "+err.Error())
+	}
+
+	return warnings
+}
+
+// restoreCollections replaces the session's collections with the bundled ones.
+// Collections and requests get fresh IDs. It returns the number restored.
+func (h *BundleHandler) restoreCollections(sessionID string, collections []*collection.Collection) int {
+	if err := h.collectionStore.DeleteSession(sessionID); err != nil {
+		fmt.Printf("[ImportBundle] Failed to clear collections of session %s: %v\n", sessionID, err)
+	}
+
+	restored := 0
+	for _, col := range collections {
+		if col == nil {
+			continue
+		}
+		created, err := h.collectionStore.Create(&collection.Collection{
+			Name:        col.Name,
+			Description: col.Description,
+			Scope:       collection.ScopeSession,
+			SessionID:   sessionID,
+		})
+		if err != nil {
+			fmt.Printf("[ImportBundle] Failed to restore collection %q: %v\n", col.Name, err)
+			continue
+		}
+		for _, r := range col.Requests {
+			restoreRedacted(r.Metadata, nil)
+			if _, err := h.collectionStore.AddRequest(created.ID, r); err != nil {
+				fmt.Printf("[ImportBundle] Failed to restore request %q: %v\n", r.Name, err)
+			}
+		}
+		restored++
+	}
+	return restored
+}
+
+// restoreRedacted replaces redacted values of an imported map with the values
+// they stand for in current, and empties those current does not have, so a
+// placeholder is never stored as a credential
+func restoreRedacted(imported, current map[string]string) {
+	secret.Unredact(imported, current)
+	for k, v := range imported {
+		if v == secret.Redacted {
+			imported[k] = ""
+		}
+	}
+}
+
+// bundleContents is a decoded session bundle
+type bundleContents struct {
+	manifest    sessionBundle
+	protos      []archive.File // Paths relative to the session root
+	descriptors []archive.File // Paths are descriptor set names
+	rejected    []string       // Entries refused because their path is unsafe
+}
+
+// readBundle decodes a session bundle, enforcing the archive size limits
+func readBundle(r io.ReaderAt, size int64) (*bundleContents, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if len(zr.File) > archive.MaxEntries {
+		return nil, archive.ErrTooManyEntries
+	}
+
+	contents := &bundleContents{}
+	foundManifest := false
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name, ok := archive.SanitizePath(f.Name)
+		if !ok {
+			contents.rejected = append(contents.rejected, f.Name)
+			continue
+		}
+
+		isManifest := name == bundleManifestName
+		isProto := strings.HasPrefix(name, bundleProtoDir) && strings.HasSuffix(name, ".proto")
+		isDescriptor := strings.HasPrefix(name, bundleDescriptorDir) && path.Dir(name) == strings.TrimSuffix(bundleDescriptorDir, "/")
+		if !isManifest && !isProto && !isDescriptor {
+			continue
+		}
+
+		limit := archive.MaxFileSize
+		if isDescriptor {
+			limit = proto.MaxDescriptorSetSize
+		}
+		data, err := readZipFile(f, limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle entry %s: %w", f.Name, err)
+		}
+		total += int64(len(data))
+		if total > archive.MaxTotalSize {
+			return nil, archive.ErrTooLarge
+		}
+
+		switch {
+		case isManifest:
+			if err := json.Unmarshal(data, &contents.manifest); err != nil {
+				return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+			}
+			foundManifest = true
+		case isProto:
+			contents.protos = append(contents.protos, archive.File{Path: strings.TrimPrefix(name, bundleProtoDir), Content: data})
+		case isDescriptor:
+			contents.descriptors = append(contents.descriptors, archive.File{Path: path.Base(name), Content: data})
+		}
+	}
+
+	if !foundManifest {
+		return nil, fmt.Errorf("invalid bundle: %s is missing", bundleManifestName)
+	}
+	if contents.manifest.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", contents.manifest.Version)
+	}
+	return contents, nil
+}
+
+// readZipFile reads a zip entry, refusing entries larger than limit
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("exceeds %d bytes", limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// Defaults for the call worker pool
+const (
+	DefaultCallWorkers          = 64   // Unary calls running at once
+	DefaultCallQueueSize        = 1024 // Calls waiting for a worker, across sessions
+	DefaultCallQueueSessionSize = 64   // Calls waiting for a worker, per session
+)
+
+// CallPoolStats counts the call pool's workers and waiting calls
+type CallPoolStats struct {
+	Workers int `json:"workers"` // Calls allowed to run at once
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+}
+
+// queuedCall is a call waiting for a worker
+type queuedCall struct {
+	sessionID string
+	callID    string
+	run       func()
+}
+
+// callPool runs unary calls on at most workers goroutines. Calls beyond that
+// wait in a FIFO queue bounded in total and per session; every waiting call
+// is told its position when it is queued and whenever it moves up.
+type callPool struct {
+	mu          sync.Mutex
+	workers     int
+	queueSize   int // 0 rejects calls when every worker is busy
+	sessionSize int // 0 leaves sessions bounded by queueSize only
+	running     int
+	queue       []*queuedCall
+	queued      map[string]int // Waiting calls by session
+
+	// position is called with the 1-based queue position of a waiting call.
+	// It runs under the pool's lock, so positions arrive in order, and must
+	// not call back into the pool.
+	position func(sessionID, callID string, position int)
+}
+
+func newCallPool(position func(sessionID, callID string, position int)) *callPool {
+	return &callPool{
+		workers:     DefaultCallWorkers,
+		queueSize:   DefaultCallQueueSize,
+		sessionSize: DefaultCallQueueSessionSize,
+		queued:      make(map[string]int),
+		position:    position,
+	}
+}
+
+// configure changes the pool's limits. Calls already queued keep their place;
+// with more workers they start at once.
+func (p *callPool) configure(workers, queueSize, sessionSize int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.workers, p.queueSize, p.sessionSize = workers, queueSize, sessionSize
+	for p.running < p.workers && len(p.queue) > 0 {
+		p.running++
+		go p.work(p.dequeue(0).run)
+	}
+}
+
+// submit runs a call on a free worker, or queues it until one frees up. It
+// fails with RATE_LIMITED when the queue, or the session's share of it, is
+// full.
+func (p *callPool) submit(sessionID, callID string, run func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running < p.workers && len(p.queue) == 0 {
+		p.running++
+		go p.work(run)
+		return nil
+	}
+	if len(p.queue) >= p.queueSize {
+		return apierror.NewError(apierror.RateLimited, fmt.Sprintf("call queue is full (%d calls waiting)", len(p.queue)))
+	}
+	if p.sessionSize > 0 && p.queued[sessionID] >= p.sessionSize {
+		return apierror.NewError(apierror.RateLimited, fmt.Sprintf("session already has %d calls waiting", p.queued[sessionID]))
+	}
+	p.queue = append(p.queue, &queuedCall{sessionID: sessionID, callID: callID, run: run})
+	p.queued[sessionID]++
+	p.position(sessionID, callID, len(p.queue))
+	return nil
+}
+
+// work runs calls until the queue is empty
+func (p *callPool) work(run func()) {
+	for {
+		run()
+
+		p.mu.Lock()
+		if len(p.queue) == 0 || p.running > p.workers {
+			// Workers beyond a lowered limit stop here
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		next := p.dequeue(0)
+		p.mu.Unlock()
+		run = next.run
+	}
+}
+
+// cancel removes a waiting call from the queue and runs it at once on its own
+// goroutine, so that it reports its cancellation. It returns false when the
+// call is not waiting.
+func (p *callPool) cancel(callID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, q := range p.queue {
+		if q.callID == callID {
+			q = p.dequeue(i)
+			go q.run()
+			return true
+		}
+	}
+	return false
+}
+
+// dequeue removes the i-th waiting call and tells the calls behind it their
+// new position. The caller holds p.mu.
+func (p *callPool) dequeue(i int) *queuedCall {
+	q := p.queue[i]
+	p.queue = append(p.queue[:i], p.queue[i+1:]...)
+	p.queued[q.sessionID]--
+	if p.queued[q.sessionID] == 0 {
+		delete(p.queued, q.sessionID)
+	}
+	for j := i; j < len(p.queue); j++ {
+		p.position(p.queue[j].sessionID, p.queue[j].callID, j+1)
+	}
+	return q
+}
+
+// stats returns the pool's counts
+func (p *callPool) stats() CallPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return CallPoolStats{Workers: p.workers, Running: p.running, Queued: len(p.queue)}
+}
+
+// SetCallPool changes how many unary calls run at once, and how many may wait
+// for a worker in total and per session (0 for no per-session limit)
+func (h *GRPCHandler) SetCallPool(workers, queueSize, sessionQueueSize int) {
+	h.pool.configure(workers, queueSize, sessionQueueSize)
+}
+
+// CallPoolStats returns the call pool's counts
+func (h *GRPCHandler) CallPoolStats() CallPoolStats {
+	return h.pool.stats()
+}
+
+// submitCall queues a unary call on the call pool and returns its ID and a
+// channel receiving its outcome. The call gets its own context: the request
+// or socket it came from ending does not cancel it, while the cancel command
+// and deleting the session do. A call cancelled while waiting fails without
+// running.
+func (h *GRPCHandler) submitCall(sess *session.Session, req *CallRequest) (string, <-chan CallGRPCResponse, error) {
+	callID := uuid.New().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	h.trackCall(callID, &activeCall{
+		sessionID: sess.ID,
+		cancel: func() {
+			cancel()
+			h.pool.cancel(callID)
+		},
+	})
+
+	outcome := make(chan CallGRPCResponse, 1)
+	err := h.pool.submit(sess.ID, callID, func() {
+		defer func() {
+			cancel()
+			h.untrackCall(callID)
+		}()
+		if ctx.Err() != nil {
+			failure := &CallError{
+				CallID: callID,
+				Code:   apierror.CallFailed,
+				Error:  "call cancelled before it started",
+				Kind:   "cancelled",
+			}
+			h.wsHub.EmitToSession(sess.ID, failure)
+			outcome <- CallGRPCResponse{Ok: false, Payload: failure}
+			return
+		}
+		outcome <- h.runCall(ctx, callID, sess, req)
+	})
+	if err != nil {
+		cancel()
+		h.untrackCall(callID)
+		return "", nil, err
+	}
+	return callID, outcome, nil
+}
+
+// respondCall queues a call and answers the request with its outcome. A
+// client that goes away first leaves the call running; its outcome still
+// reaches the session's events and history.
+func (h *GRPCHandler) respondCall(c *gin.Context, sess *session.Session, req *CallRequest) {
+	_, outcome, err := h.submitCall(sess, req)
+	if err != nil {
+		c.Header("Retry-After", "1")
+		apierror.RespondError(c, http.StatusTooManyRequests, err, apierror.RateLimited)
+		return
+	}
+	select {
+	case response := <-outcome:
+		c.JSON(http.StatusOK, response)
+	case <-c.Request.Context().Done():
+	}
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+)
+
+// GetCallStats returns rolling latency and error-rate statistics of the
+// session's unary calls, per target and method
+func (h *GRPCHandler) GetCallStats(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+	c.JSON(http.StatusOK, h.stats.Snapshot(sessionID))
+}
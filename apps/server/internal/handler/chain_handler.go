@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/audit"
+	"github.com/grpc-bridge/server/internal/template"
+)
+
+// ChainRequest represents an ordered list of calls executed sequentially.
+// Later steps may reference earlier results via {{steps.<index>.response.<field>}},
+// {{steps.<index>.request.<field>}} or {{steps.<index>.headers.<key>.0}}.
+type ChainRequest struct {
+	Steps           []CallRequest `json:"steps" binding:"required,min=1,dive"`
+	ContinueOnError bool          `json:"continue_on_error"` // Keep running remaining steps after a failure
+}
+
+// ChainStepResult represents the outcome of one chain step
+type ChainStepResult struct {
+	Index   int  `json:"index"`
+	Ok      bool `json:"ok"`
+	Skipped bool `json:"skipped,omitempty"`
+	Payload any  `json:"payload,omitempty"` // *CallResponse, *CallError or a template error
+}
+
+// CallChain executes a sequence of calls, feeding earlier responses into later requests
+func (h *GRPCHandler) CallChain(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
+		return
+	}
+
+	session, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	var req ChainRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	audit.AddDetail(c, "steps", strconv.Itoa(len(req.Steps)))
+
+	ctx, done := h.sessionManager.BeginOperation(c.Request.Context(), sessionID)
+	defer done()
+
+	chainID := uuid.New().String()
+	h.wsHub.EmitToSession(sessionID, ChainStart{
+		ChainID: chainID,
+		Total:   len(req.Steps),
+	})
+
+	// steps holds the decoded context of completed steps for placeholder lookups
+	steps := []interface{}{}
+	results := make([]ChainStepResult, 0, len(req.Steps))
+	allOk := true
+	halted := false
+
+	for idx := range req.Steps {
+		if halted || ctx.Err() != nil {
+			results = append(results, ChainStepResult{Index: idx, Skipped: true})
+			continue
+		}
+
+		step := req.Steps[idx]
+		h.wsHub.EmitToSession(sessionID, ChainStepStart{
+			ChainID: chainID,
+			Index:   idx,
+			Service: step.Service,
+			Method:  step.Method,
+		})
+
+		// Each step may use its own environment
+		lookup, err := h.callLookup(sessionID, &step)
+		resolver := template.NewResolver(template.ChainLookups(
+			template.PathLookup("steps", steps),
+			lookup,
+		))
+		step.Target = resolver.String(step.Target)
+		step.Metadata = resolver.Map(step.Metadata)
+		step.Data = resolver.Value(step.Data)
+		if err == nil {
+			err = resolver.Err()
+		}
+		if err == nil {
+			err = checkCallTarget(step.Target)
+		}
+
+		var result ChainStepResult
+		if err != nil {
+			result = ChainStepResult{
+				Index: idx,
+				Ok:    false,
+				Payload: gin.H{
+					"code":  apierror.CodeOf(err, apierror.TemplateError),
+					"error": err.Error(),
+					"kind":  "template_error",
+				},
+			}
+		} else {
+			resp := h.executeCall(ctx, session, &step)
+			result = ChainStepResult{
+				Index:   idx,
+				Ok:      resp.Ok,
+				Payload: resp.Payload,
+			}
+		}
+
+		stepContext := map[string]interface{}{
+			"request": step.Data,
+			"ok":      result.Ok,
+		}
+		if resp, ok := result.Payload.(*CallResponse); ok {
+			stepContext["response"] = resp.Parsed
+			stepContext["headers"] = toJSONValue(resp.Headers)
+			stepContext["trailers"] = toJSONValue(resp.Trailers)
+		}
+		steps = append(steps, stepContext)
+		results = append(results, result)
+
+		h.wsHub.EmitToSession(sessionID, ChainStepDone{
+			ChainID: chainID,
+			Index:   idx,
+			Ok:      result.Ok,
+			Payload: result.Payload,
+		})
+
+		if !result.Ok {
+			allOk = false
+			if !req.ContinueOnError {
+				halted = true
+			}
+		}
+	}
+
+	h.wsHub.EmitToSession(sessionID, ChainDone{
+		ChainID: chainID,
+		Ok:      allOk,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"chain_id": chainID,
+		"ok":       allOk,
+		"steps":    results,
+	})
+}
+
+// toJSONValue converts metadata maps into generic JSON values usable by template path lookups
+func toJSONValue(v interface{}) interface{} {
+	md, ok := v.(map[string][]string)
+	if !ok {
+		return v
+	}
+	out := make(map[string]interface{}, len(md))
+	for k, values := range md {
+		items := make([]interface{}, len(values))
+		for i, item := range values {
+			items[i] = item
+		}
+		out[k] = items
+	}
+	return out
+}
@@ -0,0 +1,438 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/archive"
+)
+
+// Chunked upload defaults; see NewChunkedUploadHandler
+const (
+	DefaultUploadChunkSize  int64 = 8 << 20   // Chunk size suggested to clients
+	MaxUploadChunkSize      int64 = 32 << 20  // Largest accepted chunk body
+	DefaultChunkedUploadTTL       = time.Hour // Idle time after which a partial upload is discarded
+
+	maxPendingUploadsPerSession = 4
+)
+
+// partialUploadDir holds the data of unfinished chunked uploads, relative to
+// the upload directory. Orphan reconciliation skips entries starting with ".".
+const partialUploadDir = ".partial"
+
+// chunkedUpload is an archive being received in chunks
+type chunkedUpload struct {
+	mu sync.Mutex // Serializes chunk writes and completion
+
+	id        string
+	sessionID string
+	filename  string
+	mode      string
+	size      int64
+	sha256    string // Expected hex digest of the whole archive; empty skips the check
+	received  int64
+	updatedAt time.Time
+	path      string
+	closed    bool // Completed, aborted or expired
+}
+
+// ChunkedUploadStatus reports the progress of a chunked upload. Offset is
+// where the next chunk must start, so a client that lost its connection asks
+// for the status and resumes from there.
+type ChunkedUploadStatus struct {
+	UploadID  string    `json:"upload_id"`
+	SessionID string    `json:"session_id"`
+	Filename  string    `json:"filename"`
+	Mode      string    `json:"mode"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"`
+	ChunkSize int64     `json:"chunk_size"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ChunkedUploadHandler receives proto archives too large or too fragile to send
+// in one request: the client opens an upload, PUTs the archive in chunks and
+// completes it, after which it is stored like /api/proto/upload-archive. Chunks
+// land in a file under the upload directory; uploads left idle past the TTL,
+// and those of deleted sessions, are discarded.
+type ChunkedUploadHandler struct {
+	protoHandler *ProtoHandler
+	dir          string
+	ttl          time.Duration
+
+	mu      sync.Mutex
+	uploads map[string]*chunkedUpload
+}
+
+// NewChunkedUploadHandler creates a chunked upload handler storing archives
+// through the proto handler's upload pipeline. Partial uploads left over from
+// a previous run are removed, since their state was not kept.
+func NewChunkedUploadHandler(ph *ProtoHandler, ttl time.Duration) *ChunkedUploadHandler {
+	if ttl <= 0 {
+		ttl = DefaultChunkedUploadTTL
+	}
+	h := &ChunkedUploadHandler{
+		protoHandler: ph,
+		dir:          filepath.Join(ph.uploadDir, partialUploadDir),
+		ttl:          ttl,
+		uploads:      make(map[string]*chunkedUpload),
+	}
+	if err := os.RemoveAll(h.dir); err != nil {
+		fmt.Printf("[ChunkedUpload] Warning: failed to clear partial uploads: %v\n", err)
+	}
+	go h.expireLoop()
+	return h
+}
+
+// ChunkedUploadRequest opens a chunked upload
+type ChunkedUploadRequest struct {
+	Filename string `json:"filename" binding:"required"` // Archive name; .zip, .tar.gz or .tgz selects the format
+	Size     int64  `json:"size" binding:"required"`     // Total archive size in bytes
+	Mode     string `json:"mode"`                        // "replace" (default) or "merge"
+	SHA256   string `json:"sha256"`                      // Optional hex digest checked on completion
+}
+
+// CreateUpload opens a chunked upload for a session
+func (h *ChunkedUploadHandler) CreateUpload(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	var req ChunkedUploadRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Size <= 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "size must be positive")
+		return
+	}
+	if req.Size > archive.MaxArchiveSize {
+		apierror.Respond(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("archive exceeds the maximum size of %d bytes", archive.MaxArchiveSize))
+		return
+	}
+	mode, ok := parseUploadMode(req.Mode)
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "mode must be \"replace\" or \"merge\"")
+		return
+	}
+	digest := strings.ToLower(strings.TrimSpace(req.SHA256))
+	if digest != "" {
+		if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "sha256 must be a hex SHA-256 digest")
+			return
+		}
+	}
+	if _, exists := h.protoHandler.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	h.mu.Lock()
+	pending := 0
+	for _, u := range h.uploads {
+		if u.sessionID == sessionID {
+			pending++
+		}
+	}
+	h.mu.Unlock()
+	if pending >= maxPendingUploadsPerSession {
+		apierror.Respond(c, http.StatusTooManyRequests, apierror.RateLimited, fmt.Sprintf("session already has %d unfinished uploads", pending))
+		return
+	}
+
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to create upload directory")
+		return
+	}
+	u := &chunkedUpload{
+		id:        uuid.New().String(),
+		sessionID: sessionID,
+		filename:  filepath.Base(req.Filename),
+		mode:      mode,
+		size:      req.Size,
+		sha256:    digest,
+		updatedAt: time.Now(),
+	}
+	u.path = filepath.Join(h.dir, u.id)
+	f, err := os.Create(u.path)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to create upload file")
+		return
+	}
+	f.Close()
+
+	status := h.status(u)
+	h.mu.Lock()
+	h.uploads[u.id] = u
+	h.mu.Unlock()
+
+	fmt.Printf("[ChunkedUpload] [session=%s] Opened upload %s for %s (%d bytes)\n", sessionID, u.id, u.filename, u.size)
+	c.JSON(http.StatusCreated, status)
+}
+
+// GetUpload reports the progress of an upload, including the offset to resume at
+func (h *ChunkedUploadHandler) GetUpload(c *gin.Context) {
+	u, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	c.JSON(http.StatusOK, h.status(u))
+}
+
+// PutChunk appends the request body at ?offset=, which must equal the bytes
+// received so far. If the connection drops mid-chunk, whatever arrived is
+// kept and the status reports where to resume.
+func (h *ChunkedUploadHandler) PutChunk(c *gin.Context) {
+	u, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+	// Form bodies are parsed (and consumed) by the session middleware
+	if ct := c.ContentType(); ct == "multipart/form-data" || ct == "application/x-www-form-urlencoded" {
+		apierror.Respond(c, http.StatusUnsupportedMediaType, apierror.UnsupportedMediaType, "chunks must be sent as application/octet-stream")
+		return
+	}
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "offset query parameter is required")
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound, "upload not found")
+		return
+	}
+	if offset != u.received {
+		apierror.RespondDetails(c, http.StatusConflict, apierror.Conflict, fmt.Sprintf("chunk must start at offset %d", u.received), gin.H{
+			"offset": u.received,
+		})
+		return
+	}
+	if c.Request.ContentLength > MaxUploadChunkSize {
+		apierror.Respond(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("chunk exceeds the maximum size of %d bytes", MaxUploadChunkSize))
+		return
+	}
+	if c.Request.ContentLength > u.size-offset {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("chunk extends past the declared size of %d bytes", u.size))
+		return
+	}
+
+	f, err := os.OpenFile(u.path, os.O_WRONLY, 0)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to open upload file")
+		return
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to write chunk")
+		return
+	}
+
+	limit := min(MaxUploadChunkSize, u.size-offset)
+	n, err := io.Copy(f, io.LimitReader(c.Request.Body, limit))
+	u.received += n
+	u.updatedAt = time.Now()
+	if err != nil {
+		apierror.RespondDetails(c, http.StatusBadRequest, apierror.InvalidRequest, "chunk was cut short: "+err.Error(), gin.H{
+			"offset": u.received,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, h.status(u))
+}
+
+// CompleteUpload extracts the fully received archive and stores its proto
+// files in the session, responding like /api/proto/upload-archive
+func (h *ChunkedUploadHandler) CompleteUpload(c *gin.Context) {
+	u, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound, "upload not found")
+		return
+	}
+	if u.received != u.size {
+		apierror.RespondDetails(c, http.StatusConflict, apierror.Conflict, fmt.Sprintf("upload is incomplete: %d of %d bytes received", u.received, u.size), gin.H{
+			"offset": u.received,
+		})
+		return
+	}
+
+	sess, exists := h.protoHandler.sessionManager.Get(u.sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	// The upload is consumed whatever the outcome; a bad archive is sent again
+	h.discard(u)
+	f, err := os.Open(u.path)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to open uploaded archive")
+		return
+	}
+	defer os.Remove(u.path)
+	defer f.Close()
+
+	hub := h.protoHandler.hub
+	hub.EmitToSession(u.sessionID, UploadStart{
+		SessionID: u.sessionID,
+		Archive:   u.filename,
+	})
+	fail := func(status int, msg string) {
+		code := apierror.FromStatus(status)
+		hub.EmitToSession(u.sessionID, UploadError{
+			Code:  code,
+			Error: msg,
+		})
+		apierror.Respond(c, status, code, msg)
+	}
+
+	if u.sha256 != "" {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, f); err != nil {
+			fail(http.StatusInternalServerError, "failed to read uploaded archive")
+			return
+		}
+		if hex.EncodeToString(hash.Sum(nil)) != u.sha256 {
+			fail(http.StatusUnprocessableEntity, "archive does not match the declared sha256")
+			return
+		}
+	}
+
+	head := make([]byte, 4)
+	n, _ := f.ReadAt(head, 0)
+	format, err := archive.DetectFormat(u.filename, head[:n])
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	extracted, err := archive.Extract(format, f, u.size)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	h.protoHandler.storeArchive(c, sess, u.mode, extracted, u.filename, format)
+}
+
+// AbortUpload discards an unfinished upload
+func (h *ChunkedUploadHandler) AbortUpload(c *gin.Context) {
+	u, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	h.discard(u)
+	os.Remove(u.path)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "upload aborted",
+	})
+}
+
+// DiscardSession removes the unfinished uploads of a deleted session
+func (h *ChunkedUploadHandler) DiscardSession(sessionID string) {
+	h.mu.Lock()
+	var stale []*chunkedUpload
+	for _, u := range h.uploads {
+		if u.sessionID == sessionID {
+			stale = append(stale, u)
+		}
+	}
+	h.mu.Unlock()
+	h.remove(stale)
+}
+
+// lookup finds the upload named in the route, writing a 404 when it does not
+// exist or belongs to another session
+func (h *ChunkedUploadHandler) lookup(c *gin.Context) (*chunkedUpload, bool) {
+	h.mu.Lock()
+	u, ok := h.uploads[c.Param("uploadId")]
+	h.mu.Unlock()
+	if !ok || u.sessionID != c.Param("sessionId") {
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound, "upload not found")
+		return nil, false
+	}
+	return u, true
+}
+
+// status describes u; callers hold u.mu
+func (h *ChunkedUploadHandler) status(u *chunkedUpload) ChunkedUploadStatus {
+	return ChunkedUploadStatus{
+		UploadID:  u.id,
+		SessionID: u.sessionID,
+		Filename:  u.filename,
+		Mode:      u.mode,
+		Size:      u.size,
+		Offset:    u.received,
+		ChunkSize: DefaultUploadChunkSize,
+		ExpiresAt: u.updatedAt.Add(h.ttl),
+	}
+}
+
+// discard unregisters u; callers hold u.mu and remove its file
+func (h *ChunkedUploadHandler) discard(u *chunkedUpload) {
+	u.closed = true
+	h.mu.Lock()
+	delete(h.uploads, u.id)
+	h.mu.Unlock()
+}
+
+// remove discards uploads and deletes their files
+func (h *ChunkedUploadHandler) remove(uploads []*chunkedUpload) {
+	for _, u := range uploads {
+		u.mu.Lock()
+		if !u.closed {
+			h.discard(u)
+			os.Remove(u.path)
+		}
+		u.mu.Unlock()
+	}
+}
+
+// expireLoop periodically discards uploads idle for longer than the TTL.
+// Uploads busy receiving a chunk are not idle and are skipped.
+func (h *ChunkedUploadHandler) expireLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.mu.Lock()
+		uploads := make([]*chunkedUpload, 0, len(h.uploads))
+		for _, u := range h.uploads {
+			uploads = append(uploads, u)
+		}
+		h.mu.Unlock()
+
+		cutoff := time.Now().Add(-h.ttl)
+		for _, u := range uploads {
+			if !u.mu.TryLock() {
+				continue
+			}
+			if !u.closed && u.updatedAt.Before(cutoff) {
+				fmt.Printf("[ChunkedUpload] [session=%s] Discarding abandoned upload %s\n", u.sessionID, u.id)
+				h.discard(u)
+				os.Remove(u.path)
+			}
+			u.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/collection"
+	"github.com/grpc-bridge/server/internal/secret"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// CollectionHandler exposes CRUD endpoints for saved request collections
+type CollectionHandler struct {
+	sessionManager *session.Manager
+	store          collection.Store
+	admins         map[string]bool // Users who may change any global collection
+}
+
+// NewCollectionHandler creates a new collection handler. admins may change
+// every global collection; other users only those they created.
+func NewCollectionHandler(sm *session.Manager, store collection.Store, admins []string) *CollectionHandler {
+	allowed := make(map[string]bool, len(admins))
+	for _, user := range admins {
+		allowed[user] = true
+	}
+	return &CollectionHandler{
+		sessionManager: sm,
+		store:          store,
+		admins:         allowed,
+	}
+}
+
+// CollectionRequest represents the body for creating or updating a collection
+type CollectionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Scope       string `json:"scope"` // "session" (default) or "global"; ignored on update
+}
+
+// SavedRequestBody represents the body for creating or updating a saved request
+type SavedRequestBody struct {
+	Name      string            `json:"name" binding:"required"`
+	Folder    string            `json:"folder"`
+	Target    string            `json:"target" binding:"omitempty,grpc_target"`
+	Service   string            `json:"service" binding:"required"`
+	Method    string            `json:"method" binding:"required"`
+	Data      interface{}       `json:"data"`
+	Metadata  map[string]string `json:"metadata"`
+	Plaintext bool              `json:"plaintext"`
+}
+
+// ListCollections returns global collections plus those of the calling session
+func (h *CollectionHandler) ListCollections(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+
+	collections, err := h.store.List(sessionID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to list collections: "+err.Error())
+		return
+	}
+
+	redacted := make([]*collection.Collection, len(collections))
+	for i, col := range collections {
+		redacted[i] = redactCollection(col)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collections": redacted,
+		"count":       len(collections),
+	})
+}
+
+// CreateCollection creates a new collection
+func (h *CollectionHandler) CreateCollection(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+
+	var req CollectionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = collection.ScopeSession
+	}
+	if scope != collection.ScopeSession && scope != collection.ScopeGlobal {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "scope must be \"session\" or \"global\"")
+		return
+	}
+
+	col := &collection.Collection{
+		Name:        req.Name,
+		Description: req.Description,
+		Scope:       scope,
+	}
+	if scope == collection.ScopeGlobal {
+		col.Owner = auth.User(c)
+	} else {
+		if sessionID == "" {
+			apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
+			return
+		}
+		if _, exists := h.sessionManager.Get(sessionID); !exists {
+			apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+			return
+		}
+		col.SessionID = sessionID
+	}
+
+	created, err := h.store.Create(col)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to create collection: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"collection": redactCollection(created),
+		"folders":    created.Folders(),
+	})
+}
+
+// GetCollection returns a collection with its saved requests and folders
+func (h *CollectionHandler) GetCollection(c *gin.Context) {
+	col, ok := h.loadCollection(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collection": redactCollection(col),
+		"folders":    col.Folders(),
+	})
+}
+
+// UpdateCollection renames a collection or changes its description
+func (h *CollectionHandler) UpdateCollection(c *gin.Context) {
+	col, ok := h.loadWritableCollection(c)
+	if !ok {
+		return
+	}
+
+	var req CollectionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	updated, err := h.store.Update(col.ID, req.Name, req.Description)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collection": redactCollection(updated),
+		"folders":    updated.Folders(),
+	})
+}
+
+// DeleteCollection removes a collection and all its saved requests
+func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
+	col, ok := h.loadWritableCollection(c)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Delete(col.ID); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "collection deleted",
+	})
+}
+
+// AddRequest saves a new request into a collection
+func (h *CollectionHandler) AddRequest(c *gin.Context) {
+	col, ok := h.loadWritableCollection(c)
+	if !ok {
+		return
+	}
+
+	var body SavedRequestBody
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	request := body.toSavedRequest("")
+	restoreRedacted(request.Metadata, nil)
+	saved, err := h.store.AddRequest(col.ID, request)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"request": redactRequest(*saved),
+	})
+}
+
+// UpdateRequest replaces a saved request
+func (h *CollectionHandler) UpdateRequest(c *gin.Context) {
+	col, ok := h.loadWritableCollection(c)
+	if !ok {
+		return
+	}
+
+	var body SavedRequestBody
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	// A client echoing back a redacted value keeps the stored secret
+	request := body.toSavedRequest(c.Param("requestId"))
+	var current map[string]string
+	for _, r := range col.Requests {
+		if r.ID == request.ID {
+			current = r.Metadata
+		}
+	}
+	restoreRedacted(request.Metadata, current)
+	saved, err := h.store.UpdateRequest(col.ID, request)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"request": redactRequest(*saved),
+	})
+}
+
+// DeleteRequest removes a saved request from a collection
+func (h *CollectionHandler) DeleteRequest(c *gin.Context) {
+	col, ok := h.loadWritableCollection(c)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteRequest(col.ID, c.Param("requestId")); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "saved request deleted",
+	})
+}
+
+// loadCollection fetches the collection from the path and checks session visibility.
+// It writes the error response itself and returns false when the request should stop.
+func (h *CollectionHandler) loadCollection(c *gin.Context) (*collection.Collection, bool) {
+	col, err := h.store.Get(c.Param("collectionId"))
+	if err != nil || !col.VisibleTo(c.GetHeader("X-Session-ID")) {
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound, collection.ErrCollectionNotFound.Error())
+		return nil, false
+	}
+	return col, true
+}
+
+// loadWritableCollection is loadCollection for requests that change the
+// collection. With authentication on, a global collection may only be changed
+// by the user who created it or an admin.
+func (h *CollectionHandler) loadWritableCollection(c *gin.Context) (*collection.Collection, bool) {
+	col, ok := h.loadCollection(c)
+	if !ok {
+		return nil, false
+	}
+	if col.Scope == collection.ScopeGlobal && auth.Authenticated(c) {
+		user := auth.User(c)
+		if !h.admins[user] && (user == "" || col.Owner != user) {
+			apierror.Respond(c, http.StatusForbidden, apierror.PermissionDenied, "only the creator of a global collection or an admin can change it")
+			return nil, false
+		}
+	}
+	return col, true
+}
+
+// redactCollection returns a copy of a collection with the sensitive metadata
+// of its saved requests redacted
+func redactCollection(col *collection.Collection) *collection.Collection {
+	out := *col
+	out.Requests = make([]collection.SavedRequest, len(col.Requests))
+	for i, r := range col.Requests {
+		out.Requests[i] = redactRequest(r)
+	}
+	return &out
+}
+
+// redactRequest returns a saved request with its sensitive metadata redacted
+func redactRequest(r collection.SavedRequest) collection.SavedRequest {
+	r.Metadata = secret.RedactMap(r.Metadata)
+	return r
+}
+
+// toSavedRequest converts the request body into a stored request
+func (b SavedRequestBody) toSavedRequest(id string) collection.SavedRequest {
+	return collection.SavedRequest{
+		ID:        id,
+		Name:      b.Name,
+		Folder:    strings.Trim(strings.ReplaceAll(b.Folder, "\\", "/"), "/"),
+		Target:    b.Target,
+		Service:   b.Service,
+		Method:    b.Method,
+		Data:      b.Data,
+		Metadata:  b.Metadata,
+		Plaintext: b.Plaintext,
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/proto"
+)
+
+// CommonProtosHandler exposes admin endpoints for shared proto libraries
+// (company-wide common protos) that are merged into sessions like the stdlib
+type CommonProtosHandler struct {
+	stdlibManager *proto.StdlibManager
+	roots         []string // Directories runtime registrations must be inside
+}
+
+// NewCommonProtosHandler creates a new shared proto library handler
+func NewCommonProtosHandler(stdlib *proto.StdlibManager) *CommonProtosHandler {
+	return &CommonProtosHandler{
+		stdlibManager: stdlib,
+	}
+}
+
+// SetAllowedRoots sets the directories that libraries registered through the
+// API must be inside. With none, registering through the API is refused.
+func (h *CommonProtosHandler) SetAllowedRoots(roots []string) {
+	h.roots = nil
+	for _, root := range roots {
+		if resolved, err := resolveDir(root); err == nil {
+			h.roots = append(h.roots, resolved)
+		}
+	}
+}
+
+// RegisterCommonProtosRequest registers a directory on the server as a shared library
+type RegisterCommonProtosRequest struct {
+	Name string `json:"name"` // Defaults to the directory name
+	Path string `json:"path" binding:"required"`
+}
+
+// ListLibraries returns the registered shared libraries
+func (h *CommonProtosHandler) ListLibraries(c *gin.Context) {
+	libs := h.stdlibManager.SharedLibraries()
+	c.JSON(http.StatusOK, gin.H{
+		"libraries": libs,
+		"count":     len(libs),
+	})
+}
+
+// RegisterLibrary registers (or rescans) a shared proto library
+func (h *CommonProtosHandler) RegisterLibrary(c *gin.Context) {
+	var req RegisterCommonProtosRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if len(h.roots) == 0 {
+		apierror.Respond(c, http.StatusForbidden, apierror.PermissionDenied, "registering shared libraries is disabled; set COMMON_PROTO_ROOTS")
+		return
+	}
+	dir, err := resolveDir(req.Path)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid path: "+req.Path)
+		return
+	}
+	if !h.allowed(dir) {
+		apierror.Respond(c, http.StatusForbidden, apierror.PermissionDenied, "path is outside the allowed shared library directories")
+		return
+	}
+
+	lib, err := h.stdlibManager.AddSharedLibrary(req.Name, dir)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"library": lib,
+	})
+}
+
+// DeleteLibrary unregisters a shared proto library
+func (h *CommonProtosHandler) DeleteLibrary(c *gin.Context) {
+	name := c.Param("name")
+
+	if !h.stdlibManager.RemoveSharedLibrary(name) {
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound, "shared library not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "shared library removed",
+	})
+}
+
+// allowed reports whether a resolved directory is one of the roots or inside
+// one
+func (h *CommonProtosHandler) allowed(dir string) bool {
+	for _, root := range h.roots {
+		if within(root, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path is root or below it. Both must be resolved
+// (see resolveDir) for the answer to hold on disk.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// resolveDir returns the absolute path of dir with symlinks resolved, so that
+// a link inside a root cannot point outside it
+func resolveDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/grpc-bridge/server/internal/websocket"
+)
+
+// HTTPConns counts the server's HTTP connections. Install Track as the
+// http.Server ConnState hook.
+type HTTPConns struct {
+	open     atomic.Int64
+	hijacked atomic.Int64
+}
+
+// Track updates the counts on every connection state change
+func (h *HTTPConns) Track(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		h.open.Add(1)
+	case http.StateHijacked:
+		// Taken over by a WebSocket; the hub counts it from here
+		h.open.Add(-1)
+		h.hijacked.Add(1)
+	case http.StateClosed:
+		h.open.Add(-1)
+	}
+}
+
+// DebugHandler serves pprof profiles and runtime statistics for diagnosing
+// leaked goroutines, calls and streams
+type DebugHandler struct {
+	sessionManager *session.Manager
+	grpcHandler    *GRPCHandler
+	hub            *websocket.Hub
+	conns          *HTTPConns
+	started        time.Time
+}
+
+// NewDebugHandler creates a new debug handler
+func NewDebugHandler(sm *session.Manager, gh *GRPCHandler, hub *websocket.Hub, conns *HTTPConns) *DebugHandler {
+	return &DebugHandler{
+		sessionManager: sm,
+		grpcHandler:    gh,
+		hub:            hub,
+		conns:          conns,
+		started:        time.Now(),
+	}
+}
+
+// MemoryStats is the part of runtime.MemStats worth watching for leaks
+type MemoryStats struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`     // Bytes of live and not yet collected heap objects
+	HeapInuse    uint64 `json:"heap_inuse"`     // Bytes in in-use heap spans
+	HeapObjects  uint64 `json:"heap_objects"`   // Allocated heap objects
+	Sys          uint64 `json:"sys"`            // Bytes obtained from the OS
+	NumGC        uint32 `json:"num_gc"`         // Completed GC cycles
+	PauseTotalNs uint64 `json:"pause_total_ns"` // Cumulative GC pause time
+}
+
+// RuntimeStats is the response of GetRuntime
+type RuntimeStats struct {
+	UptimeSeconds int64              `json:"uptime_seconds"`
+	GoVersion     string             `json:"go_version"`
+	Goroutines    int                `json:"goroutines"`
+	GOMAXPROCS    int                `json:"gomaxprocs"`
+	Memory        MemoryStats        `json:"memory"`
+	HTTP          HTTPConnStats      `json:"http"`
+	Hub           websocket.HubStats `json:"hub"`
+	Calls         CallStats          `json:"calls"`
+	Targets       grpc.ConnStats     `json:"targets"`
+	Sessions      SessionStats       `json:"sessions"`
+}
+
+// HTTPConnStats counts HTTP connections to the server
+type HTTPConnStats struct {
+	Open     int64 `json:"open"`     // Connections currently served over HTTP
+	Hijacked int64 `json:"hijacked"` // Connections handed to WebSockets since startup
+}
+
+// CallStats counts calls that have not finished, and the call pool's
+// workers and queue
+type CallStats struct {
+	Active    int           `json:"active"`
+	Streaming int           `json:"streaming"`
+	Pool      CallPoolStats `json:"pool"`
+}
+
+// SessionStats counts sessions and their in-flight operations
+type SessionStats struct {
+	Count      int `json:"count"`
+	Operations int `json:"operations"`
+}
+
+// GetRuntime returns goroutine, memory, connection, hub and call counts
+func (h *DebugHandler) GetRuntime(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	calls, streaming := h.grpcHandler.ActiveCalls()
+	sessions, operations := h.sessionManager.Counts()
+	c.JSON(http.StatusOK, RuntimeStats{
+		UptimeSeconds: int64(time.Since(h.started).Seconds()),
+		GoVersion:     runtime.Version(),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Memory: MemoryStats{
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			HeapObjects:  mem.HeapObjects,
+			Sys:          mem.Sys,
+			NumGC:        mem.NumGC,
+			PauseTotalNs: mem.PauseTotalNs,
+		},
+		HTTP: HTTPConnStats{
+			Open:     h.conns.open.Load(),
+			Hijacked: h.conns.hijacked.Load(),
+		},
+		Hub:      h.hub.Stats(),
+		Calls:    CallStats{Active: calls, Streaming: streaming, Pool: h.grpcHandler.CallPoolStats()},
+		Targets:  grpc.Stats(),
+		Sessions: SessionStats{Count: sessions, Operations: operations},
+	})
+}
+
+// Pprof serves net/http/pprof under /debug/pprof/ (the index, named profiles
+// such as goroutine and heap, and the cmdline, profile, symbol and trace handlers)
+func (h *DebugHandler) Pprof(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("name"), "/") {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		// The index also serves named profiles by their path
+		pprof.Index(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// descriptorSetDir is the session subdirectory holding compiled descriptor sets
+const descriptorSetDir = ".descriptors"
+
+// UploadProtoset attaches one or more compiled FileDescriptorSets (protoc
+// --descriptor_set_out --include_imports, grpcurl -protoset) to a session.
+// Unlike source uploads, descriptor sets are added next to the existing proto files.
+func (h *ProtoHandler) UploadProtoset(c *gin.Context) {
+	var req UploadStructureRequest
+	if err := c.ShouldBind(&req); err != nil {
+		if bodyTooLarge(c, err) {
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session_id is required")
+		return
+	}
+
+	if _, exists := h.sessionManager.Get(req.SessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		multipartFailure(c, err)
+		return
+	}
+	files := form.File["protoset"]
+	if len(files) == 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.NoProtoFiles, "no descriptor set uploaded")
+		return
+	}
+
+	stored := []session.DescriptorSet{}
+	errors := gin.H{}
+	var quotaErr *session.QuotaError
+	for _, fh := range files {
+		name := filepath.Base(strings.ReplaceAll(fh.Filename, "\\", "/"))
+		if fh.Size > proto.MaxDescriptorSetSize {
+			errors[name] = fmt.Sprintf("exceeds the maximum size of %d bytes", proto.MaxDescriptorSetSize)
+			continue
+		}
+		src, err := fh.Open()
+		if err != nil {
+			errors[name] = "failed to open upload"
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(src, proto.MaxDescriptorSetSize+1))
+		src.Close()
+		if err != nil {
+			errors[name] = "failed to read upload"
+			continue
+		}
+
+		set, err := h.storeDescriptorSet(req.SessionID, name, data, "upload")
+		if err != nil {
+			if qe, ok := asQuotaError(err); ok {
+				quotaErr = qe
+			}
+			errors[name] = err.Error()
+			continue
+		}
+		stored = append(stored, *set)
+	}
+
+	if len(stored) > 0 {
+		h.hub.EmitToSession(req.SessionID, DescriptorSetAdded{
+			SessionID:      req.SessionID,
+			DescriptorSets: stored,
+		})
+	}
+
+	status := http.StatusOK
+	if len(stored) == 0 {
+		status = http.StatusBadRequest
+	}
+	response := gin.H{
+		"descriptor_sets": stored,
+		"uploaded_count":  len(stored),
+	}
+	if len(errors) > 0 {
+		response["errors"] = errors
+	}
+	if quotaErr != nil {
+		for k, v := range quotaExceeded(c, quotaErr) {
+			response[k] = v
+		}
+		status = http.StatusRequestEntityTooLarge
+	}
+	c.JSON(status, response)
+}
+
+// storeDescriptorSet validates a serialized FileDescriptorSet, writes it under the
+// session's descriptor directory and registers it with the session
+func (h *ProtoHandler) storeDescriptorSet(sessionID, name string, data []byte, source string) (*session.DescriptorSet, error) {
+	if int64(len(data)) > proto.MaxDescriptorSetSize {
+		return nil, fmt.Errorf("exceeds the maximum size of %d bytes", proto.MaxDescriptorSetSize)
+	}
+	set, _, err := proto.ParseDescriptorSet(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" || name == "." || name == "/" {
+		name = "descriptors.protoset"
+	}
+	if err := h.sessionManager.CheckDescriptorSet(sessionID, name, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	sessionDir := filepath.Join(h.uploadDir, sessionID)
+	dir := filepath.Join(sessionDir, descriptorSetDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create descriptor directory")
+	}
+	if sess, ok := h.sessionManager.Get(sessionID); ok && sess.RootPath == "" {
+		if err := h.sessionManager.SetRootPath(sessionID, sessionDir); err != nil {
+			return nil, err
+		}
+	}
+
+	absPath := filepath.Join(dir, name)
+	if err := os.WriteFile(absPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to store descriptor set")
+	}
+
+	fileNames := make([]string, 0, len(set.GetFile()))
+	for _, fd := range set.GetFile() {
+		fileNames = append(fileNames, fd.GetName())
+	}
+	sort.Strings(fileNames)
+
+	stored := session.DescriptorSet{
+		Name:         name,
+		AbsolutePath: absPath,
+		Size:         int64(len(data)),
+		Files:        fileNames,
+		Source:       source,
+	}
+	if err := h.sessionManager.AddDescriptorSet(sessionID, stored); err != nil {
+		return nil, err
+	}
+	fmt.Printf("[ProtoHandler] Stored descriptor set: %s (%d files)\n", absPath, len(fileNames))
+	return &stored, nil
+}
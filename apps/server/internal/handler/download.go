@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/proto"
+)
+
+// DownloadSession streams a zip archive of the session's uploaded proto files
+// with their relative paths preserved. With stdlib=true, the standard library
+// files (copied into the session directory or from the shared stdlib tree)
+// are included as well.
+func (h *ProtoHandler) DownloadSession(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	if len(sess.ProtoFiles) == 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.NoProtoFiles, "no files uploaded for this session")
+		return
+	}
+
+	// entry name -> absolute path on disk
+	entries := make(map[string]string, len(sess.ProtoFiles))
+	order := make([]string, 0, len(sess.ProtoFiles))
+	for _, pf := range sess.ProtoFiles {
+		name := filepath.ToSlash(pf.RelativePath)
+		if _, dup := entries[name]; dup {
+			continue
+		}
+		entries[name] = pf.AbsolutePath
+		order = append(order, name)
+	}
+
+	if c.Query("stdlib") == "true" && sess.RootPath != "" {
+		stdlibFiles, err := h.stdlibManager.ListAvailableFiles()
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, fmt.Sprintf("failed to list stdlib files: %v", err))
+			return
+		}
+		for _, name := range stdlibFiles {
+			if _, uploaded := entries[name]; uploaded {
+				continue
+			}
+			path := filepath.Join(sess.RootPath, filepath.FromSlash(name))
+			if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+				// Not copied into the session; served from the shared stdlib tree
+				if path = proto.StdlibFile(name); path == "" {
+					continue
+				}
+			}
+			entries[name] = path
+			order = append(order, name)
+		}
+	}
+
+	shortID := sessionID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="session-%s-protos.zip"`, shortID))
+	c.Status(http.StatusOK)
+
+	// Headers are already sent, so failures past this point can only be logged
+	zw := zip.NewWriter(c.Writer)
+	for _, name := range order {
+		if err := addZipEntry(zw, name, entries[name]); err != nil {
+			fmt.Printf("[DownloadSession] Skipping %s: %v\n", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		fmt.Printf("[DownloadSession] Failed to finish archive for session %s: %v\n", sessionID, err)
+	}
+}
+
+// DownloadFile sends a single uploaded file (?file=<relative path>) as an
+// attachment, for files too large to show through the file-content endpoint
+func (h *ProtoHandler) DownloadFile(c *gin.Context) {
+	relativePath := c.Query("file")
+	if relativePath == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "file parameter is required")
+		return
+	}
+
+	sess, exists := h.sessionManager.Get(c.Param("sessionId"))
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	for _, pf := range sess.ProtoFiles {
+		if pf.RelativePath == relativePath {
+			c.FileAttachment(pf.AbsolutePath, filepath.Base(pf.RelativePath))
+			return
+		}
+	}
+	apierror.Respond(c, http.StatusNotFound, apierror.NotFound, fmt.Sprintf("file not found: %s", relativePath))
+}
+
+// addZipEntry copies a file from disk into the archive under name
+func addZipEntry(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
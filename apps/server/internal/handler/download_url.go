@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// Downloadable resources of a session
+const (
+	DownloadResourceProtos = "protos" // Zip of the uploaded proto files (/download)
+	DownloadResourceBundle = "bundle" // Session bundle (/bundle)
+	DownloadResourceFile   = "file"   // A single uploaded file (/file-download)
+)
+
+// DownloadURLHandler hands out signed, expiring URLs for session downloads
+type DownloadURLHandler struct {
+	sessionManager *session.Manager
+	signer         *auth.URLSigner
+}
+
+// NewDownloadURLHandler creates a new download URL handler
+func NewDownloadURLHandler(sm *session.Manager, signer *auth.URLSigner) *DownloadURLHandler {
+	return &DownloadURLHandler{
+		sessionManager: sm,
+		signer:         signer,
+	}
+}
+
+// DownloadURLRequest selects the resource to sign
+type DownloadURLRequest struct {
+	Resource  string `json:"resource" binding:"required"` // DownloadResourceProtos, DownloadResourceBundle or DownloadResourceFile
+	File      string `json:"file"`                        // Relative path, for DownloadResourceFile
+	Stdlib    bool   `json:"stdlib"`                      // Include standard library files, for DownloadResourceProtos
+	ExpiresIn int    `json:"expires_in"`                  // Lifetime in seconds, capped at DOWNLOAD_URL_TTL
+}
+
+// CreateDownloadURL signs a URL that downloads a session resource with a
+// plain GET, without credentials or the session header, until it expires
+func (h *DownloadURLHandler) CreateDownloadURL(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	var req DownloadURLRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.ExpiresIn < 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "expires_in must not be negative")
+		return
+	}
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	base := "/api/sessions/" + sessionID
+	query := url.Values{}
+	var path string
+	switch req.Resource {
+	case DownloadResourceProtos:
+		path = base + "/download"
+		if req.Stdlib {
+			query.Set("stdlib", "true")
+		}
+	case DownloadResourceBundle:
+		path = base + "/bundle"
+	case DownloadResourceFile:
+		if !sessionHasFile(sess, req.File) {
+			apierror.Respond(c, http.StatusNotFound, apierror.NotFound, fmt.Sprintf("file not found: %s", req.File))
+			return
+		}
+		path = base + "/file-download"
+		query.Set("file", req.File)
+	default:
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("resource must be %q, %q or %q", DownloadResourceProtos, DownloadResourceBundle, DownloadResourceFile))
+		return
+	}
+
+	signed, expires := h.signer.Sign(path, query, auth.User(c), time.Duration(req.ExpiresIn)*time.Second)
+	c.JSON(http.StatusOK, gin.H{
+		"url":        signed,
+		"expires_at": expires,
+	})
+}
+
+// sessionHasFile reports whether rel is one of the session's uploaded files
+func sessionHasFile(sess *session.Session, rel string) bool {
+	for _, pf := range sess.ProtoFiles {
+		if pf.RelativePath == rel {
+			return true
+		}
+	}
+	return false
+}
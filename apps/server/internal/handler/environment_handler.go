@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// maxEnvironmentNameLength bounds environment names
+const maxEnvironmentNameLength = 64
+
+// EnvironmentHandler exposes CRUD endpoints for session environments
+type EnvironmentHandler struct {
+	sessionManager *session.Manager
+}
+
+// NewEnvironmentHandler creates a new environment handler
+func NewEnvironmentHandler(sm *session.Manager) *EnvironmentHandler {
+	return &EnvironmentHandler{
+		sessionManager: sm,
+	}
+}
+
+// PutEnvironmentRequest represents the body for creating or replacing an environment
+type PutEnvironmentRequest struct {
+	Target    string            `json:"target" binding:"omitempty,grpc_target"`
+	Metadata  map[string]string `json:"metadata"`
+	Variables map[string]string `json:"variables"`
+}
+
+// ListEnvironments returns all environments of a session
+func (h *EnvironmentHandler) ListEnvironments(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	envs, err := h.sessionManager.ListEnvironments(sessionID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":   sessionID,
+		"environments": session.RedactEnvironments(envs),
+	})
+}
+
+// GetEnvironment returns a single environment
+func (h *EnvironmentHandler) GetEnvironment(c *gin.Context) {
+	env, err := h.sessionManager.GetEnvironment(c.Param("sessionId"), c.Param("name"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"environment": env.Redacted(),
+	})
+}
+
+// PutEnvironment creates or replaces an environment
+func (h *EnvironmentHandler) PutEnvironment(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	name := c.Param("name")
+
+	var req PutEnvironmentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	env, err := normalizeEnvironment(session.Environment{
+		Name:      name,
+		Target:    req.Target,
+		Metadata:  req.Metadata,
+		Variables: req.Variables,
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	created, err := h.sessionManager.PutEnvironment(sessionID, env)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"environment": env.Redacted(),
+		"created":     created,
+	})
+}
+
+// DeleteEnvironment removes an environment
+func (h *EnvironmentHandler) DeleteEnvironment(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	name := c.Param("name")
+
+	if err := h.sessionManager.DeleteEnvironment(sessionID, name); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "environment deleted",
+		"name":    name,
+	})
+}
+
+// normalizeEnvironment validates an environment and fills in empty maps
+func normalizeEnvironment(env session.Environment) (session.Environment, error) {
+	if !isValidVariableName(env.Name) || len(env.Name) > maxEnvironmentNameLength {
+		return env, fmt.Errorf("invalid environment name %q: use up to %d letters, digits, '_', '-' or '.'", env.Name, maxEnvironmentNameLength)
+	}
+	env.Target = strings.TrimSpace(env.Target)
+
+	if env.Metadata == nil {
+		env.Metadata = map[string]string{}
+	}
+	for key := range env.Metadata {
+		if strings.TrimSpace(key) == "" {
+			return env, errors.New("metadata keys must not be empty")
+		}
+	}
+
+	if env.Variables == nil {
+		env.Variables = map[string]string{}
+	}
+	for key := range env.Variables {
+		if !isValidVariableName(key) {
+			return env, fmt.Errorf("invalid variable name %q", key)
+		}
+	}
+	return env, nil
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// notFoundCode is the code for a lookup that failed with err: a missing
+// session has its own code, anything else inside it is NOT_FOUND
+func notFoundCode(err error) apierror.Code {
+	if errors.Is(err, session.ErrSessionNotFound) {
+		return apierror.SessionNotFound
+	}
+	return apierror.NotFound
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// fileETag returns the strong ETag of a session file: its SHA-256, quoted.
+// The hash recorded at upload is used when there is one, so a revalidation
+// does not read the file; otherwise the content is read and hashed, and
+// returned for reuse.
+func fileETag(pf *session.ProtoFile) (string, []byte, error) {
+	if pf.SHA256 != "" {
+		return `"` + pf.SHA256 + `"`, nil, nil
+	}
+	content, err := os.ReadFile(pf.AbsolutePath)
+	if err != nil {
+		return "", nil, err
+	}
+	return `"` + session.ContentHash(content) + `"`, content, nil
+}
+
+// notModified sets the ETag of the response and answers 304 when the
+// request's If-None-Match already names it. Tags are compared weakly, as
+// RFC 9110 requires for If-None-Match.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/callstats"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/loadtest"
+	"github.com/grpc-bridge/server/internal/script"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// Session events sent through the WebSocket hub. Each type is the payload of
+// one event (or of a family of related events, see EventName) and its JSON
+// form is part of the event schema; see websocket.SchemaVersion. Final call
+// outcomes are critical events (RequiresAck) that acknowledging clients confirm.
+
+// CallStart announces a call (grpc://call_start)
+type CallStart struct {
+	CallID    string `json:"call_id"`
+	Target    string `json:"target"`
+	Service   string `json:"service"`
+	Method    string `json:"method"`
+	Streaming bool   `json:"streaming,omitempty"`
+}
+
+func (CallStart) EventName() string { return "grpc://call_start" }
+
+// CallQueued reports the place of a unary call waiting for a worker of the
+// call pool (grpc://queued). It is sent when the call is queued and whenever
+// it moves up, and is not buffered for replay: grpc://call_start follows when
+// the call starts.
+type CallQueued struct {
+	CallID   string `json:"call_id"`
+	Position int    `json:"position"` // 1 for the next call to start
+}
+
+func (CallQueued) EventName() string { return "grpc://queued" }
+
+// CallResponse is the result of a successful unary call (grpc://response).
+// Responses larger than the preview size are sent with Raw and Parsed null and
+// a Preview instead.
+type CallResponse struct {
+	CallID      string                    `json:"call_id"`
+	Raw         any                       `json:"raw"`
+	Parsed      any                       `json:"parsed"`
+	Preview     *ResponsePreview          `json:"preview,omitempty"`
+	Headers     map[string][]string       `json:"headers"`
+	Trailers    map[string][]string       `json:"trailers"`
+	TookMs      int64                     `json:"took_ms"`
+	Wire        *grpc.WireCapture         `json:"wire,omitempty"`
+	Metrics     *grpc.CallMetrics         `json:"metrics,omitempty"`
+	Scripts     map[string]*script.Result `json:"scripts,omitempty"`
+	ScriptError error                     `json:"script_error,omitempty"` // Post-response script failure
+}
+
+func (*CallResponse) EventName() string { return "grpc://response" }
+func (*CallResponse) RequiresAck() bool { return true }
+
+// CallError is the outcome of a failed call (grpc://error)
+type CallError struct {
+	CallID      string                    `json:"call_id"`
+	Code        apierror.Code             `json:"code"` // See errorKindCode
+	Error       string                    `json:"error"`
+	TookMs      int64                     `json:"took_ms"`
+	Kind        string                    `json:"kind"`               // See classifyGRPCErrorKind; "script_error" for pre-request scripts, "cancelled" for calls cancelled while queued
+	Messages    int                       `json:"messages,omitempty"` // Streaming calls: responses received before the failure
+	Wire        *grpc.WireCapture         `json:"wire,omitempty"`     // capture_wire: bytes exchanged before the failure
+	Scripts     map[string]*script.Result `json:"scripts,omitempty"`
+	ScriptError error                     `json:"script_error,omitempty"`
+}
+
+func (*CallError) EventName() string { return "grpc://error" }
+func (*CallError) RequiresAck() bool { return true }
+
+// ResponsePreview stands in for a response too large to be sent in an event:
+// the start of its JSON and the ID under which the full JSON can be fetched
+// from GET /api/sessions/:sessionId/responses/:responseId
+type ResponsePreview struct {
+	ResponseID string `json:"response_id"`
+	Size       int    `json:"size"`    // Bytes of the full JSON
+	Content    string `json:"content"` // Its first bytes, up to the preview size
+}
+
+// StreamMessage is one response of a streaming call (grpc://stream_message).
+// Messages larger than the preview size are sent with Message null and a
+// Preview instead.
+type StreamMessage struct {
+	CallID  string           `json:"call_id"`
+	Index   int              `json:"index"`
+	Message any              `json:"message"`
+	Preview *ResponsePreview `json:"preview,omitempty"`
+}
+
+func (StreamMessage) EventName() string { return "grpc://stream_message" }
+
+// StreamEnd reports a streaming call that finished successfully (grpc://stream_end)
+type StreamEnd struct {
+	CallID   string              `json:"call_id"`
+	Messages int                 `json:"messages"`
+	Headers  map[string][]string `json:"headers"`
+	Trailers map[string][]string `json:"trailers"`
+	TookMs   int64               `json:"took_ms"`
+}
+
+func (StreamEnd) EventName() string { return "grpc://stream_end" }
+func (StreamEnd) RequiresAck() bool { return true }
+
+// StatsUpdate carries the session's rolling per-endpoint call statistics
+// (stats://update). It is sent when they change and is not buffered for replay.
+type StatsUpdate struct {
+	callstats.Snapshot
+}
+
+func (StatsUpdate) EventName() string { return "stats://update" }
+
+// ScriptError reports a failed pre-request or post-response script (script://error)
+type ScriptError struct {
+	CallID  string        `json:"call_id"`
+	Code    apierror.Code `json:"code"` // Always SCRIPT_FAILED
+	Message string        `json:"message"`
+	Phase   string        `json:"phase,omitempty"`
+	Line    int           `json:"line,omitempty"`
+}
+
+func (ScriptError) EventName() string { return "script://error" }
+
+// ChainStart announces a call chain (grpc://chain_start)
+type ChainStart struct {
+	ChainID string `json:"chain_id"`
+	Total   int    `json:"total"`
+}
+
+func (ChainStart) EventName() string { return "grpc://chain_start" }
+
+// ChainStepStart announces a chain step (grpc://chain_step_start)
+type ChainStepStart struct {
+	ChainID string `json:"chain_id"`
+	Index   int    `json:"index"`
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+func (ChainStepStart) EventName() string { return "grpc://chain_step_start" }
+
+// ChainStepDone reports a chain step's outcome (grpc://chain_step_done)
+type ChainStepDone struct {
+	ChainID string `json:"chain_id"`
+	Index   int    `json:"index"`
+	Ok      bool   `json:"ok"`
+	Payload any    `json:"payload"` // *CallResponse, *CallError or a template error
+}
+
+func (ChainStepDone) EventName() string { return "grpc://chain_step_done" }
+
+// ChainDone reports the end of a call chain (grpc://chain_done)
+type ChainDone struct {
+	ChainID string `json:"chain_id"`
+	Ok      bool   `json:"ok"`
+}
+
+func (ChainDone) EventName() string { return "grpc://chain_done" }
+func (ChainDone) RequiresAck() bool { return true }
+
+// LoadTestStart announces a load test (loadtest://start)
+type LoadTestStart struct {
+	TestID      string  `json:"test_id"`
+	Service     string  `json:"service"`
+	Method      string  `json:"method"`
+	Total       int     `json:"total"`
+	Concurrency int     `json:"concurrency"`
+	RPS         float64 `json:"rps"`
+	DurationMs  int64   `json:"duration_ms"`
+}
+
+func (LoadTestStart) EventName() string { return "loadtest://start" }
+
+// LoadTestProgress reports load test progress (loadtest://progress)
+type LoadTestProgress struct {
+	TestID   string            `json:"test_id"`
+	Progress loadtest.Progress `json:"progress"`
+}
+
+func (LoadTestProgress) EventName() string { return "loadtest://progress" }
+
+// LoadTestDone carries the load test summary (loadtest://done)
+type LoadTestDone struct {
+	TestID  string            `json:"test_id"`
+	Summary *loadtest.Summary `json:"summary"`
+}
+
+func (LoadTestDone) EventName() string { return "loadtest://done" }
+func (LoadTestDone) RequiresAck() bool { return true }
+
+// UploadStart announces an upload (proto://upload_start). The source fields
+// that are set tell what is being imported.
+type UploadStart struct {
+	SessionID string `json:"session_id"`
+	Archive   string `json:"archive,omitempty"`
+	Bundle    string `json:"bundle,omitempty"`
+	GitURL    string `json:"git_url,omitempty"`
+	Ref       string `json:"ref,omitempty"`
+}
+
+func (UploadStart) EventName() string { return "proto://upload_start" }
+
+// UploadError reports a failed upload (proto://upload_error)
+type UploadError struct {
+	Code  apierror.Code `json:"code"`
+	Error string        `json:"error"`
+}
+
+func (UploadError) EventName() string { return "proto://upload_error" }
+
+// UploadFile describes an uploaded file without its absolute path
+type UploadFile struct {
+	Name         string `json:"name"`
+	RelativePath string `json:"relative_path"`
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256"`
+}
+
+// UploadSource describes where an upload came from; only the fields of its
+// source (files, archive, git or bundle) are set
+type UploadSource struct {
+	StrippedPrefix string `json:"stripped_prefix,omitempty"`
+	ClientStripped bool   `json:"client_stripped,omitempty"`
+
+	Archive      string `json:"archive,omitempty"`
+	Format       string `json:"format,omitempty"`
+	SkippedCount int    `json:"skipped_count,omitempty"`
+
+	GitURL       string `json:"git_url,omitempty"`
+	Ref          string `json:"ref,omitempty"`
+	Subdirectory string `json:"subdirectory,omitempty"`
+
+	Bundle              string     `json:"bundle,omitempty"`
+	DescriptorSetCount  int        `json:"descriptor_set_count,omitempty"`
+	CollectionsImported int        `json:"collections_imported,omitempty"`
+	VariablesImported   int        `json:"variables_imported,omitempty"`
+	BundleExportedAt    *time.Time `json:"bundle_exported_at,omitempty"`
+	Warnings            []string   `json:"warnings,omitempty"`
+}
+
+// UploadDone reports a completed upload (proto://upload_done)
+type UploadDone struct {
+	SessionID     string       `json:"session_id"`
+	UploadedCount int          `json:"uploaded_count"`
+	ErrorCount    int          `json:"error_count"`
+	Files         []UploadFile `json:"files"`
+	Directories   []string     `json:"directories"`
+	Normalized    bool         `json:"normalized"`
+	Mode          string       `json:"mode"`
+	UploadSource
+}
+
+func (UploadDone) EventName() string { return "proto://upload_done" }
+
+// DescriptorSetAdded reports stored descriptor sets (proto://descriptor_set_added)
+type DescriptorSetAdded struct {
+	SessionID      string                  `json:"session_id"`
+	DescriptorSets []session.DescriptorSet `json:"descriptor_sets"`
+}
+
+func (DescriptorSetAdded) EventName() string { return "proto://descriptor_set_added" }
+
+// Validated reports a validation run (proto://validated)
+type Validated struct {
+	SessionID    string `json:"session_id"`
+	Valid        bool   `json:"valid"`
+	ErrorCount   int    `json:"error_count"`
+	WarningCount int    `json:"warning_count"`
+}
+
+func (Validated) EventName() string { return "proto://validated" }
+
+// FileUpdated reports an edited or created file (proto://file_updated)
+type FileUpdated struct {
+	SessionID    string `json:"session_id"`
+	RelativePath string `json:"relative_path"`
+	Size         int64  `json:"size"`
+	Created      bool   `json:"created"`
+}
+
+func (FileUpdated) EventName() string { return "proto://file_updated" }
+
+// IndexStart announces dependency analysis (proto://index_start)
+type IndexStart struct {
+	SessionID string `json:"session_id"`
+}
+
+func (IndexStart) EventName() string { return "proto://index_start" }
+
+// IndexError reports failed dependency analysis (proto://index_error)
+type IndexError struct {
+	Code  apierror.Code `json:"code"`
+	Error string        `json:"error"`
+}
+
+func (IndexError) EventName() string { return "proto://index_error" }
+
+// IndexSummary counts what dependency analysis found
+type IndexSummary struct {
+	Files    int `json:"files"`
+	Services int `json:"services"`
+}
+
+// IndexDone reports finished dependency analysis (proto://index_done), in the
+// shape the desktop app emits
+type IndexDone struct {
+	RootID   string       `json:"rootId"`
+	Summary  IndexSummary `json:"summary"`
+	Services []any        `json:"services"`
+	Files    []string     `json:"files"`
+}
+
+func (IndexDone) EventName() string { return "proto://index_done" }
+
+// ParseProgress reports how many proto files have been parsed
+// (proto://parse_progress), while a session's descriptors are compiled before
+// their first use or while dependency analysis indexes its imports. It is sent
+// at most every grpc.ParseProgressInterval.
+type ParseProgress struct {
+	grpc.ParseProgress
+}
+
+func (ParseProgress) EventName() string { return "proto://parse_progress" }
+
+// Server events are broadcast to every connected client rather than sent to
+// one session, so they carry no sequence number and are not replayed.
+
+// ServerStatus describes the running server (server://status). It is sent to
+// each client as it connects, so a UI reconnecting after an upgrade sees the
+// new version.
+type ServerStatus struct {
+	Version     string       `json:"version"`
+	StartedAt   time.Time    `json:"started_at"`
+	Maintenance *Maintenance `json:"maintenance,omitempty"` // Set while maintenance mode is on
+}
+
+func (ServerStatus) EventName() string { return "server://status" }
+
+// Maintenance reports maintenance mode being turned on or off (server://maintenance)
+type Maintenance struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+func (Maintenance) EventName() string { return "server://maintenance" }
+
+// ServerShutdown announces that the server is stopping (server://shutdown)
+type ServerShutdown struct {
+	Reason string `json:"reason"`
+}
+
+func (ServerShutdown) EventName() string { return "server://shutdown" }
+
+// Notice is an operator message for all connected UIs (server://notice)
+type Notice struct {
+	ID        string    `json:"id"`
+	Level     string    `json:"level"` // info, warning or error
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Notice) EventName() string { return "server://notice" }
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/secret"
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/grpc-bridge/server/internal/template"
+)
+
+// ExportGrpcurl renders a composed call as an equivalent grpcurl command.
+// By default proto paths are relative to the uploaded proto root (run the command
+// from that directory); pass ?absolute_paths=true to use the server-side paths.
+func (h *GRPCHandler) ExportGrpcurl(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
+		return
+	}
+
+	session, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	var req CallRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	// Substitute known variables; unknown placeholders are kept verbatim in the command
+	lookup, err := h.callLookup(sessionID, &req)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+	resolver := template.NewResolver(lookup)
+	req.Target = resolver.String(req.Target)
+	req.Metadata = resolver.Map(req.Metadata)
+	req.Data = resolver.Value(req.Data)
+
+	respondWithGrpcurlCommand(c, session, req, c.Query("absolute_paths") == "true")
+}
+
+// ExportGrpcurl renders a recorded history entry as an equivalent grpcurl command
+func (h *HistoryHandler) ExportGrpcurl(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	session, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	entry, err := h.store.Get(sessionID, c.Param("historyId"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	respondWithGrpcurlCommand(c, session, CallRequest{
+		Target:    entry.Target,
+		Service:   entry.Service,
+		Method:    entry.Method,
+		Data:      entry.Request,
+		Metadata:  secret.RedactMap(entry.Metadata),
+		Plaintext: entry.Plaintext,
+	}, c.Query("absolute_paths") == "true")
+}
+
+// respondWithGrpcurlCommand builds the grpcurl invocation for req and writes it as JSON
+func respondWithGrpcurlCommand(c *gin.Context, sess *session.Session, req CallRequest, absolutePaths bool) {
+	importRoot := "."
+	if absolutePaths {
+		importRoot = sess.RootPath
+	}
+
+	importPaths, protoFiles, err := grpc.MapGrpcurlSchema(sess.RootPath, sess.ImportMappings, exportProtoFiles(sess, req.Service, absolutePaths), !absolutePaths)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, err.Error())
+		return
+	}
+
+	args, err := grpc.BuildCallArgs(grpc.CallOptions{
+		ProtoFiles:  protoFiles,
+		Target:      req.Target,
+		Service:     req.Service,
+		Method:      req.Method,
+		Data:        req.Data,
+		Metadata:    req.Metadata,
+		Plaintext:   req.Plaintext,
+		ImportPaths: append(importPaths, req.ImportPaths...),
+		SessionRoot: importRoot,
+		ProtoSets:   exportProtoSets(sess, absolutePaths),
+		TLS:         req.TLS,
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"command": grpc.FormatCommand(args),
+		"args":    args,
+	})
+}
+
+// exportProtoFiles picks the proto file(s) to pass via -proto. When the service
+// location is known from a previous parse only that file is needed, since grpcurl
+// resolves its imports through -import-path; otherwise every session file is listed.
+func exportProtoFiles(sess *session.Session, service string, absolutePaths bool) []string {
+	pick := func(f session.ProtoFile) string {
+		if absolutePaths {
+			return f.AbsolutePath
+		}
+		return f.RelativePath
+	}
+
+	for _, svc := range sess.Services {
+		if svc.FQService != service || strings.HasPrefix(svc.File, "(") {
+			continue
+		}
+		for _, f := range sess.ProtoFiles {
+			if f.RelativePath == svc.File {
+				return []string{pick(f)}
+			}
+		}
+	}
+
+	files := make([]string, 0, len(sess.ProtoFiles))
+	for _, f := range sess.ProtoFiles {
+		files = append(files, pick(f))
+	}
+	return files
+}
+
+// exportProtoSets lists the session's descriptor sets for -protoset, relative to
+// the session root unless absolute paths were requested
+func exportProtoSets(sess *session.Session, absolutePaths bool) []string {
+	sets := make([]string, 0, len(sess.DescriptorSets))
+	for _, set := range sess.DescriptorSets {
+		if absolutePaths {
+			sets = append(sets, set.AbsolutePath)
+		} else if rel, err := filepath.Rel(sess.RootPath, set.AbsolutePath); err == nil {
+			sets = append(sets, filepath.ToSlash(rel))
+		} else {
+			sets = append(sets, set.Name)
+		}
+	}
+	return sets
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+const maxFileListLimit = 1000
+
+// fileListQuery holds the query parameters of ListFiles
+type fileListQuery struct {
+	Filter string // Case-insensitive substring of the relative path
+	Dir    string // Only files below this directory
+	Sort   string // path, name or size
+	Desc   bool
+	Offset int
+	Limit  int  // 0 lists every file
+	Tree   bool // Nest the files in a directory tree instead of a flat page
+	Depth  int  // Directory levels below Dir in tree mode; 0 for all
+}
+
+// parseFileListQuery reads and validates the ListFiles query parameters
+func parseFileListQuery(c *gin.Context) (fileListQuery, error) {
+	q := fileListQuery{
+		Filter: strings.ToLower(c.Query("q")),
+		Dir:    strings.Trim(path.Clean("/"+c.Query("dir")), "/"),
+		Sort:   c.DefaultQuery("sort", "path"),
+	}
+	switch q.Sort {
+	case "path", "name", "size":
+	default:
+		return q, fmt.Errorf("sort must be path, name or size")
+	}
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, fmt.Errorf("order must be asc or desc")
+	}
+	switch c.DefaultQuery("view", "list") {
+	case "list":
+	case "tree":
+		q.Tree = true
+	default:
+		return q, fmt.Errorf("view must be list or tree")
+	}
+
+	for name, target := range map[string]*int{"offset": &q.Offset, "limit": &q.Limit, "depth": &q.Depth} {
+		if raw := c.Query(name); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				return q, fmt.Errorf("%s must be a non-negative integer", name)
+			}
+			*target = n
+		}
+	}
+	q.Limit = min(q.Limit, maxFileListLimit)
+	return q, nil
+}
+
+// selectFiles returns the files matching the filter and directory, sorted
+func (q fileListQuery) selectFiles(files []session.ProtoFile) []session.ProtoFile {
+	var out []session.ProtoFile
+	for _, f := range files {
+		if q.Dir != "" && !strings.HasPrefix(f.RelativePath, q.Dir+"/") {
+			continue
+		}
+		if q.Filter != "" && !strings.Contains(strings.ToLower(f.RelativePath), q.Filter) {
+			continue
+		}
+		out = append(out, f)
+	}
+
+	less := func(a, b session.ProtoFile) bool { return a.RelativePath < b.RelativePath }
+	switch q.Sort {
+	case "name":
+		less = func(a, b session.ProtoFile) bool {
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.RelativePath < b.RelativePath
+		}
+	case "size":
+		less = func(a, b session.ProtoFile) bool {
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+			return a.RelativePath < b.RelativePath
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if q.Desc {
+			return less(out[j], out[i])
+		}
+		return less(out[i], out[j])
+	})
+	return out
+}
+
+// page applies offset and limit
+func (q fileListQuery) page(files []session.ProtoFile) []session.ProtoFile {
+	if q.Offset >= len(files) {
+		return nil
+	}
+	files = files[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(files) {
+		files = files[:q.Limit]
+	}
+	return files
+}
+
+// FileTreeNode is a directory or file in the tree view of ListFiles.
+// Directories report the number and total size of the files below them,
+// including those beyond the requested depth.
+type FileTreeNode struct {
+	Name      string          `json:"name"`
+	Path      string          `json:"path"`
+	Type      string          `json:"type"` // "dir" or "file"
+	Size      int64           `json:"size"`
+	FileCount int             `json:"file_count,omitempty"` // Directories only
+	Truncated bool            `json:"truncated,omitempty"`  // Children omitted below the requested depth
+	Children  []*FileTreeNode `json:"children,omitempty"`
+}
+
+// buildFileTree nests files (already sorted) under a root node for dir. Below
+// depth levels directories are summarized without children.
+func buildFileTree(dir string, files []session.ProtoFile, depth int) *FileTreeNode {
+	root := &FileTreeNode{Path: dir, Type: "dir"}
+	if dir != "" {
+		root.Name = path.Base(dir)
+	}
+	dirs := map[string]*FileTreeNode{dir: root}
+
+	for _, f := range files {
+		rel := strings.TrimPrefix(f.RelativePath, dir+"/")
+		if dir == "" {
+			rel = f.RelativePath
+		}
+		segments := strings.Split(rel, "/")
+
+		parent := root
+		parent.Size += f.Size
+		parent.FileCount++
+		for level, name := range segments[:len(segments)-1] {
+			if depth > 0 && level >= depth {
+				parent.Truncated = true
+				parent = nil
+				break
+			}
+			dirPath := path.Join(parent.Path, name)
+			node, ok := dirs[dirPath]
+			if !ok {
+				node = &FileTreeNode{Name: name, Path: dirPath, Type: "dir"}
+				dirs[dirPath] = node
+				parent.Children = append(parent.Children, node)
+			}
+			node.Size += f.Size
+			node.FileCount++
+			parent = node
+		}
+		if parent == nil {
+			continue
+		}
+		if depth > 0 && len(segments)-1 >= depth {
+			// The file lies below the deepest listed directory
+			parent.Truncated = true
+			continue
+		}
+		parent.Children = append(parent.Children, &FileTreeNode{
+			Name: f.Name,
+			Path: f.RelativePath,
+			Type: "file",
+			Size: f.Size,
+		})
+	}
+	return root
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/archive"
+	"github.com/grpc-bridge/server/internal/gitsource"
+	"github.com/grpc-bridge/server/internal/grpc"
+)
+
+// gitImportTimeout bounds the whole clone of a remote repository
+const gitImportTimeout = 2 * time.Minute
+
+// ImportGitRequest represents a request to load protos from a Git repository
+type ImportGitRequest struct {
+	SessionID    string `json:"sessionId" binding:"required"`
+	URL          string `json:"url" binding:"required"`
+	Ref          string `json:"ref"`          // Branch, tag or commit; defaults to the remote HEAD
+	Subdirectory string `json:"subdirectory"` // Only import protos below this path
+	Username     string `json:"username"`
+	Token        string `json:"token"` // Optional access token for private repositories
+	Mode         string `json:"mode"`  // "replace" (default) or "merge"
+}
+
+// ImportGit clones a repository and replaces the session's proto set with its .proto files
+func (h *ProtoHandler) ImportGit(c *gin.Context) {
+	var req ImportGitRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	sess, exists := h.sessionManager.Get(req.SessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	mode, ok := parseUploadMode(req.Mode)
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "mode must be \"replace\" or \"merge\"")
+		return
+	}
+
+	if err := gitsource.ValidateURL(req.URL); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+	if err := gitsource.ValidateToken(req.URL, req.Token); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	subdir := ""
+	if s := strings.Trim(strings.TrimSpace(req.Subdirectory), "/"); s != "" {
+		clean, ok := archive.SanitizePath(s)
+		if !ok {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid subdirectory")
+			return
+		}
+		subdir = clean
+	}
+
+	// Events are replayed to late subscribers: keep credentials in the url out of them
+	gitURL := gitsource.RedactURL(req.URL)
+	h.hub.EmitToSession(req.SessionID, UploadStart{
+		SessionID: req.SessionID,
+		GitURL:    gitURL,
+		Ref:       req.Ref,
+	})
+
+	ctx, done := h.sessionManager.BeginOperation(c.Request.Context(), req.SessionID)
+	defer done()
+	ctx, cancel := context.WithTimeout(ctx, gitImportTimeout)
+	defer cancel()
+
+	checkout, cleanup, err := gitsource.Clone(ctx, gitsource.CloneOptions{
+		URL:      req.URL,
+		Ref:      req.Ref,
+		Username: req.Username,
+		Token:    req.Token,
+	})
+	if err != nil {
+		status, code := http.StatusBadGateway, apierror.UpstreamFailed
+		var details gin.H
+		var tooLarge *gitsource.CheckoutTooLargeError
+		switch {
+		case err == gitsource.ErrInvalidRef || err == gitsource.ErrInsecureToken:
+			status, code = http.StatusBadRequest, apierror.InvalidRequest
+		case grpc.IsTargetDenied(err):
+			status, code = http.StatusForbidden, apierror.TargetDenied
+		case errors.As(err, &tooLarge):
+			status, code = http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge
+			details = gin.H{"max_checkout_size": tooLarge.Limit}
+		}
+		h.hub.EmitToSession(req.SessionID, UploadError{
+			Code:  code,
+			Error: err.Error(),
+		})
+		apierror.RespondDetails(c, status, code, err.Error(), details)
+		return
+	}
+	defer cleanup()
+
+	root, err := resolveDir(checkout)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to read repository: "+err.Error())
+		return
+	}
+	if subdir != "" {
+		// The subdirectory, or a directory above it, may be a symlink in the
+		// repository: resolve it and require the result to stay in the checkout
+		dir, err := resolveDir(filepath.Join(root, filepath.FromSlash(subdir)))
+		if err != nil || !within(root, dir) {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "subdirectory not found in repository: "+subdir)
+			return
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "subdirectory not found in repository: "+subdir)
+			return
+		}
+		root = dir
+	}
+
+	var protoPaths []string
+	walkErr := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		// Symlinks are skipped: they could point outside the checkout
+		if !d.Type().IsRegular() || !strings.HasSuffix(strings.ToLower(d.Name()), ".proto") {
+			return nil
+		}
+		protoPaths = append(protoPaths, p)
+		return nil
+	})
+	if walkErr != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to scan repository: "+walkErr.Error())
+		return
+	}
+
+	batch, ok := h.beginUpload(c, sess, mode)
+	if !ok {
+		return
+	}
+	for _, p := range protoPaths {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			batch.errorFiles = append(batch.errorFiles, p)
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		src, err := os.Open(p)
+		if err != nil {
+			batch.errorFiles = append(batch.errorFiles, rel)
+			continue
+		}
+		h.store(batch, rel, src)
+		src.Close()
+	}
+
+	h.finishUpload(c, sess, batch, UploadSource{
+		GitURL:       gitURL,
+		Ref:          req.Ref,
+		Subdirectory: subdir,
+	})
+}
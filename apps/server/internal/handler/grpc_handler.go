@@ -2,129 +2,330 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/audit"
+	"github.com/grpc-bridge/server/internal/callstats"
 	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/history"
+	"github.com/grpc-bridge/server/internal/payload"
 	pparser "github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/script"
 	"github.com/grpc-bridge/server/internal/session"
+	"github.com/grpc-bridge/server/internal/template"
 	"github.com/grpc-bridge/server/internal/websocket"
+	"google.golang.org/grpc/status"
 )
 
 type GRPCHandler struct {
 	sessionManager *session.Manager
-	grpcProxy      *grpc.Proxy        // Legacy grpcurl wrapper (deprecated)
-	nativeClient   *grpc.NativeClient // New native gRPC client
+	client         grpc.Client // Native client by default, grpcurl when configured
 	wsHub          *websocket.Hub
+	history        history.Store
+	stats          *callstats.Tracker // Rolling per-endpoint latency and errors
+	payloads       *payload.Store     // Full JSON of responses sent as previews
+	previewSize    int                // Bytes of JSON beyond which events carry a preview; 0 disables
+
+	calls   map[string]*activeCall // Running and waiting calls, by call ID
+	callsMu sync.Mutex
+	pool    *callPool // Runs unary calls on a bounded number of workers
 }
 
-func NewGRPCHandler(sm *session.Manager, gp *grpc.Proxy, hub *websocket.Hub) *GRPCHandler {
+func NewGRPCHandler(sm *session.Manager, client grpc.Client, hub *websocket.Hub, hs history.Store, stats *callstats.Tracker) *GRPCHandler {
 	return &GRPCHandler{
 		sessionManager: sm,
-		grpcProxy:      gp,
-		nativeClient:   grpc.NewNativeClient(),
+		client:         client,
 		wsHub:          hub,
+		history:        hs,
+		stats:          stats,
+		calls:          make(map[string]*activeCall),
+		pool: newCallPool(func(sessionID, callID string, position int) {
+			hub.SendToSession(sessionID, CallQueued{CallID: callID, Position: position})
+		}),
 	}
 }
 
 // CallRequest represents a gRPC call request
 type CallRequest struct {
-	Target      string            `json:"target" binding:"required"`  // gRPC server address
-	Service     string            `json:"service" binding:"required"` // Full service name (e.g. "grpc.reflection.v1alpha.ServerReflection")
-	Method      string            `json:"method" binding:"required"`  // Method name
-	Data        interface{}       `json:"data"`                       // Request payload (JSON)
-	Metadata    map[string]string `json:"metadata"`                   // gRPC metadata headers
-	Plaintext   bool              `json:"plaintext"`                  // Use plaintext (insecure) connection
-	ImportPaths []string          `json:"import_paths"`               // Additional proto import paths
+	Target      string            `json:"target" binding:"omitempty,grpc_target"` // gRPC server address; optional when the environment sets one
+	Service     string            `json:"service" binding:"required"`             // Full service name (e.g. "grpc.reflection.v1alpha.ServerReflection")
+	Method      string            `json:"method" binding:"required"`              // Method name
+	Data        interface{}       `json:"data"`                                   // Request payload (JSON)
+	Metadata    map[string]string `json:"metadata"`                               // gRPC metadata headers
+	Plaintext   bool              `json:"plaintext"`                              // Use plaintext (insecure) connection
+	ImportPaths []string          `json:"import_paths"`                           // Additional proto import paths
+	Scripts     *CallScripts      `json:"scripts"`                                // Optional pre-request / post-response hooks
+	CaptureWire bool              `json:"capture_wire"`                           // Debug: include serialized protobuf bytes in the result
+
+	Environment string               `json:"environment"` // Session environment supplying target, metadata and variable defaults
+	TargetID    string               `json:"target_id"`   // Saved target preset supplying address, transport and metadata
+	TLS         *session.TLSSettings `json:"tls"`         // TLS customization when not plaintext
 }
 
 type CallGRPCResponse struct {
-	Ok      bool  `json:"ok"`
-	Payload gin.H `json:"payload"`
+	Ok      bool `json:"ok"`
+	Payload any  `json:"payload"` // *CallResponse when Ok, *CallError otherwise
 }
 
 // CallGRPC handles gRPC call requests
 func (h *GRPCHandler) CallGRPC(c *gin.Context) {
 	sessionID := c.GetHeader("X-Session-ID")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "session ID required in X-Session-ID header",
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
 		return
 	}
 
 	// Verify session exists
 	session, exists := h.sessionManager.Get(sessionID)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "session not found",
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
 		return
 	}
 
 	var req CallRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid request: " + err.Error(),
-		})
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	// Resolve {{var}} placeholders against session variables
+	if err := h.resolveTemplates(sessionID, &req); err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, err, apierror.TemplateError)
+		return
+	}
+
+	auditCall(c, &req)
+
+	// Wait for a worker and return the final result in the HTTP response
+	h.respondCall(c, session, &req)
+}
+
+// ReplayRequest represents optional overrides applied when replaying a history entry
+type ReplayRequest struct {
+	Target    string            `json:"target" binding:"omitempty,grpc_target"` // Override target address
+	Metadata  map[string]string `json:"metadata"`                               // Metadata merged over the recorded metadata
+	Plaintext *bool             `json:"plaintext"`                              // Override transport security
+
+	Environment string `json:"environment"` // Replay against an environment; its target replaces the recorded one
+	TargetID    string `json:"target_id"`   // Replay against a saved target preset instead of the recorded address
+}
+
+// ReplayCall re-executes a call recorded in the session's history
+func (h *GRPCHandler) ReplayCall(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
+		return
+	}
+
+	session, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	if h.history == nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.FeatureDisabled, "call history is disabled")
+		return
+	}
+
+	entry, err := h.history.Get(sessionID, c.Param("historyId"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
 		return
 	}
 
-	// Emit start event
+	// Overrides are optional; an empty body replays the call as recorded
+	var overrides ReplayRequest
+	if c.Request.ContentLength > 0 {
+		if !bindJSON(c, &overrides) {
+			return
+		}
+	}
+
+	req := CallRequest{
+		Target:    entry.Target,
+		Service:   entry.Service,
+		Method:    entry.Method,
+		Data:      entry.Request,
+		Metadata:  map[string]string{},
+		Plaintext: entry.Plaintext,
+	}
+	for k, v := range entry.Metadata {
+		req.Metadata[k] = v
+	}
+	for k, v := range overrides.Metadata {
+		req.Metadata[k] = v
+	}
+	if overrides.Environment != "" {
+		req.Environment = overrides.Environment
+		req.Target = ""
+	}
+	if overrides.TargetID != "" {
+		req.TargetID = overrides.TargetID
+		req.Target = ""
+	}
+	if overrides.Target != "" {
+		req.Target = overrides.Target
+	}
+	if overrides.Plaintext != nil {
+		req.Plaintext = *overrides.Plaintext
+	}
+
+	// Overrides may reference session variables
+	if err := h.resolveTemplates(sessionID, &req); err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, err, apierror.TemplateError)
+		return
+	}
+	auditCall(c, &req)
+
+	h.respondCall(c, session, &req)
+}
+
+// executeCall runs a call against the configured client, emits grpc:// events
+// to the session and records the outcome in the call history.
+func (h *GRPCHandler) executeCall(ctx context.Context, sess *session.Session, req *CallRequest) CallGRPCResponse {
+	return h.runCall(ctx, uuid.New().String(), sess, req)
+}
+
+// runCall is executeCall for a call ID chosen by the caller
+func (h *GRPCHandler) runCall(ctx context.Context, callID string, sess *session.Session, req *CallRequest) CallGRPCResponse {
+	// Tie the call to the session so deleting the session cancels it
+	ctx, done := h.sessionManager.BeginOperation(ctx, sess.ID)
+	defer done()
+
+	h.wsHub.EmitToSession(sess.ID, CallStart{
+		CallID:  callID,
+		Target:  req.Target,
+		Service: req.Service,
+		Method:  req.Method,
+	})
+
+	scripts := map[string]*script.Result{}
+	if req.Scripts != nil && req.Scripts.PreRequest != "" {
+		preResult, err := h.runPreRequestScript(callID, sess.ID, req)
+		if err != nil {
+			payload := &CallError{
+				CallID:  callID,
+				Code:    apierror.ScriptFailed,
+				Error:   err.Error(),
+				Kind:    "script_error",
+				Scripts: map[string]*script.Result{script.PhasePreRequest: preResult},
+			}
+			h.wsHub.EmitToSession(sess.ID, payload)
+			return CallGRPCResponse{
+				Ok:      false,
+				Payload: payload,
+			}
+		}
+		scripts[script.PhasePreRequest] = preResult
+	}
+
 	startTime := time.Now()
 
 	// Build proto file paths from session
-	protoFiles := make([]string, len(session.ProtoFiles))
-	for i, pf := range session.ProtoFiles {
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
 		protoFiles[i] = pf.AbsolutePath
 	}
 
-	// Execute synchronously and return the final result in HTTP response.
-	result, err := h.nativeClient.Call(c.Request.Context(), grpc.NativeCallOptions{
-		SessionID:   sessionID,
-		SessionRoot: session.RootPath,
-		ProtoFiles:  protoFiles,
-		Target:      req.Target,
-		Service:     req.Service,
-		Method:      req.Method,
-		Data:        req.Data,
-		Metadata:    req.Metadata,
-		Plaintext:   req.Plaintext,
-		Timeout:     30 * time.Second, // Default 30s timeout
+	result, err := h.client.Call(ctx, grpc.NativeCallOptions{
+		SessionID:      sess.ID,
+		SessionRoot:    sess.RootPath,
+		ProtoFiles:     protoFiles,
+		Target:         req.Target,
+		Service:        req.Service,
+		Method:         req.Method,
+		Data:           req.Data,
+		Metadata:       req.Metadata,
+		Plaintext:      req.Plaintext,
+		Timeout:        30 * time.Second, // Default 30s timeout
+		CaptureWire:    req.CaptureWire,
+		DescriptorSets: sess.DescriptorSetPaths(),
+		ImportMappings: sess.ImportMappings,
+		TLS:            req.TLS,
 	})
 
-	tookMs := time.Since(startTime).Milliseconds()
+	took := time.Since(startTime)
+	tookMs := took.Milliseconds()
+	h.recordHistory(callID, sess.ID, req, result, err, tookMs)
+	errKind := ""
 	if err != nil {
-		c.JSON(http.StatusOK, CallGRPCResponse{
-			Ok: false,
-			Payload: gin.H{
-				"error":   err.Error(),
-				"took_ms": tookMs,
-				"kind":    classifyGRPCErrorKind(err.Error()),
-			},
-		})
-		return
+		errKind = classifyGRPCErrorKind(err.Error())
 	}
+	h.stats.Record(sess.ID, callstats.Key{Target: req.Target, Service: req.Service, Method: req.Method}, took, errKind)
 
-	c.JSON(http.StatusOK, CallGRPCResponse{
-		Ok: true,
-		Payload: gin.H{
-			"raw":      result.Response,
-			"parsed":   result.Response,
-			"headers":  result.Headers,
-			"trailers": result.Trailers,
-			"took_ms":  tookMs,
-		},
-	})
+	var failure *CallError
+	var success *CallResponse
+	if err != nil {
+		failure = &CallError{
+			CallID: callID,
+			Code:   errorKindCode(errKind),
+			Error:  err.Error(),
+			TookMs: tookMs,
+			Kind:   errKind,
+		}
+		var wireErr *grpc.WireError
+		if errors.As(err, &wireErr) {
+			failure.Wire = wireErr.Wire
+		}
+	} else {
+		success = &CallResponse{
+			CallID:   callID,
+			Raw:      result.Response,
+			Parsed:   result.Response,
+			Headers:  result.Headers,
+			Trailers: result.Trailers,
+			TookMs:   tookMs,
+			Wire:     result.Wire,
+			Metrics:  result.Metrics,
+		}
+	}
+
+	var scriptErr error
+	if req.Scripts != nil && req.Scripts.PostResponse != "" {
+		var postResult *script.Result
+		postResult, scriptErr = h.runPostResponseScript(callID, sess.ID, req, result, err, tookMs)
+		scripts[script.PhasePostResponse] = postResult
+	}
+	if len(scripts) == 0 {
+		scripts = nil
+	}
+
+	var response CallGRPCResponse
+	if success != nil {
+		success.Scripts, success.ScriptError = scripts, scriptErr
+		response = CallGRPCResponse{Ok: true, Payload: success}
+		// The caller gets the full response; the event may carry a preview
+		event := success
+		if preview := h.previewResponse(sess.ID, success.Raw); preview != nil {
+			truncated := *success
+			truncated.Raw, truncated.Parsed, truncated.Preview = nil, nil, preview
+			event = &truncated
+		}
+		h.wsHub.EmitToSession(sess.ID, event)
+	} else {
+		failure.Scripts, failure.ScriptError = scripts, scriptErr
+		response = CallGRPCResponse{Ok: false, Payload: failure}
+		h.wsHub.EmitToSession(sess.ID, failure)
+	}
+	return response
 }
 
 // ListServicesRequest represents a request to list services
 type ListServicesRequest struct {
-	Target    string `json:"target"`    // gRPC server address (optional - if empty, reads from proto files)
-	Plaintext bool   `json:"plaintext"` // Use plaintext (insecure) connection
+	Target    string `json:"target" binding:"omitempty,grpc_target"` // gRPC server address (optional - if empty, reads from proto files)
+	Plaintext bool   `json:"plaintext"`                              // Use plaintext (insecure) connection
+
+	// IncludeMethods also resolves each reflected service's methods
+	IncludeMethods bool `json:"include_methods"`
 }
 
 // ListServices lists available gRPC services
@@ -133,26 +334,19 @@ type ListServicesRequest struct {
 func (h *GRPCHandler) ListServices(c *gin.Context) {
 	sessionID := c.GetHeader("X-Session-ID")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "session ID required in X-Session-ID header",
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
 		return
 	}
 
 	// Verify session exists
 	session, exists := h.sessionManager.Get(sessionID)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "session not found",
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
 		return
 	}
 
 	var req ListServicesRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid request: " + err.Error(),
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -162,14 +356,13 @@ func (h *GRPCHandler) ListServices(c *gin.Context) {
 		for i, pf := range session.ProtoFiles {
 			protoFiles[i] = pf.AbsolutePath
 		}
-		if len(protoFiles) == 0 {
+		if len(protoFiles) == 0 && len(session.DescriptorSets) == 0 {
 			c.JSON(http.StatusOK, gin.H{"services": []interface{}{}, "source": "proto_files"})
 			return
 		}
-		parser := pparser.NewServiceParser()
-		parsed, err := parser.ParseServices(session.RootPath, protoFiles)
+		parsed, err := parseSessionServices(session, protoFiles)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse proto files: " + err.Error()})
+			apierror.Respond(c, http.StatusInternalServerError, apierror.ParseError, err.Error())
 			return
 		}
 		// Cache best-effort
@@ -203,8 +396,12 @@ func (h *GRPCHandler) ListServices(c *gin.Context) {
 	// Attempt reflection with short timeout
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 1200*time.Millisecond)
 	defer cancel()
-	services, err := h.nativeClient.ListServices(ctx, req.Target, req.Plaintext)
+	services, err := h.client.ListServices(ctx, req.Target, req.Plaintext)
 	if err != nil {
+		if grpc.IsTargetDenied(err) {
+			apierror.Respond(c, http.StatusForbidden, apierror.TargetDenied, err.Error())
+			return
+		}
 		// Fallback on common dial errors
 		lowered := strings.ToLower(err.Error())
 		if strings.Contains(lowered, "unavailable") || strings.Contains(lowered, "refused") || strings.Contains(lowered, "deadline") || strings.Contains(lowered, "connect") {
@@ -212,11 +409,27 @@ func (h *GRPCHandler) ListServices(c *gin.Context) {
 			parseFromProto()
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list services: " + err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to list services: "+err.Error())
 		return
 	}
 
-	// Convert reflection list (string names) to minimal service meta objects (methods empty; can be enriched later)
+	if req.IncludeMethods {
+		resolveCtx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		resolved, err := grpc.ResolveServicesViaReflection(resolveCtx, req.Target, req.Plaintext, services)
+		if grpc.IsTargetDenied(err) {
+			apierror.Respond(c, http.StatusForbidden, apierror.TargetDenied, err.Error())
+			return
+		}
+		if err != nil {
+			apierror.Respond(c, http.StatusBadGateway, apierror.TargetUnreachable, "failed to resolve services: "+err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"services": resolved, "source": "reflection"})
+		return
+	}
+
+	// Convert reflection list (string names) to minimal service meta objects (methods empty unless include_methods is set)
 	out := make([]gin.H, 0, len(services))
 	for _, name := range services {
 		out = append(out, gin.H{
@@ -228,37 +441,94 @@ func (h *GRPCHandler) ListServices(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"services": out, "source": "reflection"})
 }
 
+// GetSkeleton renders a sample JSON request payload for a method's input type
+func (h *GRPCHandler) GetSkeleton(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
+		return
+	}
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	service := c.Query("service")
+	method := c.Query("method")
+	if service == "" || method == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "service and method query parameters are required")
+		return
+	}
+
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+
+	methodDesc, err := h.client.GetMethodDescriptor(sess.ID, sess.RootPath, protoFiles, sess.DescriptorSetPaths(), sess.ImportMappings, service, method)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.MethodNotFound, "failed to resolve method: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"service":          service,
+		"method":           method,
+		"input_type":       methodDesc.GetInputType().GetFullyQualifiedName(),
+		"output_type":      methodDesc.GetOutputType().GetFullyQualifiedName(),
+		"client_streaming": methodDesc.IsClientStreaming(),
+		"server_streaming": methodDesc.IsServerStreaming(),
+		"skeleton":         grpc.BuildSkeleton(methodDesc.GetInputType()),
+	})
+}
+
+// parseSessionServices collects services from the session's proto sources and
+// descriptor sets. Descriptor sets carry fully linked services, no heuristics needed.
+func parseSessionServices(sess *session.Session, protoFiles []string) ([]session.ServiceInfo, error) {
+	parsed := []session.ServiceInfo{}
+	if len(protoFiles) > 0 {
+		fromSource, err := pparser.NewServiceParser().ParseServices(sess.RootPath, protoFiles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proto files: %w", err)
+		}
+		parsed = append(parsed, fromSource...)
+	}
+	for _, set := range sess.DescriptorSets {
+		files, err := pparser.LoadDescriptorSet(set.AbsolutePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load descriptor set %s: %w", set.Name, err)
+		}
+		parsed = append(parsed, pparser.DescriptorSetServices(files)...)
+	}
+	return parsed, nil
+}
+
 // DescribeServiceRequest represents a request to describe a service
 type DescribeServiceRequest struct {
-	Target    string `json:"target" binding:"required"`  // gRPC server address
-	Service   string `json:"service" binding:"required"` // Service name to describe
-	Plaintext bool   `json:"plaintext"`                  // Use plaintext (insecure) connection
+	Target    string `json:"target" binding:"required,grpc_target"` // gRPC server address
+	Service   string `json:"service" binding:"required"`            // Service name to describe
+	Plaintext bool   `json:"plaintext"`                             // Use plaintext (insecure) connection
 }
 
 // DescribeService describes a gRPC service (methods, types, etc.)
 func (h *GRPCHandler) DescribeService(c *gin.Context) {
 	sessionID := c.GetHeader("X-Session-ID")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "session ID required in X-Session-ID header",
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
 		return
 	}
 
 	// Verify session exists
 	session, exists := h.sessionManager.Get(sessionID)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "session not found",
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
 		return
 	}
 
 	var req DescribeServiceRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid request: " + err.Error(),
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -268,29 +538,340 @@ func (h *GRPCHandler) DescribeService(c *gin.Context) {
 		protoFiles[i] = pf.AbsolutePath
 	}
 
-	// Describe service using grpcurl
-	description, err := h.grpcProxy.DescribeService(c.Request.Context(), grpc.DescribeOptions{
-		SessionID:   sessionID,
-		ProtoFiles:  protoFiles,
-		Target:      req.Target,
-		Service:     req.Service,
-		Plaintext:   req.Plaintext,
-		SessionRoot: session.RootPath,
+	// Describe service (from uploaded protos when possible, otherwise via reflection)
+	description, err := h.client.DescribeService(c.Request.Context(), grpc.DescribeOptions{
+		SessionID:      sessionID,
+		ProtoFiles:     protoFiles,
+		Target:         req.Target,
+		Service:        req.Service,
+		Plaintext:      req.Plaintext,
+		SessionRoot:    session.RootPath,
+		DescriptorSets: session.DescriptorSetPaths(),
+		ImportMappings: session.ImportMappings,
 	})
 
+	if grpc.IsTargetDenied(err) {
+		apierror.Respond(c, http.StatusForbidden, apierror.TargetDenied, err.Error())
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to describe service: " + err.Error(),
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to describe service: "+err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, description)
 }
 
+// DescribeMethodRequest represents a request to describe a single method
+type DescribeMethodRequest struct {
+	Service   string `json:"service" binding:"required"`             // Fully qualified service name
+	Method    string `json:"method" binding:"required"`              // Method name within the service
+	Target    string `json:"target" binding:"omitempty,grpc_target"` // Server to ask via reflection when the session's protos lack the method
+	Plaintext bool   `json:"plaintext"`                              // Use plaintext (insecure) connection for reflection
+}
+
+// DescribeMethodResponse is the full schema of a method and where it came from
+type DescribeMethodResponse struct {
+	*grpc.MethodSchema
+	Source string `json:"source"` // "proto" (session files) or "reflection"
+}
+
+// DescribeMethod returns the input and output message schemas, streaming flags
+// and options of one method. The session's proto files and descriptor sets are
+// tried first; with a target, server reflection is the fallback.
+func (h *GRPCHandler) DescribeMethod(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
+		return
+	}
+
+	session, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	var req DescribeMethodRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	protoFiles := make([]string, len(session.ProtoFiles))
+	for i, pf := range session.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+
+	methodDesc, err := h.client.GetMethodDescriptor(session.ID, session.RootPath, protoFiles, session.DescriptorSetPaths(), session.ImportMappings, req.Service, req.Method)
+	if err == nil {
+		c.JSON(http.StatusOK, DescribeMethodResponse{MethodSchema: grpc.DescribeMethod(methodDesc), Source: "proto"})
+		return
+	}
+	if req.Target == "" {
+		apierror.Respond(c, http.StatusNotFound, apierror.MethodNotFound, "failed to resolve method: "+err.Error())
+		return
+	}
+
+	sd, err := grpc.ResolveServiceViaReflection(c.Request.Context(), req.Target, req.Plaintext, req.Service)
+	if grpc.IsTargetDenied(err) {
+		apierror.Respond(c, http.StatusForbidden, apierror.TargetDenied, err.Error())
+		return
+	}
+	if errors.Is(err, grpc.ErrNotFoundViaReflection) {
+		apierror.Respond(c, http.StatusNotFound, apierror.ServiceNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.TargetUnreachable, err.Error())
+		return
+	}
+	methodDesc = sd.FindMethodByName(req.Method)
+	if methodDesc == nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.MethodNotFound, fmt.Sprintf("method %s not found in service %s", req.Method, req.Service))
+		return
+	}
+
+	c.JSON(http.StatusOK, DescribeMethodResponse{MethodSchema: grpc.DescribeMethod(methodDesc), Source: "reflection"})
+}
+
+// DescribeMessageRequest represents a request to fetch a message schema via
+// server reflection
+type DescribeMessageRequest struct {
+	Target    string `json:"target" binding:"required,grpc_target"` // gRPC server address
+	Message   string `json:"message" binding:"required"`            // Fully qualified message name
+	Plaintext bool   `json:"plaintext"`                             // Use plaintext (insecure) connection
+}
+
+// DescribeMessageResponse is a message schema fetched via reflection, with a
+// sample payload to start a request from
+type DescribeMessageResponse struct {
+	*grpc.MessageSchema
+	Skeleton map[string]interface{} `json:"skeleton"`
+}
+
+// DescribeMessage retrieves a message type from the target's reflection
+// service and describes its fields and the types they reference, for
+// composing requests against servers whose protos are not uploaded
+func (h *GRPCHandler) DescribeMessage(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
+		return
+	}
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	var req DescribeMessageRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	md, err := grpc.ResolveMessageViaReflection(c.Request.Context(), req.Target, req.Plaintext, req.Message)
+	if grpc.IsTargetDenied(err) {
+		apierror.Respond(c, http.StatusForbidden, apierror.TargetDenied, err.Error())
+		return
+	}
+	if errors.Is(err, grpc.ErrNotFoundViaReflection) {
+		apierror.Respond(c, http.StatusNotFound, apierror.MessageNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.TargetUnreachable, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, DescribeMessageResponse{MessageSchema: grpc.DescribeMessageSchema(md), Skeleton: grpc.BuildSkeleton(md)})
+}
+
+// resolveTemplates applies the request's environment and substitutes variables
+// into the target, metadata values and data payload
+func (h *GRPCHandler) resolveTemplates(sessionID string, req *CallRequest) error {
+	lookup, err := h.callLookup(sessionID, req)
+	if err != nil {
+		return apierror.NewError(apierror.TemplateError, err.Error())
+	}
+
+	resolver := template.NewResolver(lookup)
+	req.Target = resolver.String(req.Target)
+	req.Metadata = resolver.Map(req.Metadata)
+	req.Data = resolver.Value(req.Data)
+	if err := resolver.Err(); err != nil {
+		return apierror.NewError(apierror.TemplateError, err.Error())
+	}
+	return checkCallTarget(req.Target)
+}
+
+// checkCallTarget validates a call's target once preset and environment
+// defaults and placeholders have been applied
+func checkCallTarget(target string) error {
+	if target == "" {
+		return apierror.Invalid(apierror.FieldError{
+			Field:   "target",
+			Rule:    "required",
+			Message: "target is required (set it on the request, a target preset or its environment)",
+		})
+	}
+	if err := grpc.ValidateTarget(target); err != nil {
+		return apierror.Invalid(apierror.FieldError{
+			Field:   "target",
+			Rule:    "grpc_target",
+			Message: "target " + err.Error(),
+		})
+	}
+	return nil
+}
+
+// callLookup applies the defaults of the request's target preset and
+// environment, if any, and the session's default metadata, and returns the
+// placeholder lookup for the call: environment variables first, then session
+// variables. The request's own address and metadata win over the preset's,
+// which win over the environment's, which win over the session defaults.
+func (h *GRPCHandler) callLookup(sessionID string, req *CallRequest) (template.Lookup, error) {
+	vars, err := h.sessionManager.GetVariables(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := h.sessionManager.GetDefaultMetadata(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TargetID != "" {
+		target, err := h.sessionManager.GetTarget(sessionID, req.TargetID)
+		if err != nil {
+			return nil, fmt.Errorf("target %q not found", req.TargetID)
+		}
+		if req.Target == "" {
+			req.Target = target.Address
+		}
+		// The preset decides the transport; a request TLS block refines it
+		req.Plaintext = target.Plaintext
+		if req.TLS == nil {
+			req.TLS = target.TLS
+		}
+		req.Metadata = mergeMetadata(target.Metadata, req.Metadata)
+	}
+
+	lookup := template.MapLookup(vars)
+	if req.Environment != "" {
+		env, err := h.sessionManager.GetEnvironment(sessionID, req.Environment)
+		if err != nil {
+			return nil, fmt.Errorf("environment %q not found", req.Environment)
+		}
+		if req.Target == "" {
+			req.Target = env.Target
+		}
+		req.Metadata = mergeMetadata(env.Metadata, req.Metadata)
+		lookup = template.ChainLookups(template.MapLookup(env.Variables), lookup)
+	}
+
+	req.Metadata = mergeMetadata(defaults, req.Metadata)
+	return lookup, nil
+}
+
+// auditCall adds the resolved call to the request's audit entry
+func auditCall(c *gin.Context, req *CallRequest) {
+	audit.AddDetail(c, "target", req.Target)
+	audit.AddDetail(c, "service", req.Service)
+	audit.AddDetail(c, "method", req.Method)
+}
+
+// mergeMetadata layers overrides over defaults without modifying either.
+// Keys are compared case-insensitively, as gRPC metadata keys are.
+func mergeMetadata(defaults, overrides map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return overrides
+	}
+	overridden := make(map[string]bool, len(overrides))
+	for k := range overrides {
+		overridden[strings.ToLower(k)] = true
+	}
+	merged := make(map[string]string, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		if !overridden[strings.ToLower(k)] {
+			merged[k] = v
+		}
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// recordHistory stores the outcome of a call in the session's history (best-effort)
+func (h *GRPCHandler) recordHistory(callID, sessionID string, req *CallRequest, result *grpc.NativeCallResult, callErr error, tookMs int64) {
+	if h.history == nil {
+		return
+	}
+
+	entry := &history.Entry{
+		ID:        callID,
+		SessionID: sessionID,
+		Target:    req.Target,
+		Service:   req.Service,
+		Method:    req.Method,
+		Request:   req.Data,
+		Metadata:  req.Metadata,
+		Plaintext: req.Plaintext,
+		Status:    grpcStatusName(callErr),
+		LatencyMs: tookMs,
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	}
+	if result != nil {
+		entry.Response = result.Response
+		entry.Headers = result.Headers
+		entry.Trailers = result.Trailers
+	}
+
+	if _, err := h.history.Add(entry); err != nil {
+		fmt.Printf("[History] Failed to record call for session %s: %v\n", sessionID, err)
+	}
+}
+
+// grpcStatusName returns the gRPC status code name for an error ("OK" for nil)
+func grpcStatusName(err error) string {
+	return status.Code(err).String()
+}
+
+// errorKindCode maps a call error kind to the code of its grpc://error event
+func errorKindCode(kind string) apierror.Code {
+	switch kind {
+	case "plaintext_forbidden":
+		return apierror.PlaintextForbidden
+	case "target_denied":
+		return apierror.TargetDenied
+	case "unknown_service":
+		return apierror.ServiceNotFound
+	case "unknown_method":
+		return apierror.MethodNotFound
+	case "dial_failure":
+		return apierror.TargetUnreachable
+	case "timeout":
+		return apierror.Timeout
+	case "permission_denied":
+		return apierror.PermissionDenied
+	case "unauthenticated":
+		return apierror.Unauthenticated
+	case "unavailable":
+		return apierror.Unavailable
+	case "script_error":
+		return apierror.ScriptFailed
+	default:
+		return apierror.CallFailed
+	}
+}
+
 func classifyGRPCErrorKind(msg string) string {
 	lowered := strings.ToLower(msg)
 	switch {
+	case strings.Contains(lowered, "plaintext connections are disabled"):
+		return "plaintext_forbidden"
+	case strings.Contains(lowered, "is not allowed: "):
+		return "target_denied"
 	case strings.Contains(lowered, "unknown service"):
 		return "unknown_service"
 	case strings.Contains(lowered, "unknown method"):
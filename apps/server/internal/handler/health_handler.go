@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds every readiness check
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck verifies one dependency; a non-nil error makes the server unready
+type HealthCheck func(ctx context.Context) error
+
+// HealthHandler serves liveness and readiness probes. Liveness only reports
+// that the process answers; readiness runs the registered dependency checks.
+type HealthHandler struct {
+	version  string
+	checks   map[string]HealthCheck
+	mu       sync.RWMutex
+	draining atomic.Bool
+}
+
+// NewHealthHandler creates a health handler without checks
+func NewHealthHandler(version string) *HealthHandler {
+	return &HealthHandler{
+		version: version,
+		checks:  make(map[string]HealthCheck),
+	}
+}
+
+// AddCheck registers a readiness check under name, replacing any with that name
+func (h *HealthHandler) AddCheck(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// Drain makes readiness fail from now on, so orchestrators stop routing
+// traffic while the server shuts down
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// CheckResult is the outcome of one readiness check
+type CheckResult struct {
+	Status string `json:"status"` // "ok" or "failed"
+	Error  string `json:"error,omitempty"`
+	TookMs int64  `json:"took_ms"`
+}
+
+// Live answers as long as the process serves requests
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"service": "grpc-bridge-web-api",
+		"version": h.version,
+	})
+}
+
+// Ready runs every check concurrently and answers 503 when one fails or the
+// server is draining
+func (h *HealthHandler) Ready(c *gin.Context) {
+	h.mu.RLock()
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	checks := make([]HealthCheck, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			results[i] = CheckResult{Status: "ok", TookMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				results[i].Status = "failed"
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := false
+	byName := make(map[string]CheckResult, len(names))
+	for i, name := range names {
+		byName[name] = results[i]
+		failed = failed || results[i].Status != "ok"
+	}
+
+	status, code := "ready", http.StatusOK
+	if h.draining.Load() {
+		status, code = "draining", http.StatusServiceUnavailable
+	} else if failed {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":  status,
+		"version": h.version,
+		"checks":  byName,
+	})
+}
+
+// CheckWritableDir returns a check that creates and removes a file in dir
+func CheckWritableDir(dir string) HealthCheck {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".health-*")
+		if err != nil {
+			return fmt.Errorf("%s is not writable: %w", dir, err)
+		}
+		f.Close()
+		return os.Remove(f.Name())
+	}
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/history"
+	"github.com/grpc-bridge/server/internal/secret"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+)
+
+// HistoryHandler exposes the per-session call history
+type HistoryHandler struct {
+	sessionManager *session.Manager
+	store          history.Store
+	version        string // Reported as the creator of exported archives
+}
+
+// NewHistoryHandler creates a new history handler
+func NewHistoryHandler(sm *session.Manager, store history.Store, version string) *HistoryHandler {
+	return &HistoryHandler{
+		sessionManager: sm,
+		store:          store,
+		version:        version,
+	}
+}
+
+// ListHistory returns recorded calls, newest first.
+// Query params: service, method, status (code name, "ok" or "error"), offset, limit.
+func (h *HistoryHandler) ListHistory(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHistoryLimit)))
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := h.store.List(sessionID, history.Filter{
+		Service: c.Query("service"),
+		Method:  c.Query("method"),
+		Status:  c.Query("status"),
+		Offset:  offset,
+		Limit:   limit,
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to list history: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"entries":    redactEntries(entries),
+		"total":      total,
+		"offset":     offset,
+		"limit":      limit,
+	})
+}
+
+// GetHistoryEntry returns a single recorded call
+func (h *HistoryHandler) GetHistoryEntry(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	entry, err := h.store.Get(sessionID, c.Param("historyId"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entry": redactEntry(entry),
+	})
+}
+
+// ExportHistory downloads the recorded calls of a session, oldest first, as
+// newline-delimited JSON (?format=jsonl, the default) or an HTTP Archive
+// (?format=har). The service, method and status filters of ListHistory apply.
+func (h *HistoryHandler) ExportHistory(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	format := c.DefaultQuery("format", history.FormatJSONL)
+	if format != history.FormatJSONL && format != history.FormatHAR {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "format must be jsonl or har")
+		return
+	}
+
+	entries, _, err := h.store.List(sessionID, history.Filter{
+		Service: c.Query("service"),
+		Method:  c.Query("method"),
+		Status:  c.Query("status"),
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to list history: "+err.Error())
+		return
+	}
+	entries = redactEntries(entries)
+	slices.Reverse(entries)
+
+	shortID := sessionID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="session-%s-calls.%s"`, shortID, format))
+
+	if format == history.FormatHAR {
+		c.JSON(http.StatusOK, history.ToHAR(entries, h.version))
+		return
+	}
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	if err := history.WriteJSONL(c.Writer, entries); err != nil {
+		c.Error(err)
+	}
+}
+
+// DeleteHistoryEntry removes a single recorded call
+func (h *HistoryHandler) DeleteHistoryEntry(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	if err := h.store.Delete(sessionID, c.Param("historyId")); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "history entry deleted",
+	})
+}
+
+// ClearHistory removes all recorded calls of a session
+func (h *HistoryHandler) ClearHistory(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	if err := h.store.Clear(sessionID); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to clear history: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "history cleared",
+	})
+}
+
+// redactEntry returns a copy of a history entry with sensitive metadata redacted
+func redactEntry(e *history.Entry) *history.Entry {
+	out := *e
+	out.Metadata = secret.RedactMap(e.Metadata)
+	return &out
+}
+
+// redactEntries returns redacted copies of history entries
+func redactEntries(entries []*history.Entry) []*history.Entry {
+	out := make([]*history.Entry, len(entries))
+	for i, e := range entries {
+		out[i] = redactEntry(e)
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// ImportMappingHandler exposes the per-session import path mappings used to
+// resolve imports of vendored or nested proto trees
+type ImportMappingHandler struct {
+	sessionManager *session.Manager
+}
+
+// NewImportMappingHandler creates a new import mapping handler
+func NewImportMappingHandler(sm *session.Manager) *ImportMappingHandler {
+	return &ImportMappingHandler{
+		sessionManager: sm,
+	}
+}
+
+// ReplaceImportMappingsRequest represents the body for replacing all mappings
+type ReplaceImportMappingsRequest struct {
+	Mappings []session.ImportMapping `json:"mappings"`
+}
+
+// ListImportMappings returns the import mappings of a session
+func (h *ImportMappingHandler) ListImportMappings(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"mappings":   sess.ImportMappings,
+	})
+}
+
+// ReplaceImportMappings replaces the full mapping set of a session. Cached
+// descriptors and parsed services are dropped so the next call recompiles.
+func (h *ImportMappingHandler) ReplaceImportMappings(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	var req ReplaceImportMappingsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	mappings, err := proto.NormalizeImportMappings(req.Mappings)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	if err := h.sessionManager.SetImportMappings(sessionID, mappings); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"mappings":   mappings,
+	})
+}
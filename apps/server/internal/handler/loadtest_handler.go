@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/loadtest"
+)
+
+// LoadTestRequest describes a load test against a single method
+type LoadTestRequest struct {
+	CallRequest
+	Total       int     `json:"total"`       // Number of requests (default 200 when no duration is set)
+	Concurrency int     `json:"concurrency"` // Concurrent workers (default 10, max 200)
+	RPS         float64 `json:"rps"`         // Overall rate limit, 0 = unlimited (max 10000)
+	DurationMs  int     `json:"duration_ms"` // Upper bound on run time (max 5 minutes)
+	TimeoutMs   int     `json:"timeout_ms"`  // Per-request timeout (default 10s)
+}
+
+// preparer is implemented by clients that can reuse a connection across invocations
+type preparer interface {
+	Prepare(opts grpc.NativeCallOptions) (*grpc.PreparedCall, error)
+}
+
+// LoadTest fires many requests at a method and returns latency/throughput statistics.
+// Progress is streamed as loadtest://progress events.
+func (h *GRPCHandler) LoadTest(c *gin.Context) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session ID required in X-Session-ID header")
+		return
+	}
+
+	session, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	var req LoadTestRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.RPS < 0 || req.RPS > loadtest.MaxRPS {
+		apierror.RespondError(c, http.StatusBadRequest, apierror.Invalid(apierror.FieldError{
+			Field: "rps", Rule: "max", Message: fmt.Sprintf("rps must be between 0 and %d", loadtest.MaxRPS),
+		}), apierror.ValidationFailed)
+		return
+	}
+
+	if err := h.resolveTemplates(sessionID, &req.CallRequest); err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, err, apierror.TemplateError)
+		return
+	}
+	auditCall(c, &req.CallRequest)
+
+	cfg := loadtest.Config{
+		Total:       req.Total,
+		Concurrency: req.Concurrency,
+		RPS:         req.RPS,
+		Duration:    time.Duration(req.DurationMs) * time.Millisecond,
+	}.Normalize()
+	perRequestTimeout := 10 * time.Second
+	if req.TimeoutMs > 0 {
+		perRequestTimeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+
+	protoFiles := make([]string, len(session.ProtoFiles))
+	for i, pf := range session.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+	opts := grpc.NativeCallOptions{
+		SessionID:      sessionID,
+		SessionRoot:    session.RootPath,
+		ProtoFiles:     protoFiles,
+		Target:         req.Target,
+		Service:        req.Service,
+		Method:         req.Method,
+		Data:           req.Data,
+		Metadata:       req.Metadata,
+		Plaintext:      req.Plaintext,
+		Timeout:        perRequestTimeout,
+		DescriptorSets: session.DescriptorSetPaths(),
+		ImportMappings: session.ImportMappings,
+		TLS:            req.TLS,
+	}
+
+	// Prefer a prepared call sharing one connection; fall back to full calls otherwise
+	invoke := func(ctx context.Context) error {
+		_, err := h.client.Call(ctx, opts)
+		return err
+	}
+	if p, ok := h.client.(preparer); ok {
+		prepared, err := p.Prepare(opts)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "failed to prepare call: "+err.Error())
+			return
+		}
+		defer prepared.Close()
+		invoke = func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, perRequestTimeout)
+			defer cancel()
+			return prepared.Invoke(ctx)
+		}
+	}
+
+	ctx, done := h.sessionManager.BeginOperation(c.Request.Context(), sessionID)
+	defer done()
+
+	testID := uuid.New().String()
+	h.wsHub.EmitToSession(sessionID, LoadTestStart{
+		TestID:      testID,
+		Service:     req.Service,
+		Method:      req.Method,
+		Total:       cfg.Total,
+		Concurrency: cfg.Concurrency,
+		RPS:         cfg.RPS,
+		DurationMs:  cfg.Duration.Milliseconds(),
+	})
+
+	summary := loadtest.Run(ctx, cfg, invoke, func(p loadtest.Progress) {
+		h.wsHub.EmitToSession(sessionID, LoadTestProgress{
+			TestID:   testID,
+			Progress: p,
+		})
+	})
+
+	h.wsHub.EmitToSession(sessionID, LoadTestDone{
+		TestID:  testID,
+		Summary: summary,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"test_id": testID,
+		"summary": summary,
+	})
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/secret"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// MetadataHandler exposes CRUD endpoints for session-level default metadata
+// that is merged into every call
+type MetadataHandler struct {
+	sessionManager *session.Manager
+}
+
+// NewMetadataHandler creates a new default metadata handler
+func NewMetadataHandler(sm *session.Manager) *MetadataHandler {
+	return &MetadataHandler{
+		sessionManager: sm,
+	}
+}
+
+// ReplaceMetadataRequest represents the body for replacing all default metadata
+type ReplaceMetadataRequest struct {
+	Metadata map[string]string `json:"metadata"`
+}
+
+// SetMetadataRequest represents the body for setting a single metadata entry
+type SetMetadataRequest struct {
+	Value string `json:"value"`
+}
+
+// ListMetadata returns the default metadata of a session
+func (h *MetadataHandler) ListMetadata(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	md, err := h.sessionManager.GetDefaultMetadata(sessionID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"metadata":   secret.RedactMap(md),
+	})
+}
+
+// ReplaceMetadata replaces the full default metadata of a session
+func (h *MetadataHandler) ReplaceMetadata(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	var req ReplaceMetadataRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	md := make(map[string]string, len(req.Metadata))
+	for key, value := range req.Metadata {
+		normalized, ok := normalizeMetadataKey(key)
+		if !ok {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid metadata key: "+key)
+			return
+		}
+		md[normalized] = value
+	}
+
+	if err := h.sessionManager.ReplaceDefaultMetadata(sessionID, md); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"metadata":   secret.RedactMap(md),
+	})
+}
+
+// SetMetadata creates or updates a single default metadata entry
+func (h *MetadataHandler) SetMetadata(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	key, ok := normalizeMetadataKey(c.Param("key"))
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid metadata key: "+c.Param("key"))
+		return
+	}
+
+	var req SetMetadataRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.sessionManager.SetDefaultMetadata(sessionID, key, req.Value); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":   key,
+		"value": secret.RedactValue(key, req.Value),
+	})
+}
+
+// DeleteMetadata removes a single default metadata entry
+func (h *MetadataHandler) DeleteMetadata(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	key, _ := normalizeMetadataKey(c.Param("key"))
+
+	if err := h.sessionManager.DeleteDefaultMetadata(sessionID, key); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "metadata deleted",
+	})
+}
+
+// normalizeMetadataKey lower-cases a metadata key (gRPC metadata keys are
+// case-insensitive) and reports whether it is a valid header name
+func normalizeMetadataKey(key string) (string, bool) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if key == "" || strings.HasPrefix(key, "grpc-") {
+		return key, false
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case strings.ContainsRune("_-.", r):
+		default:
+			return key, false
+		}
+	}
+	return key, true
+}
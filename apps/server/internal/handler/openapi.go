@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+)
+
+// GenerateOpenAPI renders an OpenAPI 3.0 document for the session's
+// google.api.http annotated methods as JSON (default) or YAML. With
+// download=true the document is sent as an attachment.
+func (h *SchemaHandler) GenerateOpenAPI(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "yaml" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "format must be \"json\" or \"yaml\"")
+		return
+	}
+
+	files, ok := h.loadDescriptors(c)
+	if !ok {
+		return
+	}
+
+	title := c.Query("title")
+	if title == "" {
+		title = "gRPC Bridge API"
+		if sess, exists := h.sessionManager.Get(c.Param("sessionId")); exists && sess.Name != "" {
+			title = sess.Name
+		}
+	}
+	doc := grpc.GenerateOpenAPI(files, title, c.DefaultQuery("version", "1.0.0"))
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to encode document: "+err.Error())
+		return
+	}
+	contentType := "application/json"
+	if format == "yaml" {
+		// Converting from JSON keeps the document's key order
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to encode document: "+err.Error())
+			return
+		}
+		contentType = "application/yaml"
+	}
+
+	if c.Query("download") == "true" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="openapi.%s"`, format))
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// OrphanHandler lets an operator reconcile upload directories that have no
+// session, the same way the server does on boot
+type OrphanHandler struct {
+	sessionManager *session.Manager
+	options        session.OrphanOptions
+}
+
+// NewOrphanHandler creates a new orphan reconciliation handler
+func NewOrphanHandler(sm *session.Manager, opts session.OrphanOptions) *OrphanHandler {
+	return &OrphanHandler{
+		sessionManager: sm,
+		options:        opts,
+	}
+}
+
+// Reconcile adopts or removes orphaned upload directories and returns the summary
+func (h *OrphanHandler) Reconcile(c *gin.Context) {
+	report := h.sessionManager.ReconcileOrphans(h.options)
+	c.JSON(http.StatusOK, report)
+}
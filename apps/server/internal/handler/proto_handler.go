@@ -1,14 +1,21 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/archive"
+	"github.com/grpc-bridge/server/internal/grpc"
 	"github.com/grpc-bridge/server/internal/proto"
 	"github.com/grpc-bridge/server/internal/session"
 	"github.com/grpc-bridge/server/internal/websocket"
@@ -19,66 +26,83 @@ type ProtoHandler struct {
 	hub            *websocket.Hub
 	uploadDir      string
 	stdlibManager  *proto.StdlibManager
+	importFetcher  *proto.ImportFetcher
+	limits         UploadLimits
 }
 
-func NewProtoHandler(sm *session.Manager, hub *websocket.Hub, uploadDir string) *ProtoHandler {
+func NewProtoHandler(sm *session.Manager, hub *websocket.Hub, uploadDir string, stdlib *proto.StdlibManager, fetcher *proto.ImportFetcher) *ProtoHandler {
 	return &ProtoHandler{
 		sessionManager: sm,
 		hub:            hub,
 		uploadDir:      uploadDir,
-		stdlibManager:  proto.NewStdlibManager(),
+		stdlibManager:  stdlib,
+		importFetcher:  fetcher,
+		limits: UploadLimits{
+			MaxFileSize:    DefaultMaxUploadFileSize,
+			MaxRequestSize: DefaultMaxUploadRequestSize,
+		},
 	}
 }
 
+// SetUploadLimits changes the upload size limits
+func (h *ProtoHandler) SetUploadLimits(limits UploadLimits) {
+	h.limits = limits
+}
+
+// UploadLimits returns the upload size limits
+func (h *ProtoHandler) UploadLimits() UploadLimits {
+	return h.limits
+}
+
 // UploadStructureRequest represents the upload request
 type UploadStructureRequest struct {
 	SessionID string `form:"sessionId" binding:"required"`
+	Mode      string `form:"mode"` // "replace" (default) or "merge"
 }
 
 // UploadStructure handles directory structure upload with webkitdirectory
 func (h *ProtoHandler) UploadStructure(c *gin.Context) {
 	var req UploadStructureRequest
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "session_id is required",
-		})
+		if bodyTooLarge(c, err) {
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session_id is required")
 		return
 	}
 
 	// Verify session exists
 	sess, exists := h.sessionManager.Get(req.SessionID)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "session not found",
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	mode, ok := parseUploadMode(req.Mode)
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "mode must be \"replace\" or \"merge\"")
 		return
 	}
 
 	// Emit start event
-	h.hub.EmitToSession(req.SessionID, "proto://upload_start", gin.H{
-		"session_id": req.SessionID,
+	h.hub.EmitToSession(req.SessionID, UploadStart{
+		SessionID: req.SessionID,
 	})
 
 	// Get multipart form
 	form, err := c.MultipartForm()
 	if err != nil {
-		h.hub.EmitToSession(req.SessionID, "proto://upload_error", gin.H{
-			"error": "failed to parse multipart form",
-		})
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "failed to parse multipart form",
-		})
+		h.hub.EmitToSession(req.SessionID, multipartFailure(c, err))
 		return
 	}
 
 	files := form.File["files"]
 	if len(files) == 0 {
-		h.hub.EmitToSession(req.SessionID, "proto://upload_error", gin.H{
-			"error": "no files provided",
-		})
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "no files provided",
+		h.hub.EmitToSession(req.SessionID, UploadError{
+			Code:  apierror.InvalidRequest,
+			Error: "no files provided",
 		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "no files provided")
 		return
 	}
 
@@ -92,61 +116,37 @@ func (h *ProtoHandler) UploadStructure(c *gin.Context) {
 		clientStripped = true
 	}
 
-	// Replace strategy: for one session, keep only the latest uploaded proto set.
-	// Remove existing session files first, then rebuild from the incoming upload.
-	sessionDir := filepath.Join(h.uploadDir, req.SessionID)
-	if err := os.RemoveAll(sessionDir); err != nil {
-		h.hub.EmitToSession(req.SessionID, "proto://upload_error", gin.H{
-			"error": "failed to clear previous uploaded files",
-		})
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to clear previous uploaded files",
-		})
-		return
-	}
-	if err := h.sessionManager.ResetUploadState(req.SessionID); err != nil {
-		h.hub.EmitToSession(req.SessionID, "proto://upload_error", gin.H{
-			"error": "failed to reset previous upload state",
-		})
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to reset previous upload state",
-		})
-		return
-	}
-
-	// Create session directory
-	if err := os.MkdirAll(sessionDir, 0755); err != nil {
-		h.hub.EmitToSession(req.SessionID, "proto://upload_error", gin.H{
-			"error": "failed to create session directory",
-		})
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to create session directory",
-		})
-		return
+	// Reject oversized files before the session directory is touched
+	if max := h.limits.MaxFileSize; max > 0 {
+		var oversized []string
+		for idx, fh := range files {
+			name := fh.Filename
+			if hasProvidedRelativePaths && strings.TrimSpace(providedRelativePaths[idx]) != "" {
+				name = strings.TrimSpace(providedRelativePaths[idx])
+			}
+			if fh.Size > max && strings.HasSuffix(strings.ToLower(name), ".proto") {
+				oversized = append(oversized, name)
+			}
+		}
+		if len(oversized) > 0 {
+			msg := fmt.Sprintf("%d file(s) exceed the maximum size of %d bytes", len(oversized), max)
+			h.hub.EmitToSession(req.SessionID, UploadError{
+				Code:  apierror.PayloadTooLarge,
+				Error: msg,
+			})
+			apierror.RespondDetails(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, msg, gin.H{
+				"oversized":     oversized,
+				"max_file_size": max,
+			})
+			return
+		}
 	}
-	fmt.Printf("[UploadStructure] Created/ensured session root dir: %s\n", sessionDir)
 
-	// Set root path
-	if err := h.sessionManager.SetRootPath(req.SessionID, sessionDir); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to set root path",
-		})
+	batch, ok := h.beginUpload(c, sess, mode)
+	if !ok {
 		return
 	}
 
-	// Copy standard library files to session directory
-	fmt.Printf("[ProtoHandler] Copying stdlib to session: %s\n", sessionDir)
-	if err := h.stdlibManager.CopyToSession(sessionDir); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("[ProtoHandler] Warning: failed to copy stdlib to session: %v\n", err)
-	} else {
-		fmt.Printf("[ProtoHandler] Successfully copied stdlib to session\n")
-	}
-
-	uploadedFiles := []session.ProtoFile{}
-	errorFiles := []string{}
-	dirSet := map[string]struct{}{}
-
 	// Determine common leading directory prefix (root folder name chosen in browser)
 	// webkitRelativePath provides paths like: <rootFolder>/sub/dir/file.proto
 	// We want to strip the first segment so UI sees relative paths identical to desktop scan output.
@@ -156,7 +156,7 @@ func (h *ProtoHandler) UploadStructure(c *gin.Context) {
 		for _, fh := range files {
 			// Use original filename attribute as provided via FormData append (webkitRelativePath)
 			parts := strings.Split(fh.Filename, "/")
-			if len(parts) > 1 { // has a folder component
+			if len(parts) > 1 && parts[0] != "" && parts[0] != "." && parts[0] != ".." { // has a folder component
 				leadingPrefix = parts[0]
 				break
 			}
@@ -164,24 +164,19 @@ func (h *ProtoHandler) UploadStructure(c *gin.Context) {
 		fmt.Printf("[ProtoHandler] Inferred leading prefix: '%s'\n", leadingPrefix)
 	}
 
+	// Browser-supplied names are untrusted: absolute paths, drive letters and
+	// ".." segments are rejected rather than resolved
 	normalizeRelPath := func(p string) string {
-		// Ensure forward slashes
 		p = strings.ReplaceAll(p, "\\", "/")
-		// Strip leading ./ if present
 		p = strings.TrimPrefix(p, "./")
-		p = strings.TrimPrefix(p, "/")
 		if leadingPrefix != "" && strings.HasPrefix(p, leadingPrefix+"/") {
 			p = strings.TrimPrefix(p, leadingPrefix+"/")
 		}
-		// Normalize and block parent path traversal.
-		p = filepath.ToSlash(filepath.Clean(p))
-		if p == "." || p == "" {
-			return ""
-		}
-		if strings.HasPrefix(p, "../") || strings.Contains(p, "/../") {
+		clean, ok := archive.SanitizePath(p)
+		if !ok {
 			return ""
 		}
-		return p
+		return clean
 	}
 
 	// Single-pass: for each proto file create its directory (using relative path) then store the file
@@ -203,162 +198,201 @@ func (h *ProtoHandler) UploadStructure(c *gin.Context) {
 		fmt.Printf("originalPath: %s\n", originalPath)
 		fmt.Printf("relativePath: %s\n", relativePath)
 		if relativePath == "" {
-			errorFiles = append(errorFiles, originalPath)
+			batch.errorFiles = append(batch.errorFiles, originalPath)
 			continue
 		}
 		if !strings.HasSuffix(strings.ToLower(relativePath), ".proto") {
 			continue
 		}
 
-		// Directory metadata collection
-		relDirForMeta := filepath.Dir(relativePath)
-		if relDirForMeta != "." && relDirForMeta != "" {
-			parts := strings.Split(relDirForMeta, "/")
-			cur := ""
-			for i, p := range parts {
-				if i == 0 {
-					cur = p
-				} else {
-					cur = cur + "/" + p
-				}
-				if _, exists := dirSet[cur]; !exists {
-					dirSet[cur] = struct{}{}
-				}
-			}
-		}
-
-		// Ensure directory exists (mkdir based on relative path directory)
-		absPath := filepath.Join(sessionDir, relativePath)
-		absDir := filepath.Dir(absPath)
-		if err := os.MkdirAll(absDir, 0755); err != nil {
-			errorFiles = append(errorFiles, relativePath)
-			continue
-		}
-		relDirPrinted := filepath.Dir(relativePath)
-		if relDirPrinted == "." {
-			relDirPrinted = "(root)"
-		}
-		fmt.Printf("[UploadStructure] [session=%s] dir ok: %s -> %s (file=%s)\n", req.SessionID, relDirPrinted, absDir, relativePath)
-
-		// Save file content
 		src, err := fileHeader.Open()
 		if err != nil {
-			errorFiles = append(errorFiles, relativePath)
+			batch.errorFiles = append(batch.errorFiles, relativePath)
 			continue
 		}
-		dst, err := os.Create(absPath)
-		if err != nil {
-			src.Close()
-			errorFiles = append(errorFiles, relativePath)
-			continue
-		}
-		_, err = io.Copy(dst, src)
+		h.store(batch, relativePath, src)
 		src.Close()
-		dst.Close()
-		if err != nil {
-			errorFiles = append(errorFiles, relativePath)
-			continue
-		}
+	}
 
-		protoFile := session.ProtoFile{Name: filepath.Base(relativePath), RelativePath: relativePath, AbsolutePath: absPath, Size: fileHeader.Size}
-		fmt.Printf("[UploadStructure] Stored file: %s (size=%d)\n", protoFile.AbsolutePath, protoFile.Size)
-		uploadedFiles = append(uploadedFiles, protoFile)
-		if err := h.sessionManager.AddProtoFile(req.SessionID, protoFile); err != nil {
-			errorFiles = append(errorFiles, relativePath)
+	h.finishUpload(c, sess, batch, UploadSource{
+		StrippedPrefix: leadingPrefix,
+		ClientStripped: clientStripped,
+	})
+}
+
+// UploadArchive handles a single .zip / .tar.gz upload of a proto directory.
+// Only .proto files are extracted; unsafe paths are rejected and a single
+// common top-level directory is stripped, like directory uploads.
+func (h *ProtoHandler) UploadArchive(c *gin.Context) {
+	var req UploadStructureRequest
+	if err := c.ShouldBind(&req); err != nil {
+		if bodyTooLarge(c, err) {
+			return
 		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "session_id is required")
+		return
 	}
 
-	// Persist directory metadata into session
-	if len(dirSet) > 0 {
-		dirs := make([]session.ProtoDir, 0, len(dirSet))
-		for d := range dirSet {
-			absDir := filepath.Join(sessionDir, d)
-			dirs = append(dirs, session.ProtoDir{RelativePath: d, AbsolutePath: absDir})
-		}
-		if err := h.sessionManager.AddDirectories(req.SessionID, dirs); err != nil {
-			fmt.Printf("[ProtoHandler] Warning: failed to add directories: %v\n", err)
-		}
+	sess, exists := h.sessionManager.Get(req.SessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
 	}
 
-	// Prepare directory list for event
-	dirList := make([]string, 0, len(dirSet))
-	for d := range dirSet {
-		dirList = append(dirList, d)
+	mode, ok := parseUploadMode(req.Mode)
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "mode must be \"replace\" or \"merge\"")
+		return
 	}
 
-	// Build lightweight file descriptors for event (avoid leaking absolute paths unless needed)
-	eventFiles := make([]gin.H, 0, len(uploadedFiles))
-	for _, f := range uploadedFiles {
-		eventFiles = append(eventFiles, gin.H{
-			"name":          f.Name,
-			"relative_path": f.RelativePath,
-			"size":          f.Size,
-		})
+	fileHeader, err := c.FormFile("archive")
+	if err != nil {
+		if bodyTooLarge(c, err) {
+			return
+		}
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "archive file is required")
+		return
+	}
+	if fileHeader.Size > archive.MaxArchiveSize {
+		apierror.Respond(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("archive exceeds the maximum size of %d bytes", archive.MaxArchiveSize))
+		return
 	}
 
-	h.hub.EmitToSession(req.SessionID, "proto://upload_done", gin.H{
-		"session_id":      req.SessionID,
-		"uploaded_count":  len(uploadedFiles),
-		"error_count":     len(errorFiles),
-		"files":           eventFiles,
-		"directories":     dirList,
-		"normalized":      true,
-		"stripped_prefix": leadingPrefix,
-		"client_stripped": clientStripped,
+	h.hub.EmitToSession(req.SessionID, UploadStart{
+		SessionID: req.SessionID,
+		Archive:   fileHeader.Filename,
 	})
 
-	response := gin.H{
-		"session":         sess,
-		"uploaded_files":  uploadedFiles,
-		"uploaded_count":  len(uploadedFiles),
-		"directories":     dirList,
-		"client_stripped": clientStripped,
+	src, err := fileHeader.Open()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to open uploaded archive")
+		return
+	}
+	defer src.Close()
+
+	head := make([]byte, 4)
+	n, _ := src.ReadAt(head, 0)
+	format, err := archive.DetectFormat(fileHeader.Filename, head[:n])
+	if err == nil {
+		var extracted *archive.Result
+		extracted, err = archive.Extract(format, src, fileHeader.Size)
+		if err == nil {
+			h.storeArchive(c, sess, mode, extracted, fileHeader.Filename, format)
+			return
+		}
+	}
+
+	h.hub.EmitToSession(req.SessionID, UploadError{
+		Code:  apierror.InvalidRequest,
+		Error: err.Error(),
+	})
+	apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+}
+
+// storeArchive replaces the session's proto set with the extracted archive files
+func (h *ProtoHandler) storeArchive(c *gin.Context, sess *session.Session, mode string, extracted *archive.Result, filename string, format archive.Format) {
+	batch, ok := h.beginUpload(c, sess, mode)
+	if !ok {
+		return
 	}
 
-	if len(errorFiles) > 0 {
-		response["errors"] = errorFiles
-		response["error_count"] = len(errorFiles)
+	fmt.Printf("[UploadArchive] [session=%s] %s (%s): %d proto files, %d skipped, %d rejected\n",
+		sess.ID, filename, format, len(extracted.Files), len(extracted.Skipped), len(extracted.Rejected))
+	for _, f := range extracted.Files {
+		h.store(batch, f.Path, bytes.NewReader(f.Content))
 	}
+	batch.errorFiles = append(batch.errorFiles, extracted.Rejected...)
 
-	c.JSON(http.StatusOK, response)
+	h.finishUpload(c, sess, batch, UploadSource{
+		Archive:        filename,
+		Format:         string(format),
+		StrippedPrefix: extracted.StrippedPrefix,
+		SkippedCount:   len(extracted.Skipped),
+	})
 }
 
-// ListFiles returns all proto files in a session
+// ListFiles returns the proto files of a session.
+//
+// Query parameters: q (case-insensitive path substring), dir (only files
+// below a directory), sort (path, name or size), order (asc or desc), offset
+// and limit (default: every file, max 1000). With view=tree the matching
+// files are nested in a directory tree below dir, depth levels deep (default:
+// all), instead of a flat page.
 func (h *ProtoHandler) ListFiles(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
 	sess, exists := h.sessionManager.Get(sessionID)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "session not found",
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	query, err := parseFileListQuery(c)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+	matched := query.selectFiles(sess.ProtoFiles)
+
+	if query.Tree {
+		c.JSON(http.StatusOK, gin.H{
+			"session_id": sessionID,
+			"tree":       buildFileTree(query.Dir, matched, query.Depth),
+			"total":      len(matched),
 		})
 		return
 	}
 
+	// Only the files of the page are read for their hash and syntax
+	page := query.page(matched)
+	files := make([]listedProtoFile, len(page))
+	for i, pf := range page {
+		files[i] = listedProtoFile{ProtoFile: pf}
+		if pf.SHA256 == "" {
+			if content, err := os.ReadFile(pf.AbsolutePath); err == nil {
+				files[i].SHA256 = session.ContentHash(content)
+			}
+		}
+		if syntax, edition, err := proto.FileSyntax(pf.AbsolutePath); err == nil {
+			files[i].Syntax = syntax
+			files[i].Edition = edition
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"session_id": sessionID,
-		"files":      sess.ProtoFiles,
-		"count":      len(sess.ProtoFiles),
+		"files":      files,
+		"count":      len(files),
+		"total":      len(matched),
+		"offset":     query.Offset,
+		"limit":      query.Limit,
 	})
 }
 
-// GetFileContent returns the content of a specific proto file
+// listedProtoFile is a session proto file with its declared syntax or edition
+type listedProtoFile struct {
+	session.ProtoFile
+	Syntax  string `json:"syntax,omitempty"`  // proto2, proto3 or editions
+	Edition string `json:"edition,omitempty"` // Set for editions files, e.g. "2023"
+}
+
+// GetFileContent returns the content of a specific proto file. Responses carry
+// the file's ETag and requests naming it in If-None-Match get 304. Large files
+// can be read in parts: with raw=true the file is sent as text and Range
+// requests are served, otherwise offset and limit select a byte range of the
+// JSON content.
 func (h *ProtoHandler) GetFileContent(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 	relativePath := c.Query("file")
 
 	if relativePath == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "file parameter is required",
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "file parameter is required")
 		return
 	}
 
 	sess, exists := h.sessionManager.Get(sessionID)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "session not found",
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
 		return
 	}
 
@@ -372,25 +406,344 @@ func (h *ProtoHandler) GetFileContent(c *gin.Context) {
 	}
 
 	if targetFile == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": fmt.Sprintf("file not found: %s", relativePath),
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound, fmt.Sprintf("file not found: %s", relativePath))
 		return
 	}
 
-	// Read file content
-	content, err := os.ReadFile(targetFile.AbsolutePath)
+	offset, limit, err := byteRange(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to read file",
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	etag, content, err := fileETag(targetFile)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to read file")
+		return
+	}
+
+	// Raw content goes through http.ServeContent, which answers Range,
+	// If-Range and If-None-Match against the ETag set here
+	if c.Query("raw") == "true" {
+		f, err := os.Open(targetFile.AbsolutePath)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to read file")
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to read file")
+			return
+		}
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		http.ServeContent(c.Writer, c.Request, filepath.Base(targetFile.RelativePath), info.ModTime(), f)
+		return
+	}
+
+	if notModified(c, etag) {
+		return
+	}
+	if content == nil {
+		if content, err = os.ReadFile(targetFile.AbsolutePath); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to read file")
+			return
+		}
+	}
+
+	// The page is kept on character boundaries; offset and next_offset
+	// report where it really lies
+	size := int64(len(content))
+	start, end := textPage(content, offset, limit)
+
+	response := gin.H{
+		"file":      targetFile,
+		"content":   string(content[start:end]),
+		"offset":    start,
+		"size":      size,
+		"truncated": end < size,
+	}
+	if end < size {
+		response["next_offset"] = end
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// byteRange reads the offset and limit query parameters of a partial content
+// read; a limit of 0 reads to the end
+func byteRange(c *gin.Context) (offset, limit int64, err error) {
+	for name, target := range map[string]*int64{"offset": &offset, "limit": &limit} {
+		if raw := c.Query(name); raw != "" {
+			n, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || n < 0 {
+				return 0, 0, fmt.Errorf("%s must be a non-negative integer", name)
+			}
+			*target = n
+		}
+	}
+	return offset, limit, nil
+}
+
+// ValidateSession compiles every proto file in the session and returns
+// structured diagnostics (file, line, column, message)
+func (h *ProtoHandler) ValidateSession(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	if sess.RootPath == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.NoProtoFiles, "no files uploaded for this session")
+		return
+	}
+
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+
+	result := proto.NewValidator().Validate(sess.RootPath, protoFiles)
+
+	h.hub.EmitToSession(sessionID, Validated{
+		SessionID:    sessionID,
+		Valid:        result.Valid,
+		ErrorCount:   result.ErrorCount,
+		WarningCount: result.WarningCount,
+	})
+
+	c.JSON(http.StatusOK, result)
+}
+
+// LintSession checks session protos against buf-style rules. Rules can be
+// disabled with ?except=RULE_ID,RULE_ID.
+func (h *ProtoHandler) LintSession(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	if sess.RootPath == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.NoProtoFiles, "no files uploaded for this session")
+		return
+	}
+
+	var except []string
+	if raw := c.Query("except"); raw != "" {
+		except = strings.Split(raw, ",")
+	}
+
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+
+	issues, err := proto.NewLinter(except).Lint(sess.RootPath, protoFiles)
+	if err != nil {
+		// Lint needs compilable sources; /validate reports the details
+		apierror.Respond(c, http.StatusUnprocessableEntity, apierror.ParseError, err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"file":    targetFile,
-		"content": string(content),
+		"session_id":  sessionID,
+		"issues":      issues,
+		"issue_count": len(issues),
+		"rules":       proto.LintRules,
+	})
+}
+
+// DetectBreakingChanges diffs the current protos against the snapshot taken
+// before the most recent upload and flags wire- and source-breaking changes
+func (h *ProtoHandler) DetectBreakingChanges(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	if sess.RootPath == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.NoProtoFiles, "no files uploaded for this session")
+		return
+	}
+
+	snapshotPath := filepath.Join(sess.RootPath, previousSnapshotPath)
+	info, err := os.Stat(snapshotPath)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound, "no previous snapshot; re-upload the protos to create one")
+		return
+	}
+	previous, err := proto.LoadDescriptorSet(snapshotPath)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to load previous snapshot: "+err.Error())
+		return
+	}
+
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+	data, err := proto.CompileSnapshot(sess.RootPath, protoFiles)
+	if err != nil {
+		apierror.Respond(c, http.StatusUnprocessableEntity, apierror.ParseError, "failed to compile current protos: "+err.Error())
+		return
+	}
+	_, current, err := proto.ParseDescriptorSet(data)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.ParseError, err.Error())
+		return
+	}
+
+	changes := proto.DiffSnapshots(previous, current)
+	wireBreaking, sourceBreaking := 0, 0
+	for _, ch := range changes {
+		if ch.Wire {
+			wireBreaking++
+		}
+		if ch.Source {
+			sourceBreaking++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id":           sessionID,
+		"previous_snapshot_at": info.ModTime(),
+		"breaking":             len(changes) > 0,
+		"wire_breaking":        wireBreaking,
+		"source_breaking":      sourceBreaking,
+		"changes":              changes,
+	})
+}
+
+// maxEditableFileSize limits proto sources written through the editor API
+const maxEditableFileSize = 4 << 20 // 4MB
+
+// UpdateFileContentRequest represents an edited proto source
+type UpdateFileContentRequest struct {
+	File    string `json:"file" binding:"required"` // Relative path within the session
+	Content string `json:"content"`
+}
+
+// UpdateFileContent writes edited proto source back into the session directory.
+// New .proto files are created when the path does not exist yet. Cached
+// descriptors are invalidated and dependency analysis is re-run.
+func (h *ProtoHandler) UpdateFileContent(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	var req UpdateFileContentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	if sess.RootPath == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.NoProtoFiles, "no files uploaded for this session")
+		return
+	}
+
+	relativePath, ok := archive.SanitizePath(req.File)
+	if !ok || !strings.HasSuffix(strings.ToLower(relativePath), ".proto") {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("invalid proto file path: %s", req.File))
+		return
+	}
+	if len(req.Content) > maxEditableFileSize {
+		apierror.Respond(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("file exceeds the maximum size of %d bytes", maxEditableFileSize))
+		return
+	}
+
+	if err := h.sessionManager.CheckProtoFile(sessionID, relativePath, int64(len(req.Content))); err != nil {
+		if qe, ok := asQuotaError(err); ok {
+			c.JSON(http.StatusRequestEntityTooLarge, quotaExceeded(c, qe))
+			return
+		}
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	absPath, ok := sessionFilePath(sess.RootPath, relativePath)
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("invalid proto file path: %s", req.File))
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to create directory")
+		return
+	}
+
+	// Write to a temp file first so readers never observe a half-written source
+	tmpPath := absPath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(req.Content), 0644); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to write file")
+		return
+	}
+	if err := os.Rename(tmpPath, absPath); err != nil {
+		os.Remove(tmpPath)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to write file")
+		return
+	}
+
+	file := session.ProtoFile{
+		Name:         filepath.Base(relativePath),
+		RelativePath: relativePath,
+		AbsolutePath: absPath,
+		Size:         int64(len(req.Content)),
+		SHA256:       session.ContentHash([]byte(req.Content)),
+	}
+	created, err := h.sessionManager.UpsertProtoFile(sessionID, file)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+	if created {
+		dirs := []session.ProtoDir{}
+		for dir := path.Dir(relativePath); dir != "."; dir = path.Dir(dir) {
+			dirs = append(dirs, session.ProtoDir{
+				RelativePath: dir,
+				AbsolutePath: filepath.Join(sess.RootPath, filepath.FromSlash(dir)),
+			})
+		}
+		if len(dirs) > 0 {
+			_ = h.sessionManager.AddDirectories(sessionID, dirs)
+		}
+	}
+	fmt.Printf("[UpdateFileContent] [session=%s] wrote %s (%d bytes, created=%v)\n", sessionID, absPath, file.Size, created)
+
+	h.hub.EmitToSession(sessionID, FileUpdated{
+		SessionID:    sessionID,
+		RelativePath: relativePath,
+		Size:         file.Size,
+		Created:      created,
 	})
+
+	response := gin.H{
+		"file":    file,
+		"created": created,
+	}
+
+	// Re-run dependency analysis against the edited tree
+	if updated, ok := h.sessionManager.Get(sessionID); ok {
+		if analysis, err := h.analyze(updated); err == nil {
+			response["analysis"] = analysis
+		} else {
+			response["analysis_error"] = err.Error()
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // AnalyzeDependencies analyzes proto file imports and dependencies
@@ -399,36 +752,119 @@ func (h *ProtoHandler) AnalyzeDependencies(c *gin.Context) {
 
 	sess, exists := h.sessionManager.Get(sessionID)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "session not found",
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
 		return
 	}
 
 	if sess.RootPath == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "no files uploaded for this session",
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.NoProtoFiles, "no files uploaded for this session")
+		return
+	}
+
+	analysis, err := h.analyze(sess)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, err.Error())
 		return
 	}
 
+	// Optionally install missing well-known imports and analyze again. They
+	// are stored like a merge upload, so they count against the session quota.
+	status := http.StatusOK
+	if c.Query("fetch_missing") == "true" {
+		missing := missingImportPaths(analysis)
+		if len(missing) > 0 {
+			batch, ok := h.beginUpload(c, sess, uploadModeMerge)
+			if !ok {
+				return
+			}
+			fetched := h.importFetcher.FetchMissing(c.Request.Context(), sess.RootPath, missing, func(relativePath string, content []byte) error {
+				stored := len(batch.files)
+				h.store(batch, relativePath, bytes.NewReader(content))
+				if batch.quotaErr != nil {
+					return batch.quotaErr
+				}
+				if len(batch.files) == stored {
+					return errors.New("failed to store file")
+				}
+				return nil
+			})
+			h.saveDirectories(batch)
+			if len(fetched.Fetched) > 0 {
+				if current, ok := h.sessionManager.Get(sessionID); ok {
+					sess = current
+				}
+				if analysis, err = h.analyze(sess); err != nil {
+					apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, err.Error())
+					return
+				}
+			}
+			analysis["fetched_imports"] = fetched
+
+			// Imports fetched before the quota was hit are kept
+			if batch.quotaErr != nil {
+				for k, v := range quotaExceeded(c, batch.quotaErr) {
+					analysis[k] = v
+				}
+				status = http.StatusRequestEntityTooLarge
+			}
+		}
+	}
+
+	c.JSON(status, analysis)
+}
+
+// missingImportPaths collects the unresolved import paths of an analysis result
+func missingImportPaths(analysis gin.H) []string {
+	var paths []string
+	if missing, ok := analysis["missing_imports"].([]proto.ImportInfo); ok {
+		for _, imp := range missing {
+			paths = append(paths, imp.ImportPath)
+		}
+	}
+	if stdlib, ok := analysis["missing_stdlib"].([]string); ok {
+		paths = append(paths, stdlib...)
+	}
+	return paths
+}
+
+// analyze runs import/dependency analysis over the session root and emits the
+// proto://index_* events the desktop client expects
+func (h *ProtoHandler) analyze(sess *session.Session) (gin.H, error) {
+	sessionID := sess.ID
+
 	// Emit start event
-	h.hub.EmitToSession(sessionID, "proto://index_start", gin.H{
-		"session_id": sessionID,
+	h.hub.EmitToSession(sessionID, IndexStart{
+		SessionID: sessionID,
 	})
 
 	analyzer := proto.NewImportAnalyzer()
+	analyzer.SetSharedFiles(h.stdlibManager.SharedFiles())
+	analyzer.SetImportMappings(sess.ImportMappings)
+
+	// Report parsing progress, throttled like descriptor compilation
+	started := time.Now()
+	var lastProgress time.Time
+	analyzer.SetProgress(func(analyzed, total int) {
+		if analyzed > 1 && analyzed < total && time.Since(lastProgress) < grpc.ParseProgressInterval {
+			return
+		}
+		lastProgress = time.Now()
+		h.hub.EmitToSession(sessionID, ParseProgress{grpc.ParseProgress{
+			Stage:     grpc.ParseStageIndex,
+			Parsed:    analyzed,
+			Total:     total,
+			ElapsedMs: time.Since(started).Milliseconds(),
+		}})
+	})
 
 	// Analyze all imports
 	imports, err := analyzer.AnalyzeDirectory(sess.RootPath)
 	if err != nil {
-		h.hub.EmitToSession(sessionID, "proto://index_error", gin.H{
-			"error": fmt.Sprintf("failed to analyze imports: %v", err),
-		})
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to analyze imports: %v", err),
+		h.hub.EmitToSession(sessionID, IndexError{
+			Code:  apierror.ParseError,
+			Error: fmt.Sprintf("failed to analyze imports: %v", err),
 		})
-		return
+		return nil, fmt.Errorf("failed to analyze imports: %v", err)
 	}
 
 	// Resolve imports
@@ -439,6 +875,10 @@ func (h *ProtoHandler) AnalyzeDependencies(c *gin.Context) {
 
 	// Build dependency graph
 	depGraph := analyzer.BuildDependencyGraph(sess.RootPath, imports)
+	cycles := depGraph.FindCycles()
+	if len(cycles) > 0 {
+		fmt.Printf("[AnalyzeDependencies] Detected %d import cycle(s) in session %s\n", len(cycles), sessionID)
+	}
 
 	// Build file list (relative paths)
 	files := make([]string, 0, len(sess.ProtoFiles))
@@ -450,33 +890,33 @@ func (h *ProtoHandler) AnalyzeDependencies(c *gin.Context) {
 	fmt.Printf("[AnalyzeDependencies] Files: %v\n", files)
 
 	// Emit completion event (compatible with desktop proto://index_done)
-	h.hub.EmitToSession(sessionID, "proto://index_done", gin.H{
-		"rootId": sessionID,
-		"summary": gin.H{
-			"files":    len(files),
-			"services": 0, // Will be populated by listServices call
+	h.hub.EmitToSession(sessionID, IndexDone{
+		RootID: sessionID,
+		Summary: IndexSummary{
+			Files:    len(files),
+			Services: 0, // Will be populated by listServices call
 		},
-		"services": []interface{}{}, // Empty, client will call listServices
-		"files":    files,
+		Services: []any{}, // Empty, client will call listServices
+		Files:    files,
 	})
 
-	c.JSON(http.StatusOK, gin.H{
+	return gin.H{
 		"session_id":       sessionID,
 		"imports":          imports,
 		"missing_imports":  missingImports,
 		"missing_stdlib":   missingStdlib,
 		"dependency_graph": depGraph,
+		"cycles":           cycles,
+		"compile_order":    depGraph.TopologicalOrder(),
 		"files":            files,
-	})
+	}, nil
 }
 
 // ListStdlibFiles returns available standard library proto files
 func (h *ProtoHandler) ListStdlibFiles(c *gin.Context) {
 	files, err := h.stdlibManager.ListAvailableFiles()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to list stdlib files: %v", err),
-		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, fmt.Sprintf("failed to list stdlib files: %v", err))
 		return
 	}
 
@@ -490,17 +930,13 @@ func (h *ProtoHandler) ListStdlibFiles(c *gin.Context) {
 func (h *ProtoHandler) GetStdlibFileContent(c *gin.Context) {
 	filePath := c.Query("file")
 	if filePath == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "file parameter is required",
-		})
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "file parameter is required")
 		return
 	}
 
 	content, err := h.stdlibManager.GetFileContent(filePath)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
 		return
 	}
 
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// asQuotaError unwraps a session quota violation
+func asQuotaError(err error) (*session.QuotaError, bool) {
+	var qe *session.QuotaError
+	if errors.As(err, &qe) {
+		return qe, true
+	}
+	return nil, false
+}
+
+// quotaExceeded builds the error envelope returned with 413 when an upload
+// would take a session over its quota. Upload handlers merge it into their
+// response, next to the files that were stored before the limit was hit.
+func quotaExceeded(c *gin.Context, qe *session.QuotaError) gin.H {
+	body := apierror.New(c, apierror.QuotaExceeded, qe.Error(), gin.H{
+		"limit": qe.Limit,
+		"quota": qe.Quota,
+		"usage": qe.Usage,
+	})
+	return gin.H{
+		"error":      body.Error,
+		"code":       body.Code,
+		"message":    body.Message,
+		"details":    body.Details,
+		"request_id": body.RequestID,
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/session"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// SaveReflectionRequest represents a request to snapshot a target's reflection descriptors
+type SaveReflectionRequest struct {
+	SessionID string `json:"sessionId" binding:"required"`
+	Target    string `json:"target" binding:"required,grpc_target"`
+	Plaintext bool   `json:"plaintext"`
+	Name      string `json:"name"` // Descriptor set file name; derived from the target when empty
+}
+
+// SaveReflection downloads all descriptors from a reflection-enabled target and
+// stores them as a descriptor set in the session, so calls keep working when the
+// target later disables reflection or goes offline
+func (h *ProtoHandler) SaveReflection(c *gin.Context) {
+	var req SaveReflectionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if _, exists := h.sessionManager.Get(req.SessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	ctx, done := h.sessionManager.BeginOperation(c.Request.Context(), req.SessionID)
+	defer done()
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	set, services, err := grpc.FetchDescriptorSet(ctx, req.Target, req.Plaintext)
+	if grpc.IsTargetDenied(err) {
+		apierror.Respond(c, http.StatusForbidden, apierror.TargetDenied, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusBadGateway, apierror.TargetUnreachable, err.Error())
+		return
+	}
+
+	data, err := gproto.Marshal(set)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to encode descriptor set: "+err.Error())
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = reflectionSetName(req.Target)
+	}
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if !strings.HasSuffix(name, ".protoset") {
+		name += ".protoset"
+	}
+
+	stored, err := h.storeDescriptorSet(req.SessionID, name, data, "reflection")
+	if err != nil {
+		if qe, ok := asQuotaError(err); ok {
+			c.JSON(http.StatusRequestEntityTooLarge, quotaExceeded(c, qe))
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, err.Error())
+		return
+	}
+
+	h.hub.EmitToSession(req.SessionID, DescriptorSetAdded{
+		SessionID:      req.SessionID,
+		DescriptorSets: []session.DescriptorSet{*stored},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"descriptor_set": stored,
+		"services":       services,
+	})
+}
+
+// reflectionSetName turns a target address into a file-system friendly set name
+func reflectionSetName(target string) string {
+	var b strings.Builder
+	for _, r := range target {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return "reflection-" + b.String()
+}
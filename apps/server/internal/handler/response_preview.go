@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/payload"
+)
+
+// DefaultResponsePageSize is the number of bytes GetResponse returns when the
+// request sets no limit
+const DefaultResponsePageSize = 1 << 20
+
+// SetResponsePreview makes call responses and stream messages whose JSON is
+// larger than size bytes be sent in events as a preview, with the full JSON
+// kept in store. A size of 0 sends every response in full.
+func (h *GRPCHandler) SetResponsePreview(size int, store *payload.Store) {
+	h.previewSize = size
+	h.payloads = store
+}
+
+// previewResponse returns nil when msg can be sent in an event as it is, or a
+// preview of its JSON when that is larger than the preview size. The full JSON
+// is then kept for GetResponse.
+func (h *GRPCHandler) previewResponse(sessionID string, msg any) *ResponsePreview {
+	if h.previewSize <= 0 || h.payloads == nil {
+		return nil
+	}
+	data, err := json.Marshal(msg)
+	if err != nil || len(data) <= h.previewSize {
+		return nil
+	}
+
+	return &ResponsePreview{
+		ResponseID: h.payloads.Put(sessionID, data),
+		Size:       len(data),
+		Content:    string(data[:runeBoundary(data, h.previewSize)]),
+	}
+}
+
+// emitStreamMessage sends one message of a streaming call, as a preview when
+// it is too large
+func (h *GRPCHandler) emitStreamMessage(sessionID, callID string, index int, msg any) {
+	event := StreamMessage{CallID: callID, Index: index, Message: msg}
+	if preview := h.previewResponse(sessionID, msg); preview != nil {
+		event.Message, event.Preview = nil, preview
+	}
+	h.wsHub.EmitToSession(sessionID, event)
+}
+
+// runeBoundary moves an offset into data back to the start of the rune it
+// falls in, so that text cut there stays valid UTF-8
+func runeBoundary(data []byte, offset int) int {
+	for offset > 0 && offset < len(data) && !utf8.RuneStart(data[offset]) {
+		offset--
+	}
+	return offset
+}
+
+// textPage returns the bounds of the page of data at offset, at most limit
+// bytes long (0 reads to the end). Both ends are moved back to a character
+// boundary so the page stays valid UTF-8, but a page always holds at least
+// one character when data remains.
+func textPage(data []byte, offset, limit int64) (start, end int64) {
+	size := int64(len(data))
+	start = int64(runeBoundary(data, int(min(offset, size))))
+	end = size
+	// Compared as limit < size-start so a huge limit cannot overflow start+limit
+	if limit > 0 && limit < size-start {
+		end = int64(runeBoundary(data, int(start+limit)))
+	}
+	if end <= start && start < size {
+		// A limit shorter than the rune at offset still returns that rune
+		_, width := utf8.DecodeRune(data[start:])
+		end = start + int64(width)
+	}
+	return start, end
+}
+
+// GetResponse returns the full JSON of a response that was sent as a preview.
+// offset and limit select a page of its bytes (limit defaults to
+// DefaultResponsePageSize); with raw=true the whole JSON is sent as is and
+// Range requests are served.
+func (h *GRPCHandler) GetResponse(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	responseID := c.Param("responseId")
+
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	offset, limit, err := byteRange(c)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	var data []byte
+	found := false
+	if h.payloads != nil {
+		data, found = h.payloads.Get(sessionID, responseID)
+	}
+	if !found {
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound, "response not found or expired")
+		return
+	}
+
+	if c.Query("raw") == "true" {
+		c.Header("Content-Type", "application/json")
+		http.ServeContent(c.Writer, c.Request, "", time.Time{}, bytes.NewReader(data))
+		return
+	}
+
+	if limit == 0 {
+		limit = DefaultResponsePageSize
+	}
+	size := int64(len(data))
+	start, end := textPage(data, offset, limit)
+
+	response := gin.H{
+		"response_id": responseID,
+		"content":     string(data[start:end]),
+		"offset":      start,
+		"size":        size,
+		"truncated":   end < size,
+	}
+	if end < size {
+		response["next_offset"] = end
+	}
+	c.JSON(http.StatusOK, response)
+}
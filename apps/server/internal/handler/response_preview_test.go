@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTextPage(t *testing.T) {
+	data := []byte("aé€😀b") // 1, 2, 3 and 4 byte characters: a=0 é=1 €=3 😀=6 b=10
+	tests := []struct {
+		offset, limit int64
+		start, end    int64
+	}{
+		{offset: 0, limit: 0, start: 0, end: 11},
+		{offset: 0, limit: 1, start: 0, end: 1},
+		{offset: 0, limit: 2, start: 0, end: 1},
+		{offset: 0, limit: 3, start: 0, end: 3},
+		{offset: 2, limit: 0, start: 1, end: 11},
+		{offset: 4, limit: 4, start: 3, end: 6},
+		{offset: 7, limit: 1, start: 6, end: 10},
+		{offset: 6, limit: 5, start: 6, end: 11},
+		{offset: 6, limit: 1 << 62, start: 6, end: 11},
+		{offset: 11, limit: 5, start: 11, end: 11},
+		{offset: 50, limit: 0, start: 11, end: 11},
+	}
+	for _, tt := range tests {
+		start, end := textPage(data, tt.offset, tt.limit)
+		if start != tt.start || end != tt.end {
+			t.Errorf("textPage(offset %d, limit %d) = %d, %d, want %d, %d", tt.offset, tt.limit, start, end, tt.start, tt.end)
+		}
+		if !utf8.Valid(data[start:end]) {
+			t.Errorf("textPage(offset %d, limit %d) = %q, not valid UTF-8", tt.offset, tt.limit, data[start:end])
+		}
+	}
+
+	// Following next_offset reassembles the text
+	var joined []byte
+	for offset := int64(0); offset < int64(len(data)); {
+		start, end := textPage(data, offset, 2)
+		joined = append(joined, data[start:end]...)
+		offset = end
+	}
+	if string(joined) != string(data) {
+		t.Errorf("pages joined = %q, want %q", joined, data)
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/jhump/protoreflect/desc"
+)
+
+// SchemaHandler serves network-free schema introspection from session descriptors
+type SchemaHandler struct {
+	sessionManager *session.Manager
+	client         grpc.Client
+}
+
+// NewSchemaHandler creates a new schema handler
+func NewSchemaHandler(sm *session.Manager, client grpc.Client) *SchemaHandler {
+	return &SchemaHandler{
+		sessionManager: sm,
+		client:         client,
+	}
+}
+
+// DescribeService renders methods, message types, streaming flags and field
+// lists for a service purely from the session's parsed descriptors
+func (h *SchemaHandler) DescribeService(c *gin.Context) {
+	service := c.Query("service")
+	if service == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "service query parameter is required")
+		return
+	}
+
+	files, ok := h.loadDescriptors(c)
+	if !ok {
+		return
+	}
+
+	sd, err := grpc.FindService(files, service)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.ServiceNotFound, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, grpc.DescribeServiceOffline(sd))
+}
+
+// GetType returns a structured description of a message type (fields, numbers,
+// types, labels, oneofs, nested types and options) for UI form generation
+func (h *SchemaHandler) GetType(c *gin.Context) {
+	files, ok := h.loadDescriptors(c)
+	if !ok {
+		return
+	}
+
+	md, err := grpc.FindMessage(files, c.Param("typeName"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.MessageNotFound, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, grpc.DescribeMessage(md))
+}
+
+// ListEnums lists all enums defined in the session's protos with their values,
+// so request builders can offer dropdowns
+func (h *SchemaHandler) ListEnums(c *gin.Context) {
+	files, ok := h.loadDescriptors(c)
+	if !ok {
+		return
+	}
+
+	enums := grpc.ListEnums(files)
+	c.JSON(http.StatusOK, gin.H{
+		"enums": enums,
+		"count": len(enums),
+	})
+}
+
+// ListHTTPRules returns the google.api.http REST mapping of every annotated
+// method in the session
+func (h *SchemaHandler) ListHTTPRules(c *gin.Context) {
+	files, ok := h.loadDescriptors(c)
+	if !ok {
+		return
+	}
+
+	mappings := grpc.ListHTTPRules(files)
+	c.JSON(http.StatusOK, gin.H{
+		"mappings": mappings,
+		"count":    len(mappings),
+	})
+}
+
+// loadDescriptors compiles the session's protos and descriptor sets. It writes
+// the error response itself and returns false on failure.
+func (h *SchemaHandler) loadDescriptors(c *gin.Context) (map[string]*desc.FileDescriptor, bool) {
+	sessionID := c.Param("sessionId")
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return nil, false
+	}
+
+	if len(sess.ProtoFiles) == 0 && len(sess.DescriptorSets) == 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.NoProtoFiles, "no files uploaded for this session")
+		return nil, false
+	}
+
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+
+	files, err := h.client.FileDescriptors(sess.ID, sess.RootPath, protoFiles, sess.DescriptorSetPaths(), sess.ImportMappings)
+	if err != nil {
+		apierror.Respond(c, http.StatusUnprocessableEntity, apierror.ParseError, "failed to load descriptors: "+err.Error())
+		return nil, false
+	}
+	return files, true
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/script"
+)
+
+// CallScripts holds optional scripts attached to a call (see package script for syntax)
+type CallScripts struct {
+	PreRequest   string `json:"pre_request"`   // May mutate request.data / request.metadata / request.target
+	PostResponse string `json:"post_response"` // May assert on response, status, headers and trailers
+}
+
+// runPreRequestScript executes the pre-request script and applies its mutations to req
+func (h *GRPCHandler) runPreRequestScript(callID, sessionID string, req *CallRequest) (*script.Result, error) {
+	data := toMutableJSON(req.Data)
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	metadata := make(map[string]string, len(req.Metadata))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+
+	request := map[string]interface{}{
+		"target":   req.Target,
+		"service":  req.Service,
+		"method":   req.Method,
+		"data":     data,
+		"metadata": metadata,
+	}
+	env := map[string]interface{}{
+		"request": request,
+		"vars":    h.scriptVariables(sessionID),
+	}
+
+	result, err := script.Run(script.PhasePreRequest, req.Scripts.PreRequest, env)
+	if err != nil {
+		h.emitScriptError(callID, sessionID, err)
+		return result, err
+	}
+
+	if target, ok := request["target"].(string); ok {
+		req.Target = target
+	}
+	req.Data = request["data"]
+	if md, ok := request["metadata"].(map[string]string); ok {
+		req.Metadata = md
+	}
+	return result, nil
+}
+
+// runPostResponseScript executes the post-response script against the call outcome
+func (h *GRPCHandler) runPostResponseScript(callID, sessionID string, req *CallRequest, result *grpc.NativeCallResult, callErr error, tookMs int64) (*script.Result, error) {
+	env := map[string]interface{}{
+		"request": map[string]interface{}{
+			"target":   req.Target,
+			"service":  req.Service,
+			"method":   req.Method,
+			"data":     toMutableJSON(req.Data),
+			"metadata": req.Metadata,
+		},
+		"vars":    h.scriptVariables(sessionID),
+		"ok":      callErr == nil,
+		"status":  grpcStatusName(callErr),
+		"took_ms": float64(tookMs),
+	}
+	if callErr != nil {
+		env["error"] = callErr.Error()
+	}
+	if result != nil {
+		env["response"] = toMutableJSON(result.Response)
+		env["headers"] = toJSONValue(result.Headers)
+		env["trailers"] = toJSONValue(result.Trailers)
+	}
+
+	scriptResult, err := script.Run(script.PhasePostResponse, req.Scripts.PostResponse, env)
+	if err != nil {
+		h.emitScriptError(callID, sessionID, err)
+		return scriptResult, err
+	}
+	return scriptResult, nil
+}
+
+// emitScriptError publishes a structured script://error event
+func (h *GRPCHandler) emitScriptError(callID, sessionID string, err error) {
+	payload := ScriptError{
+		CallID:  callID,
+		Code:    apierror.ScriptFailed,
+		Message: err.Error(),
+	}
+	var scriptErr *script.Error
+	if errors.As(err, &scriptErr) {
+		payload.Phase = scriptErr.Phase
+		payload.Line = scriptErr.Line
+		payload.Message = scriptErr.Message
+	}
+	h.wsHub.EmitToSession(sessionID, payload)
+}
+
+// scriptVariables exposes session variables to scripts as a generic map
+func (h *GRPCHandler) scriptVariables(sessionID string) map[string]interface{} {
+	vars, _ := h.sessionManager.GetVariables(sessionID)
+	out := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		out[k] = v
+	}
+	return out
+}
+
+// toMutableJSON deep-copies a value into plain JSON maps/slices so scripts can mutate it safely
+func toMutableJSON(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(encoded, &out); err != nil {
+		return v
+	}
+	return out
+}
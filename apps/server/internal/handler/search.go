@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/proto"
+)
+
+const (
+	defaultSearchLimit = 200
+	maxSearchLimit     = 2000
+)
+
+// SearchFiles greps the session's proto files (and optionally the bundled
+// standard library) for text or identifiers.
+//
+// Query parameters: q (required), regex, case_sensitive, whole_word, stdlib
+// (all booleans) and limit (default 200, max 2000).
+func (h *ProtoHandler) SearchFiles(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	query := c.Query("q")
+	if query == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "q parameter is required")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	searcher, err := proto.NewSearcher(proto.SearchOptions{
+		Query:         query,
+		Regex:         c.Query("regex") == "true",
+		CaseSensitive: c.Query("case_sensitive") == "true",
+		WholeWord:     c.Query("whole_word") == "true",
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid regular expression: "+err.Error())
+		return
+	}
+
+	// Ask for one extra match so truncation can be reported
+	matches := []proto.SearchMatch{}
+	remaining := func() int { return limit + 1 - len(matches) }
+
+	for _, file := range sess.ProtoFiles {
+		if remaining() <= 0 {
+			break
+		}
+		content, err := os.ReadFile(file.AbsolutePath)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, searcher.Search(file.RelativePath, string(content), remaining())...)
+	}
+
+	if c.Query("stdlib") == "true" && remaining() > 0 {
+		stdlibFiles, err := h.stdlibManager.ListAvailableFiles()
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to list stdlib files: "+err.Error())
+			return
+		}
+		for _, file := range stdlibFiles {
+			if remaining() <= 0 {
+				break
+			}
+			content, err := h.stdlibManager.GetFileContent(file)
+			if err != nil {
+				continue
+			}
+			found := searcher.Search(file, content, remaining())
+			for i := range found {
+				found[i].Stdlib = true
+			}
+			matches = append(matches, found...)
+		}
+	}
+
+	truncated := len(matches) > limit
+	if truncated {
+		matches = matches[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"query":      query,
+		"matches":    matches,
+		"count":      len(matches),
+		"truncated":  truncated,
+	})
+}
@@ -1,26 +1,47 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/audit"
+	"github.com/grpc-bridge/server/internal/auth"
 	"github.com/grpc-bridge/server/internal/session"
 )
 
 type SessionHandler struct {
 	sessionManager *session.Manager
+	tokens         *auth.SessionTokens
 }
 
-func NewSessionHandler(sm *session.Manager) *SessionHandler {
+func NewSessionHandler(sm *session.Manager, tokens *auth.SessionTokens) *SessionHandler {
 	return &SessionHandler{
 		sessionManager: sm,
+		tokens:         tokens,
 	}
 }
 
+// WSToken is a short-lived token for attaching to a session's event stream
+type WSToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// wsToken mints an event stream token for the session
+func (h *SessionHandler) wsToken(sessionID string) WSToken {
+	token, expires := h.tokens.Issue(sessionID)
+	return WSToken{Token: token, ExpiresAt: expires}
+}
+
 // CreateSessionRequest represents the request body for creating a session
 type CreateSessionRequest struct {
-	Name      string `json:"name"`       // Optional user-specified name
-	SessionID string `json:"sessionId"`  // Optional client-provided session ID
+	Name      string `json:"name"`      // Optional user-specified name
+	SessionID string `json:"sessionId"` // Optional client-provided session ID
 }
 
 // CreateSession creates a new session or returns existing one
@@ -33,52 +54,258 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 
 	// If client provided a session ID, check if it exists
 	if req.SessionID != "" {
+		if !session.ValidID(req.SessionID) {
+			apierror.RespondError(c, http.StatusBadRequest, apierror.Invalid(apierror.FieldError{
+				Field: "sessionId", Rule: "uuid", Message: "sessionId must be a UUID",
+			}), apierror.ValidationFailed)
+			return
+		}
 		if session, exists := h.sessionManager.Get(req.SessionID); exists {
 			c.JSON(http.StatusOK, gin.H{
-				"session": session,
+				"session":  session.Redacted(),
+				"ws_token": h.wsToken(session.ID),
 			})
 			return
 		}
 		// Session doesn't exist, create one with the provided ID
 		session := h.sessionManager.CreateWithID(req.SessionID, req.Name)
+		h.assignOwner(c, session)
+		audit.SetSession(c, session.ID)
 		c.JSON(http.StatusCreated, gin.H{
-			"session": session,
+			"session":  session.Redacted(),
+			"ws_token": h.wsToken(session.ID),
 		})
 		return
 	}
 
 	// No session ID provided, create a new one
 	session := h.sessionManager.Create(req.Name)
+	h.assignOwner(c, session)
+	audit.SetSession(c, session.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"session": session,
+		"session":  session.Redacted(),
+		"ws_token": h.wsToken(session.ID),
 	})
 }
 
+// IssueWSToken mints a fresh token for the session's WebSocket or SSE event
+// stream. Tokens are only checked when connecting, so clients fetch a new one
+// before each reconnect.
+func (h *SessionHandler) IssueWSToken(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.wsToken(sessionID))
+}
+
+// assignOwner makes the authenticated user the owner of a new session
+func (h *SessionHandler) assignOwner(c *gin.Context, sess *session.Session) {
+	if user := auth.User(c); user != "" {
+		_ = h.sessionManager.SetOwner(sess.ID, user)
+	}
+}
+
 // GetSession retrieves session information
 func (h *SessionHandler) GetSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
 	session, exists := h.sessionManager.Get(sessionID)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "session not found",
-		})
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+
+	usage, _ := h.sessionManager.Usage(sessionID)
+	c.JSON(http.StatusOK, gin.H{
+		"session": session.Redacted(),
+		"usage":   usage,
+		"quota":   h.sessionManager.Quota(),
+	})
+}
+
+// Limits for user-editable session metadata
+const (
+	maxSessionNameLength        = 200
+	maxSessionDescriptionLength = 4000
+	maxSessionTags              = 50
+	maxSessionTagLength         = 64
+)
+
+// UpdateSessionRequest represents a partial metadata update; omitted fields are
+// left unchanged
+type UpdateSessionRequest struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Tags        []string `json:"tags"` // Replaces all tags; [] clears them
+}
+
+// UpdateSession renames a session and updates its description and tags
+func (h *SessionHandler) UpdateSession(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	var req UpdateSessionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	update := session.MetadataUpdate{Description: req.Description}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if len(name) > maxSessionNameLength {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("name must be at most %d characters", maxSessionNameLength))
+			return
+		}
+		update.Name = &name
+	}
+	if req.Description != nil && len(*req.Description) > maxSessionDescriptionLength {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("description must be at most %d characters", maxSessionDescriptionLength))
+		return
+	}
+	if req.Tags != nil {
+		tags, err := normalizeTags(req.Tags)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+			return
+		}
+		update.Tags = tags
+	}
+
+	sess, err := h.sessionManager.UpdateMetadata(sessionID, update)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"session": session,
+		"session": sess.Redacted(),
 	})
 }
 
+// normalizeTags trims tags and drops empty and duplicate ones, keeping order
+func normalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxSessionTagLength {
+			return nil, fmt.Errorf("tag %q exceeds %d characters", tag, maxSessionTagLength)
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) > maxSessionTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxSessionTags)
+	}
+	return out, nil
+}
+
 // DeleteSession removes a session
 func (h *SessionHandler) DeleteSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
 
-	h.sessionManager.Delete(sessionID)
+	if _, exists := h.sessionManager.Get(sessionID); !exists {
+		apierror.Respond(c, http.StatusNotFound, apierror.SessionNotFound, "session not found")
+		return
+	}
+	cancelled := h.sessionManager.Delete(sessionID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "session deleted",
+		"cancelled_operations": cancelled,
+	})
+}
+
+// BulkDeleteRequest selects sessions to delete. Every criterion given must
+// match; at least one is required.
+type BulkDeleteRequest struct {
+	IDs         []string `json:"ids"`          // Only these sessions
+	OlderThan   string   `json:"older_than"`   // Go duration, e.g. 720h: sessions created longer ago than this
+	InactiveFor string   `json:"inactive_for"` // Go duration: sessions unused for at least this long
+	DryRun      bool     `json:"dry_run"`      // Report the matching sessions without deleting them
+}
+
+// BulkDeleteSessions deletes many sessions in one call, with their upload
+// directories, caches and history, for administrators tidying shared instances
+func (h *SessionHandler) BulkDeleteSessions(c *gin.Context) {
+	var req BulkDeleteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	filter := session.BulkDeleteFilter{IDs: req.IDs, DryRun: req.DryRun}
+	now := time.Now()
+	var fields []apierror.FieldError
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *time.Time
+	}{
+		{"older_than", req.OlderThan, &filter.CreatedBefore},
+		{"inactive_for", req.InactiveFor, &filter.UsedBefore},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil || d <= 0 {
+			fields = append(fields, apierror.FieldError{
+				Field:   f.name,
+				Rule:    "duration",
+				Message: fmt.Sprintf("%s must be a positive Go duration such as 24h or 720h, got %q", f.name, f.value),
+			})
+			continue
+		}
+		*f.dst = now.Add(-d)
+	}
+	if len(fields) > 0 {
+		apierror.RespondError(c, http.StatusBadRequest, apierror.Invalid(fields...), apierror.ValidationFailed)
+		return
+	}
+	if req.IDs == nil && filter.CreatedBefore.IsZero() && filter.UsedBefore.IsZero() {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "ids, older_than or inactive_for is required")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.sessionManager.BulkDelete(filter))
+}
+
+// RestoreSession brings an expired session back from the archive store
+func (h *SessionHandler) RestoreSession(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	// Users may only restore their own or unowned sessions, like SessionAccess enforces for live ones
+	var authorize func(*session.Session) bool
+	if auth.Authenticated(c) {
+		user := auth.User(c)
+		authorize = func(sess *session.Session) bool {
+			return sess.Owner == "" || sess.Owner == user
+		}
+	}
+
+	sess, err := h.sessionManager.RestoreArchived(c.Request.Context(), sessionID, authorize)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, session.ErrArchiveDisabled):
+			status = http.StatusNotImplemented
+		case errors.Is(err, session.ErrArchiveNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, session.ErrSessionExists):
+			status = http.StatusConflict
+		}
+		apierror.Respond(c, status, apierror.FromStatus(status), err.Error())
+		return
+	}
 
+	audit.SetSession(c, sess.ID)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "session deleted",
+		"session": sess.Redacted(),
 	})
 }
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/websocket"
+)
+
+// maxNoticeLength bounds operator notices
+const maxNoticeLength = 2000
+
+// StatusHandler tracks server-wide status (version, maintenance mode) and
+// broadcasts its changes and operator notices to every connected client
+type StatusHandler struct {
+	hub       *websocket.Hub
+	version   string
+	startedAt time.Time
+
+	mu          sync.Mutex
+	maintenance Maintenance
+}
+
+// NewStatusHandler creates a new status handler for the given server version
+func NewStatusHandler(hub *websocket.Hub, version string) *StatusHandler {
+	return &StatusHandler{
+		hub:       hub,
+		version:   version,
+		startedAt: time.Now().UTC(),
+	}
+}
+
+// Status returns the current server status; it is the hub's welcome event
+func (h *StatusHandler) Status() websocket.Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status := ServerStatus{Version: h.version, StartedAt: h.startedAt}
+	if h.maintenance.Enabled {
+		maintenance := h.maintenance
+		status.Maintenance = &maintenance
+	}
+	return status
+}
+
+// AnnounceShutdown tells connected clients that the server is stopping
+func (h *StatusHandler) AnnounceShutdown(reason string) {
+	h.hub.EmitToAll(ServerShutdown{Reason: reason})
+}
+
+// GetStatus returns the server status, the number of connected clients and,
+// per session, the messages dropped for clients too slow to keep up
+func (h *StatusHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":       h.Status(),
+		"clients":      h.hub.Clients(),
+		"backpressure": h.hub.Backpressure(),
+		"drops":        h.hub.Drops(),
+	})
+}
+
+// MaintenanceRequest turns maintenance mode on or off
+type MaintenanceRequest struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+// SetMaintenance turns maintenance mode on or off and broadcasts the change.
+// Maintenance mode is advisory: the server keeps serving requests, and UIs
+// decide how to warn their users.
+func (h *StatusHandler) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if len(req.Message) > maxNoticeLength {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "message is too long")
+		return
+	}
+
+	h.mu.Lock()
+	maintenance := Maintenance{Enabled: req.Enabled}
+	if req.Enabled {
+		since := time.Now().UTC()
+		if h.maintenance.Enabled {
+			since = *h.maintenance.Since
+		}
+		maintenance.Message = strings.TrimSpace(req.Message)
+		maintenance.Since = &since
+	}
+	h.maintenance = maintenance
+	h.mu.Unlock()
+
+	// Broadcast outside the lock: the hub calls Status while registering clients
+	h.hub.EmitToAll(maintenance)
+	c.JSON(http.StatusOK, maintenance)
+}
+
+// NoticeRequest is an operator notice to broadcast
+type NoticeRequest struct {
+	Level   string `json:"level"` // Defaults to info
+	Message string `json:"message"`
+}
+
+// BroadcastNotice sends an operator notice to every connected client. Notices
+// are not stored: clients that connect later do not receive them.
+func (h *StatusHandler) BroadcastNotice(c *gin.Context) {
+	var req NoticeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "message is required")
+		return
+	}
+	if len(message) > maxNoticeLength {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "message is too long")
+		return
+	}
+	level := req.Level
+	switch level {
+	case "":
+		level = "info"
+	case "info", "warning", "error":
+	default:
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "level must be info, warning or error")
+		return
+	}
+
+	notice := Notice{
+		ID:        uuid.New().String(),
+		Level:     level,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
+	}
+	h.hub.EmitToAll(notice)
+	c.JSON(http.StatusOK, gin.H{
+		"notice":  notice,
+		"clients": h.hub.Clients(),
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// StorageHandler reports disk usage under the upload directory and frees
+// space on demand
+type StorageHandler struct {
+	sessionManager *session.Manager
+}
+
+// NewStorageHandler creates a new storage handler
+func NewStorageHandler(sm *session.Manager) *StorageHandler {
+	return &StorageHandler{
+		sessionManager: sm,
+	}
+}
+
+// StorageCleanupRequest optionally overrides the quota a cleanup enforces
+type StorageCleanupRequest struct {
+	MaxBytes int64 `json:"max_bytes"` // Evict down to this size; 0 uses STORAGE_MAX_BYTES
+}
+
+// GetStorage returns per-session and total disk usage
+func (h *StorageHandler) GetStorage(c *gin.Context) {
+	c.JSON(http.StatusOK, h.sessionManager.StorageReport())
+}
+
+// Cleanup removes expired sessions and evicts the least recently used ones
+// until usage fits the quota
+func (h *StorageHandler) Cleanup(c *gin.Context) {
+	var req StorageCleanupRequest
+	if c.Request.ContentLength > 0 {
+		if !bindJSON(c, &req) {
+			return
+		}
+	}
+	if req.MaxBytes < 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "max_bytes must not be negative")
+		return
+	}
+	c.JSON(http.StatusOK, h.sessionManager.Cleanup(req.MaxBytes))
+}
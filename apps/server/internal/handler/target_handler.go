@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// maxTargetNameLength bounds target display names
+const maxTargetNameLength = 200
+
+// TargetHandler exposes CRUD endpoints for per-session gRPC server presets
+type TargetHandler struct {
+	sessionManager *session.Manager
+}
+
+// NewTargetHandler creates a new target handler
+func NewTargetHandler(sm *session.Manager) *TargetHandler {
+	return &TargetHandler{
+		sessionManager: sm,
+	}
+}
+
+// TargetRequest represents the body for creating or updating a target preset
+type TargetRequest struct {
+	Name      string               `json:"name"`
+	Address   string               `json:"address" binding:"required,grpc_target"`
+	Plaintext bool                 `json:"plaintext"`
+	TLS       *session.TLSSettings `json:"tls"`
+	Metadata  map[string]string    `json:"metadata"`
+}
+
+// ListTargets returns all target presets of a session
+func (h *TargetHandler) ListTargets(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	targets, err := h.sessionManager.ListTargets(sessionID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"targets":    session.RedactTargets(targets),
+	})
+}
+
+// GetTarget returns a single target preset
+func (h *TargetHandler) GetTarget(c *gin.Context) {
+	target, err := h.sessionManager.GetTarget(c.Param("sessionId"), c.Param("targetId"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"target": target.Redacted(),
+	})
+}
+
+// CreateTarget stores a new target preset
+func (h *TargetHandler) CreateTarget(c *gin.Context) {
+	target, ok := bindTarget(c)
+	if !ok {
+		return
+	}
+
+	created, err := h.sessionManager.AddTarget(c.Param("sessionId"), target)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"target": created.Redacted(),
+	})
+}
+
+// UpdateTarget replaces the settings of a target preset
+func (h *TargetHandler) UpdateTarget(c *gin.Context) {
+	target, ok := bindTarget(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.sessionManager.UpdateTarget(c.Param("sessionId"), c.Param("targetId"), target)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"target": updated.Redacted(),
+	})
+}
+
+// DeleteTarget removes a target preset
+func (h *TargetHandler) DeleteTarget(c *gin.Context) {
+	targetID := c.Param("targetId")
+
+	if err := h.sessionManager.DeleteTarget(c.Param("sessionId"), targetID); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "target deleted",
+		"id":      targetID,
+	})
+}
+
+// bindTarget decodes and validates a target request body
+func bindTarget(c *gin.Context) (session.Target, bool) {
+	var req TargetRequest
+	if !bindJSON(c, &req) {
+		return session.Target{}, false
+	}
+
+	target, err := normalizeTarget(session.Target{
+		Name:      req.Name,
+		Address:   req.Address,
+		Plaintext: req.Plaintext,
+		TLS:       req.TLS,
+		Metadata:  req.Metadata,
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return session.Target{}, false
+	}
+	return target, true
+}
+
+// normalizeTarget validates a target preset. The address doubles as the
+// display name when none is given.
+func normalizeTarget(target session.Target) (session.Target, error) {
+	target.Address = strings.TrimSpace(target.Address)
+	if target.Address == "" {
+		return target, errors.New("address is required")
+	}
+	target.Name = strings.TrimSpace(target.Name)
+	if target.Name == "" {
+		target.Name = target.Address
+	}
+	if len(target.Name) > maxTargetNameLength {
+		return target, fmt.Errorf("name must be at most %d characters", maxTargetNameLength)
+	}
+	if target.Plaintext || (target.TLS != nil && *target.TLS == (session.TLSSettings{})) {
+		// TLS settings only apply to TLS connections
+		target.TLS = nil
+	}
+
+	if target.Metadata == nil {
+		target.Metadata = map[string]string{}
+	}
+	for key := range target.Metadata {
+		if strings.TrimSpace(key) == "" {
+			return target, errors.New("metadata keys must not be empty")
+		}
+	}
+	return target, nil
+}
@@ -0,0 +1,441 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// Upload modes
+const (
+	uploadModeReplace = "replace" // Drop previous files, keep only the new set (default)
+	uploadModeMerge   = "merge"   // Layer new files over the existing set
+)
+
+// Per-file upload outcomes
+const (
+	fileAdded     = "added"
+	fileUpdated   = "updated"
+	fileUnchanged = "unchanged"
+)
+
+// fileChange reports what an upload did to a single file
+type fileChange struct {
+	RelativePath string `json:"relative_path"`
+	Status       string `json:"status"`
+}
+
+// Upload size defaults; see UploadLimits
+const (
+	DefaultMaxUploadFileSize    int64 = 10 << 20  // 10MB per proto file
+	DefaultMaxUploadRequestSize int64 = 128 << 20 // 128MB per request, above archive.MaxArchiveSize
+)
+
+// UploadLimits caps directory uploads. MaxFileSize applies to each uploaded
+// proto file; MaxRequestSize to the whole request body and is enforced by
+// middleware.BodyLimit before the multipart form is parsed. 0 disables a limit.
+type UploadLimits struct {
+	MaxFileSize    int64 `json:"max_file_size"`
+	MaxRequestSize int64 `json:"max_request_size"`
+}
+
+// bodyTooLarge writes a 413 response and reports true when err comes from a
+// request body cut off at the request size limit
+func bodyTooLarge(c *gin.Context, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	apierror.RespondDetails(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, fmt.Sprintf("request body exceeds the maximum size of %d bytes", tooLarge.Limit), gin.H{
+		"max_request_size": tooLarge.Limit,
+	})
+	return true
+}
+
+// multipartFailure writes the response for a multipart form that could not be
+// parsed and returns the matching upload error event
+func multipartFailure(c *gin.Context, err error) UploadError {
+	if bodyTooLarge(c, err) {
+		return UploadError{Code: apierror.PayloadTooLarge, Error: "request body is too large"}
+	}
+	msg := "failed to parse multipart form"
+	apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, msg)
+	return UploadError{Code: apierror.InvalidRequest, Error: msg}
+}
+
+// uploadBatch accumulates the outcome of storing a set of uploaded proto files
+type uploadBatch struct {
+	sessionID  string
+	sessionDir string
+	mode       string
+	files      []session.ProtoFile
+	errorFiles []string
+	dirSet     map[string]struct{}
+	changes    []fileChange
+	existing   map[string]session.ProtoFile // relative path -> file already in the session (merge mode)
+
+	quotaErr *session.QuotaError // First quota violation; later files are skipped too
+}
+
+// previousSnapshotPath is where the descriptor snapshot taken before the latest
+// upload is kept, relative to the session directory
+var previousSnapshotPath = filepath.Join(".snapshots", "previous.protoset")
+
+// parseUploadMode validates the requested upload mode, defaulting to replace
+func parseUploadMode(mode string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", uploadModeReplace:
+		return uploadModeReplace, true
+	case uploadModeMerge:
+		return uploadModeMerge, true
+	default:
+		return "", false
+	}
+}
+
+// beginUpload prepares the session directory for the given mode. Merge keeps
+// the existing files unless the session has none yet.
+func (h *ProtoHandler) beginUpload(c *gin.Context, sess *session.Session, mode string) (*uploadBatch, bool) {
+	// Compile the current set before it is touched so /breaking can diff against it
+	previous := h.captureSnapshot(sess)
+
+	if mode != uploadModeMerge || sess.RootPath == "" {
+		batch, ok := h.resetSessionDir(c, sess.ID)
+		if ok {
+			batch.mode = mode
+			h.saveSnapshot(batch.sessionDir, previous)
+		}
+		return batch, ok
+	}
+	h.saveSnapshot(sess.RootPath, previous)
+
+	existing := make(map[string]session.ProtoFile, len(sess.ProtoFiles))
+	for _, f := range sess.ProtoFiles {
+		existing[f.RelativePath] = f
+	}
+	return &uploadBatch{
+		sessionID:  sess.ID,
+		sessionDir: sess.RootPath,
+		mode:       mode,
+		files:      []session.ProtoFile{},
+		errorFiles: []string{},
+		dirSet:     map[string]struct{}{},
+		existing:   existing,
+	}, true
+}
+
+// captureSnapshot compiles the session's current protos into a descriptor set.
+// Sessions without files or with sources that do not compile yield nil.
+func (h *ProtoHandler) captureSnapshot(sess *session.Session) []byte {
+	if sess.RootPath == "" || len(sess.ProtoFiles) == 0 {
+		return nil
+	}
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+	data, err := proto.CompileSnapshot(sess.RootPath, protoFiles)
+	if err != nil {
+		fmt.Printf("[ProtoHandler] Skipping descriptor snapshot for session %s: %v\n", sess.ID, err)
+		return nil
+	}
+	return data
+}
+
+// saveSnapshot stores the pre-upload snapshot inside the session directory
+func (h *ProtoHandler) saveSnapshot(sessionDir string, data []byte) {
+	if data == nil {
+		return
+	}
+	path := filepath.Join(sessionDir, previousSnapshotPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Printf("[ProtoHandler] Warning: failed to create snapshot dir: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Printf("[ProtoHandler] Warning: failed to store descriptor snapshot: %v\n", err)
+	}
+}
+
+// resetSessionDir implements the replace strategy: for one session, keep only the
+// latest uploaded proto set. Existing session files are removed, the directory is
+// recreated and the embedded stdlib is copied in. It writes the error response
+// itself and returns false when the upload should stop.
+func (h *ProtoHandler) resetSessionDir(c *gin.Context, sessionID string) (*uploadBatch, bool) {
+	fail := func(msg string) (*uploadBatch, bool) {
+		h.hub.EmitToSession(sessionID, UploadError{
+			Code:  apierror.Internal,
+			Error: msg,
+		})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, msg)
+		return nil, false
+	}
+
+	sessionDir := filepath.Join(h.uploadDir, sessionID)
+	if err := os.RemoveAll(sessionDir); err != nil {
+		return fail("failed to clear previous uploaded files")
+	}
+	if err := h.sessionManager.ResetUploadState(sessionID); err != nil {
+		return fail("failed to reset previous upload state")
+	}
+
+	// Create session directory
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		return fail("failed to create session directory")
+	}
+	fmt.Printf("[UploadStructure] Created/ensured session root dir: %s\n", sessionDir)
+
+	// Set root path
+	if err := h.sessionManager.SetRootPath(sessionID, sessionDir); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal, "failed to set root path")
+		return nil, false
+	}
+
+	// Copy standard library files to session directory
+	fmt.Printf("[ProtoHandler] Copying stdlib to session: %s\n", sessionDir)
+	if err := h.stdlibManager.CopyToSession(sessionDir); err != nil {
+		// Log error but don't fail the request
+		fmt.Printf("[ProtoHandler] Warning: failed to copy stdlib to session: %v\n", err)
+	} else {
+		fmt.Printf("[ProtoHandler] Successfully copied stdlib to session\n")
+	}
+
+	return &uploadBatch{
+		sessionID:  sessionID,
+		sessionDir: sessionDir,
+		mode:       uploadModeReplace,
+		files:      []session.ProtoFile{},
+		errorFiles: []string{},
+		dirSet:     map[string]struct{}{},
+	}, true
+}
+
+// sessionFilePath resolves a relative path inside a session directory. It
+// reports false when the result would land outside the directory, so a path
+// that slipped past sanitization still cannot escape the session sandbox.
+func sessionFilePath(sessionDir, relativePath string) (string, bool) {
+	if strings.ContainsRune(relativePath, 0) {
+		return "", false
+	}
+	root := filepath.Clean(sessionDir)
+	absPath := filepath.Join(root, filepath.FromSlash(relativePath))
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", false
+	}
+	// A symlink inside the directory could still lead a write out of it
+	if !resolvesWithin(root, absPath) {
+		return "", false
+	}
+	return absPath, true
+}
+
+// resolvesWithin reports whether path stays inside root once the symlinks of
+// its existing part are resolved. Dangling symlinks are rejected.
+func resolvesWithin(root, path string) bool {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return os.IsNotExist(err) // Nothing there yet, so no link to follow
+	}
+	existing := path
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return false
+		}
+		existing = parent
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	return err == nil && within(resolvedRoot, resolved)
+}
+
+// store writes one proto file (relativePath already normalized) into the session
+// directory and registers it with the session. Failures are recorded in errorFiles.
+func (h *ProtoHandler) store(b *uploadBatch, relativePath string, src io.Reader) {
+	// Directory metadata collection
+	relDirForMeta := filepath.Dir(relativePath)
+	if relDirForMeta != "." && relDirForMeta != "" {
+		parts := strings.Split(relDirForMeta, "/")
+		cur := ""
+		for i, p := range parts {
+			if i == 0 {
+				cur = p
+			} else {
+				cur = cur + "/" + p
+			}
+			if _, exists := b.dirSet[cur]; !exists {
+				b.dirSet[cur] = struct{}{}
+			}
+		}
+	}
+
+	// Ensure directory exists (mkdir based on relative path directory)
+	absPath, ok := sessionFilePath(b.sessionDir, relativePath)
+	if !ok {
+		fmt.Printf("[UploadStructure] [session=%s] Rejected path outside the session directory: %s\n", b.sessionID, relativePath)
+		b.errorFiles = append(b.errorFiles, relativePath)
+		return
+	}
+	absDir := filepath.Dir(absPath)
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		b.errorFiles = append(b.errorFiles, relativePath)
+		return
+	}
+	relDirPrinted := filepath.Dir(relativePath)
+	if relDirPrinted == "." {
+		relDirPrinted = "(root)"
+	}
+	fmt.Printf("[UploadStructure] [session=%s] dir ok: %s -> %s (file=%s)\n", b.sessionID, relDirPrinted, absDir, relativePath)
+
+	content, err := io.ReadAll(src)
+	if err != nil {
+		b.errorFiles = append(b.errorFiles, relativePath)
+		return
+	}
+	if err := h.sessionManager.CheckProtoFile(b.sessionID, relativePath, int64(len(content))); err != nil {
+		if qe, ok := asQuotaError(err); ok && b.quotaErr == nil {
+			b.quotaErr = qe
+		}
+		b.errorFiles = append(b.errorFiles, relativePath)
+		return
+	}
+
+	// Compare content hashes with the stored file; records written before
+	// hashing fall back to comparing the bytes on disk
+	hash := session.ContentHash(content)
+	status := fileAdded
+	if existing, ok := b.existing[relativePath]; ok {
+		status = fileUpdated
+		if existing.SHA256 != "" {
+			if existing.SHA256 == hash {
+				status = fileUnchanged
+			}
+		} else if current, err := os.ReadFile(existing.AbsolutePath); err == nil && bytes.Equal(current, content) {
+			status = fileUnchanged
+		}
+	}
+
+	protoFile := session.ProtoFile{Name: filepath.Base(relativePath), RelativePath: relativePath, AbsolutePath: absPath, Size: int64(len(content)), SHA256: hash}
+
+	// Identical files are left untouched so cached descriptors stay valid
+	if status != fileUnchanged {
+		if err := os.WriteFile(absPath, content, 0644); err != nil {
+			b.errorFiles = append(b.errorFiles, relativePath)
+			return
+		}
+		fmt.Printf("[UploadStructure] Stored file: %s (size=%d, %s)\n", protoFile.AbsolutePath, protoFile.Size, status)
+	}
+
+	switch {
+	case status == fileUnchanged:
+		// Already registered with identical content
+	case b.mode == uploadModeMerge:
+		_, err = h.sessionManager.UpsertProtoFile(b.sessionID, protoFile)
+	default:
+		err = h.sessionManager.AddProtoFile(b.sessionID, protoFile)
+	}
+	if err != nil {
+		b.errorFiles = append(b.errorFiles, relativePath)
+		return
+	}
+	b.files = append(b.files, protoFile)
+	b.changes = append(b.changes, fileChange{RelativePath: relativePath, Status: status})
+}
+
+// saveDirectories persists the directory metadata of a batch into the session
+func (h *ProtoHandler) saveDirectories(b *uploadBatch) {
+	if len(b.dirSet) == 0 {
+		return
+	}
+	dirs := make([]session.ProtoDir, 0, len(b.dirSet))
+	for d := range b.dirSet {
+		absDir := filepath.Join(b.sessionDir, d)
+		dirs = append(dirs, session.ProtoDir{RelativePath: d, AbsolutePath: absDir})
+	}
+	if err := h.sessionManager.AddDirectories(b.sessionID, dirs); err != nil {
+		fmt.Printf("[ProtoHandler] Warning: failed to add directories: %v\n", err)
+	}
+}
+
+// finishUpload persists directory metadata, emits proto://upload_done and writes
+// the HTTP response. The source fields are included in both the event and the response.
+func (h *ProtoHandler) finishUpload(c *gin.Context, sess *session.Session, b *uploadBatch, source UploadSource) {
+	h.saveDirectories(b)
+
+	// Prepare directory list for event
+	dirList := make([]string, 0, len(b.dirSet))
+	for d := range b.dirSet {
+		dirList = append(dirList, d)
+	}
+
+	// Build lightweight file descriptors for event (avoid leaking absolute paths unless needed)
+	eventFiles := make([]UploadFile, 0, len(b.files))
+	for _, f := range b.files {
+		eventFiles = append(eventFiles, UploadFile{
+			Name:         f.Name,
+			RelativePath: f.RelativePath,
+			Size:         f.Size,
+			SHA256:       f.SHA256,
+		})
+	}
+
+	h.hub.EmitToSession(b.sessionID, UploadDone{
+		SessionID:     b.sessionID,
+		UploadedCount: len(b.files),
+		ErrorCount:    len(b.errorFiles),
+		Files:         eventFiles,
+		Directories:   dirList,
+		Normalized:    true,
+		Mode:          b.mode,
+		UploadSource:  source,
+	})
+
+	summary := map[string]int{fileAdded: 0, fileUpdated: 0, fileUnchanged: 0}
+	for _, ch := range b.changes {
+		summary[ch.Status]++
+	}
+
+	response := gin.H{
+		"session":        sess.Redacted(),
+		"uploaded_files": b.files,
+		"uploaded_count": len(b.files),
+		"directories":    dirList,
+		"mode":           b.mode,
+		"changes":        b.changes,
+		"summary":        summary,
+	}
+	if extra, ok := toMutableJSON(source).(map[string]interface{}); ok {
+		for k, v := range extra {
+			response[k] = v
+		}
+	}
+
+	if len(b.errorFiles) > 0 {
+		response["errors"] = b.errorFiles
+		response["error_count"] = len(b.errorFiles)
+	}
+
+	// Files stored before the quota was hit are kept; the rest are listed in errors
+	status := http.StatusOK
+	if b.quotaErr != nil {
+		for k, v := range quotaExceeded(c, b.quotaErr) {
+			response[k] = v
+		}
+		status = http.StatusRequestEntityTooLarge
+	}
+
+	c.JSON(status, response)
+}
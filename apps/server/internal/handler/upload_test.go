@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionFilePath(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "abc")
+	outside := filepath.Join(base, "abcd")
+	for _, dir := range []string{filepath.Join(root, "api"), outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"out":          outside,
+		"api/up":       base,
+		"inner":        filepath.Join(root, "api"),
+		"file.proto":   filepath.Join(outside, "x.proto"),
+		"dangling.txt": filepath.Join(base, "missing"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(link))); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		rel  string
+		want string // Relative to root; empty when rejected
+	}{
+		{rel: "a.proto", want: "a.proto"},
+		{rel: "api/v1/a.proto", want: "api/v1/a.proto"},
+		{rel: "./api/../b.proto", want: "b.proto"},
+		{rel: "inner/a.proto", want: "inner/a.proto"},
+		{rel: "../abcd/a.proto"},
+		{rel: "../abc/a.proto", want: "a.proto"},
+		{rel: "../a.proto"},
+		{rel: ".."},
+		{rel: "."},
+		{rel: ""},
+		{rel: "api/../../a.proto"},
+		{rel: "a\x00.proto"},
+		{rel: "out/a.proto"},
+		{rel: "out/new/a.proto"},
+		{rel: "api/up/abcd/a.proto"},
+		{rel: "file.proto"},
+		{rel: "dangling.txt"},
+	}
+	for _, tt := range tests {
+		got, ok := sessionFilePath(root, tt.rel)
+		want := ""
+		if tt.want != "" {
+			want = filepath.Join(root, filepath.FromSlash(tt.want))
+		}
+		if got != want || ok != (tt.want != "") {
+			t.Errorf("sessionFilePath(%q) = %q, %t, want %q", tt.rel, got, ok, want)
+		}
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		root, path string
+		want       bool
+	}{
+		{"/uploads/abc", "/uploads/abc", true},
+		{"/uploads/abc", "/uploads/abc/x.proto", true},
+		{"/uploads/abc", "/uploads/abcd", false},
+		{"/uploads/abc", "/uploads/abcd/x.proto", false},
+		{"/uploads/abc", "/uploads", false},
+		{"/uploads/abc", "/uploads/abc/../abcd", false},
+		{"/uploads/abc", "/uploads/abc/..x/y", true},
+	}
+	for _, tt := range tests {
+		if got := within(tt.root, tt.path); got != tt.want {
+			t.Errorf("within(%q, %q) = %t, want %t", tt.root, tt.path, got, tt.want)
+		}
+	}
+}
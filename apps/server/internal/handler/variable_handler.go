@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/secret"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// VariableHandler exposes CRUD endpoints for session-scoped template variables
+type VariableHandler struct {
+	sessionManager *session.Manager
+}
+
+// NewVariableHandler creates a new variable handler
+func NewVariableHandler(sm *session.Manager) *VariableHandler {
+	return &VariableHandler{
+		sessionManager: sm,
+	}
+}
+
+// ReplaceVariablesRequest represents the body for replacing all variables
+type ReplaceVariablesRequest struct {
+	Variables map[string]string `json:"variables"`
+}
+
+// SetVariableRequest represents the body for setting a single variable
+type SetVariableRequest struct {
+	Value string `json:"value"`
+}
+
+// ListVariables returns all variables of a session
+func (h *VariableHandler) ListVariables(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	vars, err := h.sessionManager.GetVariables(sessionID)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"variables":  secret.RedactMap(vars),
+	})
+}
+
+// ReplaceVariables replaces the full variable set of a session
+func (h *VariableHandler) ReplaceVariables(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	var req ReplaceVariablesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	for name := range req.Variables {
+		if !isValidVariableName(name) {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid variable name: "+name)
+			return
+		}
+	}
+
+	if err := h.sessionManager.ReplaceVariables(sessionID, req.Variables); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	vars, _ := h.sessionManager.GetVariables(sessionID)
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"variables":  secret.RedactMap(vars),
+	})
+}
+
+// SetVariable creates or updates a single variable
+func (h *VariableHandler) SetVariable(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	name := c.Param("name")
+
+	if !isValidVariableName(name) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid variable name: "+name)
+		return
+	}
+
+	var req SetVariableRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.sessionManager.SetVariable(sessionID, name, req.Value); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":  name,
+		"value": secret.RedactValue(name, req.Value),
+	})
+}
+
+// DeleteVariable removes a single variable
+func (h *VariableHandler) DeleteVariable(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+	name := c.Param("name")
+
+	if err := h.sessionManager.DeleteVariable(sessionID, name); err != nil {
+		apierror.Respond(c, http.StatusNotFound, notFoundCode(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "variable deleted",
+	})
+}
+
+// isValidVariableName reports whether name can be referenced as a {{name}} placeholder
+func isValidVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("_-.", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
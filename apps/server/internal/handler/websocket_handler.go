@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/auth"
 	ws "github.com/grpc-bridge/server/internal/websocket"
 )
 
@@ -19,39 +23,132 @@ var upgrader = websocket.Upgrader{
 
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
-	hub *ws.Hub
+	hub    *ws.Hub
+	auth   *auth.Authenticator
+	tokens *auth.SessionTokens
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler(hub *ws.Hub) *WebSocketHandler {
+func NewWebSocketHandler(hub *ws.Hub, authenticator *auth.Authenticator, tokens *auth.SessionTokens) *WebSocketHandler {
 	return &WebSocketHandler{
-		hub: hub,
+		hub:    hub,
+		auth:   authenticator,
+		tokens: tokens,
 	}
 }
 
+// authorize checks that the client may attach to the session's events: it
+// needs a session token (?token=, see SessionHandler.IssueWSToken) or an API
+// key in the X-API-Key header. A known session ID alone is not enough.
+func (h *WebSocketHandler) authorize(c *gin.Context, sessionID string) bool {
+	if h.tokens.Verify(c.Query("token"), sessionID) || h.auth.ValidAPIKey(c.GetHeader(auth.APIKeyHeader)) {
+		return true
+	}
+	apierror.Respond(c, http.StatusUnauthorized, apierror.Unauthenticated, "a valid session token is required")
+	return false
+}
+
 // HandleConnection handles WebSocket connection upgrades
 func (h *WebSocketHandler) HandleConnection(c *gin.Context) {
 	sessionID := c.Query("sessionId")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "sessionId is required"})
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "sessionId is required")
+		return
+	}
+	if !h.authorize(c, sessionID) {
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	since, resume, err := parseSince(c.Query("since"))
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+	// ?acks=true opts into acknowledging critical events, which are resent until acknowledged
+	acks := false
+	if raw := c.Query("acks"); raw != "" {
+		if acks, err = strconv.ParseBool(raw); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, "acks must be true or false")
+			return
+		}
+	}
+
+	compression := h.hub.Compression()
+	u := upgrader
+	u.EnableCompression = compression.Enabled
+	conn, err := u.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("[WebSocket] Failed to upgrade connection: %v", err)
 		return
 	}
+	if compression.Enabled {
+		if err := conn.SetCompressionLevel(compression.Level); err != nil {
+			log.Printf("[WebSocket] Invalid compression level %d: %v", compression.Level, err)
+		}
+	}
 
 	client := &ws.Client{
 		SessionID: sessionID,
 		Conn:      conn,
 		Send:      make(chan ws.Message, 256),
+		Resume:    resume,
+		Since:     since,
+		Events:    ws.ParseEventPrefixes(c.Query("events")),
+		Acks:      acks,
 	}
 
 	h.hub.Register(client)
 
 	// Start pumps in goroutines
-	go client.WritePump()
+	go client.WritePump(h.hub)
 	go client.ReadPump(h.hub)
 }
+
+// HandleEvents streams the session's events as Server-Sent Events, for
+// networks that block WebSockets. It accepts the same sessionId, token, since
+// and events parameters; since defaults to the Last-Event-ID header that
+// EventSource sends when it reconnects. An SSE stream takes the session's
+// single connection slot like a WebSocket does, but cannot send commands.
+func (h *WebSocketHandler) HandleEvents(c *gin.Context) {
+	sessionID := c.Query("sessionId")
+	if sessionID == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.SessionRequired, "sessionId is required")
+		return
+	}
+	if !h.authorize(c, sessionID) {
+		return
+	}
+
+	raw := c.Query("since")
+	if raw == "" {
+		raw = c.GetHeader("Last-Event-ID")
+	}
+	since, resume, err := parseSince(raw)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+
+	client := &ws.Client{
+		SessionID: sessionID,
+		Send:      make(chan ws.Message, 256),
+		Resume:    resume,
+		Since:     since,
+		Events:    ws.ParseEventPrefixes(c.Query("events")),
+	}
+
+	h.hub.Register(client)
+	client.ServeSSE(h.hub, c.Writer, c.Request)
+}
+
+// parseSince reads the sequence number a client resumes after ("" for none)
+func parseSince(raw string) (since uint64, resume bool, err error) {
+	if raw == "" {
+		return 0, false, nil
+	}
+	since, err = strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false, errors.New("since must be a non-negative event sequence number")
+	}
+	return since, true, nil
+}
@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/grpc"
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/grpc-bridge/server/internal/websocket"
+)
+
+// streamer is implemented by clients that support client, server and
+// bidirectional streaming calls
+type streamer interface {
+	OpenStream(ctx context.Context, opts grpc.NativeCallOptions) (*grpc.StreamCall, error)
+}
+
+// outputStreamer is implemented by clients that deliver the responses of a
+// server-streaming call as they arrive but cannot send streamed requests
+type outputStreamer interface {
+	CallStream(ctx context.Context, opts grpc.NativeCallOptions, onMessage func(msg any)) (*grpc.NativeCallResult, error)
+}
+
+// activeCall is a running or waiting call. For streaming calls, which are
+// only started over the WebSocket, stream is set and ready closed once the
+// stream is open (or failed to open).
+type activeCall struct {
+	sessionID string
+	cancel    context.CancelFunc
+	streaming bool
+	ready     chan struct{}
+	stream    *grpc.StreamCall
+}
+
+// CallCommandRequest identifies a running call
+type CallCommandRequest struct {
+	CallID string `json:"call_id"`
+}
+
+// StreamSendRequest is the payload of a stream.send command
+type StreamSendRequest struct {
+	CallID string `json:"call_id"`
+	Data   any    `json:"data"`
+}
+
+// HandleCommand executes a WebSocket command for the client's session.
+// Calls run in the background and report through the usual grpc:// events;
+// the returned ack carries their call_id.
+func (h *GRPCHandler) HandleCommand(client *websocket.Client, cmd websocket.Command) (any, error) {
+	switch cmd.Type {
+	case websocket.CommandInvoke:
+		var req CallRequest
+		if err := decodeCommand(cmd, &req); err != nil {
+			return nil, err
+		}
+		return h.invokeCommand(client.SessionID, &req)
+
+	case websocket.CommandCancel:
+		var req CallCommandRequest
+		if err := decodeCommand(cmd, &req); err != nil {
+			return nil, err
+		}
+		call, err := h.activeCall(client.SessionID, req.CallID)
+		if err != nil {
+			return nil, err
+		}
+		call.cancel()
+		return gin.H{"call_id": req.CallID}, nil
+
+	case websocket.CommandStreamSend:
+		var req StreamSendRequest
+		if err := decodeCommand(cmd, &req); err != nil {
+			return nil, err
+		}
+		stream, err := h.openStream(client.SessionID, req.CallID)
+		if err != nil {
+			return nil, err
+		}
+		if !stream.ClientStreaming() {
+			return nil, errors.New("method does not accept streamed requests")
+		}
+		if err := stream.Send(req.Data); err != nil {
+			return nil, fmt.Errorf("failed to send: %w", err)
+		}
+		return gin.H{"call_id": req.CallID}, nil
+
+	case websocket.CommandStreamClose:
+		var req CallCommandRequest
+		if err := decodeCommand(cmd, &req); err != nil {
+			return nil, err
+		}
+		stream, err := h.openStream(client.SessionID, req.CallID)
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.CloseSend(); err != nil {
+			return nil, fmt.Errorf("failed to close stream: %w", err)
+		}
+		return gin.H{"call_id": req.CallID}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown command type: %s", cmd.Type)
+	}
+}
+
+// decodeCommand unmarshals a command payload
+func decodeCommand(cmd websocket.Command, v any) error {
+	if len(cmd.Payload) == 0 {
+		return errors.New("payload is required")
+	}
+	if err := json.Unmarshal(cmd.Payload, v); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return nil
+}
+
+// invokeCommand resolves the call request and starts it in the background;
+// unary calls first wait for a worker of the call pool. Calls are not tied to
+// the socket: a client reconnecting with ?since= still receives the result.
+func (h *GRPCHandler) invokeCommand(sessionID string, req *CallRequest) (any, error) {
+	sess, exists := h.sessionManager.Get(sessionID)
+	if !exists {
+		return nil, apierror.NewError(apierror.SessionNotFound, "session not found")
+	}
+	if req.Service == "" || req.Method == "" {
+		return nil, errors.New("service and method are required")
+	}
+	if err := h.resolveTemplates(sessionID, req); err != nil {
+		return nil, err
+	}
+
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+	methodDesc, err := h.client.GetMethodDescriptor(sess.ID, sess.RootPath, protoFiles, sess.DescriptorSetPaths(), sess.ImportMappings, req.Service, req.Method)
+	if err != nil {
+		return nil, apierror.NewError(apierror.MethodNotFound, "failed to resolve method: "+err.Error())
+	}
+	streaming := methodDesc.IsClientStreaming() || methodDesc.IsServerStreaming()
+	st, canStream := h.client.(streamer)
+	ost, canStreamOutput := h.client.(outputStreamer)
+	if streaming && !canStream && (methodDesc.IsClientStreaming() || !canStreamOutput) {
+		return nil, apierror.NewError(apierror.FeatureDisabled, "client and bidirectional streaming calls require the native gRPC backend")
+	}
+
+	ack := gin.H{
+		"client_streaming": methodDesc.IsClientStreaming(),
+		"server_streaming": methodDesc.IsServerStreaming(),
+	}
+
+	// Unary calls wait for a worker of the call pool; streams are long-lived
+	// and run on their own goroutine
+	if !streaming {
+		callID, _, err := h.submitCall(sess, req)
+		if err != nil {
+			return nil, err
+		}
+		ack["call_id"] = callID
+		return ack, nil
+	}
+
+	callID := uuid.New().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	call := &activeCall{
+		sessionID: sessionID,
+		cancel:    cancel,
+		streaming: streaming,
+		ready:     make(chan struct{}),
+	}
+	h.trackCall(callID, call)
+
+	go func() {
+		defer func() {
+			cancel()
+			h.untrackCall(callID)
+		}()
+		if canStream {
+			h.runStream(ctx, callID, sess, req, st, call)
+		} else {
+			close(call.ready)
+			h.runOutputStream(ctx, callID, sess, req, ost)
+		}
+	}()
+
+	ack["call_id"] = callID
+	return ack, nil
+}
+
+// trackCall registers a running or waiting call for the cancel and stream
+// commands
+func (h *GRPCHandler) trackCall(callID string, call *activeCall) {
+	h.callsMu.Lock()
+	defer h.callsMu.Unlock()
+
+	h.calls[callID] = call
+}
+
+// untrackCall forgets a finished call
+func (h *GRPCHandler) untrackCall(callID string) {
+	h.callsMu.Lock()
+	defer h.callsMu.Unlock()
+
+	delete(h.calls, callID)
+}
+
+// CancelSessionCalls cancels every running or waiting call of a session (e.g.
+// when it is deleted)
+func (h *GRPCHandler) CancelSessionCalls(sessionID string) {
+	h.callsMu.Lock()
+	var cancels []context.CancelFunc
+	for _, call := range h.calls {
+		if call.sessionID == sessionID {
+			cancels = append(cancels, call.cancel)
+		}
+	}
+	h.callsMu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// ActiveCalls returns the number of calls, started over the WebSocket or the
+// REST API, that have not finished (including those waiting for a worker),
+// and how many of them are streaming
+func (h *GRPCHandler) ActiveCalls() (calls, streams int) {
+	h.callsMu.Lock()
+	defer h.callsMu.Unlock()
+
+	for _, call := range h.calls {
+		if call.streaming {
+			streams++
+		}
+	}
+	return len(h.calls), streams
+}
+
+// activeCall finds a running call of the session
+func (h *GRPCHandler) activeCall(sessionID, callID string) (*activeCall, error) {
+	h.callsMu.Lock()
+	defer h.callsMu.Unlock()
+
+	call, ok := h.calls[callID]
+	if !ok || call.sessionID != sessionID {
+		return nil, apierror.NewError(apierror.NotFound, fmt.Sprintf("call %q not found or already finished", callID))
+	}
+	return call, nil
+}
+
+// openStream waits for a streaming call's stream to be open
+func (h *GRPCHandler) openStream(sessionID, callID string) (*grpc.StreamCall, error) {
+	call, err := h.activeCall(sessionID, callID)
+	if err != nil {
+		return nil, err
+	}
+	if !call.streaming {
+		return nil, errors.New("call is not a streaming call")
+	}
+	<-call.ready
+	if call.stream == nil {
+		return nil, fmt.Errorf("call %q failed to start", callID)
+	}
+	return call.stream, nil
+}
+
+// runStream runs a streaming call, emitting each response as a
+// grpc://stream_message event and the outcome as grpc://stream_end or
+// grpc://error. The request's data, when set, is sent as the first message;
+// for server streaming it is the only one.
+func (h *GRPCHandler) runStream(ctx context.Context, callID string, sess *session.Session, req *CallRequest, st streamer, call *activeCall) {
+	ctx, done := h.sessionManager.BeginOperation(ctx, sess.ID)
+	defer done()
+
+	h.wsHub.EmitToSession(sess.ID, CallStart{
+		CallID:    callID,
+		Target:    req.Target,
+		Service:   req.Service,
+		Method:    req.Method,
+		Streaming: true,
+	})
+	startTime := time.Now()
+	fail := func(err error, messages int) {
+		kind := classifyGRPCErrorKind(err.Error())
+		h.wsHub.EmitToSession(sess.ID, &CallError{
+			CallID:   callID,
+			Code:     errorKindCode(kind),
+			Error:    err.Error(),
+			TookMs:   time.Since(startTime).Milliseconds(),
+			Kind:     kind,
+			Messages: messages,
+		})
+	}
+
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+	stream, err := st.OpenStream(ctx, grpc.NativeCallOptions{
+		SessionID:      sess.ID,
+		SessionRoot:    sess.RootPath,
+		ProtoFiles:     protoFiles,
+		Target:         req.Target,
+		Service:        req.Service,
+		Method:         req.Method,
+		Metadata:       req.Metadata,
+		Plaintext:      req.Plaintext,
+		DescriptorSets: sess.DescriptorSetPaths(),
+		ImportMappings: sess.ImportMappings,
+		TLS:            req.TLS,
+	})
+	call.stream = stream
+	close(call.ready)
+	if err != nil {
+		fail(err, 0)
+		return
+	}
+	defer stream.Close()
+
+	if req.Data != nil || !stream.ClientStreaming() {
+		if err := stream.Send(req.Data); err != nil {
+			fail(err, 0)
+			return
+		}
+	}
+	if !stream.ClientStreaming() {
+		if err := stream.CloseSend(); err != nil {
+			fail(err, 0)
+			return
+		}
+	}
+
+	messages := 0
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fail(err, messages)
+			return
+		}
+		h.emitStreamMessage(sess.ID, callID, messages, msg)
+		messages++
+	}
+
+	h.wsHub.EmitToSession(sess.ID, StreamEnd{
+		CallID:   callID,
+		Messages: messages,
+		Headers:  stream.Header(),
+		Trailers: stream.Trailer(),
+		TookMs:   time.Since(startTime).Milliseconds(),
+	})
+}
+
+// runOutputStream runs a server-streaming call on a client that streams its
+// output, such as the grpcurl backend, emitting the same events as runStream.
+// Headers and trailers are not available.
+func (h *GRPCHandler) runOutputStream(ctx context.Context, callID string, sess *session.Session, req *CallRequest, st outputStreamer) {
+	ctx, done := h.sessionManager.BeginOperation(ctx, sess.ID)
+	defer done()
+
+	h.wsHub.EmitToSession(sess.ID, CallStart{
+		CallID:    callID,
+		Target:    req.Target,
+		Service:   req.Service,
+		Method:    req.Method,
+		Streaming: true,
+	})
+	startTime := time.Now()
+
+	protoFiles := make([]string, len(sess.ProtoFiles))
+	for i, pf := range sess.ProtoFiles {
+		protoFiles[i] = pf.AbsolutePath
+	}
+	messages := 0
+	_, err := st.CallStream(ctx, grpc.NativeCallOptions{
+		SessionID:      sess.ID,
+		SessionRoot:    sess.RootPath,
+		ProtoFiles:     protoFiles,
+		Target:         req.Target,
+		Service:        req.Service,
+		Method:         req.Method,
+		Data:           req.Data,
+		Metadata:       req.Metadata,
+		Plaintext:      req.Plaintext,
+		DescriptorSets: sess.DescriptorSetPaths(),
+		ImportMappings: sess.ImportMappings,
+		TLS:            req.TLS,
+	}, func(msg any) {
+		h.emitStreamMessage(sess.ID, callID, messages, msg)
+		messages++
+	})
+	if err != nil {
+		kind := classifyGRPCErrorKind(err.Error())
+		h.wsHub.EmitToSession(sess.ID, &CallError{
+			CallID:   callID,
+			Code:     errorKindCode(kind),
+			Error:    err.Error(),
+			TookMs:   time.Since(startTime).Milliseconds(),
+			Kind:     kind,
+			Messages: messages,
+		})
+		return
+	}
+
+	h.wsHub.EmitToSession(sess.ID, StreamEnd{
+		CallID:   callID,
+		Messages: messages,
+		TookMs:   time.Since(startTime).Milliseconds(),
+	})
+}
@@ -0,0 +1,262 @@
+package history
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Export formats
+const (
+	FormatJSONL = "jsonl" // One Entry per line
+	FormatHAR   = "har"   // HTTP Archive 1.2 with gRPC specifics in underscore fields
+)
+
+// WriteJSONL writes entries as newline-delimited JSON, one Entry per line
+func WriteJSONL(w io.Writer, entries []*Entry) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HAR is an HTTP Archive holding recorded calls. Each call becomes a POST to
+// grpc://target/service/method (grpcs:// over TLS) with the JSON request as
+// its body. Fields starting with an underscore are HAR custom fields.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of an archive
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the application that wrote the archive
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is one recorded call
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            int64       `json:"time"` // Milliseconds
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ID              string      `json:"_id"`
+	Service         string      `json:"_service"`
+	Method          string      `json:"_method"`
+	GRPCStatus      string      `json:"_grpcStatus"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+// HARRequest is the request of a call; metadata becomes headers
+type HARRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []HARNameVal `json:"cookies"`
+	Headers     []HARNameVal `json:"headers"`
+	QueryString []HARNameVal `json:"queryString"`
+	PostData    *HARPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+// HARResponse is the response of a call. Status is the HTTP equivalent of
+// the gRPC status code; the trailers are kept in _trailers.
+type HARResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []HARNameVal `json:"cookies"`
+	Headers     []HARNameVal `json:"headers"`
+	Trailers    []HARNameVal `json:"_trailers"`
+	Content     HARContent   `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+// HARNameVal is a header, cookie or query parameter
+type HARNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is a request body
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is a response body
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARTimings splits the time of a call; the bridge only measures the whole
+// call, so it is reported as wait
+type HARTimings struct {
+	Blocked int   `json:"blocked"`
+	DNS     int   `json:"dns"`
+	Connect int   `json:"connect"`
+	Send    int   `json:"send"`
+	Wait    int64 `json:"wait"`
+	Receive int   `json:"receive"`
+	SSL     int   `json:"ssl"`
+}
+
+// ToHAR converts entries into an archive, keeping their order
+func ToHAR(entries []*Entry, creatorVersion string) HAR {
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "grpc-bridge", Version: creatorVersion},
+		Entries: make([]HAREntry, 0, len(entries)),
+	}}
+	for _, e := range entries {
+		har.Log.Entries = append(har.Log.Entries, harEntry(e))
+	}
+	return har
+}
+
+// harEntry converts one history entry
+func harEntry(e *Entry) HAREntry {
+	scheme, ssl := "grpcs", 0
+	if e.Plaintext {
+		scheme, ssl = "grpc", -1
+	}
+
+	request := HARRequest{
+		Method:      http.MethodPost,
+		URL:         scheme + "://" + e.Target + "/" + e.Service + "/" + e.Method,
+		HTTPVersion: "HTTP/2",
+		Cookies:     []HARNameVal{},
+		Headers:     harMetadata(e.Metadata),
+		QueryString: []HARNameVal{},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	if body := harJSON(e.Request); body != "" {
+		request.PostData = &HARPostData{MimeType: "application/json", Text: body}
+		request.BodySize = len(body)
+	}
+
+	body := harJSON(e.Response)
+	response := HARResponse{
+		Status:      httpStatus(e.Status),
+		StatusText:  e.Status,
+		HTTPVersion: "HTTP/2",
+		Cookies:     []HARNameVal{},
+		Headers:     harHeaders(e.Headers),
+		Trailers:    harHeaders(e.Trailers),
+		Content:     HARContent{Size: len(body), MimeType: "application/json", Text: body},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+
+	return HAREntry{
+		StartedDateTime: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Time:            e.LatencyMs,
+		Request:         request,
+		Response:        response,
+		Timings:         HARTimings{Blocked: -1, DNS: -1, Connect: -1, Wait: e.LatencyMs, SSL: ssl},
+		ID:              e.ID,
+		Service:         e.Service,
+		Method:          e.Method,
+		GRPCStatus:      e.Status,
+		Error:           e.Error,
+	}
+}
+
+// harJSON renders a payload, or returns "" when there is none
+func harJSON(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// harMetadata converts outgoing metadata into sorted headers
+func harMetadata(md map[string]string) []HARNameVal {
+	out := make([]HARNameVal, 0, len(md))
+	for name, value := range md {
+		out = append(out, HARNameVal{Name: name, Value: value})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// harHeaders converts received headers or trailers, one pair per value
+func harHeaders(md map[string][]string) []HARNameVal {
+	out := []HARNameVal{}
+	for name, values := range md {
+		for _, value := range values {
+			out = append(out, HARNameVal{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// grpcCodes maps status code names, as recorded in Entry.Status, to codes
+var grpcCodes = func() map[string]codes.Code {
+	m := make(map[string]codes.Code)
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		m[c.String()] = c
+	}
+	return m
+}()
+
+// httpStatus maps a gRPC status code name to the HTTP status that gRPC
+// gateways use for it; unknown names map to 500
+func httpStatus(name string) int {
+	code, ok := grpcCodes[name]
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,180 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/secret"
+)
+
+// SQLStore keeps history in the SQL database opened by the database package,
+// bounded per session like MemoryStore
+type SQLStore struct {
+	db         *sql.DB
+	maxEntries int
+	cipher     *secret.Cipher
+}
+
+// NewSQLStore creates a database-backed store keeping at most maxEntries per session
+func NewSQLStore(db *sql.DB, maxEntries int) *SQLStore {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &SQLStore{db: db, maxEntries: maxEntries}
+}
+
+// SetCipher encrypts the sensitive metadata values (see secret.IsSensitive) of
+// entries written from now on. Entries written with a cipher cannot be read
+// without it.
+func (s *SQLStore) SetCipher(c *secret.Cipher) {
+	s.cipher = c
+}
+
+// Add records an entry, evicting the oldest ones past the per-session limit
+func (s *SQLStore) Add(entry *Entry) (*Entry, error) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	data, err := s.encodeEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO history (id, session_id, created_at, service, method, status, entry) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.SessionID, entry.CreatedAt.UnixNano(), entry.Service, entry.Method, entry.Status, data); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM history WHERE session_id = ? AND id NOT IN (
+		SELECT id FROM history WHERE session_id = ? ORDER BY created_at DESC LIMIT ?)`,
+		entry.SessionID, entry.SessionID, s.maxEntries); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Get returns a single entry
+func (s *SQLStore) Get(sessionID, id string) (*Entry, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT entry FROM history WHERE session_id = ? AND id = ?`, sessionID, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeEntry(data)
+}
+
+// List returns matching entries newest first
+func (s *SQLStore) List(sessionID string, filter Filter) ([]*Entry, int, error) {
+	where := []string{"session_id = ?"}
+	args := []any{sessionID}
+	if filter.Service != "" {
+		where = append(where, "service = ?")
+		args = append(args, filter.Service)
+	}
+	if filter.Method != "" {
+		where = append(where, "method = ?")
+		args = append(args, filter.Method)
+	}
+	switch status := strings.ToLower(filter.Status); status {
+	case "":
+	case "error":
+		where = append(where, "status <> 'OK'")
+	default:
+		where = append(where, "lower(status) = ?")
+		args = append(args, status)
+	}
+	clause := strings.Join(where, " AND ")
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM history WHERE `+clause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset, limit := filter.Offset, filter.Limit
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = -1 // No limit
+	}
+	rows, err := s.db.Query(`SELECT entry FROM history WHERE `+clause+` ORDER BY created_at DESC LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []*Entry{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, 0, err
+		}
+		entry, err := s.decodeEntry(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// Delete removes a single entry
+func (s *SQLStore) Delete(sessionID, id string) error {
+	result, err := s.db.Exec(`DELETE FROM history WHERE session_id = ? AND id = ?`, sessionID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrEntryNotFound
+	}
+	return nil
+}
+
+// Clear removes all entries of a session
+func (s *SQLStore) Clear(sessionID string) error {
+	_, err := s.db.Exec(`DELETE FROM history WHERE session_id = ?`, sessionID)
+	return err
+}
+
+// encodeEntry encodes an entry for storage with its secrets sealed
+func (s *SQLStore) encodeEntry(entry *Entry) (string, error) {
+	sealed := *entry
+	metadata, err := s.cipher.Seal(entry.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("history metadata %w", err)
+	}
+	sealed.Metadata = metadata
+	data, err := json.Marshal(&sealed)
+	return string(data), err
+}
+
+// decodeEntry decodes a stored entry, opening its sealed secrets
+func (s *SQLStore) decodeEntry(data string) (*Entry, error) {
+	var entry Entry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		return nil, err
+	}
+	if err := s.cipher.Open(entry.Metadata); err != nil {
+		return nil, fmt.Errorf("history metadata %w", err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-bridge/server/internal/database"
+	"github.com/grpc-bridge/server/internal/secret"
+)
+
+func TestSQLStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.db")
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	store := NewSQLStore(db, 10)
+	added, err := store.Add(&Entry{SessionID: "s1", Service: "pkg.Greeter", Method: "SayHello", Status: "OK", Request: map[string]any{"name": "a"}})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	db.Close()
+
+	db, err = database.Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	store = NewSQLStore(db, 10)
+
+	got, err := store.Get("s1", added.ID)
+	if err != nil {
+		t.Fatalf("Get after reopen: %v", err)
+	}
+	if got.Method != "SayHello" || got.Request.(map[string]any)["name"] != "a" {
+		t.Errorf("Get after reopen = %+v", got)
+	}
+	if _, err := store.Get("s2", added.ID); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("Get from another session = %v, want ErrEntryNotFound", err)
+	}
+}
+
+func TestSQLStoreListFilterAndLimit(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "bridge.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	store := NewSQLStore(db, 3)
+
+	base := time.Now()
+	statuses := []string{"OK", "Unavailable", "OK", "NotFound"}
+	for i, status := range statuses {
+		if _, err := store.Add(&Entry{SessionID: "s1", CreatedAt: base.Add(time.Duration(i) * time.Second), Method: "M", Status: status}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	entries, total, err := store.List("s1", Filter{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if total != 3 || len(entries) != 3 || entries[0].Status != "NotFound" {
+		t.Fatalf("List = %d entries (total %d), first %+v; want the 3 newest, newest first", len(entries), total, entries[0])
+	}
+	if _, total, _ := store.List("s1", Filter{Status: "error"}); total != 2 {
+		t.Errorf("error filter total = %d, want 2", total)
+	}
+
+	if err := store.Delete("s1", entries[0].ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete("s1", entries[0].ID); !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("second Delete = %v, want ErrEntryNotFound", err)
+	}
+	if err := store.Clear("s1"); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, total, _ := store.List("s1", Filter{}); total != 0 {
+		t.Errorf("total after Clear = %d", total)
+	}
+}
+
+func TestSQLStoreSealsMetadata(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "bridge.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	c, err := secret.NewCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	store := NewSQLStore(db, 10)
+	store.SetCipher(c)
+
+	added, err := store.Add(&Entry{SessionID: "s1", Method: "SayHello", Status: "OK",
+		Metadata: map[string]string{"authorization": "Bearer tok", "x-trace": "t1"}})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if added.Metadata["authorization"] != "Bearer tok" {
+		t.Errorf("Add returned metadata %v, want plaintext", added.Metadata)
+	}
+
+	var raw string
+	if err := db.QueryRow(`SELECT entry FROM history WHERE id = ?`, added.ID).Scan(&raw); err != nil {
+		t.Fatalf("raw row: %v", err)
+	}
+	if strings.Contains(raw, "Bearer tok") || !strings.Contains(raw, "enc:v1:") || !strings.Contains(raw, "t1") {
+		t.Errorf("raw row = %s, want the token encrypted and other metadata as is", raw)
+	}
+
+	got, err := store.Get("s1", added.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Metadata["authorization"] != "Bearer tok" {
+		t.Errorf("Get metadata = %v, want plaintext", got.Metadata)
+	}
+
+	store.SetCipher(nil)
+	if _, err := store.Get("s1", added.ID); !errors.Is(err, secret.ErrNoKey) {
+		t.Errorf("Get without key = %v, want ErrNoKey", err)
+	}
+}
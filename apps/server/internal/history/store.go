@@ -0,0 +1,195 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry represents a single executed gRPC call
+type Entry struct {
+	ID        string              `json:"id"`
+	SessionID string              `json:"session_id"`
+	CreatedAt time.Time           `json:"created_at"`
+	Target    string              `json:"target"`
+	Service   string              `json:"service"`
+	Method    string              `json:"method"`
+	Request   interface{}         `json:"request"`            // Request payload as sent (after templating)
+	Metadata  map[string]string   `json:"metadata,omitempty"` // Outgoing metadata
+	Plaintext bool                `json:"plaintext"`
+	Response  interface{}         `json:"response,omitempty"`
+	Headers   map[string][]string `json:"headers,omitempty"`
+	Trailers  map[string][]string `json:"trailers,omitempty"`
+	Status    string              `json:"status"`          // gRPC status code name (e.g. "OK", "Unavailable")
+	Error     string              `json:"error,omitempty"` // Error message for failed calls
+	LatencyMs int64               `json:"latency_ms"`
+}
+
+// Filter narrows down history queries. Empty fields match everything.
+type Filter struct {
+	Service string // Exact fully qualified service name
+	Method  string // Exact method name
+	Status  string // Status code name, or "ok" / "error" to match success / any failure
+	Offset  int
+	Limit   int
+}
+
+// Matches reports whether an entry satisfies the filter criteria (ignores pagination)
+func (f Filter) Matches(e *Entry) bool {
+	if f.Service != "" && e.Service != f.Service {
+		return false
+	}
+	if f.Method != "" && e.Method != f.Method {
+		return false
+	}
+	switch status := strings.ToLower(f.Status); status {
+	case "":
+	case "error":
+		if e.Status == "OK" {
+			return false
+		}
+	default:
+		if strings.ToLower(e.Status) != status {
+			return false
+		}
+	}
+	return true
+}
+
+// Store persists call history per session
+type Store interface {
+	// Add records an entry, assigning ID and timestamp when empty
+	Add(entry *Entry) (*Entry, error)
+	// Get returns a single entry of a session
+	Get(sessionID, id string) (*Entry, error)
+	// List returns entries newest first along with the total count matching the filter
+	List(sessionID string, filter Filter) ([]*Entry, int, error)
+	// Delete removes a single entry
+	Delete(sessionID, id string) error
+	// Clear removes all entries of a session
+	Clear(sessionID string) error
+}
+
+// MemoryStore keeps history in memory, bounded per session
+type MemoryStore struct {
+	entries    map[string][]*Entry // sessionID -> entries (oldest first)
+	maxEntries int
+	mu         sync.RWMutex
+}
+
+// NewMemoryStore creates an in-memory store keeping at most maxEntries per session
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryStore{
+		entries:    make(map[string][]*Entry),
+		maxEntries: maxEntries,
+	}
+}
+
+// Add records an entry, evicting the oldest ones past the per-session limit
+func (s *MemoryStore) Add(entry *Entry) (*Entry, error) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := append(s.entries[entry.SessionID], entry)
+	if len(list) > s.maxEntries {
+		list = list[len(list)-s.maxEntries:]
+	}
+	s.entries[entry.SessionID] = list
+	return entry, nil
+}
+
+// Get returns a single entry
+func (s *MemoryStore) Get(sessionID, id string) (*Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, e := range s.entries[sessionID] {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, ErrEntryNotFound
+}
+
+// List returns matching entries newest first
+func (s *MemoryStore) List(sessionID string, filter Filter) ([]*Entry, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := []*Entry{}
+	for _, e := range s.entries[sessionID] {
+		if filter.Matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	return paginate(matched, filter.Offset, filter.Limit), len(matched), nil
+}
+
+// Delete removes a single entry
+func (s *MemoryStore) Delete(sessionID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.entries[sessionID]
+	for i, e := range list {
+		if e.ID == id {
+			s.entries[sessionID] = append(list[:i:i], list[i+1:]...)
+			return nil
+		}
+	}
+	return ErrEntryNotFound
+}
+
+// Clear removes all entries of a session
+func (s *MemoryStore) Clear(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, sessionID)
+	return nil
+}
+
+// paginate applies offset/limit to a slice
+func paginate(entries []*Entry, offset, limit int) []*Entry {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(entries) {
+		return []*Entry{}
+	}
+	end := len(entries)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return entries[offset:end]
+}
+
+// Errors
+var (
+	ErrEntryNotFound = &HistoryError{"history entry not found"}
+)
+
+type HistoryError struct {
+	Message string
+}
+
+func (e *HistoryError) Error() string {
+	return e.Message
+}
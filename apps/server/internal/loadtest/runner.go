@@ -0,0 +1,287 @@
+package loadtest
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+)
+
+// Limits applied to user supplied configurations
+const (
+	MaxTotal       = 100000
+	MaxConcurrency = 200
+	MaxDuration    = 5 * time.Minute
+	MaxRPS         = 10000
+)
+
+// InvokeFunc performs a single request
+type InvokeFunc func(ctx context.Context) error
+
+// Config controls a load test run. The run stops when Total requests have been
+// issued or Duration has elapsed, whichever comes first (zero disables a bound).
+type Config struct {
+	Total            int           // Number of requests to send
+	Concurrency      int           // Number of concurrent workers
+	RPS              float64       // Overall request rate limit (0 = unlimited)
+	Duration         time.Duration // Maximum run time
+	ProgressInterval time.Duration // How often onProgress is invoked
+}
+
+// Progress is a periodic snapshot of a running test
+type Progress struct {
+	Completed  int     `json:"completed"`
+	Ok         int     `json:"ok"`
+	Errors     int     `json:"errors"`
+	ElapsedMs  float64 `json:"elapsed_ms"`
+	CurrentRPS float64 `json:"current_rps"`
+}
+
+// Bucket is one latency histogram bin
+type Bucket struct {
+	FromMs float64 `json:"from_ms"`
+	ToMs   float64 `json:"to_ms"`
+	Count  int     `json:"count"`
+}
+
+// Summary describes the outcome of a load test
+type Summary struct {
+	Total       int            `json:"total"`
+	Ok          int            `json:"ok"`
+	Errors      int            `json:"errors"`
+	Interrupted int            `json:"interrupted"` // Requests cut off by the end of the run; not counted in Total
+	ErrorCodes  map[string]int `json:"error_codes"` // gRPC status code name -> count
+	DurationMs  float64        `json:"duration_ms"`
+	Throughput  float64        `json:"throughput_rps"`
+	Cancelled   bool           `json:"cancelled"`
+	Latency     LatencyStats   `json:"latency"`
+	Histogram   []Bucket       `json:"histogram"`
+	Concurrency int            `json:"concurrency"`
+}
+
+// LatencyStats summarizes request latencies in milliseconds
+type LatencyStats struct {
+	MinMs  float64 `json:"min_ms"`
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// Normalize fills defaults and clamps a config to the allowed limits
+func (c Config) Normalize() Config {
+	if c.Concurrency <= 0 {
+		c.Concurrency = 10
+	}
+	if c.Concurrency > MaxConcurrency {
+		c.Concurrency = MaxConcurrency
+	}
+	if c.Total <= 0 && c.Duration <= 0 {
+		c.Total = 200
+	}
+	if c.Total > MaxTotal {
+		c.Total = MaxTotal
+	}
+	if c.RPS < 0 || math.IsNaN(c.RPS) {
+		c.RPS = 0
+	}
+	if c.RPS > MaxRPS {
+		c.RPS = MaxRPS
+	}
+	if c.Duration <= 0 || c.Duration > MaxDuration {
+		c.Duration = MaxDuration
+	}
+	if c.ProgressInterval <= 0 {
+		c.ProgressInterval = 500 * time.Millisecond
+	}
+	return c
+}
+
+// Run executes invoke according to cfg and returns aggregated statistics.
+// onProgress (optional) is called periodically from a separate goroutine.
+func Run(ctx context.Context, cfg Config, invoke InvokeFunc, onProgress func(Progress)) *Summary {
+	cfg = cfg.Normalize()
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var (
+		mu         sync.Mutex
+		latencies  []float64
+		errorCodes = map[string]int{}
+		ok         int
+		failed     int
+		cutOff     int
+	)
+
+	jobs := make(chan struct{})
+	start := time.Now()
+
+	// Producer: emits jobs honoring Total and RPS
+	go func() {
+		defer close(jobs)
+		var ticker *time.Ticker
+		if cfg.RPS > 0 {
+			ticker = time.NewTicker(time.Duration(float64(time.Second) / cfg.RPS))
+			defer ticker.Stop()
+		}
+		for sent := 0; cfg.Total <= 0 || sent < cfg.Total; sent++ {
+			if ticker != nil {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Progress reporter
+	progressDone := make(chan struct{})
+	if onProgress != nil {
+		go func() {
+			ticker := time.NewTicker(cfg.ProgressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					mu.Lock()
+					p := Progress{Completed: ok + failed, Ok: ok, Errors: failed}
+					mu.Unlock()
+					elapsed := time.Since(start)
+					p.ElapsedMs = float64(elapsed.Microseconds()) / 1000
+					if elapsed > 0 {
+						p.CurrentRPS = float64(p.Completed) / elapsed.Seconds()
+					}
+					onProgress(p)
+				case <-progressDone:
+					return
+				}
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				reqStart := time.Now()
+				err := invoke(ctx)
+				latency := float64(time.Since(reqStart).Microseconds()) / 1000
+
+				mu.Lock()
+				if err != nil && ctx.Err() != nil {
+					// The run ended (Duration elapsed or cancelled) while the
+					// request was in flight; that says nothing about the target
+					cutOff++
+					mu.Unlock()
+					continue
+				}
+				latencies = append(latencies, latency)
+				if err != nil {
+					failed++
+					errorCodes[status.Code(err).String()]++
+				} else {
+					ok++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(progressDone)
+
+	elapsed := time.Since(start)
+	summary := &Summary{
+		Total:       ok + failed,
+		Ok:          ok,
+		Errors:      failed,
+		Interrupted: cutOff,
+		ErrorCodes:  errorCodes,
+		DurationMs:  float64(elapsed.Microseconds()) / 1000,
+		Cancelled:   ctx.Err() == context.Canceled,
+		Latency:     ComputeLatencyStats(latencies),
+		Histogram:   buildHistogram(latencies, 10),
+		Concurrency: cfg.Concurrency,
+	}
+	if elapsed > 0 {
+		summary.Throughput = float64(summary.Total) / elapsed.Seconds()
+	}
+	return summary
+}
+
+// ComputeLatencyStats derives min/mean/percentiles/max from raw latencies in milliseconds
+func ComputeLatencyStats(latencies []float64) LatencyStats {
+	if len(latencies) == 0 {
+		return LatencyStats{}
+	}
+	sorted := append([]float64(nil), latencies...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, l := range sorted {
+		sum += l
+	}
+	return LatencyStats{
+		MinMs:  sorted[0],
+		MeanMs: sum / float64(len(sorted)),
+		P50Ms:  percentile(sorted, 50),
+		P90Ms:  percentile(sorted, 90),
+		P95Ms:  percentile(sorted, 95),
+		P99Ms:  percentile(sorted, 99),
+		MaxMs:  sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of a sorted slice
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// buildHistogram splits the latency range into equal-width buckets
+func buildHistogram(latencies []float64, buckets int) []Bucket {
+	if len(latencies) == 0 {
+		return []Bucket{}
+	}
+	minL, maxL := latencies[0], latencies[0]
+	for _, l := range latencies {
+		minL = math.Min(minL, l)
+		maxL = math.Max(maxL, l)
+	}
+	width := (maxL - minL) / float64(buckets)
+	if width == 0 {
+		return []Bucket{{FromMs: minL, ToMs: maxL, Count: len(latencies)}}
+	}
+
+	out := make([]Bucket, buckets)
+	for i := range out {
+		out[i].FromMs = minL + float64(i)*width
+		out[i].ToMs = minL + float64(i+1)*width
+	}
+	for _, l := range latencies {
+		idx := int((l - minL) / width)
+		if idx >= buckets {
+			idx = buckets - 1
+		}
+		out[idx].Count++
+	}
+	return out
+}
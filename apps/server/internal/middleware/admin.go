@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/auth"
+)
+
+// RequireAdmin lets only the users listed in admins through. It runs after
+// Auth. When authentication is off every request is anonymous and passes, so
+// routes behind it are as open as the rest of the API.
+func RequireAdmin(a *auth.Authenticator, admins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(admins))
+	for _, user := range admins {
+		allowed[user] = true
+	}
+	return func(c *gin.Context) {
+		if !a.Enabled() {
+			c.Next()
+			return
+		}
+		if user := auth.User(c); user == "" || !allowed[user] {
+			apierror.Abort(c, http.StatusForbidden, apierror.PermissionDenied, "admin access required", nil)
+			return
+		}
+		c.Next()
+	}
+}
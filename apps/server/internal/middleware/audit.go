@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/audit"
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// auditedRoutes maps the routes recorded in session audit trails to their action
+var auditedRoutes = map[string]string{
+	"POST /api/sessions":                                        audit.ActionCreate,
+	"POST /api/sessions/import":                                 audit.ActionCreate,
+	"POST /api/sessions/:sessionId/restore":                     audit.ActionCreate,
+	"PATCH /api/sessions/:sessionId":                            audit.ActionEdit,
+	"DELETE /api/sessions/:sessionId":                           audit.ActionDelete,
+	"PUT /api/sessions/:sessionId/variables":                    audit.ActionEdit,
+	"PUT /api/sessions/:sessionId/variables/:name":              audit.ActionEdit,
+	"DELETE /api/sessions/:sessionId/variables/:name":           audit.ActionDelete,
+	"PUT /api/sessions/:sessionId/metadata":                     audit.ActionEdit,
+	"PUT /api/sessions/:sessionId/metadata/:key":                audit.ActionEdit,
+	"DELETE /api/sessions/:sessionId/metadata/:key":             audit.ActionDelete,
+	"PUT /api/sessions/:sessionId/environments/:name":           audit.ActionEdit,
+	"DELETE /api/sessions/:sessionId/environments/:name":        audit.ActionDelete,
+	"POST /api/sessions/:sessionId/targets":                     audit.ActionEdit,
+	"PUT /api/sessions/:sessionId/targets/:targetId":            audit.ActionEdit,
+	"DELETE /api/sessions/:sessionId/targets/:targetId":         audit.ActionDelete,
+	"PUT /api/sessions/:sessionId/import-mappings":              audit.ActionEdit,
+	"DELETE /api/sessions/:sessionId/history":                   audit.ActionDelete,
+	"DELETE /api/sessions/:sessionId/history/:historyId":        audit.ActionDelete,
+	"PUT /api/sessions/:sessionId/file-content":                 audit.ActionEdit,
+	"POST /api/collections":                                     audit.ActionEdit,
+	"PUT /api/collections/:collectionId":                        audit.ActionEdit,
+	"DELETE /api/collections/:collectionId":                     audit.ActionDelete,
+	"POST /api/collections/:collectionId/requests":              audit.ActionEdit,
+	"PUT /api/collections/:collectionId/requests/:requestId":    audit.ActionEdit,
+	"DELETE /api/collections/:collectionId/requests/:requestId": audit.ActionDelete,
+	"POST /api/proto/upload-structure":                          audit.ActionUpload,
+	"POST /api/proto/upload-archive":                            audit.ActionUpload,
+	"POST /api/proto/import-git":                                audit.ActionUpload,
+	"POST /api/proto/upload-protoset":                           audit.ActionUpload,
+	"POST /api/proto/save-reflection":                           audit.ActionUpload,
+	"POST /api/grpc/call":                                       audit.ActionCall,
+	"POST /api/grpc/replay/:historyId":                          audit.ActionCall,
+	"POST /api/grpc/chain":                                      audit.ActionCall,
+	"POST /api/grpc/loadtest":                                   audit.ActionCall,
+}
+
+// Audit records uploads, edits, deletions and calls in the audit trail of the
+// sessions they touch, along with the acting user and client IP. Requests that
+// end without an existing session (unknown IDs, deleted sessions) are not
+// recorded.
+func Audit(store audit.Store, sm *session.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action, ok := auditedRoutes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+		ids, _ := requestSessionIDs(c)
+
+		c.Next()
+
+		if id := audit.Session(c); id != "" {
+			ids = append(ids, id)
+		}
+		details := map[string]string{}
+		for _, p := range c.Params {
+			if p.Key != "sessionId" {
+				details[p.Key] = p.Value
+			}
+		}
+		for k, v := range audit.Details(c) {
+			details[k] = v
+		}
+
+		seen := map[string]bool{}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if _, exists := sm.Get(id); !exists {
+				continue
+			}
+			_, _ = store.Add(&audit.Entry{
+				SessionID: id,
+				Action:    action,
+				Method:    c.Request.Method,
+				Route:     c.FullPath(),
+				Status:    c.Writer.Status(),
+				User:      auth.User(c),
+				IP:        c.ClientIP(),
+				Details:   details,
+			})
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// Auth rejects unauthenticated requests and records the user on the context.
+// Paths in public (e.g. the health check) are served without credentials, and
+// signed download URLs act as the user they were signed for.
+func Auth(a *auth.Authenticator, public ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.Enabled() || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+		for _, p := range public {
+			if c.Request.URL.Path == p {
+				c.Next()
+				return
+			}
+		}
+
+		if user, ok := a.SignedURL(c.Request); ok {
+			auth.SetUser(c, user)
+			c.Next()
+			return
+		}
+
+		user, ok := a.Authenticate(c.Request)
+		if !ok {
+			if challenge := a.Challenge(); challenge != "" {
+				c.Header("WWW-Authenticate", challenge)
+			}
+			var details map[string]any
+			if a.OIDC() != nil {
+				details = gin.H{"login_url": auth.LoginPath}
+			}
+			apierror.Abort(c, http.StatusUnauthorized, apierror.Unauthenticated, "authentication required", details)
+			return
+		}
+		auth.SetUser(c, user)
+		c.Next()
+	}
+}
+
+// RequireLogin sends browsers that have not signed in through OIDC to the
+// login page, coming back to the requested page afterwards. It guards the
+// frontend; outside OIDC mode it lets every request through.
+func RequireLogin(a *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a.OIDC() == nil {
+			c.Next()
+			return
+		}
+		if _, ok := a.Identity(c.Request); !ok {
+			c.Redirect(http.StatusFound, auth.LoginPath+"?return="+url.QueryEscape(c.Request.URL.RequestURI()))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// SessionAccess keeps users out of sessions owned by someone else. The session
+// is taken from the :sessionId path parameter, the X-Session-ID header, the
+// sessionId query or form field, or a sessionId field of a JSON body. Foreign
+// sessions are reported as missing so their IDs cannot be probed. Anonymous
+// API keys only reach sessions without an owner. A JSON body that cannot be
+// searched (over 1MB or malformed) is rejected rather than let through.
+func SessionAccess(sm *session.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.Authenticated(c) {
+			c.Next()
+			return
+		}
+		user := auth.User(c)
+
+		ids, err := requestSessionIDs(c)
+		switch {
+		case errors.Is(err, errSessionBodyTooLarge):
+			apierror.Abort(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge,
+				fmt.Sprintf("JSON request body exceeds the maximum size of %d bytes", maxSessionLookupBody),
+				gin.H{"max_request_size": maxSessionLookupBody})
+			return
+		case err != nil:
+			apierror.Abort(c, http.StatusBadRequest, apierror.InvalidRequest, "invalid request: "+err.Error(), nil)
+			return
+		}
+		for _, id := range ids {
+			sess, exists := sm.Get(id)
+			if exists && sess.Owner != "" && sess.Owner != user {
+				apierror.Abort(c, http.StatusNotFound, apierror.SessionNotFound, "session not found", nil)
+				return
+			}
+		}
+		c.Next()
+	}
+}
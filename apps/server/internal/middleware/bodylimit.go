@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+)
+
+// BodyLimit caps the body of requests whose path starts with one of prefixes
+// at max bytes. Reading past the cap fails with *http.MaxBytesError, so
+// handlers can answer 413; it must run before anything parses the body (such
+// as the session lookup of other middleware). A max of 0 disables the cap.
+func BodyLimit(max int64, prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if max > 0 && c.Request.Body != nil && hasAnyPrefix(c.Request.URL.Path, prefixes) {
+			if c.Request.ContentLength > max {
+				apierror.Abort(c, http.StatusRequestEntityTooLarge, apierror.PayloadTooLarge,
+					fmt.Sprintf("request body exceeds the maximum size of %d bytes", max),
+					gin.H{"max_request_size": max})
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+		}
+		c.Next()
+	}
+}
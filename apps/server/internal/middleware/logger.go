@@ -1,14 +1,79 @@
 package middleware
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"math/rand/v2"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+	"github.com/grpc-bridge/server/internal/auth"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Logger middleware for logging HTTP requests
-func Logger() gin.HandlerFunc {
+// Access log formats; see AccessLogConfig
+const (
+	AccessLogText     = "text"     // The server's classic one-line format, through the standard logger
+	AccessLogJSON     = "json"     // One JSON object per request
+	AccessLogCombined = "combined" // Apache/NGINX combined log format
+	AccessLogOff      = "off"
+)
+
+// AccessLogFields lists the fields a JSON access log can contain, in output order
+var AccessLogFields = []string{
+	"time", "status", "method", "path", "query", "route", "latency_ms", "bytes",
+	"client_ip", "user", "session_id", "user_agent", "referer", "request_id", "trace_id", "errors",
+}
+
+// AccessLogConfig controls the access log
+type AccessLogConfig struct {
+	Format     string    // text (default), json, combined or off
+	Fields     []string  // JSON fields to write, from AccessLogFields; empty writes all
+	SampleRate float64   // Share of requests below 400 that are logged, 0 to 1; errors are always logged
+	Output     io.Writer // Destination for json and combined lines; text goes to the standard logger
+}
+
+// ParseAccessLogFields splits a comma-separated field list and rejects
+// unknown fields
+func ParseAccessLogFields(list string) ([]string, error) {
+	known := make(map[string]bool, len(AccessLogFields))
+	for _, field := range AccessLogFields {
+		known[field] = true
+	}
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !known[field] {
+			return nil, fmt.Errorf("unknown access log field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// AccessLog logs every request (or a sample of successful ones) once it has
+// been served
+func AccessLog(cfg AccessLogConfig) gin.HandlerFunc {
+	if cfg.Format == AccessLogOff {
+		return func(c *gin.Context) { c.Next() }
+	}
+	fields := cfg.Fields
+	if len(fields) == 0 {
+		fields = AccessLogFields
+	}
+	// A logger without prefix or flags writes each line with a single Write
+	var out *log.Logger
+	if cfg.Output != nil {
+		out = log.New(cfg.Output, "", 0)
+	}
+
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -16,22 +81,128 @@ func Logger() gin.HandlerFunc {
 
 		c.Next()
 
-		latency := time.Since(start)
-		clientIP := c.ClientIP()
-		method := c.Request.Method
 		statusCode := c.Writer.Status()
+		if statusCode < 400 && cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate {
+			return
+		}
+		latency := time.Since(start)
 
-		if raw != "" {
-			path = path + "?" + raw
+		switch cfg.Format {
+		case AccessLogJSON:
+			out.Print(jsonAccessLine(c, fields, start, latency, path, raw))
+		case AccessLogCombined:
+			out.Print(combinedAccessLine(c, start, path, raw))
+		default:
+			if raw != "" {
+				path = path + "?" + raw
+			}
+			log.Printf("[HTTP] %s | %3d | %13v | %15s | %-7s %s",
+				time.Now().Format("2006/01/02 - 15:04:05"),
+				statusCode,
+				latency,
+				c.ClientIP(),
+				c.Request.Method,
+				path,
+			)
 		}
+	}
+}
+
+// jsonAccessLine renders the selected fields of a request as a JSON object
+func jsonAccessLine(c *gin.Context, fields []string, start time.Time, latency time.Duration, path, raw string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, field := range fields {
+		var value any
+		switch field {
+		case "time":
+			value = start.UTC().Format(time.RFC3339Nano)
+		case "status":
+			value = c.Writer.Status()
+		case "method":
+			value = c.Request.Method
+		case "path":
+			value = path
+		case "query":
+			value = raw
+		case "route":
+			value = c.FullPath()
+		case "latency_ms":
+			value = float64(latency.Microseconds()) / 1000
+		case "bytes":
+			value = max(c.Writer.Size(), 0)
+		case "client_ip":
+			value = c.ClientIP()
+		case "user":
+			value = auth.User(c)
+		case "session_id":
+			value = requestSessionID(c)
+		case "user_agent":
+			value = c.Request.UserAgent()
+		case "referer":
+			value = c.Request.Referer()
+		case "request_id":
+			value = apierror.RequestID(c)
+		case "trace_id":
+			if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+				value = sc.TraceID().String()
+			} else {
+				value = ""
+			}
+		case "errors":
+			errs := c.Errors.Errors()
+			if errs == nil {
+				errs = []string{}
+			}
+			value = errs
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			encoded = []byte("null")
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%q:%s", field, encoded)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// combinedAccessLine renders a request in the combined log format:
+// host ident user [time] "request" status bytes "referer" "user agent"
+func combinedAccessLine(c *gin.Context, start time.Time, path, raw string) string {
+	if raw != "" {
+		path = path + "?" + raw
+	}
+	return fmt.Sprintf("%s - %s [%s] %q %d %d %q %q",
+		c.ClientIP(),
+		dash(auth.User(c)),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		c.Request.Method+" "+path+" "+c.Request.Proto,
+		c.Writer.Status(),
+		max(c.Writer.Size(), 0),
+		dash(c.Request.Referer()),
+		dash(c.Request.UserAgent()),
+	)
+}
+
+// requestSessionID returns the session a request names in its path or
+// X-Session-ID header, without reading the body
+func requestSessionID(c *gin.Context) string {
+	if id := c.Param("sessionId"); id != "" {
+		return id
+	}
+	if id := c.GetHeader("X-Session-ID"); id != "" {
+		return id
+	}
+	return c.Query("sessionId")
+}
 
-		log.Printf("[HTTP] %s | %3d | %13v | %15s | %-7s %s",
-			time.Now().Format("2006/01/02 - 15:04:05"),
-			statusCode,
-			latency,
-			clientIP,
-			method,
-			path,
-		)
+// dash stands in for empty combined log fields
+func dash(s string) string {
+	if s == "" {
+		return "-"
 	}
+	return s
 }
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+)
+
+// APINotFound answers unknown routes under one of prefixes with the API error
+// envelope instead of falling through to the frontend or Gin's plain-text 404
+func APINotFound(prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasAnyPrefix(c.Request.URL.Path, prefixes) {
+			apierror.Abort(c, http.StatusNotFound, apierror.NotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path, nil)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/apierror"
+)
+
+// rateLimitSweepInterval is how often idle buckets are dropped
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter is a set of token buckets, one per key (a session ID or client
+// IP). Each bucket holds up to burst tokens and refills at rate tokens per
+// second; a request takes one token.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rate requests per second per key,
+// with bursts of up to burst requests. A burst below 1 defaults to the rate
+// rounded up.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = int(math.Ceil(rate))
+	}
+	return &RateLimiter{
+		rate:      rate,
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from the key's bucket. When the bucket is empty it
+// reports false and how long until the next token.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have refilled completely; they behave exactly like
+// new ones. Callers hold l.mu.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit throttles requests whose path starts with one of prefixes, per
+// session (bySession) and per client IP (byIP). Either limiter may be nil.
+// Requests over the limit get 429 with a Retry-After header.
+func RateLimit(bySession, byIP *RateLimiter, prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions || !hasAnyPrefix(c.Request.URL.Path, prefixes) {
+			c.Next()
+			return
+		}
+
+		if byIP != nil {
+			if ok, wait := byIP.Allow(c.ClientIP()); !ok {
+				tooManyRequests(c, "client", wait)
+				return
+			}
+		}
+		if bySession != nil {
+			ids, _ := requestSessionIDs(c)
+			for _, id := range ids {
+				if ok, wait := bySession.Allow(id); !ok {
+					tooManyRequests(c, "session", wait)
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+func tooManyRequests(c *gin.Context, scope string, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	apierror.Abort(c, http.StatusTooManyRequests, apierror.RateLimited, "rate limit exceeded for this "+scope, gin.H{
+		"retry_after": seconds,
+	})
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
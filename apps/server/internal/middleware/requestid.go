@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/apierror"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID accepts the IDs proxies commonly generate while keeping
+// arbitrary text out of logs and response headers
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID tags every request with an ID, reusing a well-formed X-Request-ID
+// from the client or a proxy and generating one otherwise. The ID is echoed in
+// the response header and in error bodies so a failure can be matched with
+// the logs.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		apierror.SetRequestID(c, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
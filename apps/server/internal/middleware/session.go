@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// maxSessionLookupBody bounds how much of a JSON body is buffered to find its session ID
+const maxSessionLookupBody = 1 << 20 // 1MB
+
+// sessionIDsKey caches the sessionLookup of a request
+const sessionIDsKey = "middleware.sessionIDs"
+
+// sessionLookup is the outcome of requestSessionIDs
+type sessionLookup struct {
+	ids []string
+	err error
+}
+
+// Reasons a JSON body cannot be searched for its session ID. SessionAccess
+// rejects such requests: the handler would bind the whole body and could find
+// a session ID the lookup missed.
+var (
+	errSessionBodyTooLarge = errors.New("JSON request body is too large")
+	errSessionBodyInvalid  = errors.New("request body is not valid JSON")
+)
+
+// SessionActivity marks the sessions a request refers to as used, keeping
+// active sessions from expiring
+func SessionActivity(sm *session.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ids, _ := requestSessionIDs(c)
+		for _, id := range ids {
+			sm.Touch(id)
+		}
+		c.Next()
+	}
+}
+
+// requestSessionIDs collects every session ID a request refers to. A JSON body
+// over maxSessionLookupBody bytes or that does not parse yields an error, as
+// it may refer to a session that was not found. The result is cached on the
+// context so the body is only inspected once.
+func requestSessionIDs(c *gin.Context) ([]string, error) {
+	if cached, ok := c.Get(sessionIDsKey); ok {
+		lookup := cached.(sessionLookup)
+		return lookup.ids, lookup.err
+	}
+
+	var ids []string
+	var lookupErr error
+	add := func(id string) {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	add(c.Param("sessionId"))
+	add(c.GetHeader("X-Session-ID"))
+	add(c.Query("sessionId"))
+
+	contentType := c.ContentType()
+	switch {
+	case contentType == "multipart/form-data" || contentType == "application/x-www-form-urlencoded":
+		// The parsed form is cached on the request for the handler
+		add(c.PostForm("sessionId"))
+	case strings.HasSuffix(contentType, "json") && c.Request.Body != nil:
+		// One byte past the cap tells a body at the cap from a longer one
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSessionLookupBody+1))
+		// Hand the handler an untouched body: the buffered prefix plus any remainder
+		c.Request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+
+		var maxBytes *http.MaxBytesError
+		switch trimmed := bytes.TrimSpace(body); {
+		case errors.As(err, &maxBytes) || len(body) > maxSessionLookupBody:
+			lookupErr = errSessionBodyTooLarge
+		case err != nil:
+			lookupErr = errSessionBodyInvalid
+		case len(trimmed) == 0:
+			// No body: the handler reports it
+		case !json.Valid(trimmed):
+			lookupErr = errSessionBodyInvalid
+		case trimmed[0] == '{':
+			var payload struct {
+				SessionID string `json:"sessionId"`
+			}
+			if json.Unmarshal(trimmed, &payload) != nil {
+				lookupErr = errSessionBodyInvalid
+			}
+			add(payload.SessionID)
+		}
+	}
+	c.Set(sessionIDsKey, sessionLookup{ids: ids, err: lookupErr})
+	return ids, lookupErr
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// sessionAccessRouter serves POST /bind as user "mallory" behind
+// SessionAccess; the handler binds the whole body like the real handlers do
+func sessionAccessRouter(sm *session.Manager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { auth.SetUser(c, "mallory") }, SessionAccess(sm))
+	r.POST("/bind", func(c *gin.Context) {
+		var req struct {
+			SessionID string `json:"sessionId"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Status(http.StatusUnprocessableEntity)
+			return
+		}
+		c.String(http.StatusOK, req.SessionID)
+	})
+	return r
+}
+
+func TestSessionAccessJSONBody(t *testing.T) {
+	sm := session.NewManager(t.TempDir())
+	victim := sm.Create("victim")
+	if err := sm.SetOwner(victim.ID, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	own := sm.Create("own")
+	if err := sm.SetOwner(own.ID, "mallory"); err != nil {
+		t.Fatal(err)
+	}
+	r := sessionAccessRouter(sm)
+
+	body := func(fields map[string]any) string {
+		b, _ := json.Marshal(fields)
+		return string(b)
+	}
+	pad := strings.Repeat("x", maxSessionLookupBody)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "own session", body: body(map[string]any{"sessionId": own.ID}), status: http.StatusOK},
+		{name: "foreign session", body: body(map[string]any{"sessionId": victim.ID}), status: http.StatusNotFound},
+		{name: "foreign session after padding", body: `{"pad":"` + pad + `","sessionId":"` + victim.ID + `"}`, status: http.StatusRequestEntityTooLarge},
+		{name: "own session after padding", body: `{"pad":"` + pad + `","sessionId":"` + own.ID + `"}`, status: http.StatusRequestEntityTooLarge},
+		{name: "malformed JSON", body: `{"sessionId":"` + victim.ID + `",}`, status: http.StatusBadRequest},
+		{name: "non-string session ID", body: `{"sessionId":42}`, status: http.StatusBadRequest},
+		{name: "no session", body: `{"name":"x"}`, status: http.StatusOK},
+		{name: "array body", body: `[1,2]`, status: http.StatusUnprocessableEntity},
+		{name: "empty body", body: ``, status: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.status, w.Body.String())
+			}
+			if w.Code == http.StatusOK && strings.Contains(tt.body, own.ID) && w.Body.String() != own.ID {
+				t.Errorf("handler bound session %q, want the untouched body with %q", w.Body.String(), own.ID)
+			}
+		})
+	}
+}
+
+func TestSessionAccessAnonymousWhenAuthOff(t *testing.T) {
+	sm := session.NewManager(t.TempDir())
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(SessionAccess(sm))
+	r.POST("/bind", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// Without authentication there is no owner to protect, so nothing is rejected
+	req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(`{not json`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,199 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Store is a minimal key/value blob store (S3, GCS or a local directory)
+type Store interface {
+	// Put uploads size bytes from r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.ReadSeeker, size int64) error
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; missing objects are not an error
+	Delete(ctx context.Context, key string) error
+	// String describes the store location for logs
+	String() string
+}
+
+// pingKey is read by Ping; it is not expected to exist
+const pingKey = ".grpc-bridge-ping"
+
+// Ping checks that the store is reachable and accepts the credentials. Stores
+// without a Ping method of their own are asked for an object that does not
+// exist: not found means the store answered.
+func Ping(ctx context.Context, s Store) error {
+	if p, ok := s.(interface{ Ping(context.Context) error }); ok {
+		return p.Ping(ctx)
+	}
+	r, err := s.Get(ctx, pingKey)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.Close()
+}
+
+// Credentials authenticate against S3-compatible APIs. GCS accepts HMAC keys
+// through its S3-interoperable XML API.
+type Credentials struct {
+	AccessKey string
+	SecretKey string
+}
+
+// Options tune how Open reaches a remote store
+type Options struct {
+	Endpoint    string // Overrides the service endpoint (e.g. a MinIO server)
+	Region      string // Signing region; defaults to us-east-1 (auto for GCS)
+	Credentials Credentials
+}
+
+// Open creates a store from a location URL: s3://bucket/prefix,
+// gs://bucket/prefix, or a local directory (file:///path or a plain path).
+func Open(location string, opts Options) (Store, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// Plain paths, including Windows drive letters
+		return NewFileStore(location)
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "file":
+		return NewFileStore(filepath.FromSlash(u.Path))
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing bucket in %q", location)
+		}
+		return NewS3Store(S3Config{
+			Bucket:      u.Host,
+			Prefix:      prefix,
+			Endpoint:    opts.Endpoint,
+			Region:      opts.Region,
+			Credentials: opts.Credentials,
+		})
+	case "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("missing bucket in %q", location)
+		}
+		endpoint, region := opts.Endpoint, opts.Region
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		if region == "" {
+			region = "auto"
+		}
+		return NewS3Store(S3Config{
+			Bucket:      u.Host,
+			Prefix:      prefix,
+			Endpoint:    endpoint,
+			Region:      region,
+			Credentials: opts.Credentials,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported object store scheme %q (expected s3, gs or file)", u.Scheme)
+	}
+}
+
+// FileStore keeps objects as files below a directory, e.g. a mounted volume
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store rooted at dir, creating it if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("object store directory is empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create object store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path maps a key to a file below the store directory
+func (s *FileStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
+
+// Put writes the object through a temporary file renamed into place
+func (s *FileStore) Put(ctx context.Context, key string, r io.ReadSeeker, size int64) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, io.LimitReader(r, size)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// Get opens the object file
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Ping checks that the store directory still exists
+func (s *FileStore) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", s.dir)
+	}
+	return nil
+}
+
+// Delete removes the object file
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) String() string {
+	return s.dir
+}
@@ -0,0 +1,114 @@
+// Package payload keeps the full JSON of call responses too large to be sent
+// in events, so clients that received a preview can fetch them in pages.
+package payload
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Defaults for the preview threshold and how long full payloads are kept
+const (
+	DefaultPreviewSize = 256 << 10 // Bytes of JSON
+	DefaultRetention   = 10 * time.Minute
+)
+
+// DefaultMaxBytes bounds the memory held by a store; the oldest payloads are
+// dropped beyond it
+const DefaultMaxBytes = 256 << 20
+
+// entry is one stored payload
+type entry struct {
+	id        string
+	sessionID string
+	data      []byte
+	stored    time.Time
+}
+
+// Store holds payloads by ID for the retention period, oldest dropped first
+// when the byte budget is exceeded
+type Store struct {
+	mu        sync.Mutex
+	order     *list.List               // Front is the most recently stored
+	entries   map[string]*list.Element // By payload ID
+	bytes     int64
+	retention time.Duration
+	maxBytes  int64
+}
+
+// NewStore creates a store keeping payloads for retention, up to maxBytes in
+// total (0 for unlimited)
+func NewStore(retention time.Duration, maxBytes int64) *Store {
+	return &Store{
+		order:     list.New(),
+		entries:   make(map[string]*list.Element),
+		retention: retention,
+		maxBytes:  maxBytes,
+	}
+}
+
+// Put stores a session's payload and returns its ID
+func (s *Store) Put(sessionID string, data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &entry{id: uuid.New().String(), sessionID: sessionID, data: data, stored: time.Now()}
+	s.entries[e.id] = s.order.PushFront(e)
+	s.bytes += int64(len(data))
+	s.evict()
+	return e.id
+}
+
+// Get returns a payload of the session, unless it expired or was dropped
+func (s *Store) Get(sessionID, id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evict()
+	el, ok := s.entries[id]
+	if !ok || el.Value.(*entry).sessionID != sessionID {
+		return nil, false
+	}
+	return el.Value.(*entry).data, true
+}
+
+// Forget drops every payload of a session (e.g. when it is deleted)
+func (s *Store) Forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for el := s.order.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*entry).sessionID == sessionID {
+			s.remove(el)
+		}
+		el = next
+	}
+}
+
+// evict drops expired payloads and the oldest ones beyond the byte budget.
+// The newest payload is kept even when it alone exceeds the budget. The
+// caller holds s.mu.
+func (s *Store) evict() {
+	for el := s.order.Back(); el != nil && el != s.order.Front(); el = s.order.Back() {
+		expired := time.Since(el.Value.(*entry).stored) >= s.retention
+		if !expired && (s.maxBytes <= 0 || s.bytes <= s.maxBytes) {
+			return
+		}
+		s.remove(el)
+	}
+	if el := s.order.Front(); el != nil && time.Since(el.Value.(*entry).stored) >= s.retention {
+		s.remove(el)
+	}
+}
+
+// remove deletes an element. The caller holds s.mu.
+func (s *Store) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	s.order.Remove(el)
+	delete(s.entries, e.id)
+	s.bytes -= int64(len(e.data))
+}
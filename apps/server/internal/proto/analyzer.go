@@ -1,80 +1,89 @@
 package proto
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/grpc-bridge/server/internal/session"
 )
 
 // ImportAnalyzer analyzes proto file dependencies
 type ImportAnalyzer struct {
-	importRegex *regexp.Regexp
+	sharedFiles    map[string]string       // Shared library files (relative -> absolute path)
+	importMappings []session.ImportMapping // Session import path remapping
+	progress       func(analyzed, total int)
 }
 
 // NewImportAnalyzer creates a new import analyzer
 func NewImportAnalyzer() *ImportAnalyzer {
-	// Regex to match: import "path/to/file.proto";
-	// Also matches: import public "path.proto";
-	return &ImportAnalyzer{
-		importRegex: regexp.MustCompile(`^\s*import\s+(?:public\s+)?["']([^"']+)["']\s*;`),
-	}
+	return &ImportAnalyzer{}
+}
+
+// SetSharedFiles makes files from shared proto libraries resolvable even when
+// they are not present in the analyzed directory
+func (a *ImportAnalyzer) SetSharedFiles(files map[string]string) {
+	a.sharedFiles = files
+}
+
+// SetImportMappings resolves imports through the session's import path
+// mappings before falling back to shared libraries
+func (a *ImportAnalyzer) SetImportMappings(mappings []session.ImportMapping) {
+	a.importMappings = mappings
+}
+
+// SetProgress makes AnalyzeDirectory report how many files it has analyzed.
+// fn is called from the analyzing goroutines, one call at a time.
+func (a *ImportAnalyzer) SetProgress(fn func(analyzed, total int)) {
+	a.progress = fn
 }
 
 // ImportInfo represents information about a proto import
 type ImportInfo struct {
-	ImportPath string   // The import path as written in the proto file
-	IsPublic   bool     // Whether it's a public import
-	SourceFile string   // The file that contains this import
-	IsStdlib   bool     // Whether this is a standard library import
-	Found      bool     // Whether the imported file was found
+	ImportPath   string // The import path as written in the proto file
+	IsPublic     bool   // Whether it's a public import
+	SourceFile   string // The file that contains this import
+	IsStdlib     bool   // Whether this is a standard library import
+	Found        bool   // Whether the imported file was found
 	ResolvedPath string // Resolved absolute path (if found)
 }
 
 // AnalyzeFile analyzes a single proto file and extracts its imports
 func (a *ImportAnalyzer) AnalyzeFile(filePath string) ([]ImportInfo, error) {
-	file, err := os.Open(filePath)
+	fileNode, err := parseProtoAST(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
 	var imports []ImportInfo
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Check if line contains an import
-		matches := a.importRegex.FindStringSubmatch(line)
-		if len(matches) > 1 {
-			importPath := matches[1]
-			isPublic := strings.Contains(line, "public")
-			isStdlib := isStandardLibrary(importPath)
-
-			imports = append(imports, ImportInfo{
-				ImportPath: importPath,
-				IsPublic:   isPublic,
-				SourceFile: filePath,
-				IsStdlib:   isStdlib,
-				Found:      false, // Will be updated by ResolveImports
-			})
+	for _, decl := range fileNode.Decls {
+		imp, ok := decl.(*ast.ImportNode)
+		if !ok {
+			continue
 		}
-	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		importPath := imp.Name.AsString()
+		imports = append(imports, ImportInfo{
+			ImportPath: importPath,
+			IsPublic:   imp.Public != nil,
+			SourceFile: filePath,
+			IsStdlib:   isStandardLibrary(importPath),
+			Found:      false, // Will be updated by ResolveImports
+		})
 	}
 
 	return imports, nil
 }
 
-// AnalyzeDirectory analyzes all proto files in a directory
+// AnalyzeDirectory analyzes all proto files in a directory. Files are parsed
+// by one worker per available CPU.
 func (a *ImportAnalyzer) AnalyzeDirectory(rootDir string) (map[string][]ImportInfo, error) {
-	result := make(map[string][]ImportInfo)
-
+	var paths []string
 	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -84,10 +93,46 @@ func (a *ImportAnalyzer) AnalyzeDirectory(rootDir string) (map[string][]ImportIn
 		if info.IsDir() || !strings.HasSuffix(path, ".proto") {
 			return nil
 		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		imports, err := a.AnalyzeFile(path)
-		if err != nil {
-			return fmt.Errorf("error analyzing %s: %w", path, err)
+	results := make([][]ImportInfo, len(paths))
+	errs := make([]error, len(paths))
+	var (
+		mu       sync.Mutex
+		analyzed int
+	)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = a.AnalyzeFile(paths[i])
+				if a.progress != nil {
+					mu.Lock()
+					analyzed++
+					a.progress(analyzed, len(paths))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := make(map[string][]ImportInfo, len(paths))
+	for i, path := range paths {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("error analyzing %s: %w", path, errs[i])
 		}
 
 		// Store with relative path
@@ -95,13 +140,7 @@ func (a *ImportAnalyzer) AnalyzeDirectory(rootDir string) (map[string][]ImportIn
 		if err != nil {
 			relPath = path
 		}
-
-		result[relPath] = imports
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
+		result[relPath] = results[i]
 	}
 
 	return result, nil
@@ -126,19 +165,40 @@ func (a *ImportAnalyzer) ResolveImports(rootDir string, imports map[string][]Imp
 	// Check each import
 	for sourceFile, importList := range imports {
 		for i, imp := range importList {
-			// Skip standard library imports
+			// Normalize import path
+			normalizedImport := filepath.ToSlash(imp.ImportPath)
+
+			// Standard library imports are reported by GetMissingStandardLibraries.
+			// google/protobuf is bundled with every compiler, so it always resolves.
 			if imp.IsStdlib {
-				imports[sourceFile][i].Found = true
+				if availableFiles[normalizedImport] {
+					imports[sourceFile][i].Found = true
+					imports[sourceFile][i].ResolvedPath = filepath.Join(rootDir, imp.ImportPath)
+				} else if sharedPath, ok := a.sharedFiles[normalizedImport]; ok {
+					imports[sourceFile][i].Found = true
+					imports[sourceFile][i].ResolvedPath = sharedPath
+				} else if stdlibPath := StdlibFile(normalizedImport); stdlibPath != "" {
+					imports[sourceFile][i].Found = true
+					imports[sourceFile][i].ResolvedPath = stdlibPath
+				} else {
+					imports[sourceFile][i].Found = strings.HasPrefix(normalizedImport, "google/protobuf/")
+				}
 				continue
 			}
 
-			// Normalize import path
-			normalizedImport := filepath.ToSlash(imp.ImportPath)
-
 			// Check if file exists
 			if availableFiles[normalizedImport] {
 				imports[sourceFile][i].Found = true
 				imports[sourceFile][i].ResolvedPath = filepath.Join(rootDir, imp.ImportPath)
+			} else if mappedPath := MapImport(rootDir, a.importMappings, normalizedImport); mappedPath != "" {
+				imports[sourceFile][i].Found = true
+				imports[sourceFile][i].ResolvedPath = mappedPath
+			} else if sharedPath, ok := a.sharedFiles[normalizedImport]; ok {
+				imports[sourceFile][i].Found = true
+				imports[sourceFile][i].ResolvedPath = sharedPath
+			} else if stdlibPath := StdlibFile(normalizedImport); stdlibPath != "" {
+				imports[sourceFile][i].Found = true
+				imports[sourceFile][i].ResolvedPath = stdlibPath
 			} else {
 				imports[sourceFile][i].Found = false
 				missing = append(missing, imports[sourceFile][i])
@@ -182,6 +242,10 @@ func (a *ImportAnalyzer) BuildDependencyGraph(rootDir string, imports map[string
 		for _, imp := range importList {
 			if imp.Found && !imp.IsStdlib {
 				normalizedImport := filepath.ToSlash(imp.ImportPath)
+				// Remapped imports point at the file's actual location in the session
+				if rel, err := filepath.Rel(rootDir, imp.ResolvedPath); err == nil && imp.ResolvedPath != "" && !strings.HasPrefix(rel, "..") {
+					normalizedImport = filepath.ToSlash(rel)
+				}
 				node.Dependencies = append(node.Dependencies, normalizedImport)
 
 				// Add reverse dependency
@@ -195,6 +259,110 @@ func (a *ImportAnalyzer) BuildDependencyGraph(rootDir string, imports map[string
 	return graph
 }
 
+// FindCycles returns the import cycles in the graph. Each cycle is a file
+// chain that starts and ends with the same file, e.g. [a.proto b.proto a.proto].
+// Nodes are visited in sorted order so results are deterministic.
+func (g *DependencyGraph) FindCycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	cycles := [][]string{}
+	state := make(map[string]int, len(g.Nodes))
+	var stack []string
+
+	var visit func(file string)
+	visit = func(file string) {
+		state[file] = visiting
+		stack = append(stack, file)
+
+		deps := append([]string(nil), g.Nodes[file].Dependencies...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if _, exists := g.Nodes[dep]; !exists {
+				continue
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				// Back edge: the chain from dep to the top of the stack is a cycle
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == dep {
+						chain := append([]string(nil), stack[i:]...)
+						cycles = append(cycles, append(chain, dep))
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[file] = done
+	}
+
+	files := make([]string, 0, len(g.Nodes))
+	for file := range g.Nodes {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if state[file] == unvisited {
+			visit(file)
+		}
+	}
+
+	return cycles
+}
+
+// TopologicalOrder returns the graph's files ordered so that every file comes
+// after the files it imports. Ties are broken alphabetically so the order is
+// deterministic; files caught in import cycles are appended at the end.
+func (g *DependencyGraph) TopologicalOrder() []string {
+	pending := make(map[string]map[string]bool, len(g.Nodes))
+	for file, node := range g.Nodes {
+		deps := make(map[string]bool)
+		for _, dep := range node.Dependencies {
+			if _, exists := g.Nodes[dep]; exists && dep != file {
+				deps[dep] = true
+			}
+		}
+		pending[file] = deps
+	}
+
+	order := make([]string, 0, len(g.Nodes))
+	for len(pending) > 0 {
+		var ready []string
+		for file, deps := range pending {
+			if len(deps) == 0 {
+				ready = append(ready, file)
+			}
+		}
+		if len(ready) == 0 {
+			break
+		}
+		sort.Strings(ready)
+		for _, file := range ready {
+			order = append(order, file)
+			delete(pending, file)
+			for _, deps := range pending {
+				delete(deps, file)
+			}
+		}
+	}
+
+	// Whatever is left cannot be ordered because of cycles
+	remaining := make([]string, 0, len(pending))
+	for file := range pending {
+		remaining = append(remaining, file)
+	}
+	sort.Strings(remaining)
+
+	return append(order, remaining...)
+}
+
 // isStandardLibrary checks if an import is from the standard proto library
 func isStandardLibrary(importPath string) bool {
 	stdlibPrefixes := []string{
@@ -0,0 +1,48 @@
+package proto
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// parseProtoAST parses a proto source file into an AST without resolving
+// imports. Syntax errors are tolerated: the parser recovers where it can, so a
+// partially broken file still yields the declarations around the error.
+func parseProtoAST(filePath string) (*ast.FileNode, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// Keep parsing past syntax errors; the AST is always returned
+	handler := reporter.NewHandler(reporter.NewReporter(
+		func(reporter.ErrorWithPos) error { return nil },
+		nil,
+	))
+	fileNode, _ := parser.Parse(filePath, file, handler)
+	return fileNode, nil
+}
+
+// FileSyntax reports the syntax of a proto source file ("proto2", "proto3" or
+// "editions") and, for editions files, the edition (e.g. "2023"). Files
+// without a declaration default to proto2.
+func FileSyntax(filePath string) (syntax, edition string, err error) {
+	fileNode, err := parseProtoAST(filePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch {
+	case fileNode.Edition != nil:
+		return "editions", fileNode.Edition.Edition.AsString(), nil
+	case fileNode.Syntax != nil:
+		return fileNode.Syntax.Syntax.AsString(), "", nil
+	default:
+		return "proto2", "", nil
+	}
+}
@@ -0,0 +1,316 @@
+package proto
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	gproto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// BreakingChange is a difference between two descriptor snapshots that can break
+// existing clients on the wire, in generated source code, or both
+type BreakingChange struct {
+	Rule    string `json:"rule"`
+	Subject string `json:"subject"` // Fully qualified element that changed
+	File    string `json:"file"`
+	Wire    bool   `json:"wire"`   // Breaks binary/JSON compatibility
+	Source  bool   `json:"source"` // Breaks generated code
+	Message string `json:"message"`
+}
+
+// CompileSnapshot compiles the files (absolute paths under rootPath) into a
+// serialized FileDescriptorSet including imports, suitable for later diffing
+func CompileSnapshot(rootPath string, protoFiles []string) ([]byte, error) {
+	relativePaths := make([]string, 0, len(protoFiles))
+	for _, absPath := range protoFiles {
+		rel, err := filepath.Rel(rootPath, absPath)
+		if err != nil {
+			rel = absPath
+		}
+		relativePaths = append(relativePaths, filepath.ToSlash(rel))
+	}
+	if len(relativePaths) == 0 {
+		return nil, fmt.Errorf("no proto files to snapshot")
+	}
+
+	parser := protoparse.Parser{ImportPaths: ImportPaths(rootPath)}
+	fds, err := parser.ParseFiles(relativePaths...)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if seen[fd.GetName()] {
+			return
+		}
+		seen[fd.GetName()] = true
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+	for _, fd := range fds {
+		add(fd)
+	}
+	return gproto.Marshal(set)
+}
+
+// DiffSnapshots compares the previous and current descriptors. Well-known
+// google/protobuf files are ignored.
+func DiffSnapshots(previous, current map[string]*desc.FileDescriptor) []BreakingChange {
+	d := &snapshotDiff{changes: []BreakingChange{}}
+
+	oldMsgs, oldEnums, oldSvcs := indexDescriptors(previous)
+	newMsgs, newEnums, newSvcs := indexDescriptors(current)
+
+	for _, name := range sortedKeys(oldMsgs) {
+		om := oldMsgs[name]
+		nm, ok := newMsgs[name]
+		if !ok {
+			d.add("MESSAGE_REMOVED", om, false, true, "message %s was removed", name)
+			continue
+		}
+		d.diffMessage(om, nm)
+	}
+	for _, name := range sortedKeys(oldEnums) {
+		oe := oldEnums[name]
+		ne, ok := newEnums[name]
+		if !ok {
+			d.add("ENUM_REMOVED", oe, false, true, "enum %s was removed", name)
+			continue
+		}
+		d.diffEnum(oe, ne)
+	}
+	for _, name := range sortedKeys(oldSvcs) {
+		oldSvc := oldSvcs[name]
+		newSvc, ok := newSvcs[name]
+		if !ok {
+			d.add("SERVICE_REMOVED", oldSvc, true, true, "service %s was removed", name)
+			continue
+		}
+		d.diffService(oldSvc, newSvc)
+	}
+
+	return d.changes
+}
+
+type snapshotDiff struct {
+	changes []BreakingChange
+}
+
+func (d *snapshotDiff) add(rule string, subject desc.Descriptor, wire, source bool, format string, args ...interface{}) {
+	d.changes = append(d.changes, BreakingChange{
+		Rule:    rule,
+		Subject: subject.GetFullyQualifiedName(),
+		File:    subject.GetFile().GetName(),
+		Wire:    wire,
+		Source:  source,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *snapshotDiff) diffMessage(om, nm *desc.MessageDescriptor) {
+	newByNumber := map[int32]*desc.FieldDescriptor{}
+	for _, f := range nm.GetFields() {
+		newByNumber[f.GetNumber()] = f
+	}
+
+	for _, of := range om.GetFields() {
+		nf, ok := newByNumber[of.GetNumber()]
+		if !ok {
+			// Deleting a field is only wire-safe when its number is reserved
+			reserved := isReservedNumber(nm, of.GetNumber())
+			d.add("FIELD_REMOVED", of, !reserved, true, "field %d (%s) was removed from %s%s",
+				of.GetNumber(), of.GetName(), om.GetFullyQualifiedName(), reservedNote(reserved))
+			continue
+		}
+		if of.GetName() != nf.GetName() {
+			// The JSON mapping uses names, so a rename breaks JSON clients too
+			d.add("FIELD_RENAMED", nf, true, true, "field %d was renamed from %s to %s (breaks JSON and generated code)",
+				of.GetNumber(), of.GetName(), nf.GetName())
+		}
+		if fieldTypeName(of) != fieldTypeName(nf) {
+			d.add("FIELD_TYPE_CHANGED", nf, true, true, "field %s changed type from %s to %s",
+				nf.GetName(), fieldTypeName(of), fieldTypeName(nf))
+		}
+		if labelName(of) != labelName(nf) {
+			d.add("FIELD_CARDINALITY_CHANGED", nf, true, true, "field %s changed cardinality from %s to %s",
+				nf.GetName(), labelName(of), labelName(nf))
+		}
+		if oneofName(of) != oneofName(nf) {
+			d.add("FIELD_ONEOF_CHANGED", nf, true, true, "field %s moved from oneof %q to %q",
+				nf.GetName(), oneofName(of), oneofName(nf))
+		}
+	}
+}
+
+func (d *snapshotDiff) diffEnum(oe, ne *desc.EnumDescriptor) {
+	newByNumber := map[int32]*desc.EnumValueDescriptor{}
+	for _, v := range ne.GetValues() {
+		if _, exists := newByNumber[v.GetNumber()]; !exists {
+			newByNumber[v.GetNumber()] = v
+		}
+	}
+	for _, ov := range oe.GetValues() {
+		nv, ok := newByNumber[ov.GetNumber()]
+		if !ok {
+			reserved := false
+			for _, r := range ne.AsEnumDescriptorProto().GetReservedRange() {
+				if ov.GetNumber() >= r.GetStart() && ov.GetNumber() <= r.GetEnd() {
+					reserved = true
+				}
+			}
+			d.add("ENUM_VALUE_REMOVED", ov, !reserved, true, "enum value %s (%d) was removed%s",
+				ov.GetName(), ov.GetNumber(), reservedNote(reserved))
+			continue
+		}
+		if ov.GetName() != nv.GetName() {
+			d.add("ENUM_VALUE_RENAMED", nv, true, true, "enum value %d was renamed from %s to %s (breaks JSON and generated code)",
+				ov.GetNumber(), ov.GetName(), nv.GetName())
+		}
+	}
+}
+
+func (d *snapshotDiff) diffService(oldSvc, newSvc *desc.ServiceDescriptor) {
+	added := []*desc.MethodDescriptor{}
+	for _, nm := range newSvc.GetMethods() {
+		if oldSvc.FindMethodByName(nm.GetName()) == nil {
+			added = append(added, nm)
+		}
+	}
+
+	for _, om := range oldSvc.GetMethods() {
+		nm := newSvc.FindMethodByName(om.GetName())
+		if nm == nil {
+			// An added RPC with the same signature is most likely a rename
+			if idx := findSameSignature(om, added); idx >= 0 {
+				renamed := added[idx]
+				added = append(added[:idx], added[idx+1:]...)
+				d.add("RPC_RENAMED", renamed, true, true, "rpc %s was renamed to %s; existing clients calling %s will fail",
+					om.GetName(), renamed.GetName(), om.GetName())
+			} else {
+				d.add("RPC_REMOVED", om, true, true, "rpc %s was removed from %s", om.GetName(), oldSvc.GetFullyQualifiedName())
+			}
+			continue
+		}
+		if om.GetInputType().GetFullyQualifiedName() != nm.GetInputType().GetFullyQualifiedName() {
+			d.add("RPC_REQUEST_TYPE_CHANGED", nm, true, true, "rpc %s request type changed from %s to %s",
+				nm.GetName(), om.GetInputType().GetFullyQualifiedName(), nm.GetInputType().GetFullyQualifiedName())
+		}
+		if om.GetOutputType().GetFullyQualifiedName() != nm.GetOutputType().GetFullyQualifiedName() {
+			d.add("RPC_RESPONSE_TYPE_CHANGED", nm, true, true, "rpc %s response type changed from %s to %s",
+				nm.GetName(), om.GetOutputType().GetFullyQualifiedName(), nm.GetOutputType().GetFullyQualifiedName())
+		}
+		if om.IsClientStreaming() != nm.IsClientStreaming() || om.IsServerStreaming() != nm.IsServerStreaming() {
+			d.add("RPC_STREAMING_CHANGED", nm, true, true, "rpc %s changed streaming mode", nm.GetName())
+		}
+	}
+}
+
+// findSameSignature returns the index of the first candidate with m's signature, or -1
+func findSameSignature(m *desc.MethodDescriptor, candidates []*desc.MethodDescriptor) int {
+	for i, c := range candidates {
+		if c.GetInputType().GetFullyQualifiedName() == m.GetInputType().GetFullyQualifiedName() &&
+			c.GetOutputType().GetFullyQualifiedName() == m.GetOutputType().GetFullyQualifiedName() &&
+			c.IsClientStreaming() == m.IsClientStreaming() && c.IsServerStreaming() == m.IsServerStreaming() {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexDescriptors maps fully qualified names to messages (including nested),
+// enums and services, skipping google/protobuf well-known files
+func indexDescriptors(files map[string]*desc.FileDescriptor) (map[string]*desc.MessageDescriptor, map[string]*desc.EnumDescriptor, map[string]*desc.ServiceDescriptor) {
+	msgs := map[string]*desc.MessageDescriptor{}
+	enums := map[string]*desc.EnumDescriptor{}
+	svcs := map[string]*desc.ServiceDescriptor{}
+
+	var walk func(md *desc.MessageDescriptor)
+	walk = func(md *desc.MessageDescriptor) {
+		if md.IsMapEntry() {
+			return
+		}
+		msgs[md.GetFullyQualifiedName()] = md
+		for _, nested := range md.GetNestedMessageTypes() {
+			walk(nested)
+		}
+		for _, ed := range md.GetNestedEnumTypes() {
+			enums[ed.GetFullyQualifiedName()] = ed
+		}
+	}
+
+	for name, fd := range files {
+		if strings.HasPrefix(name, "google/protobuf/") {
+			continue
+		}
+		for _, md := range fd.GetMessageTypes() {
+			walk(md)
+		}
+		for _, ed := range fd.GetEnumTypes() {
+			enums[ed.GetFullyQualifiedName()] = ed
+		}
+		for _, sd := range fd.GetServices() {
+			svcs[sd.GetFullyQualifiedName()] = sd
+		}
+	}
+	return msgs, enums, svcs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isReservedNumber(md *desc.MessageDescriptor, number int32) bool {
+	for _, r := range md.AsDescriptorProto().GetReservedRange() {
+		// Message reserved ranges are end-exclusive
+		if number >= r.GetStart() && number < r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func reservedNote(reserved bool) string {
+	if reserved {
+		return " (number is reserved)"
+	}
+	return ""
+}
+
+func fieldTypeName(f *desc.FieldDescriptor) string {
+	if mt := f.GetMessageType(); mt != nil {
+		return mt.GetFullyQualifiedName()
+	}
+	if et := f.GetEnumType(); et != nil {
+		return et.GetFullyQualifiedName()
+	}
+	return strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+}
+
+// labelName returns the resolved cardinality, so editions fields using
+// features.field_presence = LEGACY_REQUIRED report as required
+func labelName(f *desc.FieldDescriptor) string {
+	return f.UnwrapField().Cardinality().String()
+}
+
+func oneofName(f *desc.FieldDescriptor) string {
+	// Synthetic oneofs (proto3 optional) are not real oneof membership
+	if oo := f.GetOneOf(); oo != nil && !oo.IsSynthetic() {
+		return oo.GetName()
+	}
+	return ""
+}
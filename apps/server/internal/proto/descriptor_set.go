@@ -0,0 +1,72 @@
+package proto
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/grpc-bridge/server/internal/session"
+	"github.com/jhump/protoreflect/desc"
+	gproto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// MaxDescriptorSetSize limits the size of a single uploaded descriptor set
+const MaxDescriptorSetSize int64 = 50 << 20 // 50MB
+
+// ParseDescriptorSet decodes a serialized FileDescriptorSet and verifies that
+// every file can be linked, i.e. the set was built with its imports included.
+func ParseDescriptorSet(data []byte) (*descriptorpb.FileDescriptorSet, map[string]*desc.FileDescriptor, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := gproto.Unmarshal(data, set); err != nil {
+		return nil, nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	if len(set.GetFile()) == 0 {
+		return nil, nil, fmt.Errorf("descriptor set contains no files")
+	}
+	files, err := desc.CreateFileDescriptorsFromSet(set)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to link descriptor set (build it with --include_imports): %w", err)
+	}
+	return set, files, nil
+}
+
+// LoadDescriptorSet reads and links a descriptor set from disk
+func LoadDescriptorSet(path string) (map[string]*desc.FileDescriptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	_, files, err := ParseDescriptorSet(data)
+	return files, err
+}
+
+// DescriptorSetServices lists the services defined in linked descriptor set files
+func DescriptorSetServices(files map[string]*desc.FileDescriptor) []session.ServiceInfo {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := []session.ServiceInfo{}
+	for _, name := range names {
+		for _, svc := range files[name].GetServices() {
+			info := session.ServiceInfo{
+				FQService: svc.GetFullyQualifiedName(),
+				File:      name,
+				Methods:   []session.MethodInfo{},
+			}
+			for _, m := range svc.GetMethods() {
+				info.Methods = append(info.Methods, session.MethodInfo{
+					Name:       m.GetName(),
+					InputType:  m.GetInputType().GetFullyQualifiedName(),
+					OutputType: m.GetOutputType().GetFullyQualifiedName(),
+					Streaming:  m.IsClientStreaming() || m.IsServerStreaming(),
+				})
+			}
+			services = append(services, info)
+		}
+	}
+	return services
+}
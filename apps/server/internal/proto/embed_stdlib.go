@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // Embedded standard proto library files
@@ -15,15 +16,20 @@ import (
 //go:embed all:stdlib
 var stdlibFiles embed.FS
 
-// StdlibManager manages standard proto library files
+// StdlibManager manages standard proto library files and operator-registered
+// shared proto libraries
 type StdlibManager struct {
 	embeddedFS embed.FS
+
+	mu     sync.RWMutex
+	shared map[string]*SharedLibrary // keyed by library name
 }
 
 // NewStdlibManager creates a new standard library manager
 func NewStdlibManager() *StdlibManager {
 	return &StdlibManager{
 		embeddedFS: stdlibFiles,
+		shared:     make(map[string]*SharedLibrary),
 	}
 }
 
@@ -69,10 +75,16 @@ func (m *StdlibManager) ExtractToDirectory(targetDir string) error {
 	})
 }
 
-// CopyToSession copies standard library files to a session directory
+// CopyToSession copies standard library files and shared libraries to a
+// session directory. Shared library files win over same-named stdlib files.
+// With a shared stdlib tree (SetStdlibDir) only shared libraries are copied.
 func (m *StdlibManager) CopyToSession(sessionDir string) error {
-	// Extract to session directory
-	return m.ExtractToDirectory(sessionDir)
+	if StdlibDir() == "" {
+		if err := m.ExtractToDirectory(sessionDir); err != nil {
+			return err
+		}
+	}
+	return m.copySharedLibraries(sessionDir)
 }
 
 // ListAvailableFiles returns a list of all available standard library files
@@ -100,6 +112,20 @@ func (m *StdlibManager) ListAvailableFiles() ([]string, error) {
 		return nil, err
 	}
 
+	// Append shared library files not already provided by the stdlib
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[f] = true
+	}
+	for _, lib := range m.SharedLibraries() {
+		for _, f := range lib.Files {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+
 	return files, nil
 }
 
@@ -111,6 +137,13 @@ func (m *StdlibManager) GetFileContent(relativePath string) (string, error) {
 		fullPath = "stdlib/" + relativePath
 	}
 
+	// Shared library files take precedence, matching CopyToSession
+	if absPath, ok := m.SharedFiles()[strings.TrimPrefix(fullPath, "stdlib/")]; ok {
+		if content, err := os.ReadFile(absPath); err == nil {
+			return string(content), nil
+		}
+	}
+
 	content, err := m.embeddedFS.ReadFile(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("file not found: %s", relativePath)
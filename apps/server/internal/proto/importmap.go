@@ -0,0 +1,182 @@
+package proto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grpc-bridge/server/internal/session"
+)
+
+// ImportMapDir is the session subdirectory holding the symlink tree that
+// exposes prefixed import mappings to tools that only take import paths (grpcurl)
+const ImportMapDir = ".importmap"
+
+// NormalizeImportMappings cleans mapping prefixes and directories and rejects
+// absolute or escaping paths. Longer prefixes are ordered first so they win.
+func NormalizeImportMappings(mappings []session.ImportMapping) ([]session.ImportMapping, error) {
+	out := make([]session.ImportMapping, 0, len(mappings))
+	seen := make(map[string]bool, len(mappings))
+
+	for _, m := range mappings {
+		prefix, err := cleanRelative(m.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prefix %q: %w", m.Prefix, err)
+		}
+		dir, err := cleanRelative(m.Directory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid directory %q: %w", m.Directory, err)
+		}
+		if prefix == "" && dir == "" {
+			return nil, fmt.Errorf("mapping needs a prefix or a directory")
+		}
+		if seen[prefix] {
+			return nil, fmt.Errorf("duplicate prefix %q", prefix)
+		}
+		seen[prefix] = true
+		out = append(out, session.ImportMapping{Prefix: prefix, Directory: dir})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return len(out[i].Prefix) > len(out[j].Prefix)
+	})
+	return out, nil
+}
+
+// MapImport resolves an import path through the mappings and returns the
+// absolute path of the file it refers to, or "" when no mapping applies.
+func MapImport(rootDir string, mappings []session.ImportMapping, importPath string) string {
+	importPath = filepath.ToSlash(importPath)
+	for _, m := range mappings {
+		rest, ok := trimPathPrefix(importPath, m.Prefix)
+		if !ok {
+			continue
+		}
+		candidate := filepath.Join(rootDir, filepath.FromSlash(m.Directory), filepath.FromSlash(rest))
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// ImportName returns the name other files import a session file by: files
+// under a mapped directory are named by the mapping's prefix instead of their
+// location in the session tree.
+func ImportName(relPath string, mappings []session.ImportMapping) string {
+	relPath = filepath.ToSlash(relPath)
+
+	// The most specific directory wins
+	best, bestLen := relPath, -1
+	for _, m := range mappings {
+		rest, ok := trimPathPrefix(relPath, m.Directory)
+		if !ok || len(m.Directory) <= bestLen {
+			continue
+		}
+		best, bestLen = path.Join(m.Prefix, rest), len(m.Directory)
+	}
+	return best
+}
+
+// materializeMu serializes rebuilds of import map trees
+var materializeMu sync.Mutex
+
+// MaterializeImportMappings prepares import paths for tools that cannot remap
+// prefixes themselves. Prefixed mappings are exposed as symlinks under
+// <root>/.importmap; mappings without a prefix become import paths directly.
+// The tree is only rebuilt when the mappings change.
+func MaterializeImportMappings(rootDir string, mappings []session.ImportMapping) ([]string, error) {
+	materializeMu.Lock()
+	defer materializeMu.Unlock()
+
+	mapRoot := filepath.Join(rootDir, ImportMapDir)
+	stampPath := filepath.Join(mapRoot, ".mappings")
+	stamp, err := json.Marshal(mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := os.ReadFile(stampPath)
+	rebuild := err != nil || !bytes.Equal(current, stamp)
+	if rebuild {
+		if err := os.RemoveAll(mapRoot); err != nil {
+			return nil, fmt.Errorf("failed to reset import map: %w", err)
+		}
+	}
+
+	var importPaths []string
+	linked := false
+	for _, m := range mappings {
+		target := filepath.Join(rootDir, filepath.FromSlash(m.Directory))
+		if m.Prefix == "" {
+			importPaths = append(importPaths, target)
+			continue
+		}
+		linked = true
+		if !rebuild {
+			continue
+		}
+
+		link := filepath.Join(mapRoot, filepath.FromSlash(m.Prefix))
+		if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create import map: %w", err)
+		}
+		if err := os.Symlink(target, link); err != nil {
+			// Nested prefixes cannot both be linked; the longer one (linked first) wins
+			if os.IsExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to link %s: %w", m.Prefix, err)
+		}
+	}
+
+	if rebuild {
+		if err := os.MkdirAll(mapRoot, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create import map: %w", err)
+		}
+		if err := os.WriteFile(stampPath, stamp, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write import map: %w", err)
+		}
+	}
+
+	if linked {
+		importPaths = append([]string{mapRoot}, importPaths...)
+	}
+	return importPaths, nil
+}
+
+// cleanRelative normalizes a slash-separated relative path ("" for the root)
+func cleanRelative(p string) (string, error) {
+	p = strings.Trim(filepath.ToSlash(strings.TrimSpace(p)), "/")
+	if p == "" {
+		return "", nil
+	}
+	if filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return "", fmt.Errorf("must be relative")
+	}
+	p = path.Clean(p)
+	if p == "." {
+		return "", nil
+	}
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("must not leave the session directory")
+	}
+	return p, nil
+}
+
+// trimPathPrefix strips a directory prefix on a path segment boundary
+func trimPathPrefix(p, prefix string) (string, bool) {
+	if prefix == "" {
+		return p, true
+	}
+	if !strings.HasPrefix(p, prefix+"/") {
+		return "", false
+	}
+	return p[len(prefix)+1:], true
+}
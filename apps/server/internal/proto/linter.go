@@ -0,0 +1,259 @@
+package proto
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// LintRule describes a single style check
+type LintRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// LintRules lists the supported buf-style rules
+var LintRules = []LintRule{
+	{"PACKAGE_DEFINED", "Files must declare a package."},
+	{"PACKAGE_LOWER_SNAKE_CASE", "Packages must be lower_snake_case."},
+	{"PACKAGE_VERSION_SUFFIX", "The last package component must be a version such as v1 or v1beta1."},
+	{"MESSAGE_PASCAL_CASE", "Message names must be PascalCase."},
+	{"FIELD_LOWER_SNAKE_CASE", "Field names must be lower_snake_case."},
+	{"ENUM_PASCAL_CASE", "Enum names must be PascalCase."},
+	{"ENUM_VALUE_UPPER_SNAKE_CASE", "Enum values must be UPPER_SNAKE_CASE."},
+	{"ENUM_VALUE_PREFIX", "Enum values must be prefixed with the UPPER_SNAKE_CASE enum name."},
+	{"ENUM_ZERO_VALUE_SUFFIX", "The zero enum value must be suffixed with _UNSPECIFIED."},
+	{"SERVICE_PASCAL_CASE", "Service names must be PascalCase."},
+	{"SERVICE_SUFFIX", "Service names must be suffixed with Service."},
+	{"RPC_PASCAL_CASE", "RPC names must be PascalCase."},
+	{"RPC_REQUEST_STANDARD_NAME", "RPC request types must be named MethodRequest or ServiceMethodRequest."},
+	{"RPC_RESPONSE_STANDARD_NAME", "RPC response types must be named MethodResponse or ServiceMethodResponse."},
+	{"RPC_REQUEST_RESPONSE_UNIQUE", "Request and response types must be unique across RPCs."},
+}
+
+// LintIssue is a rule violation at a source location
+type LintIssue struct {
+	RuleID  string `json:"rule_id"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`   // 1-based; 0 when unknown
+	Column  int    `json:"column"` // 1-based; 0 when unknown
+	Message string `json:"message"`
+}
+
+var (
+	reLowerSnake    = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	reUpperSnake    = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+	rePascal        = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	reVersionSuffix = regexp.MustCompile(`^v\d+((alpha|beta)\d*)?$`)
+)
+
+// Linter checks session protos against LintRules
+type Linter struct {
+	disabled map[string]bool
+}
+
+// NewLinter creates a linter with the given rule IDs disabled
+func NewLinter(except []string) *Linter {
+	disabled := map[string]bool{}
+	for _, id := range except {
+		disabled[strings.ToUpper(strings.TrimSpace(id))] = true
+	}
+	return &Linter{disabled: disabled}
+}
+
+// Lint compiles the files (absolute paths under rootPath) and returns issues sorted
+// by location. Imported files that are not part of protoFiles are not linted.
+func (l *Linter) Lint(rootPath string, protoFiles []string) ([]LintIssue, error) {
+	relativePaths := make([]string, 0, len(protoFiles))
+	for _, absPath := range protoFiles {
+		rel, err := filepath.Rel(rootPath, absPath)
+		if err != nil {
+			rel = absPath
+		}
+		relativePaths = append(relativePaths, filepath.ToSlash(rel))
+	}
+	if len(relativePaths) == 0 {
+		return []LintIssue{}, nil
+	}
+
+	parser := protoparse.Parser{
+		ImportPaths:           ImportPaths(rootPath),
+		IncludeSourceCodeInfo: true,
+	}
+	fds, err := parser.ParseFiles(relativePaths...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile protos: %w", err)
+	}
+
+	issues := []LintIssue{}
+	report := func(rule string, d desc.Descriptor, format string, args ...interface{}) {
+		if l.disabled[rule] {
+			return
+		}
+		issue := LintIssue{
+			RuleID:  rule,
+			File:    d.GetFile().GetName(),
+			Message: fmt.Sprintf(format, args...),
+		}
+		span := d.GetSourceInfo().GetSpan()
+		if fd, ok := d.(*desc.FileDescriptor); ok {
+			span = packageSpan(fd)
+		}
+		if len(span) >= 2 {
+			issue.Line = int(span[0]) + 1
+			issue.Column = int(span[1]) + 1
+		}
+		issues = append(issues, issue)
+	}
+
+	for _, fd := range fds {
+		l.lintFile(fd, report)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return issues, nil
+}
+
+type reportFunc func(rule string, d desc.Descriptor, format string, args ...interface{})
+
+func (l *Linter) lintFile(fd *desc.FileDescriptor, report reportFunc) {
+	pkg := fd.GetPackage()
+	if pkg == "" {
+		report("PACKAGE_DEFINED", fd, "file %s does not declare a package", fd.GetName())
+	} else {
+		parts := strings.Split(pkg, ".")
+		for _, part := range parts {
+			if !reLowerSnake.MatchString(part) {
+				report("PACKAGE_LOWER_SNAKE_CASE", fd, "package %q should be lower_snake_case", pkg)
+				break
+			}
+		}
+		if !reVersionSuffix.MatchString(parts[len(parts)-1]) {
+			report("PACKAGE_VERSION_SUFFIX", fd, "package %q should end with a version component such as v1", pkg)
+		}
+	}
+
+	for _, md := range fd.GetMessageTypes() {
+		l.lintMessage(md, report)
+	}
+	for _, ed := range fd.GetEnumTypes() {
+		l.lintEnum(ed, report)
+	}
+
+	seenTypes := map[string]string{}
+	for _, sd := range fd.GetServices() {
+		name := sd.GetName()
+		if !rePascal.MatchString(name) {
+			report("SERVICE_PASCAL_CASE", sd, "service %q should be PascalCase", name)
+		}
+		if !strings.HasSuffix(name, "Service") {
+			report("SERVICE_SUFFIX", sd, "service %q should be suffixed with Service", name)
+		}
+		serviceBase := strings.TrimSuffix(name, "Service")
+
+		for _, m := range sd.GetMethods() {
+			method := m.GetName()
+			if !rePascal.MatchString(method) {
+				report("RPC_PASCAL_CASE", m, "rpc %q should be PascalCase", method)
+			}
+			input := m.GetInputType()
+			output := m.GetOutputType()
+			if input.GetName() != method+"Request" && input.GetName() != serviceBase+method+"Request" {
+				report("RPC_REQUEST_STANDARD_NAME", m, "rpc %q request type %q should be named %sRequest", method, input.GetName(), method)
+			}
+			if output.GetName() != method+"Response" && output.GetName() != serviceBase+method+"Response" {
+				report("RPC_RESPONSE_STANDARD_NAME", m, "rpc %q response type %q should be named %sResponse", method, output.GetName(), method)
+			}
+			for _, t := range []*desc.MessageDescriptor{input, output} {
+				fq := t.GetFullyQualifiedName()
+				if prev, ok := seenTypes[fq]; ok {
+					report("RPC_REQUEST_RESPONSE_UNIQUE", m, "type %q is already used by rpc %s", fq, prev)
+					continue
+				}
+				seenTypes[fq] = sd.GetName() + "." + method
+			}
+		}
+	}
+}
+
+func (l *Linter) lintMessage(md *desc.MessageDescriptor, report reportFunc) {
+	if md.IsMapEntry() {
+		return
+	}
+	if !rePascal.MatchString(md.GetName()) {
+		report("MESSAGE_PASCAL_CASE", md, "message %q should be PascalCase", md.GetName())
+	}
+	for _, f := range md.GetFields() {
+		if !reLowerSnake.MatchString(f.GetName()) {
+			report("FIELD_LOWER_SNAKE_CASE", f, "field %q should be lower_snake_case", f.GetName())
+		}
+	}
+	for _, nested := range md.GetNestedMessageTypes() {
+		l.lintMessage(nested, report)
+	}
+	for _, ed := range md.GetNestedEnumTypes() {
+		l.lintEnum(ed, report)
+	}
+}
+
+func (l *Linter) lintEnum(ed *desc.EnumDescriptor, report reportFunc) {
+	name := ed.GetName()
+	if !rePascal.MatchString(name) {
+		report("ENUM_PASCAL_CASE", ed, "enum %q should be PascalCase", name)
+	}
+	prefix := toUpperSnake(name) + "_"
+	for i, v := range ed.GetValues() {
+		if !reUpperSnake.MatchString(v.GetName()) {
+			report("ENUM_VALUE_UPPER_SNAKE_CASE", v, "enum value %q should be UPPER_SNAKE_CASE", v.GetName())
+		}
+		if !strings.HasPrefix(v.GetName(), prefix) {
+			report("ENUM_VALUE_PREFIX", v, "enum value %q should be prefixed with %s", v.GetName(), prefix)
+		}
+		if i == 0 && v.GetNumber() == 0 && !strings.HasSuffix(v.GetName(), "_UNSPECIFIED") {
+			report("ENUM_ZERO_VALUE_SUFFIX", v, "enum zero value %q should be suffixed with _UNSPECIFIED", v.GetName())
+		}
+	}
+}
+
+// packageSpan returns the source span of the file's package statement, if any
+func packageSpan(fd *desc.FileDescriptor) []int32 {
+	for _, loc := range fd.AsFileDescriptorProto().GetSourceCodeInfo().GetLocation() {
+		// Field 2 of FileDescriptorProto is the package
+		if len(loc.GetPath()) == 1 && loc.GetPath()[0] == 2 {
+			return loc.GetSpan()
+		}
+	}
+	return nil
+}
+
+// toUpperSnake converts PascalCase to UPPER_SNAKE_CASE (e.g. HTTPStatus -> HTTP_STATUS)
+func toUpperSnake(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		isUpper := r >= 'A' && r <= 'Z'
+		if i > 0 && isUpper {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z' || runes[i-1] >= '0' && runes[i-1] <= '9'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevLower || (nextLower && runes[i-1] >= 'A' && runes[i-1] <= 'Z') {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
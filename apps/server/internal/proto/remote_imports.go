@@ -0,0 +1,184 @@
+package proto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RemoteSource maps an import path prefix to the base URL its files are served
+// from; the full import path is appended to BaseURL
+type RemoteSource struct {
+	Prefix  string `json:"prefix"`
+	BaseURL string `json:"base_url"`
+}
+
+// DefaultRemoteSources lists well-known public proto trees that can be fetched
+// when a session imports them without uploading them
+var DefaultRemoteSources = []RemoteSource{
+	{Prefix: "google/api/", BaseURL: "https://raw.githubusercontent.com/googleapis/googleapis/master/"},
+	{Prefix: "google/rpc/", BaseURL: "https://raw.githubusercontent.com/googleapis/googleapis/master/"},
+	{Prefix: "google/type/", BaseURL: "https://raw.githubusercontent.com/googleapis/googleapis/master/"},
+	{Prefix: "google/longrunning/", BaseURL: "https://raw.githubusercontent.com/googleapis/googleapis/master/"},
+	{Prefix: "google/iam/", BaseURL: "https://raw.githubusercontent.com/googleapis/googleapis/master/"},
+	{Prefix: "google/geo/", BaseURL: "https://raw.githubusercontent.com/googleapis/googleapis/master/"},
+	{Prefix: "google/logging/", BaseURL: "https://raw.githubusercontent.com/googleapis/googleapis/master/"},
+	{Prefix: "buf/validate/", BaseURL: "https://raw.githubusercontent.com/bufbuild/protovalidate/main/proto/protovalidate/"},
+	{Prefix: "validate/", BaseURL: "https://raw.githubusercontent.com/bufbuild/protoc-gen-validate/main/"},
+	{Prefix: "protoc-gen-openapiv2/options/", BaseURL: "https://raw.githubusercontent.com/grpc-ecosystem/grpc-gateway/main/"},
+}
+
+const (
+	// maxRemoteImportFiles bounds how many files one fetch may install,
+	// including transitive imports
+	maxRemoteImportFiles = 200
+	// maxRemoteImportSize bounds the size of a single fetched file
+	maxRemoteImportSize = 4 << 20
+)
+
+// ImportFetcher downloads missing imports from known public sources
+type ImportFetcher struct {
+	client  *http.Client
+	sources []RemoteSource
+}
+
+// NewImportFetcher creates a fetcher over sources. Longer prefixes take
+// precedence, so specific overrides win over broad defaults.
+func NewImportFetcher(sources []RemoteSource) *ImportFetcher {
+	sorted := append([]RemoteSource{}, sources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+	return &ImportFetcher{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		sources: sorted,
+	}
+}
+
+// Sources returns the configured remote sources
+func (f *ImportFetcher) Sources() []RemoteSource {
+	return append([]RemoteSource{}, f.sources...)
+}
+
+// FetchResult reports the outcome of a fetch
+type FetchResult struct {
+	Fetched     []string          `json:"fetched"`     // Files installed into the session
+	Failed      map[string]string `json:"failed"`      // Import path -> error
+	Unsupported []string          `json:"unsupported"` // Imports with no known source
+}
+
+// StoreFunc installs a fetched file at its import path below the root
+// directory, so the caller can account for it like an upload
+type StoreFunc func(relativePath string, content []byte) error
+
+// FetchMissing downloads importPaths and installs them with store, following
+// the imports of every fetched file. Files already present under rootDir are
+// left alone, and google/protobuf imports are skipped since compilers bundle
+// them. An error from store stops the fetch; the file is reported as failed.
+func (f *ImportFetcher) FetchMissing(ctx context.Context, rootDir string, importPaths []string, store StoreFunc) *FetchResult {
+	result := &FetchResult{
+		Fetched:     []string{},
+		Failed:      make(map[string]string),
+		Unsupported: []string{},
+	}
+
+	analyzer := NewImportAnalyzer()
+	queue := append([]string{}, importPaths...)
+	seen := make(map[string]bool)
+
+	for len(queue) > 0 {
+		importPath := filepath.ToSlash(queue[0])
+		queue = queue[1:]
+		if seen[importPath] || strings.HasPrefix(importPath, "google/protobuf/") {
+			continue
+		}
+		seen[importPath] = true
+
+		rel, err := cleanRelative(importPath)
+		if err != nil || rel == "" {
+			result.Failed[importPath] = "invalid import path"
+			continue
+		}
+		target := filepath.Join(rootDir, filepath.FromSlash(rel))
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+
+		source, ok := f.sourceFor(rel)
+		if !ok {
+			result.Unsupported = append(result.Unsupported, rel)
+			continue
+		}
+		if len(result.Fetched) >= maxRemoteImportFiles {
+			result.Failed[rel] = fmt.Sprintf("fetch limit of %d files reached", maxRemoteImportFiles)
+			continue
+		}
+
+		content, err := f.download(ctx, source.BaseURL+rel)
+		if err != nil {
+			result.Failed[rel] = err.Error()
+			continue
+		}
+		if err := store(rel, content); err != nil {
+			result.Failed[rel] = err.Error()
+			break
+		}
+		result.Fetched = append(result.Fetched, rel)
+		fmt.Printf("[ImportFetcher] Fetched %s from %s\n", rel, source.BaseURL)
+
+		// Fetched files may import further files that are missing too
+		imports, err := analyzer.AnalyzeFile(target)
+		if err != nil {
+			continue
+		}
+		for _, imp := range imports {
+			queue = append(queue, imp.ImportPath)
+		}
+	}
+
+	sort.Strings(result.Fetched)
+	sort.Strings(result.Unsupported)
+	return result
+}
+
+// sourceFor returns the source serving importPath
+func (f *ImportFetcher) sourceFor(importPath string) (RemoteSource, bool) {
+	for _, s := range f.sources {
+		if strings.HasPrefix(importPath, s.Prefix) {
+			return s, true
+		}
+	}
+	return RemoteSource{}, false
+}
+
+// download returns the body of url
+func (f *ImportFetcher) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteImportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > maxRemoteImportSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxRemoteImportSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,87 @@
+package proto
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxMatchLineLength caps the length of a matched line returned to clients
+const maxMatchLineLength = 500
+
+// SearchOptions controls how a Searcher matches lines
+type SearchOptions struct {
+	Query         string
+	Regex         bool // Treat Query as a regular expression
+	CaseSensitive bool
+	WholeWord     bool // Only match Query as a complete identifier
+}
+
+// SearchMatch is a single matching line
+type SearchMatch struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`   // 1-based
+	Column int    `json:"column"` // 1-based, in characters
+	Text   string `json:"text"`
+	Stdlib bool   `json:"stdlib,omitempty"`
+}
+
+// Searcher finds lines matching a query in proto sources
+type Searcher struct {
+	pattern *regexp.Regexp
+}
+
+// NewSearcher compiles the search options into a matcher
+func NewSearcher(opts SearchOptions) (*Searcher, error) {
+	expr := opts.Query
+	if !opts.Regex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if opts.WholeWord {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	if !opts.CaseSensitive {
+		expr = `(?i)` + expr
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Searcher{pattern: pattern}, nil
+}
+
+// Search returns the matching lines of content, reporting the first match on
+// each line. At most limit matches are returned when limit is positive.
+func (s *Searcher) Search(file, content string, limit int) []SearchMatch {
+	var matches []SearchMatch
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		loc := s.pattern.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+
+		text := line
+		if len(text) > maxMatchLineLength {
+			text = strings.ToValidUTF8(text[:maxMatchLineLength], "")
+		}
+		matches = append(matches, SearchMatch{
+			File:   file,
+			Line:   lineNum,
+			Column: utf8.RuneCountInString(line[:loc[0]]) + 1,
+			Text:   text,
+		})
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+	}
+
+	return matches
+}
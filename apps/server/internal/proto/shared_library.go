@@ -0,0 +1,141 @@
+package proto
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SharedLibrary is an operator-provided proto tree (e.g. company-wide common
+// protos) that is merged into sessions next to the embedded stdlib
+type SharedLibrary struct {
+	Name  string   `json:"name"`
+	Path  string   `json:"path"`  // Absolute directory on the server
+	Files []string `json:"files"` // Proto files relative to Path
+}
+
+// AddSharedLibrary registers a directory of proto files under name. The tree
+// is scanned now; registering the same name again rescans and replaces it.
+func (m *StdlibManager) AddSharedLibrary(name, dir string) (*SharedLibrary, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %s: %w", dir, err)
+	}
+	if name == "" {
+		name = filepath.Base(absDir)
+	}
+
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shared library %s: %w", absDir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("shared library path is not a directory: %s", absDir)
+	}
+
+	files, err := scanProtoTree(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan shared library %s: %w", absDir, err)
+	}
+
+	lib := &SharedLibrary{Name: name, Path: absDir, Files: files}
+
+	m.mu.Lock()
+	m.shared[name] = lib
+	m.mu.Unlock()
+
+	return lib, nil
+}
+
+// RemoveSharedLibrary unregisters a shared library. Files already copied into
+// sessions are left in place.
+func (m *StdlibManager) RemoveSharedLibrary(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.shared[name]; !exists {
+		return false
+	}
+	delete(m.shared, name)
+	return true
+}
+
+// SharedLibraries returns the registered shared libraries sorted by name
+func (m *StdlibManager) SharedLibraries() []SharedLibrary {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	libs := make([]SharedLibrary, 0, len(m.shared))
+	for _, lib := range m.shared {
+		libs = append(libs, *lib)
+	}
+	sort.Slice(libs, func(i, j int) bool {
+		return libs[i].Name < libs[j].Name
+	})
+	return libs
+}
+
+// SharedFiles maps the relative path of every shared library file to its
+// absolute path. When libraries overlap, the one sorting last by name wins.
+func (m *StdlibManager) SharedFiles() map[string]string {
+	files := make(map[string]string)
+	for _, lib := range m.SharedLibraries() {
+		for _, f := range lib.Files {
+			files[f] = filepath.Join(lib.Path, filepath.FromSlash(f))
+		}
+	}
+	return files
+}
+
+// copySharedLibraries copies all shared library files into targetDir
+func (m *StdlibManager) copySharedLibraries(targetDir string) error {
+	for relPath, absPath := range m.SharedFiles() {
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			return fmt.Errorf("failed to read shared file %s: %w", absPath, err)
+		}
+
+		targetPath := filepath.Join(targetDir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(targetPath, content, 0644); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", targetPath, err)
+		}
+	}
+	return nil
+}
+
+// scanProtoTree lists the .proto files under root as slash-separated relative
+// paths, skipping hidden directories
+func scanProtoTree(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(path, ".proto") {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,209 @@
+package proto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// stdlibDir is the shared read-only tree holding the embedded standard
+// library; empty when the stdlib is copied into every session instead
+var stdlibDir atomic.Pointer[string]
+
+// SetStdlibDir makes dir, a tree written by ExtractShared, the import path
+// every compiler falls back to after the session root
+func SetStdlibDir(dir string) {
+	stdlibDir.Store(&dir)
+}
+
+// StdlibDir returns the shared standard library tree, or "" when the stdlib
+// is copied into each session
+func StdlibDir() string {
+	if dir := stdlibDir.Load(); dir != nil {
+		return *dir
+	}
+	return ""
+}
+
+// ImportPaths returns the import paths for compiling the protos under root:
+// root first, so uploaded files override the stdlib, then the shared tree
+func ImportPaths(root string) []string {
+	if dir := StdlibDir(); dir != "" {
+		return []string{root, dir}
+	}
+	return []string{root}
+}
+
+// StdlibFile returns the path of an import in the shared stdlib tree, or ""
+// when there is no shared tree or it does not hold the file
+func StdlibFile(importPath string) string {
+	dir := StdlibDir()
+	if dir == "" {
+		return ""
+	}
+	path := filepath.Join(dir, filepath.FromSlash(importPath))
+	if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return path
+}
+
+// ExtractShared extracts the embedded stdlib once into a read-only directory
+// below baseDir, named after a hash of its content, and returns that
+// directory. A tree left by an earlier run with the same content is reused;
+// trees of other versions are removed.
+func (m *StdlibManager) ExtractShared(baseDir string) (string, error) {
+	version, err := m.version()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create stdlib directory: %w", err)
+	}
+	dir := filepath.Join(baseDir, version)
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		// Extract next to the final location and rename, so a crash never
+		// leaves a partial tree that would be reused
+		tmp, err := os.MkdirTemp(baseDir, ".extract-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create stdlib directory: %w", err)
+		}
+		if err := m.ExtractToDirectory(tmp); err != nil {
+			removeTree(tmp)
+			return "", err
+		}
+		if err := makeReadOnly(tmp); err != nil {
+			removeTree(tmp)
+			return "", err
+		}
+		if err := os.Rename(tmp, dir); err != nil {
+			removeTree(tmp)
+			return "", fmt.Errorf("failed to install stdlib directory: %w", err)
+		}
+	}
+
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.Name() != version {
+			removeTree(filepath.Join(baseDir, entry.Name()))
+		}
+	}
+	return dir, nil
+}
+
+// PruneCopies removes stdlib files copied into a session directory before the
+// shared tree existed. Files listed in keep (uploaded by the user) and files
+// whose content differs from the embedded stdlib are left alone, as are
+// directories that still hold anything. It returns the number of files removed.
+func (m *StdlibManager) PruneCopies(sessionDir string, keep []string) (int, error) {
+	kept := make(map[string]bool, len(keep))
+	for _, rel := range keep {
+		kept[filepath.ToSlash(rel)] = true
+	}
+
+	var dirs []string
+	removed := 0
+	err := fs.WalkDir(m.embeddedFS, "stdlib", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == "stdlib" {
+			return err
+		}
+		rel := strings.TrimPrefix(path, "stdlib/")
+		target := filepath.Join(sessionDir, filepath.FromSlash(rel))
+		if d.IsDir() {
+			dirs = append(dirs, target)
+			return nil
+		}
+		if kept[rel] {
+			return nil
+		}
+		current, err := os.ReadFile(target)
+		if err != nil {
+			return nil
+		}
+		embedded, err := m.embeddedFS.ReadFile(path)
+		if err != nil || string(current) != string(embedded) {
+			return nil
+		}
+		if err := os.Remove(target); err == nil {
+			removed++
+		}
+		return nil
+	})
+
+	// Deepest first, so emptied parents are removed too
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		os.Remove(dir) // Fails, as intended, for directories that are not empty
+	}
+	return removed, err
+}
+
+// version hashes the paths and content of the embedded stdlib
+func (m *StdlibManager) version() (string, error) {
+	hash := sha256.New()
+	err := fs.WalkDir(m.embeddedFS, "stdlib", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := m.embeddedFS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", path, len(content))
+		hash.Write(content)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash embedded stdlib: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
+
+// makeReadOnly removes write permission from every file and directory under root
+func makeReadOnly(root string) error {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		return os.Chmod(path, 0444)
+	})
+	if err != nil {
+		return err
+	}
+	// Directories last, so their entries could still be changed above
+	for _, dir := range dirs {
+		if err := os.Chmod(dir, 0555); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeTree deletes a tree made read-only by makeReadOnly
+func removeTree(root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			os.Chmod(path, 0755)
+		}
+		return nil
+	})
+	os.RemoveAll(root)
+}
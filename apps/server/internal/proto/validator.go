@@ -0,0 +1,118 @@
+package proto
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a single compiler finding tied to a source location
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`   // 1-based; 0 when unknown
+	Column   int    `json:"column"` // 1-based; 0 when unknown
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ValidationResult holds all diagnostics produced while compiling a session
+type ValidationResult struct {
+	Valid        bool         `json:"valid"`
+	Files        int          `json:"files"`
+	ErrorCount   int          `json:"error_count"`
+	WarningCount int          `json:"warning_count"`
+	Diagnostics  []Diagnostic `json:"diagnostics"`
+}
+
+// Validator compiles proto sources and collects every error instead of
+// stopping at the first one
+type Validator struct{}
+
+// NewValidator creates a new validator
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+// Validate compiles the given files (absolute paths under rootPath, which is
+// also the import path) and reports diagnostics sorted by file and position
+func (v *Validator) Validate(rootPath string, protoFiles []string) *ValidationResult {
+	result := &ValidationResult{
+		Files:       len(protoFiles),
+		Diagnostics: []Diagnostic{},
+	}
+
+	relativePaths := make([]string, 0, len(protoFiles))
+	for _, absPath := range protoFiles {
+		rel, err := filepath.Rel(rootPath, absPath)
+		if err != nil {
+			rel = absPath
+		}
+		relativePaths = append(relativePaths, filepath.ToSlash(rel))
+	}
+
+	add := func(severity string, err error) {
+		d := Diagnostic{Severity: severity, Message: err.Error()}
+		var ewp protoparse.ErrorWithPos
+		if errors.As(err, &ewp) {
+			pos := ewp.GetPosition()
+			d.File = pos.Filename
+			d.Line = pos.Line
+			d.Column = pos.Col
+			d.Message = ewp.Unwrap().Error()
+		}
+		// Don't leak server paths through messages such as missing imports
+		d.Message = strings.ReplaceAll(d.Message, rootPath+string(filepath.Separator), "")
+		result.Diagnostics = append(result.Diagnostics, d)
+	}
+
+	parser := protoparse.Parser{
+		ImportPaths: ImportPaths(rootPath),
+		ErrorReporter: func(err protoparse.ErrorWithPos) error {
+			add(SeverityError, err)
+			return nil // keep going to collect all errors
+		},
+		WarningReporter: func(err protoparse.ErrorWithPos) {
+			add(SeverityWarning, err)
+		},
+	}
+
+	if len(relativePaths) > 0 {
+		if _, err := parser.ParseFiles(relativePaths...); err != nil {
+			// Errors already passed to the reporter surface as ErrInvalidSource;
+			// anything else (e.g. unreadable file) is reported here
+			if !errors.Is(err, protoparse.ErrInvalidSource) {
+				add(SeverityError, err)
+			}
+		}
+	}
+
+	sort.SliceStable(result.Diagnostics, func(i, j int) bool {
+		a, b := result.Diagnostics[i], result.Diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	for _, d := range result.Diagnostics {
+		if d.Severity == SeverityError {
+			result.ErrorCount++
+		} else {
+			result.WarningCount++
+		}
+	}
+	result.Valid = result.ErrorCount == 0
+	return result
+}
@@ -0,0 +1,779 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokString tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokDot
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators in matching order: two-character operators first
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "%"}
+
+// tokenize splits an expression into tokens
+func tokenize(src string) ([]token, error) {
+	tokens := []token{}
+	i := 0
+	for i < len(src) {
+		ch := src[i]
+		afterDot := len(tokens) > 0 && tokens[len(tokens)-1].kind == tokDot
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '"' || ch == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != ch {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string at column %d", i+1)
+			}
+			raw := src[i : end+1]
+			if ch == '\'' {
+				raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+			}
+			text, err := strconv.Unquote(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at column %d", i+1)
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, pos: i})
+			i = end + 1
+		case isDigit(ch) && afterDot:
+			// An array index inside a path: digits only, so "items.0.name"
+			// does not lex "0." as a number
+			end := i + 1
+			for end < len(src) && isDigit(src[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[i:end], pos: i})
+			i = end
+		case isDigit(ch):
+			end := scanNumber(src, i)
+			tokens = append(tokens, token{kind: tokNumber, text: src[i:end], pos: i})
+			i = end
+		case isIdentStart(ch):
+			end := i + 1
+			for end < len(src) && isIdentPart(src[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[i:end], pos: i})
+			i = end
+		case ch == '.':
+			tokens = append(tokens, token{kind: tokDot, text: ".", pos: i})
+			i++
+		case ch == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case ch == '[':
+			tokens = append(tokens, token{kind: tokLBracket, text: "[", pos: i})
+			i++
+		case ch == ']':
+			tokens = append(tokens, token{kind: tokRBracket, text: "]", pos: i})
+			i++
+		case ch == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at column %d", ch, i+1)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// scanNumber returns the end of the number literal starting at i: digits,
+// an optional fraction and an optional exponent
+func scanNumber(src string, i int) int {
+	end := i
+	for end < len(src) && isDigit(src[end]) {
+		end++
+	}
+	if end+1 < len(src) && src[end] == '.' && isDigit(src[end+1]) {
+		end++
+		for end < len(src) && isDigit(src[end]) {
+			end++
+		}
+	}
+	if end < len(src) && (src[end] == 'e' || src[end] == 'E') {
+		exp := end + 1
+		if exp < len(src) && (src[exp] == '+' || src[exp] == '-') {
+			exp++
+		}
+		if exp < len(src) && isDigit(src[exp]) {
+			end = exp
+			for end < len(src) && isDigit(src[end]) {
+				end++
+			}
+		}
+	}
+	return end
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || isDigit(ch)
+}
+
+// isPath reports whether s is a valid dotted path for set and unset. Unlike
+// in expressions, where "-" subtracts, segments may contain "-" so metadata
+// keys such as request.metadata.x-trace can be written directly.
+func isPath(s string) bool {
+	if s == "" || !isIdentStart(s[0]) {
+		return false
+	}
+	for _, segment := range strings.Split(s, ".") {
+		if segment == "" {
+			return false
+		}
+		for i := 0; i < len(segment); i++ {
+			if !isIdentPart(segment[i]) && segment[i] != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parser is a recursive-descent evaluator over a token list. Each method
+// parses one level of the grammar documented on the package, lowest
+// precedence first.
+type parser struct {
+	tokens []token
+	pos    int
+	env    map[string]interface{}
+}
+
+// Eval evaluates an expression against env
+func Eval(src string, env map[string]interface{}) (interface{}, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	p := &parser{tokens: tokens, env: env}
+	value, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at column %d", p.tokens[p.pos].text, p.tokens[p.pos].pos+1)
+	}
+	return value, nil
+}
+
+func (p *parser) peek() *token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+// peekOp returns the operator at the current position if it is one of ops
+func (p *parser) peekOp(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok == nil || tok.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// expect consumes a token of the given kind or fails with "missing <text>"
+func (p *parser) expect(kind tokenKind, text string) error {
+	if next := p.peek(); next == nil || next.kind != kind {
+		return fmt.Errorf("missing '%s'", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) parseOr() (interface{}, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for _, ok := p.peekOp("||"); ok; _, ok = p.peekOp("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) || truthy(right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (interface{}, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for _, ok := p.peekOp("&&"); ok; _, ok = p.peekOp("&&") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) && truthy(right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (interface{}, error) {
+	if _, ok := p.peekOp("!"); ok {
+		p.pos++
+		value, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return !truthy(value), nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (interface{}, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	if tok == nil {
+		return left, nil
+	}
+	op := tok.text
+	_, isCmp := p.peekOp("==", "!=", "<", "<=", ">", ">=")
+	isWordOp := tok.kind == tokIdent && (op == "contains" || op == "matches")
+	if !isCmp && !isWordOp {
+		return left, nil
+	}
+	p.pos++
+
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	return compare(op, left, right)
+}
+
+func (p *parser) parseSum() (interface{}, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op, ok := p.peekOp("+", "-"); ok; op, ok = p.peekOp("+", "-") {
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arithmetic(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseProduct() (interface{}, error) {
+	left, err := p.parseNegation()
+	if err != nil {
+		return nil, err
+	}
+	for op, ok := p.peekOp("*", "/", "%"); ok; op, ok = p.peekOp("*", "/", "%") {
+		p.pos++
+		right, err := p.parseNegation()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arithmetic(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNegation() (interface{}, error) {
+	if _, ok := p.peekOp("-"); ok {
+		p.pos++
+		value, err := p.parseNegation()
+		if err != nil {
+			return nil, err
+		}
+		n, ok := toNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("operator - requires a number")
+		}
+		return -n, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (interface{}, error) {
+	tok := p.peek()
+	if tok == nil {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+
+	switch tok.kind {
+	case tokString:
+		return tok.text, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return n, nil
+	case tokLParen:
+		value, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRParen, ")"); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		case "len", "exists":
+			if next := p.peek(); next != nil && next.kind == tokLParen {
+				return p.parseCall(tok.text)
+			}
+		}
+		segments, err := p.parsePath(tok.text)
+		if err != nil {
+			return nil, err
+		}
+		value, _ := lookupSegments(p.env, segments)
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at column %d", tok.text, tok.pos+1)
+	}
+}
+
+// parsePath reads the rest of a path whose first segment has been consumed:
+// ".name" and ".0" segments, and ["key"] segments for keys that are not
+// identifiers (e.g. request.metadata["x-trace"])
+func (p *parser) parsePath(first string) ([]string, error) {
+	segments := []string{first}
+	for {
+		tok := p.peek()
+		switch {
+		case tok == nil:
+			return segments, nil
+		case tok.kind == tokDot:
+			p.pos++
+			next := p.peek()
+			if next == nil || (next.kind != tokIdent && next.kind != tokNumber) {
+				return nil, fmt.Errorf("expected a name after '.' at column %d", tok.pos+1)
+			}
+			p.pos++
+			segments = append(segments, next.text)
+		case tok.kind == tokLBracket:
+			p.pos++
+			key := p.peek()
+			if key == nil || key.kind != tokString {
+				return nil, fmt.Errorf("expected a string key after '[' at column %d", tok.pos+1)
+			}
+			p.pos++
+			if err := p.expect(tokRBracket, "]"); err != nil {
+				return nil, err
+			}
+			segments = append(segments, key.text)
+		default:
+			return segments, nil
+		}
+	}
+}
+
+// parseCall evaluates a built-in function call; the opening paren is the current token
+func (p *parser) parseCall(name string) (interface{}, error) {
+	p.pos++ // consume '('
+
+	if name == "exists" {
+		arg := p.peek()
+		if arg == nil || arg.kind != tokIdent {
+			return nil, fmt.Errorf("exists() expects a path argument")
+		}
+		p.pos++
+		segments, err := p.parsePath(arg.text)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRParen, ")"); err != nil {
+			return nil, err
+		}
+		_, found := lookupSegments(p.env, segments)
+		return found, nil
+	}
+
+	value, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokRParen, ")"); err != nil {
+		return nil, err
+	}
+
+	switch val := value.(type) {
+	case string:
+		return float64(len(val)), nil
+	case []interface{}:
+		return float64(len(val)), nil
+	case map[string]interface{}:
+		return float64(len(val)), nil
+	case map[string]string:
+		return float64(len(val)), nil
+	case nil:
+		return float64(0), nil
+	default:
+		return nil, fmt.Errorf("len() not supported for %T", value)
+	}
+}
+
+// arithmetic applies + - * / %. Numeric strings count as numbers, since
+// protojson renders 64-bit integers as strings; otherwise + concatenates
+// strings.
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		ls, lstr := left.(string)
+		rs, rstr := right.(string)
+		if op == "+" && (lstr || rstr) {
+			if !lstr {
+				ls = stringify(left)
+			}
+			if !rstr {
+				rs = stringify(right)
+			}
+			return ls + rs, nil
+		}
+		return nil, fmt.Errorf("operator %s requires numbers", op)
+	}
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+// compare applies a binary comparison operator
+func compare(op string, left, right interface{}) (interface{}, error) {
+	switch op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		if l, lok := toNumber(left); lok {
+			if r, rok := toNumber(right); rok {
+				return orderResult(op, compareFloat(l, r)), nil
+			}
+		}
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		if !lok || !rok {
+			return nil, fmt.Errorf("operator %s requires numbers or strings", op)
+		}
+		return orderResult(op, strings.Compare(ls, rs)), nil
+	case "contains":
+		switch l := left.(type) {
+		case string:
+			rs, ok := right.(string)
+			return ok && strings.Contains(l, rs), nil
+		case []interface{}:
+			for _, item := range l {
+				if equal(item, right) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			rs, ok := right.(string)
+			if !ok {
+				return false, nil
+			}
+			_, found := l[rs]
+			return found, nil
+		default:
+			return false, nil
+		}
+	case "matches":
+		pattern, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("matches requires a string pattern")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %v", err)
+		}
+		ls, ok := left.(string)
+		return ok && re.MatchString(ls), nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func orderResult(op string, cmp int) bool {
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// equal compares values loosely: numbers compare numerically (protojson renders
+// 64-bit integers as strings), everything else by deep equality.
+func equal(a, b interface{}) bool {
+	if an, aok := toNumber(a); aok {
+		if bn, bok := toNumber(b); bok {
+			return an == bn
+		}
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// toNumber converts numbers and numeric strings to float64
+func toNumber(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case json.Number:
+		f, err := val.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(val, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// normalize round-trips a value through JSON so map/slice types compare consistently
+func normalize(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, string, bool, float64:
+		return v
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(encoded, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// truthy reports the boolean interpretation of a value
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case string:
+		return val != ""
+	case []interface{}:
+		return len(val) > 0
+	case map[string]interface{}:
+		return len(val) > 0
+	default:
+		return true
+	}
+}
+
+// lookupPath resolves a dotted path inside env
+func lookupPath(env map[string]interface{}, path string) (interface{}, bool) {
+	return lookupSegments(env, strings.Split(path, "."))
+}
+
+// lookupSegments resolves a path given as its segments inside env
+func lookupSegments(env map[string]interface{}, segments []string) (interface{}, bool) {
+	var current interface{} = env
+	for _, segment := range segments {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case map[string]string:
+			next, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// setPath assigns value at a dotted path, creating intermediate objects as needed
+func setPath(env map[string]interface{}, path string, value interface{}) error {
+	segments := strings.Split(path, ".")
+	var current interface{} = env
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch node := current.(type) {
+		case map[string]interface{}:
+			if last {
+				node[segment] = value
+				return nil
+			}
+			next, ok := node[segment]
+			if !ok || next == nil {
+				next = map[string]interface{}{}
+				node[segment] = next
+			}
+			current = next
+		case map[string]string:
+			if !last {
+				return fmt.Errorf("cannot descend into %q", strings.Join(segments[:i], "."))
+			}
+			s, ok := value.(string)
+			if !ok {
+				s = stringify(value)
+			}
+			node[segment] = s
+			return nil
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return fmt.Errorf("index %q out of range in %q", segment, path)
+			}
+			if last {
+				node[idx] = value
+				return nil
+			}
+			current = node[idx]
+		default:
+			return fmt.Errorf("cannot set %q: %q is not an object", path, strings.Join(segments[:i], "."))
+		}
+	}
+	return nil
+}
+
+// unsetPath removes the value at a dotted path if present
+func unsetPath(env map[string]interface{}, path string) {
+	idx := strings.LastIndex(path, ".")
+	if idx < 0 {
+		delete(env, path)
+		return
+	}
+	parent, ok := lookupPath(env, path[:idx])
+	if !ok {
+		return
+	}
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		delete(node, path[idx+1:])
+	case map[string]string:
+		delete(node, path[idx+1:])
+	}
+}
@@ -0,0 +1,161 @@
+package script
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testEnv() map[string]interface{} {
+	return map[string]interface{}{
+		"status": "OK",
+		"headers": map[string]interface{}{
+			"x-request-id": "abc",
+		},
+		"response": map[string]interface{}{
+			"id":    "9007199254740993",
+			"count": float64(3),
+			"name":  "Ada Lovelace",
+			"items": []interface{}{
+				map[string]interface{}{"name": "ada", "price": float64(2.5)},
+				map[string]interface{}{"name": "bob", "price": float64(4)},
+			},
+		},
+		"vars": map[string]interface{}{
+			"user_id": "u-1",
+			"limit":   "10",
+		},
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		// Literals
+		{`"a \"b\""`, `a "b"`},
+		{`'single "quoted"'`, `single "quoted"`},
+		{`42`, float64(42)},
+		{`1.5e3`, float64(1500)},
+		{`2E-1`, float64(0.2)},
+		{`true`, true},
+		{`null`, nil},
+
+		// Paths
+		{`status`, "OK"},
+		{`response.items.1.name`, "bob"},
+		{`response.items.0["name"]`, "ada"},
+		{`headers["x-request-id"]`, "abc"},
+		{`response.missing.deeper`, nil},
+		{`exists(response.count)`, true},
+		{`exists(headers["x-request-id"])`, true},
+		{`exists(response.nope)`, false},
+		{`len(response.items)`, float64(2)},
+		{`len(response.name)`, float64(12)},
+		{`len(response.nope)`, float64(0)},
+
+		// Arithmetic and precedence
+		{`1 + 2 * 3`, float64(7)},
+		{`(1 + 2) * 3`, float64(9)},
+		{`10 - 4 - 3`, float64(3)},
+		{`12 / 4 / 3`, float64(1)},
+		{`7 % 4`, float64(3)},
+		{`-2 * -3`, float64(6)},
+		{`- (1 + 2)`, float64(-3)},
+		{`1-1`, float64(0)},
+		{`response.count-1`, float64(2)},
+		{`response.items.0.price + response.items.1.price`, float64(6.5)},
+		{`vars.limit * 2`, float64(20)},
+		{`"id-" + vars.user_id`, "id-u-1"},
+		{`"n" + 1`, "n1"},
+
+		// Comparisons
+		{`status == "OK"`, true},
+		{`response.id == 9007199254740993`, true},
+		{`response.count >= 3`, true},
+		{`response.count + 1 > 3`, true},
+		{`"abc" < "abd"`, true},
+		{`response.name contains "Love"`, true},
+		{`response.items contains "ada"`, false},
+		{`response.name matches "^Ada"`, true},
+		{`null == response.nope`, true},
+
+		// Boolean logic
+		{`!false`, true},
+		{`!!status`, true},
+		{`!status == "OK"`, false},
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`len(response.items) > 0 && response.items.0.name contains "ad"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Eval(tt.expr, testEnv())
+			if err != nil {
+				t.Fatalf("Eval(%q): %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{``, "empty expression"},
+		{`"open`, "unterminated string"},
+		{`status @ 1`, "unexpected character"},
+		{`(1 + 2`, "missing ')'"},
+		{`1 +`, "unexpected end of expression"},
+		{`1 2`, `unexpected "2"`},
+		{`response.`, "expected a name after '.'"},
+		{`headers[x]`, "expected a string key"},
+		{`headers["x"`, "missing ']'"},
+		{`exists("x")`, "exists() expects a path"},
+		{`len(true)`, "len() not supported"},
+		{`1 / 0`, "division by zero"},
+		{`5 % 0`, "division by zero"},
+		{`status - 1`, "operator - requires numbers"},
+		{`true + 1`, "operator + requires numbers"},
+		{`-status`, "operator - requires a number"},
+		{`status matches "("`, "invalid pattern"},
+		{`response.count > "x"`, "operator > requires numbers or strings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Eval(tt.expr, testEnv())
+			if err == nil {
+				t.Fatalf("Eval(%q) = %#v, want an error containing %q", tt.expr, got, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Eval(%q) error = %q, want it to contain %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"request.data.id", true},
+		{"request.metadata.x-trace", true},
+		{"items.0.name", true},
+		{"", false},
+		{"0.name", false},
+		{"request..id", false},
+		{"request.data.", false},
+		{`request["id"]`, false},
+	}
+	for _, tt := range tests {
+		if got := isPath(tt.path); got != tt.want {
+			t.Errorf("isPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
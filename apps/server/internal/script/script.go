@@ -0,0 +1,220 @@
+// Package script implements a tiny, sandboxed scripting language used for
+// pre-request and post-response hooks on gRPC calls.
+//
+// A script is a list of statements, one per line. Blank lines and lines
+// starting with "#" or "//" are ignored.
+//
+//	set request.data.user.id = vars.user_id
+//	set request.metadata.x-trace = "debug"
+//	unset request.data.password
+//	assert status == "OK", "call should succeed"
+//	assert len(response.items) > 0 && response.items.0.name contains "ada"
+//	log response.id
+//
+// Expressions follow this grammar, lowest precedence first:
+//
+//	expr       = and { "||" and }
+//	and        = not { "&&" not }
+//	not        = "!" not | comparison
+//	comparison = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "contains" | "matches" ) sum ]
+//	sum        = product { ( "+" | "-" ) product }
+//	product    = unary { ( "*" | "/" | "%" ) unary }
+//	unary      = "-" unary | primary
+//	primary    = string | number | "true" | "false" | "null" | "(" expr ")"
+//	           | "len(" expr ")" | "exists(" path ")" | path
+//	path       = ident { "." ( ident | digits ) | "[" string "]" }
+//
+// Identifiers are letters, digits and "_"; numeric segments index arrays.
+// Keys that are not identifiers use brackets, e.g. request.metadata["x-trace"],
+// since "-" subtracts. Numbers and numeric strings (protojson renders 64-bit
+// integers as strings) are interchangeable in arithmetic and comparisons; "+"
+// concatenates when either side is a non-numeric string. Division by zero is
+// an error. The right-hand side of "set" may also be a JSON object or array
+// literal, and "set"/"unset" paths may contain "-" directly.
+// There are no loops or user-defined functions, so every script terminates.
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxScriptSize bounds the accepted script source length in bytes
+const MaxScriptSize = 64 * 1024
+
+// Phases in which scripts run
+const (
+	PhasePreRequest   = "pre_request"
+	PhasePostResponse = "post_response"
+)
+
+// Assertion is the outcome of a single assert statement
+type Assertion struct {
+	Line       int    `json:"line"`
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+	Passed     bool   `json:"passed"`
+}
+
+// Result collects assertions and log output produced by a script
+type Result struct {
+	Phase      string      `json:"phase"`
+	Assertions []Assertion `json:"assertions"`
+	Logs       []string    `json:"logs,omitempty"`
+	Passed     bool        `json:"passed"` // True when every assertion passed
+}
+
+// Error describes a script failure (syntax or runtime) with its location
+type Error struct {
+	Phase   string `json:"phase"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s script error at line %d: %s", e.Phase, e.Line, e.Message)
+}
+
+// Run executes a script against env. Statements may mutate env through "set"
+// and "unset". The returned Result is non-nil even when an error occurs so that
+// assertions evaluated before the failure are still reported.
+func Run(phase, source string, env map[string]interface{}) (*Result, error) {
+	result := &Result{
+		Phase:      phase,
+		Assertions: []Assertion{},
+		Passed:     true,
+	}
+
+	if len(source) > MaxScriptSize {
+		return result, &Error{Phase: phase, Message: fmt.Sprintf("script exceeds %d bytes", MaxScriptSize)}
+	}
+
+	for idx, raw := range strings.Split(source, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if err := runStatement(line, env, result, idx+1); err != nil {
+			return result, &Error{Phase: phase, Line: idx + 1, Message: err.Error()}
+		}
+	}
+
+	return result, nil
+}
+
+// runStatement executes a single statement line
+func runStatement(line string, env map[string]interface{}, result *Result, lineNo int) error {
+	keyword, rest := splitKeyword(line)
+
+	switch keyword {
+	case "set":
+		eq := strings.Index(rest, "=")
+		if eq <= 0 || strings.HasPrefix(rest[eq:], "==") {
+			return fmt.Errorf("expected 'set <path> = <value>'")
+		}
+		path := strings.TrimSpace(rest[:eq])
+		if !isPath(path) {
+			return fmt.Errorf("invalid path %q", path)
+		}
+		valueSrc := strings.TrimSpace(rest[eq+1:])
+		value, err := evalValue(valueSrc, env)
+		if err != nil {
+			return err
+		}
+		return setPath(env, path, value)
+
+	case "unset":
+		path := strings.TrimSpace(rest)
+		if !isPath(path) {
+			return fmt.Errorf("invalid path %q", path)
+		}
+		unsetPath(env, path)
+		return nil
+
+	case "assert":
+		exprSrc, message, err := splitMessage(rest)
+		if err != nil {
+			return err
+		}
+		value, err := Eval(exprSrc, env)
+		if err != nil {
+			return err
+		}
+		passed := truthy(value)
+		result.Assertions = append(result.Assertions, Assertion{
+			Line:       lineNo,
+			Expression: exprSrc,
+			Message:    message,
+			Passed:     passed,
+		})
+		if !passed {
+			result.Passed = false
+		}
+		return nil
+
+	case "log":
+		value, err := Eval(rest, env)
+		if err != nil {
+			return err
+		}
+		result.Logs = append(result.Logs, stringify(value))
+		return nil
+
+	default:
+		return fmt.Errorf("unknown statement %q (expected set, unset, assert or log)", keyword)
+	}
+}
+
+// evalValue evaluates the right-hand side of "set", accepting JSON object/array literals
+func evalValue(src string, env map[string]interface{}) (interface{}, error) {
+	if strings.HasPrefix(src, "{") || strings.HasPrefix(src, "[") {
+		var value interface{}
+		if err := json.Unmarshal([]byte(src), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON literal: %v", err)
+		}
+		return value, nil
+	}
+	return Eval(src, env)
+}
+
+// splitKeyword splits the leading statement keyword from the rest of the line
+func splitKeyword(line string) (string, string) {
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		return line[:i], strings.TrimSpace(line[i+1:])
+	}
+	return line, ""
+}
+
+// splitMessage separates an optional trailing `, "message"` from an assert expression
+func splitMessage(src string) (string, string, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return "", "", err
+	}
+	for i, tok := range tokens {
+		if tok.kind == tokComma {
+			if i != len(tokens)-2 || tokens[i+1].kind != tokString {
+				return "", "", fmt.Errorf("assert message must be a single string literal")
+			}
+			return strings.TrimSpace(src[:tok.pos]), tokens[i+1].text, nil
+		}
+	}
+	return src, "", nil
+}
+
+// stringify renders a value for log output
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return "null"
+	default:
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(encoded)
+	}
+}
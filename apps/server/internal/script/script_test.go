@@ -0,0 +1,94 @@
+package script
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	env := testEnv()
+	env["request"] = map[string]interface{}{
+		"data":     map[string]interface{}{"password": "hunter2"},
+		"metadata": map[string]interface{}{},
+	}
+	source := `# comment
+set request.data.user.id = vars.user_id
+set request.data.total = response.count * 2 + 1
+set request.data.tags = ["a", "b"]
+set request.metadata.x-trace = "debug-" + status
+unset request.data.password
+// another comment
+
+assert status == "OK", "call should succeed"
+assert request.metadata["x-trace"] == "debug-OK"
+assert len(response.items) > 5, "expected many items"
+log response.items.0
+log request.data.total`
+
+	result, err := Run(PhasePostResponse, source, env)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	wantData := map[string]interface{}{
+		"user":  map[string]interface{}{"id": "u-1"},
+		"total": float64(7),
+		"tags":  []interface{}{"a", "b"},
+	}
+	request := env["request"].(map[string]interface{})
+	if !reflect.DeepEqual(request["data"], wantData) {
+		t.Errorf("request.data = %#v, want %#v", request["data"], wantData)
+	}
+	if got := request["metadata"].(map[string]interface{})["x-trace"]; got != "debug-OK" {
+		t.Errorf("request.metadata.x-trace = %#v, want debug-OK", got)
+	}
+
+	wantAssertions := []Assertion{
+		{Line: 9, Expression: `status == "OK"`, Message: "call should succeed", Passed: true},
+		{Line: 10, Expression: `request.metadata["x-trace"] == "debug-OK"`, Passed: true},
+		{Line: 11, Expression: `len(response.items) > 5`, Message: "expected many items", Passed: false},
+	}
+	if !reflect.DeepEqual(result.Assertions, wantAssertions) {
+		t.Errorf("assertions = %+v, want %+v", result.Assertions, wantAssertions)
+	}
+	if result.Passed {
+		t.Error("result passed with a failing assertion")
+	}
+	wantLogs := []string{`{"name":"ada","price":2.5}`, "7"}
+	if !reflect.DeepEqual(result.Logs, wantLogs) {
+		t.Errorf("logs = %q, want %q", result.Logs, wantLogs)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		line   int
+	}{
+		{name: "unknown statement", source: "print status", line: 1},
+		{name: "set without value", source: "\nset request.id", line: 2},
+		{name: "set with comparison", source: "set request.id == 1", line: 1},
+		{name: "invalid set path", source: `set request["id"] = 1`, line: 1},
+		{name: "invalid unset path", source: "unset request..id", line: 1},
+		{name: "bad JSON literal", source: "set request.data = {oops}", line: 1},
+		{name: "assert message not a string", source: "assert true, status", line: 1},
+		{name: "runtime error", source: "assert true\nlog 1 / 0", line: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Run(PhasePreRequest, tt.source, testEnv())
+			var scriptErr *Error
+			if !errors.As(err, &scriptErr) {
+				t.Fatalf("Run(%q) error = %v, want a *script.Error", tt.source, err)
+			}
+			if scriptErr.Line != tt.line || scriptErr.Phase != PhasePreRequest {
+				t.Errorf("Run(%q) error at %s line %d, want %s line %d", tt.source, scriptErr.Phase, scriptErr.Line, PhasePreRequest, tt.line)
+			}
+			if result == nil {
+				t.Errorf("Run(%q) returned a nil result with the error", tt.source)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Package secret protects credentials kept by the bridge, such as
+// authorization metadata on target presets: it encrypts them at rest and
+// redacts them from API responses.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Redacted replaces sensitive values in API responses. Writing it back (e.g.
+// saving an edited preset) keeps the stored value.
+const Redacted = "********"
+
+// encryptedPrefix marks encrypted values; values without it are plaintext,
+// as written before encryption was configured
+const encryptedPrefix = "enc:v1:"
+
+// ErrNoKey is returned when encrypted values are found but no key is configured
+var ErrNoKey = errors.New("secret: encrypted value found but no encryption key is configured")
+
+// sensitiveWords mark metadata keys and variable names holding credentials
+var sensitiveWords = []string{"authorization", "token", "secret", "password", "passwd", "api-key", "api_key", "apikey", "cookie", "credential", "private-key", "private_key"}
+
+// IsSensitive reports whether a metadata key or variable name looks like it
+// holds a credential (e.g. "authorization", "x-api-key", "AUTH_TOKEN")
+func IsSensitive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactMap returns a copy of m with sensitive values replaced by Redacted.
+// A nil map stays nil.
+func RedactMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = RedactValue(k, v)
+	}
+	return out
+}
+
+// RedactValue returns Redacted for a non-empty value of a sensitive name and
+// the value itself otherwise
+func RedactValue(name, value string) string {
+	if value != "" && IsSensitive(name) {
+		return Redacted
+	}
+	return value
+}
+
+// Unredact replaces Redacted values in an update with the values they stand
+// for in current, so clients can send back what they were shown. Keys missing
+// from current keep the placeholder as their literal value.
+func Unredact(update, current map[string]string) {
+	for k, v := range update {
+		if v != Redacted {
+			continue
+		}
+		if old, ok := current[k]; ok {
+			update[k] = old
+		}
+	}
+}
+
+// Cipher encrypts values with AES-256-GCM
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// ParseKey decodes an encryption key given as 64 hex characters or base64 of
+// 32 bytes. Passphrases are refused: a fast hash of one is easy to brute-force.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("secret: empty encryption key")
+	}
+	if len(s) == 64 {
+		if key, err := hex.DecodeString(s); err == nil {
+			return key, nil
+		}
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("secret: encryption key must be 64 hex characters or base64 of 32 bytes (e.g. from `openssl rand -hex 32`)")
+}
+
+// NewCipher creates a cipher from a 32-byte key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secret: key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt returns the encrypted form of a value. Empty and already encrypted
+// values are returned as is.
+func (c *Cipher) Encrypt(value string) (string, error) {
+	if value == "" || IsEncrypted(value) {
+		return value, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("secret: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of a value produced by Encrypt. Plaintext
+// values are returned as is. A nil Cipher fails on encrypted values.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrNoKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("secret: malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("secret: cannot decrypt value (wrong key?)")
+	}
+	return string(plain), nil
+}
+
+// IsEncrypted reports whether a value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Seal returns a copy of m with its sensitive values encrypted, for storing.
+// A nil Cipher returns m as is.
+func (c *Cipher) Seal(m map[string]string) (map[string]string, error) {
+	if c == nil || m == nil {
+		return m, nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	if err := TransformSensitive(out, c.Encrypt); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Open decrypts the sensitive values of a map written by Seal in place. A nil
+// Cipher fails on encrypted values.
+func (c *Cipher) Open(m map[string]string) error {
+	return TransformSensitive(m, c.Decrypt)
+}
+
+// TransformSensitive applies fn to the sensitive values of m in place
+func TransformSensitive(m map[string]string, fn func(string) (string, error)) error {
+	for k, v := range m {
+		if !IsSensitive(k) {
+			continue
+		}
+		out, err := fn(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		m[k] = out
+	}
+	return nil
+}
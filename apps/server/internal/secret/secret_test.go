@@ -0,0 +1,59 @@
+package secret
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "hex", key: strings.Repeat("ab", 32)},
+		{name: "hex with whitespace", key: " " + strings.Repeat("0f", 32) + "\n"},
+		{name: "base64", key: "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="},
+		{name: "empty", key: "  ", wantErr: true},
+		{name: "passphrase", key: "correct horse battery staple", wantErr: true},
+		{name: "short hex", key: strings.Repeat("ab", 16), wantErr: true},
+		{name: "base64 of 16 bytes", key: "AAECAwQFBgcICQoLDA0ODw==", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseKey(%q) = %x, want an error", tt.key, key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKey(%q): %v", tt.key, err)
+			}
+			if len(key) != 32 {
+				t.Errorf("ParseKey(%q) returned %d bytes, want 32", tt.key, len(key))
+			}
+		})
+	}
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	key, _ := ParseKey(strings.Repeat("ab", 32))
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	sealed, err := c.Encrypt("Bearer abc")
+	if err != nil || !IsEncrypted(sealed) {
+		t.Fatalf("Encrypt = %q, %v", sealed, err)
+	}
+	if plain, err := c.Decrypt(sealed); err != nil || plain != "Bearer abc" {
+		t.Errorf("Decrypt = %q, %v", plain, err)
+	}
+
+	other, _ := NewCipher(make([]byte, 32))
+	if _, err := other.Decrypt(sealed); err == nil {
+		t.Error("Decrypt with another key succeeded")
+	}
+}
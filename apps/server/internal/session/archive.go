@@ -0,0 +1,267 @@
+package session
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grpc-bridge/server/internal/archive"
+	"github.com/grpc-bridge/server/internal/objectstore"
+)
+
+// Archive layout: the session record plus its directory tree
+const (
+	archiveRecordName = "session.json"
+	archiveFilesDir   = "files/"
+)
+
+// archiveKey is the object key of a session's archive
+func archiveKey(sessionID string) string {
+	return "sessions/" + sessionID + ".zip"
+}
+
+// SetArchiveStore makes expired sessions be archived to store before their
+// files are deleted, so they can be restored later
+func (m *Manager) SetArchiveStore(store objectstore.Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.archive = store
+}
+
+// ArchiveEnabled reports whether expired sessions are archived
+func (m *Manager) ArchiveEnabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.archive != nil
+}
+
+// archiveSession uploads a zip of the session record and its directory tree
+func archiveSession(ctx context.Context, store objectstore.Store, sessionID string, record []byte, root string) error {
+	tmp, err := os.CreateTemp("", "grpc-bridge-archive-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	w, err := zw.Create(archiveRecordName)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(record); err != nil {
+		return err
+	}
+
+	if root != "" {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !d.Type().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			w, err := zw.Create(archiveFilesDir + filepath.ToSlash(rel))
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(w, f)
+			return err
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return store.Put(ctx, archiveKey(sessionID), tmp, size)
+}
+
+// RestoreArchived brings an archived session back: its files are extracted
+// into the upload directory and the session becomes active again with a fresh
+// expiry. authorize, when set, may refuse the stored record (e.g. another
+// user's session), which is reported as ErrArchiveNotFound. The archive is kept.
+func (m *Manager) RestoreArchived(ctx context.Context, sessionID string, authorize func(*Session) bool) (*Session, error) {
+	m.mu.RLock()
+	store := m.archive
+	_, exists := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if store == nil {
+		return nil, ErrArchiveDisabled
+	}
+	if exists {
+		return nil, ErrSessionExists
+	}
+
+	rc, err := store.Get(ctx, archiveKey(sessionID))
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return nil, ErrArchiveNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download archive: %w", err)
+	}
+	tmp, err := os.CreateTemp("", "grpc-bridge-restore-*.zip")
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download archive: %w", err)
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session archive: %w", err)
+	}
+	sess, err := readArchivedRecord(zr)
+	if err != nil {
+		return nil, err
+	}
+	if sess.ID != sessionID {
+		return nil, fmt.Errorf("invalid session archive: record is for session %q", sess.ID)
+	}
+	m.mu.RLock()
+	cipher := m.cipher
+	m.mu.RUnlock()
+	if err := openSecrets(sess, cipher); err != nil {
+		return nil, fmt.Errorf("failed to decrypt archived session: %w", err)
+	}
+	if authorize != nil && !authorize(sess) {
+		return nil, ErrArchiveNotFound
+	}
+
+	root := filepath.Join(m.uploadDir, sessionID)
+	if err := os.RemoveAll(root); err != nil {
+		return nil, err
+	}
+	if err := extractArchivedFiles(zr, root); err != nil {
+		os.RemoveAll(root)
+		return nil, err
+	}
+	sess.relocate(root)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.sessions[sessionID]; exists {
+		return nil, ErrSessionExists
+	}
+	now := time.Now()
+	sess.LastUsedAt = now
+	sess.ExpiresAt = now.Add(m.ttl)
+	reconcileSession(sess)
+	m.sessions[sessionID] = sess
+	m.markDirty()
+	log.Printf("[SessionManager] Restored session %s from %s", sessionID, store)
+	return sess, nil
+}
+
+// readArchivedRecord decodes the session record of an archive
+func readArchivedRecord(zr *zip.Reader) (*Session, error) {
+	f, err := zr.Open(archiveRecordName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session archive: missing %s", archiveRecordName)
+	}
+	defer f.Close()
+
+	var sess Session
+	if err := json.NewDecoder(f).Decode(&sess); err != nil {
+		return nil, fmt.Errorf("invalid session archive: %w", err)
+	}
+	return &sess, nil
+}
+
+// extractArchivedFiles writes the archived directory tree below root
+func extractArchivedFiles(zr *zip.Reader, root string) error {
+	for _, f := range zr.File {
+		name, found := strings.CutPrefix(f.Name, archiveFilesDir)
+		if !found || !f.Mode().IsRegular() {
+			continue
+		}
+		rel, ok := archive.SanitizePath(name)
+		if !ok {
+			continue
+		}
+		target := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := writeArchivedFile(f, target); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+func writeArchivedFile(f *zip.File, target string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// relocate points the session's file paths at a new root directory
+func (s *Session) relocate(root string) {
+	oldRoot := s.RootPath
+	if oldRoot == "" {
+		return
+	}
+	move := func(path string) string {
+		rel, err := filepath.Rel(oldRoot, path)
+		if err != nil {
+			return path
+		}
+		return filepath.Join(root, rel)
+	}
+
+	s.RootPath = root
+	for i := range s.ProtoFiles {
+		s.ProtoFiles[i].AbsolutePath = move(s.ProtoFiles[i].AbsolutePath)
+	}
+	for i := range s.Directories {
+		s.Directories[i].AbsolutePath = move(s.Directories[i].AbsolutePath)
+	}
+	for i := range s.DescriptorSets {
+		s.DescriptorSets[i].AbsolutePath = move(s.DescriptorSets[i].AbsolutePath)
+	}
+}
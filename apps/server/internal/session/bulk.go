@@ -0,0 +1,84 @@
+package session
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// BulkDeleteFilter selects the sessions BulkDelete removes. Every criterion
+// that is set must match, so IDs combined with CreatedBefore only deletes the
+// listed sessions that are old enough.
+type BulkDeleteFilter struct {
+	IDs           []string  // Only these sessions
+	CreatedBefore time.Time // Sessions created before this time
+	UsedBefore    time.Time // Sessions last used before this time
+	DryRun        bool      // Report what would be deleted without deleting it
+}
+
+// BulkDeleteResult reports what a bulk deletion removed
+type BulkDeleteResult struct {
+	Deleted             []string `json:"deleted"`
+	NotFound            []string `json:"not_found"`            // Listed IDs that do not exist
+	CancelledOperations int      `json:"cancelled_operations"` // In-flight calls and uploads that were cancelled
+	FreedBytes          int64    `json:"freed_bytes"`          // Disk space of the deleted session directories
+	DryRun              bool     `json:"dry_run"`
+}
+
+// BulkDelete deletes every session matching filter, like Delete does one:
+// the session record, its upload directory and, through the OnDelete hooks,
+// its cached descriptors, history and collections. Deleted IDs are sorted.
+func (m *Manager) BulkDelete(filter BulkDeleteFilter) BulkDeleteResult {
+	result := BulkDeleteResult{Deleted: []string{}, NotFound: []string{}, DryRun: filter.DryRun}
+
+	var listed map[string]bool
+	if filter.IDs != nil {
+		listed = make(map[string]bool, len(filter.IDs))
+		for _, id := range filter.IDs {
+			listed[id] = true
+		}
+	}
+
+	m.mu.RLock()
+	dirs := map[string][]string{}
+	for id, sess := range m.sessions {
+		if listed != nil && !listed[id] {
+			continue
+		}
+		if !filter.CreatedBefore.IsZero() && !sess.CreatedAt.Before(filter.CreatedBefore) {
+			continue
+		}
+		if !filter.UsedBefore.IsZero() && !sess.LastUsedAt.Before(filter.UsedBefore) {
+			continue
+		}
+		dirs[id] = []string{filepath.Join(m.uploadDir, id)}
+		if sess.RootPath != "" && filepath.Clean(sess.RootPath) != filepath.Join(m.uploadDir, id) {
+			dirs[id] = append(dirs[id], sess.RootPath)
+		}
+	}
+	for id := range listed {
+		if _, exists := m.sessions[id]; !exists {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+	m.mu.RUnlock()
+
+	for id, sessionDirs := range dirs {
+		for _, dir := range sessionDirs {
+			bytes, _ := dirUsage(dir)
+			result.FreedBytes += bytes
+		}
+		if !filter.DryRun {
+			result.CancelledOperations += m.Delete(id)
+		}
+		result.Deleted = append(result.Deleted, id)
+	}
+	sort.Strings(result.Deleted)
+	sort.Strings(result.NotFound)
+
+	if !filter.DryRun && len(result.Deleted) > 0 {
+		log.Printf("[SessionManager] Bulk deleted %d session(s), freeing %d bytes", len(result.Deleted), result.FreedBytes)
+	}
+	return result
+}
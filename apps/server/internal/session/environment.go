@@ -0,0 +1,135 @@
+package session
+
+import (
+	"sort"
+
+	"github.com/grpc-bridge/server/internal/secret"
+)
+
+// Environment is a named set of call defaults within a session (e.g. dev,
+// staging, prod). Calls that reference it get its target when they do not set
+// one, its metadata under their own, and its variables ahead of the session's.
+type Environment struct {
+	Name      string            `json:"name"`
+	Target    string            `json:"target,omitempty"` // Default gRPC server address; may contain {{var}} placeholders
+	Metadata  map[string]string `json:"metadata"`         // Default metadata; request keys win
+	Variables map[string]string `json:"variables"`        // Override session variables of the same name
+}
+
+// clone returns a deep copy so callers never share maps with the session
+func (e Environment) clone() Environment {
+	out := Environment{
+		Name:      e.Name,
+		Target:    e.Target,
+		Metadata:  make(map[string]string, len(e.Metadata)),
+		Variables: make(map[string]string, len(e.Variables)),
+	}
+	for k, v := range e.Metadata {
+		out.Metadata[k] = v
+	}
+	for k, v := range e.Variables {
+		out.Variables[k] = v
+	}
+	return out
+}
+
+// ListEnvironments returns copies of a session's environments sorted by name
+func (m *Manager) ListEnvironments(sessionID string) ([]Environment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	envs := make([]Environment, 0, len(session.Environments))
+	for _, env := range session.Environments {
+		envs = append(envs, env.clone())
+	}
+	sort.Slice(envs, func(i, j int) bool { return envs[i].Name < envs[j].Name })
+	return envs, nil
+}
+
+// GetEnvironment returns a copy of a single environment
+func (m *Manager) GetEnvironment(sessionID, name string) (*Environment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	for _, env := range session.Environments {
+		if env.Name == name {
+			out := env.clone()
+			return &out, nil
+		}
+	}
+	return nil, ErrEnvironmentNotFound
+}
+
+// PutEnvironment creates or replaces the environment with env.Name. It
+// reports whether the environment was created. Values sent back as
+// secret.Redacted keep their current value.
+func (m *Manager) PutEnvironment(sessionID string, env Environment) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return false, ErrSessionNotFound
+	}
+
+	m.markDirty()
+	for i, existing := range session.Environments {
+		if existing.Name == env.Name {
+			env = env.clone()
+			secret.Unredact(env.Metadata, existing.Metadata)
+			secret.Unredact(env.Variables, existing.Variables)
+			session.Environments[i] = env
+			return false, nil
+		}
+	}
+	session.Environments = append(session.Environments, env.clone())
+	return true, nil
+}
+
+// ReplaceEnvironments replaces all environments of a session
+func (m *Manager) ReplaceEnvironments(sessionID string, envs []Environment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	session.Environments = make([]Environment, 0, len(envs))
+	for _, env := range envs {
+		session.Environments = append(session.Environments, env.clone())
+	}
+	m.markDirty()
+	return nil
+}
+
+// DeleteEnvironment removes an environment from a session
+func (m *Manager) DeleteEnvironment(sessionID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	for i, env := range session.Environments {
+		if env.Name == name {
+			session.Environments = append(session.Environments[:i], session.Environments[i+1:]...)
+			m.markDirty()
+			return nil
+		}
+	}
+	return ErrEnvironmentNotFound
+}
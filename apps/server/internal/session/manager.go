@@ -1,22 +1,35 @@
 package session
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/objectstore"
+	"github.com/grpc-bridge/server/internal/secret"
 )
 
 // ProtoFile represents a proto file with its relative path
 type ProtoFile struct {
-	Name         string `json:"name"`          // File name (e.g., "service.proto")
-	RelativePath string `json:"relative_path"` // Path relative to root (e.g., "api/v1/service.proto")
-	AbsolutePath string `json:"absolute_path"` // Absolute path on server
-	Size         int64  `json:"size"`          // File size in bytes
+	Name         string `json:"name"`             // File name (e.g., "service.proto")
+	RelativePath string `json:"relative_path"`    // Path relative to root (e.g., "api/v1/service.proto")
+	AbsolutePath string `json:"absolute_path"`    // Absolute path on server
+	Size         int64  `json:"size"`             // File size in bytes
+	SHA256       string `json:"sha256,omitempty"` // Hex SHA-256 of the content; empty for files stored before hashing
+}
+
+// ContentHash returns the hex SHA-256 recorded in ProtoFile.SHA256
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
 }
 
 // ProtoDir represents a directory in the uploaded proto structure
@@ -28,6 +41,22 @@ type ProtoDir struct {
 	AbsolutePath string `json:"absolute_path"` // Full path on server
 }
 
+// DescriptorSet represents a compiled FileDescriptorSet (.protoset) stored in a session
+type DescriptorSet struct {
+	Name         string   `json:"name"`          // File name (e.g., "api.protoset")
+	AbsolutePath string   `json:"absolute_path"` // Absolute path on server
+	Size         int64    `json:"size"`          // File size in bytes
+	Files        []string `json:"files"`         // Proto file names contained in the set
+	Source       string   `json:"source"`        // "upload" or "reflection"
+}
+
+// ImportMapping maps an import path prefix to a directory in the session tree,
+// for repos whose imports don't match their on-disk layout (e.g. vendored prefixes)
+type ImportMapping struct {
+	Prefix    string `json:"prefix"`    // Import path prefix, e.g. "github.com/acme/apis"; empty adds Directory as an import root
+	Directory string `json:"directory"` // Directory relative to the session root, e.g. "vendor/acme"
+}
+
 // ServiceInfo represents a parsed gRPC service
 type ServiceInfo struct {
 	FQService string       `json:"fq_service"` // Fully qualified service name
@@ -45,15 +74,40 @@ type MethodInfo struct {
 
 // Session represents a user session with uploaded proto files
 type Session struct {
-	ID          string        `json:"id"`
-	Name        string        `json:"name"` // User-specified name for this session
-	CreatedAt   time.Time     `json:"created_at"`
-	ExpiresAt   time.Time     `json:"expires_at"`
-	ProtoFiles  []ProtoFile   `json:"proto_files"` // Uploaded proto files with structure
-	Directories []ProtoDir    `json:"directories"` // Uploaded directory hierarchy (excluding root)
-	Services    []ServiceInfo `json:"services"`    // Parsed services (cached)
-	ParsedAt    *time.Time    `json:"parsed_at"`   // Last parse time
-	RootPath    string        `json:"root_path"`   // Root directory path on server
+	ID          string            `json:"id"`
+	Name        string            `json:"name"` // User-specified name for this session
+	CreatedAt   time.Time         `json:"created_at"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+	ProtoFiles  []ProtoFile       `json:"proto_files"` // Uploaded proto files with structure
+	Directories []ProtoDir        `json:"directories"` // Uploaded directory hierarchy (excluding root)
+	Services    []ServiceInfo     `json:"services"`    // Parsed services (cached)
+	ParsedAt    *time.Time        `json:"parsed_at"`   // Last parse time
+	RootPath    string            `json:"root_path"`   // Root directory path on server
+	Variables   map[string]string `json:"variables"`   // Session-scoped template variables ({{name}})
+
+	DescriptorSets []DescriptorSet `json:"descriptor_sets"` // Compiled descriptor sets used alongside proto sources
+	ImportMappings []ImportMapping `json:"import_mappings"` // Import path prefix remapping
+
+	Description string   `json:"description"` // Free-form user description
+	Tags        []string `json:"tags"`        // User labels for organizing sessions
+
+	Owner string `json:"owner,omitempty"` // Authenticated user that created the session; empty when auth is off
+
+	LastUsedAt time.Time `json:"last_used_at"` // Last API access or gRPC call; ExpiresAt follows it
+
+	Environments []Environment `json:"environments"` // Named call defaults (dev, staging, ...)
+	Targets      []Target      `json:"targets"`      // Saved gRPC server presets
+
+	DefaultMetadata map[string]string `json:"default_metadata"` // Metadata sent with every call unless overridden
+}
+
+// DescriptorSetPaths returns the absolute paths of the session's descriptor sets
+func (s *Session) DescriptorSetPaths() []string {
+	paths := make([]string, len(s.DescriptorSets))
+	for i, set := range s.DescriptorSets {
+		paths[i] = set.AbsolutePath
+	}
+	return paths
 }
 
 // Manager manages user sessions
@@ -62,14 +116,37 @@ type Manager struct {
 	mu        sync.RWMutex
 	ttl       time.Duration
 	uploadDir string // Root upload directory for cleanup
+
+	// In-flight operations per session, cancelled when the session goes away
+	operations  map[string]map[uint64]context.CancelFunc
+	nextOpID    uint64
+	opsMu       sync.Mutex
+	deleteHooks []func(sessionID string)
+	changeHooks []func(sessionID string)
+	hooksMu     sync.RWMutex
+
+	// Optional persistence of session records (see UseStore)
+	store     Store
+	dirty     chan struct{}
+	persistMu sync.Mutex
+
+	quota Quota // Per-session storage limits
+
+	archive objectstore.Store // Where expired sessions are archived; nil deletes them
+
+	cipher *secret.Cipher // Encrypts sensitive values of persisted and archived records; see SetCipher
+
+	maxStorage int64 // Global limit on the upload directory; see SetStorageQuota
 }
 
 // NewManager creates a new session manager
 func NewManager(uploadDir string) *Manager {
 	m := &Manager{
-		sessions:  make(map[string]*Session),
-		ttl:       24 * time.Hour, // Sessions expire after 24 hours
-		uploadDir: uploadDir,
+		sessions:   make(map[string]*Session),
+		ttl:        24 * time.Hour, // Sessions expire after 24 hours without use
+		uploadDir:  uploadDir,
+		operations: make(map[string]map[uint64]context.CancelFunc),
+		quota:      Quota{MaxFiles: DefaultMaxFiles, MaxBytes: DefaultMaxBytes},
 	}
 
 	// Start cleanup goroutine
@@ -86,17 +163,26 @@ func (m *Manager) Create(name string) *Session {
 
 	sessionID := uuid.New().String()
 	session := &Session{
-		ID:          sessionID,
-		Name:        name,
-		CreatedAt:   time.Now(),
-		ExpiresAt:   time.Now().Add(m.ttl),
-		ProtoFiles:  []ProtoFile{},
-		Directories: []ProtoDir{},
-		Services:    []ServiceInfo{},
-		RootPath:    "", // Will be set when files are uploaded
+		ID:              sessionID,
+		Name:            name,
+		CreatedAt:       time.Now(),
+		ExpiresAt:       time.Now().Add(m.ttl),
+		LastUsedAt:      time.Now(),
+		ProtoFiles:      []ProtoFile{},
+		Directories:     []ProtoDir{},
+		DescriptorSets:  []DescriptorSet{},
+		ImportMappings:  []ImportMapping{},
+		Tags:            []string{},
+		Environments:    []Environment{},
+		Targets:         []Target{},
+		Services:        []ServiceInfo{},
+		RootPath:        "", // Will be set when files are uploaded
+		Variables:       map[string]string{},
+		DefaultMetadata: map[string]string{},
 	}
 
 	m.sessions[session.ID] = session
+	m.markDirty()
 	return session
 }
 
@@ -111,20 +197,70 @@ func (m *Manager) CreateWithID(id, name string) *Session {
 	}
 
 	session := &Session{
-		ID:          id,
-		Name:        name,
-		CreatedAt:   time.Now(),
-		ExpiresAt:   time.Now().Add(m.ttl),
-		ProtoFiles:  []ProtoFile{},
-		Directories: []ProtoDir{},
-		Services:    []ServiceInfo{},
-		RootPath:    "",
+		ID:              id,
+		Name:            name,
+		CreatedAt:       time.Now(),
+		ExpiresAt:       time.Now().Add(m.ttl),
+		LastUsedAt:      time.Now(),
+		ProtoFiles:      []ProtoFile{},
+		Directories:     []ProtoDir{},
+		DescriptorSets:  []DescriptorSet{},
+		ImportMappings:  []ImportMapping{},
+		Tags:            []string{},
+		Environments:    []Environment{},
+		Targets:         []Target{},
+		Services:        []ServiceInfo{},
+		RootPath:        "",
+		Variables:       map[string]string{},
+		DefaultMetadata: map[string]string{},
 	}
 
 	m.sessions[session.ID] = session
+	m.markDirty()
 	return session
 }
 
+// SetIdleTimeout sets how long a session may go unused before it expires
+func (m *Manager) SetIdleTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ttl = d
+}
+
+// IdleTimeout returns how long a session may go unused before it expires
+func (m *Manager) IdleTimeout() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ttl
+}
+
+// Touch records activity on a session and pushes its expiry out by the idle
+// timeout. Expired sessions are left alone.
+func (m *Manager) Touch(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	now := time.Now()
+	if !exists || m.expired(session, now) {
+		return
+	}
+
+	// Busy sessions are touched on every request; only persist occasionally
+	if now.Sub(session.LastUsedAt) >= touchPersistInterval {
+		m.markDirty()
+	}
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(m.ttl)
+}
+
+// expired reports whether a session is past its expiry or has been idle for
+// longer than the idle timeout, which may have been shortened since its last
+// use. Callers hold m.mu.
+func (m *Manager) expired(session *Session, now time.Time) bool {
+	return now.After(session.ExpiresAt) || now.Sub(session.LastUsedAt) > m.ttl
+}
+
 // Get retrieves a session by ID
 func (m *Manager) Get(id string) (*Session, bool) {
 	m.mu.RLock()
@@ -136,27 +272,201 @@ func (m *Manager) Get(id string) (*Session, bool) {
 	}
 
 	// Check if expired
-	if time.Now().After(session.ExpiresAt) {
+	if m.expired(session, time.Now()) {
 		return nil, false
 	}
 
 	return session, true
 }
 
-// Delete removes a session and its directory
-func (m *Manager) Delete(id string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// RootFiles maps the directory of every session with uploaded files to the
+// relative paths of those files
+func (m *Manager) RootFiles() map[string][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	session, exists := m.sessions[id]
-	if exists && session.RootPath != "" {
-		// Delete session directory
-		if err := os.RemoveAll(session.RootPath); err != nil {
-			fmt.Printf("Error removing session directory %s: %v\n", session.RootPath, err)
+	roots := make(map[string][]string)
+	for _, session := range m.sessions {
+		if session.RootPath == "" || len(session.ProtoFiles) == 0 {
+			continue
+		}
+		for _, f := range session.ProtoFiles {
+			roots[session.RootPath] = append(roots[session.RootPath], f.RelativePath)
 		}
 	}
+	return roots
+}
 
+// Delete removes a session and its directory, cancels its in-flight operations
+// and runs registered delete hooks. It returns the number of cancelled
+// operations. Unknown sessions are left alone and nothing is removed.
+func (m *Manager) Delete(id string) int {
+	m.mu.Lock()
+	session, exists := m.sessions[id]
+	if !exists {
+		m.mu.Unlock()
+		return 0
+	}
+	if session.RootPath != "" {
+		m.removeSessionDir(session.RootPath)
+	}
+	// Uploads always live under <uploadDir>/<sessionID>, even before RootPath is recorded
+	if ValidID(id) {
+		m.removeSessionDir(filepath.Join(m.uploadDir, id))
+	}
 	delete(m.sessions, id)
+	m.markDirty()
+	m.mu.Unlock()
+
+	return m.releaseSession(id)
+}
+
+// ValidID reports whether id has the form of a session ID: hex digits and
+// hyphens, as in a UUID, up to 64 characters. IDs name directories under the
+// upload directory, so no other characters are accepted.
+func ValidID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// removeSessionDir deletes a session directory, refusing any path that is not
+// strictly inside the upload directory
+func (m *Manager) removeSessionDir(dir string) {
+	if !m.insideUploadDir(dir) {
+		log.Printf("[SessionManager] Refusing to remove %s: not inside the upload directory %s", dir, m.uploadDir)
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		fmt.Printf("Error removing session directory %s: %v\n", dir, err)
+	}
+}
+
+// insideUploadDir reports whether path lies strictly below the upload
+// directory; the upload directory itself is not inside it
+func (m *Manager) insideUploadDir(path string) bool {
+	root, err := filepath.Abs(m.uploadDir)
+	if err != nil {
+		return false
+	}
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == "." || rel == ".." || filepath.IsAbs(rel) {
+		return false
+	}
+	return !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// OnDelete registers a hook invoked after a session is deleted or expires,
+// used by other subsystems (descriptor caches, history, collections) to drop session state.
+func (m *Manager) OnDelete(hook func(sessionID string)) {
+	m.hooksMu.Lock()
+	defer m.hooksMu.Unlock()
+
+	m.deleteHooks = append(m.deleteHooks, hook)
+}
+
+// OnProtoChange registers a hook that runs after a session's proto sources were
+// edited in place (e.g. to invalidate cached descriptors)
+func (m *Manager) OnProtoChange(hook func(sessionID string)) {
+	m.hooksMu.Lock()
+	defer m.hooksMu.Unlock()
+
+	m.changeHooks = append(m.changeHooks, hook)
+}
+
+func (m *Manager) notifyProtoChange(sessionID string) {
+	m.hooksMu.RLock()
+	hooks := append([]func(string){}, m.changeHooks...)
+	m.hooksMu.RUnlock()
+	for _, hook := range hooks {
+		hook(sessionID)
+	}
+}
+
+// BeginOperation registers an in-flight operation for a session. The returned
+// context is cancelled when the session is deleted; done must be called when
+// the operation finishes.
+func (m *Manager) BeginOperation(parent context.Context, sessionID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	m.opsMu.Lock()
+	m.nextOpID++
+	opID := m.nextOpID
+	if m.operations[sessionID] == nil {
+		m.operations[sessionID] = make(map[uint64]context.CancelFunc)
+	}
+	m.operations[sessionID][opID] = cancel
+	m.opsMu.Unlock()
+
+	done := func() {
+		m.opsMu.Lock()
+		if ops, ok := m.operations[sessionID]; ok {
+			delete(ops, opID)
+			if len(ops) == 0 {
+				delete(m.operations, sessionID)
+			}
+		}
+		m.opsMu.Unlock()
+		cancel()
+	}
+	return ctx, done
+}
+
+// InFlight returns the number of in-flight operations of a session
+func (m *Manager) InFlight(sessionID string) int {
+	m.opsMu.Lock()
+	defer m.opsMu.Unlock()
+
+	return len(m.operations[sessionID])
+}
+
+// Counts returns the number of sessions and of in-flight operations across them
+func (m *Manager) Counts() (sessions, operations int) {
+	m.mu.RLock()
+	sessions = len(m.sessions)
+	m.mu.RUnlock()
+
+	m.opsMu.Lock()
+	defer m.opsMu.Unlock()
+	for _, ops := range m.operations {
+		operations += len(ops)
+	}
+	return sessions, operations
+}
+
+// releaseSession cancels in-flight operations of a session and runs delete hooks.
+// Must be called without holding m.mu.
+func (m *Manager) releaseSession(sessionID string) int {
+	m.opsMu.Lock()
+	ops := m.operations[sessionID]
+	delete(m.operations, sessionID)
+	m.opsMu.Unlock()
+
+	for _, cancel := range ops {
+		cancel()
+	}
+	if len(ops) > 0 {
+		log.Printf("[SessionManager] Cancelled %d in-flight operation(s) for session %s", len(ops), sessionID)
+	}
+
+	m.hooksMu.RLock()
+	hooks := append([]func(string){}, m.deleteHooks...)
+	m.hooksMu.RUnlock()
+	for _, hook := range hooks {
+		hook(sessionID)
+	}
+
+	return len(ops)
 }
 
 // AddProtoFile adds a proto file to a session
@@ -170,9 +480,40 @@ func (m *Manager) AddProtoFile(sessionID string, file ProtoFile) error {
 	}
 
 	session.ProtoFiles = append(session.ProtoFiles, file)
+	m.markDirty()
 	return nil
 }
 
+// UpsertProtoFile replaces the session file with the same relative path, or adds
+// it when missing. Cached services are dropped and change hooks run afterwards.
+func (m *Manager) UpsertProtoFile(sessionID string, file ProtoFile) (bool, error) {
+	m.mu.Lock()
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		m.mu.Unlock()
+		return false, ErrSessionNotFound
+	}
+
+	created := true
+	for i, existing := range session.ProtoFiles {
+		if existing.RelativePath == file.RelativePath {
+			session.ProtoFiles[i] = file
+			created = false
+			break
+		}
+	}
+	if created {
+		session.ProtoFiles = append(session.ProtoFiles, file)
+	}
+	session.Services = []ServiceInfo{}
+	session.ParsedAt = nil
+	m.markDirty()
+	m.mu.Unlock()
+
+	m.notifyProtoChange(sessionID)
+	return created, nil
+}
+
 // AddDirectories merges a set of directories into the session (deduplicated)
 func (m *Manager) AddDirectories(sessionID string, dirs []ProtoDir) error {
 	m.mu.Lock()
@@ -199,26 +540,125 @@ func (m *Manager) AddDirectories(sessionID string, dirs []ProtoDir) error {
 		session.Directories = append(session.Directories, d)
 		existing[d.RelativePath] = struct{}{}
 	}
+	m.markDirty()
 	return nil
 }
 
 // ResetUploadState clears upload-derived session state so the next upload fully replaces previous files.
+// Change hooks run afterwards.
 func (m *Manager) ResetUploadState(sessionID string) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	session, exists := m.sessions[sessionID]
 	if !exists {
+		m.mu.Unlock()
 		return ErrSessionNotFound
 	}
 
 	session.ProtoFiles = []ProtoFile{}
 	session.Directories = []ProtoDir{}
 	session.Services = []ServiceInfo{}
+	session.DescriptorSets = []DescriptorSet{}
 	session.ParsedAt = nil
+	m.markDirty()
+	m.mu.Unlock()
+
+	m.notifyProtoChange(sessionID)
+	return nil
+}
+
+// AddDescriptorSet attaches a descriptor set to a session, replacing any set with the same name.
+// Change hooks run afterwards.
+func (m *Manager) AddDescriptorSet(sessionID string, set DescriptorSet) error {
+	m.mu.Lock()
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		m.mu.Unlock()
+		return ErrSessionNotFound
+	}
+
+	replaced := false
+	for i, existing := range session.DescriptorSets {
+		if existing.Name == set.Name {
+			session.DescriptorSets[i] = set
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		session.DescriptorSets = append(session.DescriptorSets, set)
+	}
+	m.markDirty()
+	m.mu.Unlock()
+
+	m.notifyProtoChange(sessionID)
+	return nil
+}
+
+// SetImportMappings replaces the import path mappings of a session. Mappings
+// are configuration and survive re-uploads.
+func (m *Manager) SetImportMappings(sessionID string, mappings []ImportMapping) error {
+	m.mu.Lock()
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		m.mu.Unlock()
+		return ErrSessionNotFound
+	}
+
+	session.ImportMappings = append([]ImportMapping{}, mappings...)
+	session.Services = []ServiceInfo{}
+	session.ParsedAt = nil
+	m.markDirty()
+	m.mu.Unlock()
+
+	m.notifyProtoChange(sessionID)
 	return nil
 }
 
+// MetadataUpdate holds user-editable session metadata; nil fields are left unchanged
+type MetadataUpdate struct {
+	Name        *string
+	Description *string
+	Tags        []string // nil leaves tags unchanged, an empty slice clears them
+}
+
+// SetOwner assigns the user that owns a session
+func (m *Manager) SetOwner(sessionID, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	session.Owner = owner
+	m.markDirty()
+	return nil
+}
+
+// UpdateMetadata updates the name, description and tags of a session
+func (m *Manager) UpdateMetadata(sessionID string, update MetadataUpdate) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists || m.expired(session, time.Now()) {
+		return nil, ErrSessionNotFound
+	}
+
+	if update.Name != nil {
+		session.Name = *update.Name
+	}
+	if update.Description != nil {
+		session.Description = *update.Description
+	}
+	if update.Tags != nil {
+		session.Tags = append([]string{}, update.Tags...)
+	}
+	m.markDirty()
+	return session, nil
+}
+
 // SetRootPath sets the root path for a session
 func (m *Manager) SetRootPath(sessionID, rootPath string) error {
 	m.mu.Lock()
@@ -230,6 +670,7 @@ func (m *Manager) SetRootPath(sessionID, rootPath string) error {
 	}
 
 	session.RootPath = rootPath
+	m.markDirty()
 	return nil
 }
 
@@ -246,6 +687,161 @@ func (m *Manager) SetServices(sessionID string, services []ServiceInfo) error {
 	now := time.Now()
 	session.Services = services
 	session.ParsedAt = &now
+	m.markDirty()
+	return nil
+}
+
+// GetVariables returns a copy of the session's template variables
+func (m *Manager) GetVariables(sessionID string) (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	vars := make(map[string]string, len(session.Variables))
+	for k, v := range session.Variables {
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// ReplaceVariables replaces all template variables of a session. Values sent
+// back as secret.Redacted keep their current value.
+func (m *Manager) ReplaceVariables(sessionID string, vars map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	variables := make(map[string]string, len(vars))
+	for k, v := range vars {
+		variables[k] = v
+	}
+	secret.Unredact(variables, session.Variables)
+	session.Variables = variables
+	m.markDirty()
+	return nil
+}
+
+// SetVariable creates or updates a single template variable
+func (m *Manager) SetVariable(sessionID, name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	if session.Variables == nil {
+		session.Variables = map[string]string{}
+	}
+	if _, ok := session.Variables[name]; ok && value == secret.Redacted {
+		return nil
+	}
+	session.Variables[name] = value
+	m.markDirty()
+	return nil
+}
+
+// DeleteVariable removes a single template variable
+func (m *Manager) DeleteVariable(sessionID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	if _, ok := session.Variables[name]; !ok {
+		return ErrVariableNotFound
+	}
+	delete(session.Variables, name)
+	m.markDirty()
+	return nil
+}
+
+// GetDefaultMetadata returns a copy of the metadata sent with every call
+func (m *Manager) GetDefaultMetadata(sessionID string) (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	md := make(map[string]string, len(session.DefaultMetadata))
+	for k, v := range session.DefaultMetadata {
+		md[k] = v
+	}
+	return md, nil
+}
+
+// ReplaceDefaultMetadata replaces all default metadata of a session. Values
+// sent back as secret.Redacted keep their current value.
+func (m *Manager) ReplaceDefaultMetadata(sessionID string, md map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	metadata := make(map[string]string, len(md))
+	for k, v := range md {
+		metadata[k] = v
+	}
+	secret.Unredact(metadata, session.DefaultMetadata)
+	session.DefaultMetadata = metadata
+	m.markDirty()
+	return nil
+}
+
+// SetDefaultMetadata creates or updates a single default metadata entry
+func (m *Manager) SetDefaultMetadata(sessionID, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	if session.DefaultMetadata == nil {
+		session.DefaultMetadata = map[string]string{}
+	}
+	if _, ok := session.DefaultMetadata[key]; ok && value == secret.Redacted {
+		return nil
+	}
+	session.DefaultMetadata[key] = value
+	m.markDirty()
+	return nil
+}
+
+// DeleteDefaultMetadata removes a single default metadata entry
+func (m *Manager) DeleteDefaultMetadata(sessionID, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	if _, ok := session.DefaultMetadata[key]; !ok {
+		return ErrMetadataNotFound
+	}
+	delete(session.DefaultMetadata, key)
+	m.markDirty()
 	return nil
 }
 
@@ -259,25 +855,85 @@ func (m *Manager) cleanupExpired() {
 	}
 }
 
-// cleanupExpiredSessions performs the actual cleanup
-func (m *Manager) cleanupExpiredSessions() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// cleanupExpiredSessions performs the actual cleanup. With an archive store
+// configured, sessions are archived first; a session whose archive fails is
+// kept and retried on the next run. It returns the IDs of removed sessions.
+func (m *Manager) cleanupExpiredSessions() []string {
+	archived := m.archiveExpired()
 
+	m.mu.Lock()
 	now := time.Now()
+	expired := []string{}
 	for id, session := range m.sessions {
-		if now.After(session.ExpiresAt) {
+		if archived != nil && !archived[id] {
+			continue
+		}
+		if m.expired(session, now) {
 			// Delete session directory if it exists
 			if session.RootPath != "" {
-				if err := os.RemoveAll(session.RootPath); err != nil {
-					// Log error but continue cleanup
-					fmt.Printf("Error removing session directory %s: %v\n", session.RootPath, err)
-				}
+				m.removeSessionDir(session.RootPath)
 			}
 			// Remove from memory
 			delete(m.sessions, id)
+			expired = append(expired, id)
+		}
+	}
+	if len(expired) > 0 {
+		m.markDirty()
+	}
+	m.mu.Unlock()
+
+	for _, id := range expired {
+		m.releaseSession(id)
+	}
+	return expired
+}
+
+// archiveExpired archives the currently expired sessions and returns the IDs
+// that were archived, or nil when archiving is disabled
+func (m *Manager) archiveExpired() map[string]bool {
+	type pending struct {
+		id     string
+		root   string
+		record []byte
+	}
+
+	m.mu.RLock()
+	store := m.archive
+	cipher := m.cipher
+	var sessions []pending
+	if store != nil {
+		now := time.Now()
+		for id, session := range m.sessions {
+			if !m.expired(session, now) {
+				continue
+			}
+			record, err := sealedRecord(session, cipher)
+			if err != nil {
+				log.Printf("[SessionManager] Failed to encode session %s for archiving: %v", id, err)
+				continue
+			}
+			sessions = append(sessions, pending{id: id, root: session.RootPath, record: record})
+		}
+	}
+	m.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+
+	archived := make(map[string]bool, len(sessions))
+	for _, p := range sessions {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		err := archiveSession(ctx, store, p.id, p.record, p.root)
+		cancel()
+		if err != nil {
+			log.Printf("[SessionManager] Failed to archive session %s, keeping it: %v", p.id, err)
+			continue
 		}
+		log.Printf("[SessionManager] Archived expired session %s to %s", p.id, store)
+		archived[p.id] = true
 	}
+	return archived
 }
 
 // cleanupUploadsDailyAtMidnight removes all entries under uploads/* every day at 00:00 (server local time).
@@ -297,10 +953,17 @@ func (m *Manager) cleanupUploadsDailyAtMidnight() {
 	}
 }
 
-// clearAllUploadEntries is equivalent to: rm -rdf <uploadDir>/*
+// clearAllUploadEntries is equivalent to: rm -rdf <uploadDir>/* (hidden entries are kept)
 // It also clears in-memory sessions to avoid dangling references to deleted files.
 func (m *Manager) clearAllUploadEntries() {
+	// Sessions dropped below are released (operations cancelled, hooks run) after unlocking
+	var cleared []string
 	m.mu.Lock()
+	defer func() {
+		for _, id := range cleared {
+			m.releaseSession(id)
+		}
+	}()
 	defer m.mu.Unlock()
 
 	entries, err := os.ReadDir(m.uploadDir)
@@ -318,6 +981,10 @@ func (m *Manager) clearAllUploadEntries() {
 
 	removed := 0
 	for _, entry := range entries {
+		// Hidden entries hold server state such as the shared stdlib tree
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
 		target := filepath.Join(m.uploadDir, entry.Name())
 		if err := os.RemoveAll(target); err != nil {
 			log.Printf("[SessionManager] Failed to remove %s: %v", target, err)
@@ -327,13 +994,26 @@ func (m *Manager) clearAllUploadEntries() {
 	}
 
 	prevSessions := len(m.sessions)
+	for id := range m.sessions {
+		cleared = append(cleared, id)
+	}
 	m.sessions = make(map[string]*Session)
+	m.markDirty()
 	log.Printf("[SessionManager] Midnight cleanup completed: removed %d upload entries, cleared %d sessions", removed, prevSessions)
 }
 
 // Errors
 var (
-	ErrSessionNotFound = &SessionError{"session not found"}
+	ErrSessionNotFound  = &SessionError{"session not found"}
+	ErrVariableNotFound = &SessionError{"variable not found"}
+
+	ErrEnvironmentNotFound = &SessionError{"environment not found"}
+	ErrTargetNotFound      = &SessionError{"target not found"}
+	ErrMetadataNotFound    = &SessionError{"metadata key not found"}
+
+	ErrSessionExists   = &SessionError{"session already exists"}
+	ErrArchiveDisabled = &SessionError{"session archiving is not enabled"}
+	ErrArchiveNotFound = &SessionError{"archived session not found"}
 )
 
 type SessionError struct {
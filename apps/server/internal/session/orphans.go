@@ -0,0 +1,225 @@
+package session
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultOrphanGrace is how long an orphaned upload directory is left alone
+// before it is deleted, so directories of requests still in flight survive
+const DefaultOrphanGrace = time.Hour
+
+// OrphanOptions controls how upload directories without a session are handled
+type OrphanOptions struct {
+	Grace       time.Duration            // Orphans modified more recently are left alone
+	SharedFiles func() ([]string, error) // Relative paths of copied stdlib files, not listed in adopted sessions
+}
+
+// OrphanReport summarizes a reconciliation of the upload directory
+type OrphanReport struct {
+	Scanned int      `json:"scanned"` // Session directories examined
+	Adopted []string `json:"adopted"` // Orphans registered as sessions
+	Removed []string `json:"removed"` // Orphans deleted
+	Pending []string `json:"pending"` // Orphans still within the grace period
+	Failed  []string `json:"failed"`  // Orphans that could not be adopted or removed
+}
+
+// ReconcileOrphans scans the upload directory for session directories that do
+// not belong to any known session. When session persistence is enabled they are
+// adopted as new sessions with the files found on disk; otherwise they are
+// deleted once older than the grace period. Entries starting with "." and plain
+// files (such as the session store) are never touched.
+func (m *Manager) ReconcileOrphans(opts OrphanOptions) OrphanReport {
+	report := OrphanReport{
+		Adopted: []string{},
+		Removed: []string{},
+		Pending: []string{},
+		Failed:  []string{},
+	}
+
+	entries, err := os.ReadDir(m.uploadDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[SessionManager] Failed to read upload directory %s: %v", m.uploadDir, err)
+		}
+		return report
+	}
+
+	m.mu.RLock()
+	known := make(map[string]bool, len(m.sessions))
+	for id, sess := range m.sessions {
+		known[filepath.Join(m.uploadDir, id)] = true
+		if sess.RootPath != "" {
+			known[filepath.Clean(sess.RootPath)] = true
+		}
+	}
+	adopt := m.store != nil
+	m.mu.RUnlock()
+
+	shared := map[string]bool{}
+	if adopt && opts.SharedFiles != nil {
+		files, err := opts.SharedFiles()
+		if err != nil {
+			log.Printf("[SessionManager] Failed to list shared proto files: %v", err)
+		}
+		for _, f := range files {
+			shared[f] = true
+		}
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		report.Scanned++
+		dir := filepath.Join(m.uploadDir, entry.Name())
+		if known[dir] {
+			continue
+		}
+
+		if adopt {
+			if m.adoptOrphan(entry.Name(), dir, shared) {
+				report.Adopted = append(report.Adopted, entry.Name())
+				continue
+			}
+			// Directories without any protos are not worth a session; fall through to removal
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			report.Failed = append(report.Failed, entry.Name())
+			continue
+		}
+		if now.Sub(info.ModTime()) < opts.Grace {
+			report.Pending = append(report.Pending, entry.Name())
+			continue
+		}
+		removed, err := m.removeOrphan(entry.Name(), dir)
+		if err != nil {
+			log.Printf("[SessionManager] Failed to remove orphaned directory %s: %v", dir, err)
+			report.Failed = append(report.Failed, entry.Name())
+			continue
+		}
+		if removed {
+			report.Removed = append(report.Removed, entry.Name())
+		}
+	}
+
+	log.Printf("[SessionManager] Upload reconciliation: %d directories scanned, %d adopted, %d removed, %d pending, %d failed",
+		report.Scanned, len(report.Adopted), len(report.Removed), len(report.Pending), len(report.Failed))
+	return report
+}
+
+// adoptOrphan registers an orphaned directory as a session named after it. It
+// reports false when the directory holds no proto files or descriptor sets, or
+// a session claimed it in the meantime.
+func (m *Manager) adoptOrphan(id, dir string, shared map[string]bool) bool {
+	files := []ProtoFile{}
+	dirs := []ProtoDir{}
+	sets := []DescriptorSet{}
+	var latest time.Time
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+
+		if d.IsDir() {
+			if !strings.HasPrefix(d.Name(), ".") {
+				dirs = append(dirs, ProtoDir{RelativePath: rel, AbsolutePath: path})
+			}
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".protoset":
+			sets = append(sets, DescriptorSet{
+				Name:         d.Name(),
+				AbsolutePath: path,
+				Size:         info.Size(),
+				Files:        []string{},
+				Source:       "upload",
+			})
+		case ".proto":
+			if shared[rel] {
+				return nil
+			}
+			files = append(files, ProtoFile{
+				Name:         d.Name(),
+				RelativePath: rel,
+				AbsolutePath: path,
+				Size:         info.Size(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[SessionManager] Failed to scan orphaned directory %s: %v", dir, err)
+		return false
+	}
+	if len(files) == 0 && len(sets) == 0 {
+		return false
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].RelativePath < files[j].RelativePath })
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].RelativePath < dirs[j].RelativePath })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.sessions[id]; exists {
+		return false
+	}
+
+	now := time.Now()
+	m.sessions[id] = &Session{
+		ID:              id,
+		Name:            "Recovered " + id,
+		CreatedAt:       latest,
+		ExpiresAt:       now.Add(m.ttl),
+		LastUsedAt:      now,
+		ProtoFiles:      files,
+		Directories:     dirs,
+		DescriptorSets:  sets,
+		ImportMappings:  []ImportMapping{},
+		Tags:            []string{"recovered"},
+		Environments:    []Environment{},
+		Targets:         []Target{},
+		Services:        []ServiceInfo{},
+		RootPath:        dir,
+		Variables:       map[string]string{},
+		DefaultMetadata: map[string]string{},
+	}
+	m.markDirty()
+	return true
+}
+
+// removeOrphan deletes an orphaned directory unless a session claimed it in
+// the meantime. It reports whether the directory was removed.
+func (m *Manager) removeOrphan(id, dir string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sessions[id]; exists {
+		return false, nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,129 @@
+package session
+
+import "fmt"
+
+// Default per-session limits
+const (
+	DefaultMaxFiles       = 10000
+	DefaultMaxBytes int64 = 256 << 20 // 256MB
+)
+
+// Quota limits what a single session may store. Zero disables a limit.
+type Quota struct {
+	MaxFiles int   `json:"max_files"` // Proto files
+	MaxBytes int64 `json:"max_bytes"` // Proto files and descriptor sets combined
+}
+
+// Usage is what a session currently stores
+type Usage struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// QuotaError reports an upload that would take a session over its quota
+type QuotaError struct {
+	Limit string // "files" or "bytes"
+	Quota Quota
+	Usage Usage // Usage before the rejected change
+}
+
+func (e *QuotaError) Error() string {
+	if e.Limit == "files" {
+		return fmt.Sprintf("session quota exceeded: at most %d proto files allowed", e.Quota.MaxFiles)
+	}
+	return fmt.Sprintf("session quota exceeded: at most %d bytes allowed", e.Quota.MaxBytes)
+}
+
+// Usage returns the number of proto files and the bytes stored by the session
+func (s *Session) Usage() Usage {
+	usage := Usage{Files: len(s.ProtoFiles)}
+	for _, f := range s.ProtoFiles {
+		usage.Bytes += f.Size
+	}
+	for _, set := range s.DescriptorSets {
+		usage.Bytes += set.Size
+	}
+	return usage
+}
+
+// SetQuota sets the limits applied to every session
+func (m *Manager) SetQuota(q Quota) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quota = q
+}
+
+// Quota returns the limits applied to every session
+func (m *Manager) Quota() Quota {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.quota
+}
+
+// Usage returns the current usage of a session
+func (m *Manager) Usage(sessionID string) (Usage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return Usage{}, ErrSessionNotFound
+	}
+	return session.Usage(), nil
+}
+
+// CheckProtoFile reports whether storing a proto file of the given size at
+// relativePath fits the session's quota. A file replacing one at the same path
+// only counts the difference in size.
+func (m *Manager) CheckProtoFile(sessionID, relativePath string, size int64) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	usage := session.Usage()
+	files, bytes := usage.Files+1, usage.Bytes+size
+	for _, f := range session.ProtoFiles {
+		if f.RelativePath == relativePath {
+			files, bytes = usage.Files, usage.Bytes-f.Size+size
+			break
+		}
+	}
+	return m.checkQuota(usage, files, bytes)
+}
+
+// CheckDescriptorSet reports whether storing a descriptor set of the given size
+// under name fits the session's quota
+func (m *Manager) CheckDescriptorSet(sessionID, name string, size int64) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	usage := session.Usage()
+	bytes := usage.Bytes + size
+	for _, set := range session.DescriptorSets {
+		if set.Name == name {
+			bytes -= set.Size
+			break
+		}
+	}
+	return m.checkQuota(usage, usage.Files, bytes)
+}
+
+// checkQuota compares projected usage against the quota. Callers hold m.mu.
+func (m *Manager) checkQuota(usage Usage, files int, bytes int64) error {
+	if m.quota.MaxFiles > 0 && files > m.quota.MaxFiles {
+		return &QuotaError{Limit: "files", Quota: m.quota, Usage: usage}
+	}
+	if m.quota.MaxBytes > 0 && bytes > m.quota.MaxBytes {
+		return &QuotaError{Limit: "bytes", Quota: m.quota, Usage: usage}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/grpc-bridge/server/internal/secret"
+)
+
+// SetCipher encrypts sensitive values (see secret.IsSensitive) of default
+// metadata, variables, environments and target presets when sessions are
+// persisted or archived. Call it before UseStore so stored values can be
+// decrypted. Without a cipher they are written in plaintext.
+func (m *Manager) SetCipher(c *secret.Cipher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cipher = c
+}
+
+// transformSecrets applies fn to every sensitive value of a session
+func transformSecrets(sess *Session, fn func(string) (string, error)) error {
+	if err := secret.TransformSensitive(sess.DefaultMetadata, fn); err != nil {
+		return fmt.Errorf("default metadata %w", err)
+	}
+	if err := secret.TransformSensitive(sess.Variables, fn); err != nil {
+		return fmt.Errorf("variable %w", err)
+	}
+	for _, env := range sess.Environments {
+		if err := secret.TransformSensitive(env.Metadata, fn); err != nil {
+			return fmt.Errorf("environment %s metadata %w", env.Name, err)
+		}
+		if err := secret.TransformSensitive(env.Variables, fn); err != nil {
+			return fmt.Errorf("environment %s variable %w", env.Name, err)
+		}
+	}
+	for _, t := range sess.Targets {
+		if err := secret.TransformSensitive(t.Metadata, fn); err != nil {
+			return fmt.Errorf("target %s metadata %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// sealSecrets encrypts the sensitive values of a session record in place; a
+// no-op without a cipher
+func sealSecrets(sess *Session, c *secret.Cipher) error {
+	if c == nil {
+		return nil
+	}
+	return transformSecrets(sess, c.Encrypt)
+}
+
+// openSecrets decrypts the sensitive values of a stored session record in
+// place. Encrypted values without a cipher are an error (secret.ErrNoKey).
+func openSecrets(sess *Session, c *secret.Cipher) error {
+	return transformSecrets(sess, c.Decrypt)
+}
+
+// sealedRecord encodes a session for archiving with its secrets encrypted
+func sealedRecord(sess *Session, c *secret.Cipher) ([]byte, error) {
+	record, err := json.Marshal(sess)
+	if err != nil || c == nil {
+		return record, err
+	}
+	// Encrypt a copy: the live session keeps its plaintext values
+	var sealed Session
+	if err := json.Unmarshal(record, &sealed); err != nil {
+		return nil, err
+	}
+	if err := sealSecrets(&sealed, c); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&sealed)
+}
+
+// Redacted returns a copy of the session for API responses, with sensitive
+// values of default metadata, variables, environments and target presets
+// replaced by secret.Redacted
+func (s *Session) Redacted() *Session {
+	out := *s
+	out.DefaultMetadata = secret.RedactMap(s.DefaultMetadata)
+	out.Variables = secret.RedactMap(s.Variables)
+	out.Environments = RedactEnvironments(s.Environments)
+	out.Targets = RedactTargets(s.Targets)
+	return &out
+}
+
+// Redacted returns a copy of the environment with sensitive values redacted
+func (e Environment) Redacted() Environment {
+	out := e.clone()
+	out.Metadata = secret.RedactMap(out.Metadata)
+	out.Variables = secret.RedactMap(out.Variables)
+	return out
+}
+
+// Redacted returns a copy of the target preset with sensitive metadata redacted
+func (t Target) Redacted() Target {
+	out := t.clone()
+	out.Metadata = secret.RedactMap(out.Metadata)
+	return out
+}
+
+// RedactEnvironments returns redacted copies of environments
+func RedactEnvironments(envs []Environment) []Environment {
+	if envs == nil {
+		return nil
+	}
+	out := make([]Environment, len(envs))
+	for i, e := range envs {
+		out[i] = e.Redacted()
+	}
+	return out
+}
+
+// RedactTargets returns redacted copies of target presets
+func RedactTargets(targets []Target) []Target {
+	if targets == nil {
+		return nil
+	}
+	out := make([]Target, len(targets))
+	for i, t := range targets {
+		out[i] = t.Redacted()
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package session
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SessionStorage is the disk usage of one session directory
+type SessionStorage struct {
+	SessionID  string    `json:"session_id"`
+	Name       string    `json:"name"`
+	Bytes      int64     `json:"bytes"`
+	Files      int       `json:"files"` // Every file on disk, including copied stdlib protos
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// StorageReport summarizes disk usage under the upload directory
+type StorageReport struct {
+	UploadDir    string           `json:"upload_dir"`
+	TotalBytes   int64            `json:"total_bytes"`   // Everything under the upload directory
+	SessionBytes int64            `json:"session_bytes"` // Directories of known sessions
+	OtherBytes   int64            `json:"other_bytes"`   // Orphans, partial uploads, the session store
+	MaxBytes     int64            `json:"max_bytes"`     // Global quota; 0 when unlimited
+	Sessions     []SessionStorage `json:"sessions"`      // Largest first
+}
+
+// StorageCleanup reports what a cleanup removed
+type StorageCleanup struct {
+	Expired    []string `json:"expired"`     // Sessions past their TTL
+	Evicted    []string `json:"evicted"`     // Least recently used sessions removed to fit the quota
+	FreedBytes int64    `json:"freed_bytes"` // Disk space released by evictions
+	TotalBytes int64    `json:"total_bytes"` // Usage after the cleanup
+	MaxBytes   int64    `json:"max_bytes"`   // Quota that was enforced; 0 when unlimited
+}
+
+// SetStorageQuota limits the total size of the upload directory. When it is
+// exceeded, EnforceStorageQuota evicts the least recently used sessions.
+// 0 disables the limit.
+func (m *Manager) SetStorageQuota(maxBytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxStorage = maxBytes
+}
+
+// StorageQuota returns the global limit on the upload directory
+func (m *Manager) StorageQuota() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.maxStorage
+}
+
+// StorageReport measures the disk usage of every session directory and of
+// everything else under the upload directory
+func (m *Manager) StorageReport() StorageReport {
+	type sessionDir struct {
+		storage SessionStorage
+		dirs    []string
+	}
+
+	m.mu.RLock()
+	report := StorageReport{UploadDir: m.uploadDir, MaxBytes: m.maxStorage, Sessions: []SessionStorage{}}
+	owned := make(map[string]bool, len(m.sessions))
+	sessions := make([]sessionDir, 0, len(m.sessions))
+	for id, sess := range m.sessions {
+		sd := sessionDir{storage: SessionStorage{SessionID: id, Name: sess.Name, LastUsedAt: sess.LastUsedAt}}
+		for _, dir := range []string{filepath.Join(m.uploadDir, id), sess.RootPath} {
+			if dir == "" || owned[filepath.Clean(dir)] {
+				continue
+			}
+			owned[filepath.Clean(dir)] = true
+			sd.dirs = append(sd.dirs, dir)
+		}
+		sessions = append(sessions, sd)
+	}
+	m.mu.RUnlock()
+
+	for _, sd := range sessions {
+		for _, dir := range sd.dirs {
+			bytes, files := dirUsage(dir)
+			sd.storage.Bytes += bytes
+			sd.storage.Files += files
+		}
+		report.SessionBytes += sd.storage.Bytes
+		report.Sessions = append(report.Sessions, sd.storage)
+	}
+	sort.Slice(report.Sessions, func(i, j int) bool {
+		return report.Sessions[i].Bytes > report.Sessions[j].Bytes
+	})
+
+	entries, err := os.ReadDir(m.uploadDir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("[SessionManager] Failed to read upload directory %s: %v", m.uploadDir, err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(m.uploadDir, entry.Name())
+		if owned[filepath.Clean(path)] {
+			continue
+		}
+		bytes, _ := dirUsage(path)
+		report.OtherBytes += bytes
+	}
+	report.TotalBytes = report.SessionBytes + report.OtherBytes
+	return report
+}
+
+// Cleanup removes expired sessions, then evicts the least recently used
+// sessions until the upload directory fits maxBytes (the storage quota when 0)
+func (m *Manager) Cleanup(maxBytes int64) StorageCleanup {
+	expired := m.cleanupExpiredSessions()
+	cleanup := m.EnforceStorageQuota(maxBytes)
+	cleanup.Expired = expired
+	return cleanup
+}
+
+// EnforceStorageQuota evicts the least recently used sessions until the upload
+// directory fits maxBytes (the storage quota when 0). Sessions with in-flight
+// operations, and those without files, are kept. With an archive store
+// configured, sessions are archived before eviction and a session whose
+// archive fails is kept.
+func (m *Manager) EnforceStorageQuota(maxBytes int64) StorageCleanup {
+	if maxBytes <= 0 {
+		maxBytes = m.StorageQuota()
+	}
+	report := m.StorageReport()
+	cleanup := StorageCleanup{Expired: []string{}, Evicted: []string{}, TotalBytes: report.TotalBytes, MaxBytes: maxBytes}
+	if maxBytes <= 0 || report.TotalBytes <= maxBytes {
+		return cleanup
+	}
+
+	candidates := report.Sessions
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].LastUsedAt.Before(candidates[j].LastUsedAt)
+	})
+	for _, candidate := range candidates {
+		if cleanup.TotalBytes <= maxBytes {
+			break
+		}
+		// Sessions without files free nothing
+		if candidate.Bytes == 0 || m.busy(candidate.SessionID) || !m.archiveForEviction(candidate.SessionID) {
+			continue
+		}
+		m.Delete(candidate.SessionID)
+		log.Printf("[SessionManager] Evicted session %s (%d bytes) to fit the storage quota of %d bytes", candidate.SessionID, candidate.Bytes, maxBytes)
+		cleanup.Evicted = append(cleanup.Evicted, candidate.SessionID)
+		cleanup.FreedBytes += candidate.Bytes
+		cleanup.TotalBytes -= candidate.Bytes
+	}
+	if cleanup.TotalBytes > maxBytes {
+		log.Printf("[SessionManager] Upload directory still uses %d bytes, above the storage quota of %d bytes", cleanup.TotalBytes, maxBytes)
+	}
+	return cleanup
+}
+
+// WatchStorageQuota enforces the storage quota every interval
+func (m *Manager) WatchStorageQuota(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			m.EnforceStorageQuota(0)
+		}
+	}()
+}
+
+// busy reports whether a session has in-flight operations
+func (m *Manager) busy(sessionID string) bool {
+	m.opsMu.Lock()
+	defer m.opsMu.Unlock()
+	return len(m.operations[sessionID]) > 0
+}
+
+// archiveForEviction archives a session about to be evicted. It reports true
+// when the session may be deleted: archiving is disabled or succeeded.
+func (m *Manager) archiveForEviction(sessionID string) bool {
+	m.mu.RLock()
+	store, cipher := m.archive, m.cipher
+	sess, exists := m.sessions[sessionID]
+	var record []byte
+	var root string
+	var err error
+	if exists && store != nil {
+		record, err = sealedRecord(sess, cipher)
+		root = sess.RootPath
+	}
+	m.mu.RUnlock()
+	if !exists {
+		return false
+	}
+	if store == nil {
+		return true
+	}
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		err = archiveSession(ctx, store, sessionID, record, root)
+		cancel()
+	}
+	if err != nil {
+		log.Printf("[SessionManager] Failed to archive session %s, not evicting it: %v", sessionID, err)
+		return false
+	}
+	log.Printf("[SessionManager] Archived session %s to %s before eviction", sessionID, store)
+	return true
+}
+
+// dirUsage returns the size and number of regular files under path
+func dirUsage(path string) (int64, int) {
+	var bytes int64
+	var files int
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			bytes += info.Size()
+			files++
+		}
+		return nil
+	})
+	return bytes, files
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// persistDelay batches bursts of session changes (e.g. one per uploaded file)
+// into a single write
+const persistDelay = 500 * time.Millisecond
+
+// touchPersistInterval limits how often activity alone triggers a write
+const touchPersistInterval = time.Minute
+
+// Store persists session records so sessions survive server restarts
+type Store interface {
+	Load() ([]*Session, error)
+	Save(sessions []*Session) error
+}
+
+// FileStore keeps session records in a single JSON file. Writes go to a
+// temporary file that is renamed over the old one, so a crash mid-write never
+// leaves a truncated store behind.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store backed by the JSON file at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// storeFile is the on-disk layout of a FileStore
+type storeFile struct {
+	Version  int        `json:"version"`
+	SavedAt  time.Time  `json:"saved_at"`
+	Sessions []*Session `json:"sessions"`
+}
+
+// Load reads the stored sessions. A missing file yields no sessions.
+func (s *FileStore) Load() ([]*Session, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read session store: %w", err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse session store %s: %w", s.path, err)
+	}
+	return file.Sessions, nil
+}
+
+// Save replaces the stored sessions
+func (s *FileStore) Save(sessions []*Session) error {
+	data, err := json.MarshalIndent(storeFile{
+		Version:  1,
+		SavedAt:  time.Now(),
+		Sessions: sessions,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sessions: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create session store directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".sessions-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write session store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write session store: %w", err)
+	}
+	return nil
+}
+
+// UseStore rehydrates sessions from store and persists every later change to
+// it. Stored sessions are reconciled with the uploads directory: expired
+// sessions and sessions whose directory is gone are dropped, as are files that
+// no longer exist on disk. It returns the number of restored sessions.
+func (m *Manager) UseStore(store Store) (int, error) {
+	stored, err := store.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	restored := 0
+	for _, sess := range stored {
+		if sess == nil || sess.ID == "" || now.After(sess.ExpiresAt) {
+			continue
+		}
+		if err := openSecrets(sess, m.cipher); err != nil {
+			m.mu.Unlock()
+			return 0, fmt.Errorf("failed to decrypt session %s: %w", sess.ID, err)
+		}
+		if sess.RootPath != "" {
+			if info, err := os.Stat(sess.RootPath); err != nil || !info.IsDir() {
+				log.Printf("[SessionManager] Dropping stored session %s: directory %s is gone", sess.ID, sess.RootPath)
+				continue
+			}
+		}
+		reconcileSession(sess)
+		if _, exists := m.sessions[sess.ID]; !exists {
+			m.sessions[sess.ID] = sess
+			restored++
+		}
+	}
+	m.store = store
+	m.dirty = make(chan struct{}, 1)
+	m.mu.Unlock()
+
+	go m.persistLoop()
+
+	// Write back the reconciled set right away
+	m.markDirty()
+	return restored, nil
+}
+
+// Flush writes all sessions to the store immediately
+func (m *Manager) Flush() error {
+	m.mu.RLock()
+	store, cipher := m.store, m.cipher
+	if store == nil {
+		m.mu.RUnlock()
+		return nil
+	}
+	// Snapshot through JSON so the store never sees sessions being mutated
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, sess := range m.sessions {
+		sessions = append(sessions, sess)
+	}
+	data, err := json.Marshal(sessions)
+	m.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to snapshot sessions: %w", err)
+	}
+
+	var snapshot []*Session
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to snapshot sessions: %w", err)
+	}
+	for _, sess := range snapshot {
+		if err := sealSecrets(sess, cipher); err != nil {
+			return fmt.Errorf("failed to encrypt session %s: %w", sess.ID, err)
+		}
+	}
+
+	m.persistMu.Lock()
+	defer m.persistMu.Unlock()
+	return store.Save(snapshot)
+}
+
+// markDirty schedules a write of the session records. Safe to call with m.mu
+// held; a no-op when no store is configured.
+func (m *Manager) markDirty() {
+	if m.dirty == nil {
+		return
+	}
+	select {
+	case m.dirty <- struct{}{}:
+	default:
+		// A write is already pending
+	}
+}
+
+// persistLoop writes session records after changes, coalescing bursts
+func (m *Manager) persistLoop() {
+	for range m.dirty {
+		time.Sleep(persistDelay)
+		if err := m.Flush(); err != nil {
+			log.Printf("[SessionManager] Failed to persist sessions: %v", err)
+		}
+	}
+}
+
+// reconcileSession drops files and descriptor sets of a stored session that no
+// longer exist on disk, and normalizes nil collections
+func reconcileSession(sess *Session) {
+	files := make([]ProtoFile, 0, len(sess.ProtoFiles))
+	for _, f := range sess.ProtoFiles {
+		if _, err := os.Stat(f.AbsolutePath); err == nil {
+			files = append(files, f)
+		}
+	}
+	if len(files) != len(sess.ProtoFiles) {
+		// Cached services may refer to removed files
+		sess.Services = nil
+		sess.ParsedAt = nil
+	}
+	sess.ProtoFiles = files
+
+	sets := make([]DescriptorSet, 0, len(sess.DescriptorSets))
+	for _, set := range sess.DescriptorSets {
+		if _, err := os.Stat(set.AbsolutePath); err == nil {
+			sets = append(sets, set)
+		}
+	}
+	sess.DescriptorSets = sets
+
+	if sess.Directories == nil {
+		sess.Directories = []ProtoDir{}
+	}
+	if sess.Services == nil {
+		sess.Services = []ServiceInfo{}
+	}
+	if sess.ImportMappings == nil {
+		sess.ImportMappings = []ImportMapping{}
+	}
+	if sess.Tags == nil {
+		sess.Tags = []string{}
+	}
+	if sess.Variables == nil {
+		sess.Variables = map[string]string{}
+	}
+	if sess.Environments == nil {
+		sess.Environments = []Environment{}
+	}
+	if sess.Targets == nil {
+		sess.Targets = []Target{}
+	}
+	if sess.DefaultMetadata == nil {
+		sess.DefaultMetadata = map[string]string{}
+	}
+	if sess.LastUsedAt.IsZero() {
+		sess.LastUsedAt = sess.CreatedAt
+	}
+}
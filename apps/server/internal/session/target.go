@@ -0,0 +1,168 @@
+package session
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/grpc-bridge/server/internal/secret"
+)
+
+// TLSSettings customizes TLS for connections that are not plaintext
+type TLSSettings struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // Accept any server certificate (e.g. self-signed dev servers)
+	ServerName         string `json:"server_name,omitempty"`          // Name verified against the server certificate, if not the target host
+}
+
+// Target is a saved gRPC server preset, so calls can pick a server by ID
+// instead of repeating its address and transport settings
+type Target struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`    // Display name
+	Address   string            `json:"address"` // host:port; may contain {{var}} placeholders
+	Plaintext bool              `json:"plaintext"`
+	TLS       *TLSSettings      `json:"tls,omitempty"`
+	Metadata  map[string]string `json:"metadata"` // Default metadata; request keys win
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// clone returns a deep copy so callers never share state with the session
+func (t Target) clone() Target {
+	out := t
+	if t.TLS != nil {
+		tlsCopy := *t.TLS
+		out.TLS = &tlsCopy
+	}
+	out.Metadata = make(map[string]string, len(t.Metadata))
+	for k, v := range t.Metadata {
+		out.Metadata[k] = v
+	}
+	return out
+}
+
+// ListTargets returns copies of a session's target presets in creation order
+func (m *Manager) ListTargets(sessionID string) ([]Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	targets := make([]Target, 0, len(session.Targets))
+	for _, t := range session.Targets {
+		targets = append(targets, t.clone())
+	}
+	return targets, nil
+}
+
+// GetTarget returns a copy of a single target preset
+func (m *Manager) GetTarget(sessionID, targetID string) (*Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	for _, t := range session.Targets {
+		if t.ID == targetID {
+			out := t.clone()
+			return &out, nil
+		}
+	}
+	return nil, ErrTargetNotFound
+}
+
+// AddTarget stores a new target preset under a generated ID
+func (m *Manager) AddTarget(sessionID string, target Target) (*Target, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	now := time.Now()
+	target.ID = uuid.New().String()
+	target.CreatedAt = now
+	target.UpdatedAt = now
+	session.Targets = append(session.Targets, target.clone())
+	m.markDirty()
+
+	out := target.clone()
+	return &out, nil
+}
+
+// UpdateTarget replaces the settings of an existing target preset. Metadata
+// values sent back as secret.Redacted keep their current value.
+func (m *Manager) UpdateTarget(sessionID, targetID string, target Target) (*Target, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	for i, existing := range session.Targets {
+		if existing.ID != targetID {
+			continue
+		}
+		target.ID = existing.ID
+		target.CreatedAt = existing.CreatedAt
+		target.UpdatedAt = time.Now()
+		target = target.clone()
+		secret.Unredact(target.Metadata, existing.Metadata)
+		session.Targets[i] = target
+		m.markDirty()
+
+		out := target.clone()
+		return &out, nil
+	}
+	return nil, ErrTargetNotFound
+}
+
+// ReplaceTargets replaces all target presets of a session, keeping their IDs
+func (m *Manager) ReplaceTargets(sessionID string, targets []Target) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	session.Targets = make([]Target, 0, len(targets))
+	for _, t := range targets {
+		if t.ID == "" {
+			t.ID = uuid.New().String()
+		}
+		session.Targets = append(session.Targets, t.clone())
+	}
+	m.markDirty()
+	return nil
+}
+
+// DeleteTarget removes a target preset from a session
+func (m *Manager) DeleteTarget(sessionID, targetID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	for i, t := range session.Targets {
+		if t.ID == targetID {
+			session.Targets = append(session.Targets[:i], session.Targets[i+1:]...)
+			m.markDirty()
+			return nil
+		}
+	}
+	return ErrTargetNotFound
+}
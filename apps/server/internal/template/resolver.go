@@ -0,0 +1,170 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placeholderRegex matches {{name}} placeholders, allowing surrounding whitespace
+// inside the braces (e.g. "{{ host }}"). Names may contain letters, digits,
+// underscores, dashes and dots so nested lookups like "steps.0.id" can be expressed.
+var placeholderRegex = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_\-\.]+)\s*\}\}`)
+
+// Lookup resolves a placeholder name to its value
+type Lookup func(name string) (string, bool)
+
+// MapLookup returns a Lookup backed by a plain variable map
+func MapLookup(vars map[string]string) Lookup {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+// ChainLookups returns a Lookup that tries each lookup in order
+func ChainLookups(lookups ...Lookup) Lookup {
+	return func(name string) (string, bool) {
+		for _, lookup := range lookups {
+			if lookup == nil {
+				continue
+			}
+			if v, ok := lookup(name); ok {
+				return v, true
+			}
+		}
+		return "", false
+	}
+}
+
+// PathLookup returns a Lookup resolving dotted paths rooted at prefix inside a
+// decoded JSON value, e.g. PathLookup("steps", steps) resolves "steps.0.response.id".
+// Strings are substituted verbatim; other values are rendered as JSON.
+func PathLookup(prefix string, root interface{}) Lookup {
+	return func(name string) (string, bool) {
+		if !strings.HasPrefix(name, prefix+".") {
+			return "", false
+		}
+
+		current := root
+		for _, segment := range strings.Split(strings.TrimPrefix(name, prefix+"."), ".") {
+			switch node := current.(type) {
+			case map[string]interface{}:
+				next, ok := node[segment]
+				if !ok {
+					return "", false
+				}
+				current = next
+			case []interface{}:
+				idx, err := strconv.Atoi(segment)
+				if err != nil || idx < 0 || idx >= len(node) {
+					return "", false
+				}
+				current = node[idx]
+			default:
+				return "", false
+			}
+		}
+
+		switch val := current.(type) {
+		case nil:
+			return "", false
+		case string:
+			return val, true
+		default:
+			encoded, err := json.Marshal(val)
+			if err != nil {
+				return "", false
+			}
+			return string(encoded), true
+		}
+	}
+}
+
+// Resolver substitutes {{var}} placeholders in strings and JSON-like values
+type Resolver struct {
+	lookup     Lookup
+	unresolved map[string]struct{}
+}
+
+// NewResolver creates a new resolver using the given lookup
+func NewResolver(lookup Lookup) *Resolver {
+	return &Resolver{
+		lookup:     lookup,
+		unresolved: make(map[string]struct{}),
+	}
+}
+
+// String resolves all placeholders in s. Unknown placeholders are left untouched
+// and recorded so the caller can report them via Err.
+func (r *Resolver) String(s string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return placeholderRegex.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholderRegex.FindStringSubmatch(match)[1]
+		if r.lookup != nil {
+			if v, ok := r.lookup(name); ok {
+				return v
+			}
+		}
+		r.unresolved[name] = struct{}{}
+		return match
+	})
+}
+
+// Map resolves placeholders in every value of a string map (keys are kept as-is)
+func (r *Resolver) Map(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = r.String(v)
+	}
+	return out
+}
+
+// Value walks a decoded JSON value (maps, slices, strings) and resolves
+// placeholders in every string it contains. Non-string scalars are returned unchanged.
+func (r *Resolver) Value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return r.String(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = r.Value(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.Value(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Unresolved returns the sorted list of placeholder names that could not be resolved
+func (r *Resolver) Unresolved() []string {
+	names := make([]string, 0, len(r.unresolved))
+	for name := range r.unresolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Err returns an error describing unresolved placeholders, or nil if all were resolved
+func (r *Resolver) Err() error {
+	if len(r.unresolved) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unresolved template variable(s): %s", strings.Join(r.Unresolved(), ", "))
+}
@@ -0,0 +1,158 @@
+// Package tlsconfig builds the TLS configuration for serving the bridge over
+// HTTPS, from certificate files or with certificates from Let's Encrypt.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certReloadInterval is how often certificate files are checked for renewal
+const certReloadInterval = time.Minute
+
+// Options selects the certificate source. CertFile and KeyFile name a PEM
+// certificate chain and key; AutocertDomains instead obtains certificates
+// from Let's Encrypt for those host names, cached in AutocertCache and
+// registered with AutocertEmail. Setting both sources is an error.
+type Options struct {
+	CertFile string
+	KeyFile  string
+
+	AutocertDomains []string
+	AutocertCache   string
+	AutocertEmail   string
+}
+
+// Setup is how the server terminates TLS
+type Setup struct {
+	Config *tls.Config
+	// Challenge wraps the plain HTTP listener's handler to answer ACME
+	// HTTP-01 challenges; nil unless certificates come from Let's Encrypt
+	Challenge func(http.Handler) http.Handler
+	// Description names the certificate source for logs
+	Description string
+}
+
+// New builds the TLS setup for opts. It returns nil when no certificate
+// source is configured and the server should serve plain HTTP. Certificate
+// files are reloaded when they change, so renewed certificates are picked up
+// without a restart.
+func New(opts Options) (*Setup, error) {
+	certFile, keyFile, domains := opts.CertFile, opts.KeyFile, opts.AutocertDomains
+
+	switch {
+	case len(domains) > 0 && (certFile != "" || keyFile != ""):
+		return nil, fmt.Errorf("autocert domains cannot be combined with a certificate file")
+
+	case len(domains) > 0:
+		if opts.AutocertCache == "" {
+			return nil, fmt.Errorf("autocert requires a cache directory")
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(opts.AutocertCache),
+			Email:      opts.AutocertEmail,
+		}
+		config := m.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+		return &Setup{
+			Config:      config,
+			Challenge:   m.HTTPHandler,
+			Description: fmt.Sprintf("autocert for %s (cache %s)", strings.Join(domains, ", "), opts.AutocertCache),
+		}, nil
+
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("a certificate file and a key file must be set together")
+		}
+		loader := &certLoader{certFile: certFile, keyFile: keyFile}
+		if err := loader.load(); err != nil {
+			return nil, err
+		}
+		return &Setup{
+			Config: &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: loader.getCertificate,
+			},
+			Description: fmt.Sprintf("certificate %s", certFile),
+		}, nil
+	}
+	return nil, nil
+}
+
+// certLoader serves a certificate from files, reloading it when they change
+type certLoader struct {
+	certFile, keyFile string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func (l *certLoader) load() error {
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	info, err := os.Stat(l.certFile)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.cert = &cert
+	l.modTime = info.ModTime()
+	l.checked = time.Now()
+	l.mu.Unlock()
+	return nil
+}
+
+// getCertificate returns the current certificate, checking the files for a
+// renewal at most once per certReloadInterval. A renewal that fails to load
+// keeps the previous certificate.
+func (l *certLoader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.RLock()
+	cert, modTime, due := l.cert, l.modTime, time.Since(l.checked) >= certReloadInterval
+	l.mu.RUnlock()
+	if !due {
+		return cert, nil
+	}
+
+	l.mu.Lock()
+	l.checked = time.Now()
+	l.mu.Unlock()
+	if info, err := os.Stat(l.certFile); err == nil && info.ModTime().After(modTime) {
+		if err := l.load(); err != nil {
+			log.Printf("[TLS] Keeping previous certificate: %v", err)
+		} else {
+			log.Printf("[TLS] Reloaded certificate %s", l.certFile)
+		}
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cert, nil
+}
+
+// RedirectToHTTPS sends plain HTTP requests to the same URL over HTTPS on httpsPort
+func RedirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
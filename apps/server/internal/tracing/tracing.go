@@ -0,0 +1,163 @@
+// Package tracing exports OpenTelemetry spans for bridged calls over OTLP.
+// Configuration follows the standard OTEL_* environment variables.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// instrumentationName identifies the spans created by this server
+const instrumentationName = "github.com/grpc-bridge/server"
+
+// ServiceName is reported as service.name unless OTEL_SERVICE_NAME or
+// OTEL_RESOURCE_ATTRIBUTES override it
+const ServiceName = "grpc-bridge"
+
+// Setup installs the W3C trace context propagator and, when an OTLP endpoint
+// is configured (OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT), a tracer provider exporting to it.
+// OTEL_EXPORTER_OTLP_PROTOCOL selects grpc (default) or http/protobuf;
+// OTEL_TRACES_EXPORTER=none or OTEL_SDK_DISABLED=true turn exporting off.
+// The returned function flushes pending spans and is a no-op when exporting
+// is off.
+func Setup(ctx context.Context, version string) (shutdown func(context.Context) error, enabled bool, err error) {
+	// Propagate incoming trace context even without an exporter, so calls
+	// stay linked to the caller's trace
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	noop := func(context.Context) error { return nil }
+	if !exporting() {
+		return noop, false, nil
+	}
+
+	exporter, err := newExporter(ctx)
+	if err != nil {
+		return noop, false, err
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", ServiceName),
+			attribute.String("service.version", version),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return noop, false, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, true, nil
+}
+
+// exporting reports whether the environment asks for spans to be exported
+func exporting() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	if exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter != "" && exporter != "otlp" {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// newExporter creates the OTLP exporter for the configured protocol. Endpoint,
+// headers, TLS and timeouts are read from the environment by the exporter.
+func newExporter(ctx context.Context) (*otlptrace.Exporter, error) {
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	switch protocol {
+	case "", "grpc":
+		return otlptracegrpc.New(ctx)
+	case "http/protobuf":
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (use grpc or http/protobuf)", protocol)
+	}
+}
+
+// Tracer returns the tracer for spans created by this server
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span as a child of the span in ctx
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End records err, if any, on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// InjectMetadata adds the trace context of ctx (traceparent, tracestate,
+// baggage) to outgoing gRPC metadata
+func InjectMetadata(ctx context.Context, md metadata.MD) {
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+}
+
+// Headers returns the trace context of ctx as header fields, for callers that
+// pass metadata as a plain map. Existing keys in md are kept; md may be nil.
+func Headers(ctx context.Context, md map[string]string) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return md
+	}
+	out := make(map[string]string, len(md)+len(carrier))
+	for k, v := range carrier {
+		out[k] = v
+	}
+	for k, v := range md {
+		out[k] = v
+	}
+	return out
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
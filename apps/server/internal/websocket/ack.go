@@ -0,0 +1,125 @@
+package websocket
+
+import (
+	"log"
+	"time"
+)
+
+// Acknowledgement defaults; see Hub.SetAcks
+const (
+	DefaultAckTimeout = 2 * time.Second
+	DefaultAckRetries = 5
+)
+
+// CriticalEvent is implemented by events whose loss would leave the client
+// inconsistent, such as call results. Clients that connect with ?acks=true
+// must acknowledge them by sequence number; unacknowledged ones are resent.
+type CriticalEvent interface {
+	Event
+	RequiresAck() bool
+}
+
+// AckRequest is the payload of an ack command
+type AckRequest struct {
+	Seq  uint64   `json:"seq"`
+	Seqs []uint64 `json:"seqs"`
+}
+
+// pendingAck is a critical event sent to an acknowledging client and not yet acknowledged
+type pendingAck struct {
+	message Message
+	sent    int       // Delivery attempts so far
+	due     time.Time // When to resend
+}
+
+// requiresAck reports whether an event must be acknowledged
+func requiresAck(event Event) bool {
+	critical, ok := event.(CriticalEvent)
+	return ok && critical.RequiresAck()
+}
+
+// SetAcks configures how long the hub waits for an acknowledgement before
+// resending a critical event, and how many times it sends it in total. Zero
+// values keep the current setting.
+func (h *Hub) SetAcks(timeout time.Duration, retries int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if timeout > 0 {
+		h.ackTimeout = timeout
+	}
+	if retries > 0 {
+		h.ackRetries = retries
+	}
+}
+
+// track records a critical event sent to an acknowledging client. Callers hold h.mu.
+func (h *Hub) track(buf *replayBuffer, message Message, now time.Time) {
+	if buf.pending == nil {
+		buf.pending = make(map[uint64]*pendingAck)
+	}
+	buf.pending[message.Seq] = &pendingAck{
+		message: message,
+		sent:    1,
+		due:     now.Add(h.ackTimeout),
+	}
+}
+
+// acknowledge drops acknowledged events of the client's session
+func (h *Hub) acknowledge(client *Client, seqs []uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buf, ok := h.replay[client.SessionID]
+	if !ok {
+		return
+	}
+	for _, seq := range seqs {
+		delete(buf.pending, seq)
+	}
+}
+
+// retransmitLoop periodically resends unacknowledged critical events
+func (h *Hub) retransmitLoop() {
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		h.retransmit(now)
+	}
+}
+
+// retransmit resends overdue critical events to the session's acknowledging
+// client. An event is given up after ackRetries sends; while no such client is
+// connected it waits, until it falls out of the replay window.
+func (h *Hub) retransmit(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sessionID, buf := range h.replay {
+		client := h.clients[sessionID]
+		for seq, p := range buf.pending {
+			if now.Before(p.due) {
+				continue
+			}
+			if client == nil || !client.Acks {
+				if now.Sub(p.message.Timestamp) > h.replayWindow {
+					delete(buf.pending, seq)
+				}
+				continue
+			}
+			if p.sent >= h.ackRetries {
+				log.Printf("[Hub] Event %d of session %s not acknowledged after %d sends", seq, sessionID, p.sent)
+				delete(buf.pending, seq)
+				continue
+			}
+			if h.deliver(client, p.message) {
+				p.sent++
+			} else if h.clients[sessionID] != client {
+				// Disconnected by the backpressure policy
+				client = nil
+			}
+			p.due = now.Add(h.ackTimeout)
+		}
+	}
+}
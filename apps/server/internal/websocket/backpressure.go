@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+)
+
+// BackpressurePolicy decides what happens when a client's Send channel is
+// full, i.e. the client reads slower than events are produced
+type BackpressurePolicy string
+
+const (
+	// DropOldest discards the oldest queued message to make room for the new one
+	DropOldest BackpressurePolicy = "drop-oldest"
+	// Disconnect drops the client; it can reconnect with ?since=<seq> to
+	// recover the session events it missed
+	Disconnect BackpressurePolicy = "disconnect"
+)
+
+// DefaultBackpressurePolicy is the policy of a new hub
+const DefaultBackpressurePolicy = DropOldest
+
+// ParseBackpressurePolicy validates a policy name
+func ParseBackpressurePolicy(s string) (BackpressurePolicy, error) {
+	switch policy := BackpressurePolicy(s); policy {
+	case DropOldest, Disconnect:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown backpressure policy %q (expected %s or %s)", s, DropOldest, Disconnect)
+}
+
+// SetBackpressure changes the policy applied to slow clients
+func (h *Hub) SetBackpressure(policy BackpressurePolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.backpressure = policy
+}
+
+// Backpressure returns the policy applied to slow clients
+func (h *Hub) Backpressure() BackpressurePolicy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.backpressure
+}
+
+// Drops returns, per session, how many messages were dropped or clients
+// disconnected because their Send channel was full
+func (h *Hub) Drops() map[string]uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	drops := make(map[string]uint64, len(h.drops))
+	for sessionID, n := range h.drops {
+		drops[sessionID] = n
+	}
+	return drops
+}
+
+// deliver queues a message for a registered client, applying the
+// backpressure policy when its Send channel is full. It reports whether the
+// message was queued. Callers hold h.mu for writing: the disconnect policy
+// removes the client, and senders must not race for the room drop-oldest makes.
+func (h *Hub) deliver(client *Client, message Message) bool {
+	select {
+	case client.Send <- message:
+		return true
+	default:
+	}
+
+	switch h.backpressure {
+	case Disconnect:
+		h.drops[client.SessionID]++
+		log.Printf("[Hub] Send buffer full, disconnecting session: %s", client.SessionID)
+		close(client.Send)
+		delete(h.clients, client.SessionID)
+		return false
+
+	default:
+		// The writer may drain the channel meanwhile; then nothing is discarded
+		select {
+		case <-client.Send:
+			h.drops[client.SessionID]++
+		default:
+		}
+		select {
+		case client.Send <- message:
+			return true
+		default:
+			return false
+		}
+	}
+}
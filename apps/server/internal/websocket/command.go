@@ -0,0 +1,134 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/grpc-bridge/server/internal/apierror"
+)
+
+// Command is a client-to-server message:
+//
+//	{"id": "c1", "type": "call.invoke", "payload": {...}}
+//
+// ID is chosen by the client and echoed in the ws://command_ack or
+// ws://command_error reply.
+type Command struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Command types
+const (
+	CommandInvoke      = "call.invoke"  // Start a call; payload is a call request
+	CommandCancel      = "call.cancel"  // Cancel a running call: {"call_id"}
+	CommandStreamSend  = "stream.send"  // Send a request on a streaming call: {"call_id", "data"}
+	CommandStreamClose = "stream.close" // Half-close a streaming call: {"call_id"}
+	CommandSubscribe   = "subscribe"    // Filter events and/or replay buffered ones: {"events", "since"}
+	CommandAcknowledge = "ack"          // Acknowledge critical events: {"seq"} or {"seqs"}; not answered
+)
+
+// CommandHandler executes a command for a client and returns the ack payload,
+// or an error reported to the client as ws://command_error
+type CommandHandler func(client *Client, cmd Command) (any, error)
+
+// SubscribeRequest is the payload of a subscribe command
+type SubscribeRequest struct {
+	Events *[]string `json:"events"` // Event prefixes to receive; empty for all, omitted to keep the current ones
+	Since  *uint64   `json:"since"`  // Replay buffered events after this sequence number
+}
+
+// SetCommandHandler installs the handler for commands other than subscribe
+func (h *Hub) SetCommandHandler(handler CommandHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.commands = handler
+}
+
+// dispatch runs one inbound message and replies to the sending client
+func (h *Hub) dispatch(client *Client, data []byte) {
+	var cmd Command
+	if err := json.Unmarshal(data, &cmd); err != nil || cmd.Type == "" {
+		h.replyError(client, cmd.ID, apierror.InvalidRequest, "invalid command: expected {\"id\", \"type\", \"payload\"}")
+		return
+	}
+
+	if cmd.Type == CommandAcknowledge {
+		var req AckRequest
+		if err := json.Unmarshal(cmd.Payload, &req); err != nil {
+			h.replyError(client, cmd.ID, apierror.InvalidRequest, "invalid payload: "+err.Error())
+			return
+		}
+		if req.Seq > 0 {
+			req.Seqs = append(req.Seqs, req.Seq)
+		}
+		h.acknowledge(client, req.Seqs)
+		return
+	}
+
+	if cmd.Type == CommandSubscribe {
+		var req SubscribeRequest
+		if len(cmd.Payload) > 0 {
+			if err := json.Unmarshal(cmd.Payload, &req); err != nil {
+				h.replyError(client, cmd.ID, apierror.InvalidRequest, "invalid payload: "+err.Error())
+				return
+			}
+		}
+		if req.Events != nil {
+			h.subscribe(client, cleanPrefixes(*req.Events))
+		}
+		h.reply(client, CommandAck{ID: cmd.ID, Type: cmd.Type})
+		if req.Since != nil {
+			h.resume(client, *req.Since)
+		}
+		return
+	}
+
+	h.mu.RLock()
+	handler := h.commands
+	h.mu.RUnlock()
+	if handler == nil {
+		h.replyError(client, cmd.ID, apierror.InvalidRequest, "unknown command type: "+cmd.Type)
+		return
+	}
+
+	result, err := handler(client, cmd)
+	if err != nil {
+		h.replyError(client, cmd.ID, apierror.CodeOf(err, apierror.InvalidRequest), err.Error())
+		return
+	}
+	h.reply(client, CommandAck{ID: cmd.ID, Type: cmd.Type, Result: result})
+}
+
+// resume replays the client's missed events after since on the open socket
+func (h *Hub) resume(client *Client, since uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[client.SessionID] != client {
+		return
+	}
+	client.Since = since
+	h.replayTo(client)
+}
+
+func (h *Hub) replyError(client *Client, id string, code apierror.Code, message string) {
+	h.reply(client, CommandError{ID: id, Code: code, Error: message})
+}
+
+// reply sends a command response to the client only. Replies are not
+// sequenced or buffered: they belong to the socket that sent the command.
+func (h *Hub) reply(client *Client, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// A replaced client's Send channel is closed
+	if h.clients[client.SessionID] != client {
+		return
+	}
+	if !h.deliver(client, newMessage(client.SessionID, event)) {
+		log.Printf("[Hub] Failed to reply to session: %s", client.SessionID)
+	}
+}
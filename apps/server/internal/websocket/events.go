@@ -0,0 +1,67 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/grpc-bridge/server/internal/apierror"
+)
+
+// SchemaVersion is the version of the message envelope and event payloads.
+// It changes only when an existing field is removed or changes meaning;
+// new events and fields are added without a bump.
+const SchemaVersion = 1
+
+// Event is a typed event payload. EventName returns its name, such as
+// "grpc://response"; the prefix groups events for subscriptions.
+type Event interface {
+	EventName() string
+}
+
+// Message is the envelope of every event sent to clients
+type Message struct {
+	SchemaVersion int       `json:"schema_version"`
+	Event         string    `json:"event"`
+	SessionID     string    `json:"session_id,omitempty"` // Empty for broadcasts
+	Seq           uint64    `json:"seq,omitempty"`        // Per-session sequence number; zero for broadcasts and replies
+	Timestamp     time.Time `json:"timestamp"`
+	AckRequired   bool      `json:"ack_required,omitempty"` // Acknowledge with an ack command when connected with ?acks=true
+	Payload       Event     `json:"payload"`
+}
+
+// newMessage wraps an event for a session ("" for broadcasts)
+func newMessage(sessionID string, event Event) Message {
+	return Message{
+		SchemaVersion: SchemaVersion,
+		Event:         event.EventName(),
+		SessionID:     sessionID,
+		Timestamp:     time.Now().UTC(),
+		Payload:       event,
+	}
+}
+
+// Replayed summarizes a replay of missed events (ws://replayed)
+type Replayed struct {
+	Since    uint64 `json:"since"`
+	Replayed int    `json:"replayed"`
+	Complete bool   `json:"complete"` // False when some missed events were no longer buffered
+}
+
+func (Replayed) EventName() string { return "ws://replayed" }
+
+// CommandAck acknowledges a command (ws://command_ack)
+type CommandAck struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Result any    `json:"result,omitempty"`
+}
+
+func (CommandAck) EventName() string { return "ws://command_ack" }
+
+// CommandError reports a rejected command (ws://command_error)
+type CommandError struct {
+	ID    string        `json:"id"`
+	Code  apierror.Code `json:"code"`
+	Error string        `json:"error"`
+}
+
+func (CommandError) EventName() string { return "ws://command_error" }
@@ -0,0 +1,45 @@
+package websocket
+
+import "strings"
+
+// controlEventPrefix marks hub replies (acks, replay summaries), which every
+// client receives regardless of its subscription
+const controlEventPrefix = "ws://"
+
+// wants reports whether the client subscribed to an event. Callers hold h.mu.
+func (c *Client) wants(event string) bool {
+	if len(c.Events) == 0 || strings.HasPrefix(event, controlEventPrefix) {
+		return true
+	}
+	for _, prefix := range c.Events {
+		if strings.HasPrefix(event, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEventPrefixes splits a comma-separated subscription such as
+// "grpc://,loadtest://" into prefixes, dropping blanks. Nil means all events.
+func ParseEventPrefixes(list string) []string {
+	return cleanPrefixes(strings.Split(list, ","))
+}
+
+// cleanPrefixes trims prefixes and drops blank ones
+func cleanPrefixes(list []string) []string {
+	var prefixes []string
+	for _, prefix := range list {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// subscribe replaces the client's event prefixes
+func (h *Hub) subscribe(client *Client, events []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client.Events = events
+}
@@ -2,16 +2,47 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-// Message represents a WebSocket message
-type Message struct {
-	Event   string `json:"event"`
-	Payload any    `json:"payload"`
+// Heartbeat defaults; see Heartbeat
+const (
+	DefaultPingInterval = 30 * time.Second
+	DefaultPongTimeout  = 60 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+// maxInboundMessageSize bounds messages read from clients
+const maxInboundMessageSize = 1 << 20 // 1MB
+
+// Heartbeat controls WebSocket keepalive: the server pings every PingInterval
+// and drops connections that send nothing (not even a pong) for PongTimeout.
+// Writes that take longer than WriteTimeout fail the connection.
+type Heartbeat struct {
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// Compression defaults; see Compression
+const (
+	DefaultCompressionLevel   = 1    // flate.BestSpeed
+	DefaultCompressionMinSize = 1024 // Bytes
+)
+
+// Compression controls permessage-deflate (RFC 7692). When Enabled, the
+// extension is offered to clients during the upgrade; messages of at least
+// MinSize bytes are then compressed at Level (1-9), smaller ones are sent as is.
+type Compression struct {
+	Enabled bool
+	Level   int
+	MinSize int
 }
 
 // Client represents a WebSocket client
@@ -19,6 +50,18 @@ type Client struct {
 	SessionID string
 	Conn      *websocket.Conn
 	Send      chan Message
+
+	// Resume is set when the client reconnects with ?since=<seq>; events
+	// after Since still in the replay buffer are sent before new ones
+	Resume bool
+	Since  uint64
+
+	// Events lists the event prefixes the client subscribed to (e.g.
+	// "grpc://"); empty means all events. Guarded by the hub's mutex.
+	Events []string
+
+	// Acks is set when the client acknowledges critical events; see CriticalEvent
+	Acks bool
 }
 
 // Hub manages WebSocket connections
@@ -28,6 +71,22 @@ type Hub struct {
 	unregister chan *Client
 	broadcast  chan Message
 	mu         sync.RWMutex
+
+	heartbeat   Heartbeat
+	compression Compression
+
+	replay       map[string]*replayBuffer // sessionID -> recent events
+	replaySize   int
+	replayWindow time.Duration
+
+	commands CommandHandler
+	welcome  func() Event
+
+	ackTimeout time.Duration
+	ackRetries int
+
+	backpressure BackpressurePolicy
+	drops        map[string]uint64 // sessionID -> messages dropped for a full Send
 }
 
 // NewHub creates a new WebSocket hub
@@ -37,9 +96,27 @@ func NewHub() *Hub {
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		broadcast:  make(chan Message, 256),
+		heartbeat: Heartbeat{
+			PingInterval: DefaultPingInterval,
+			PongTimeout:  DefaultPongTimeout,
+			WriteTimeout: DefaultWriteTimeout,
+		},
+		compression: Compression{
+			Enabled: true,
+			Level:   DefaultCompressionLevel,
+			MinSize: DefaultCompressionMinSize,
+		},
+		replay:       make(map[string]*replayBuffer),
+		replaySize:   DefaultReplaySize,
+		replayWindow: DefaultReplayWindow,
+		ackTimeout:   DefaultAckTimeout,
+		ackRetries:   DefaultAckRetries,
+		backpressure: DefaultBackpressurePolicy,
+		drops:        make(map[string]uint64),
 	}
 
 	go h.run()
+	go h.retransmitLoop()
 
 	return h
 }
@@ -50,13 +127,30 @@ func (h *Hub) run() {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
+			if previous, ok := h.clients[client.SessionID]; ok && previous != client {
+				// A reconnect replaces the old socket; closing Send stops its pumps
+				close(previous.Send)
+			}
 			h.clients[client.SessionID] = client
+			if h.welcome != nil {
+				if welcome := h.welcome(); client.wants(welcome.EventName()) {
+					select {
+					case client.Send <- newMessage("", welcome):
+					default:
+					}
+				}
+			}
+			if client.Resume {
+				h.replayTo(client)
+			}
 			h.mu.Unlock()
 			log.Printf("[Hub] Client registered: %s", client.SessionID)
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client.SessionID]; ok {
+			// Only the current client of a session may remove it; a replaced
+			// client unregistering late must not drop its successor
+			if current, ok := h.clients[client.SessionID]; ok && current == client {
 				delete(h.clients, client.SessionID)
 				close(client.Send)
 			}
@@ -64,20 +158,108 @@ func (h *Hub) run() {
 			log.Printf("[Hub] Client unregistered: %s", client.SessionID)
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
+			// Write lock: delivering may disconnect slow clients
+			h.mu.Lock()
 			for _, client := range h.clients {
-				select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(h.clients, client.SessionID)
+				if client.wants(message.Event) {
+					h.deliver(client, message)
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
 		}
 	}
 }
 
+// SetHeartbeat changes the keepalive settings for connections started afterwards.
+// Zero fields keep their current value.
+func (h *Hub) SetHeartbeat(hb Heartbeat) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if hb.PingInterval > 0 {
+		h.heartbeat.PingInterval = hb.PingInterval
+	}
+	if hb.PongTimeout > 0 {
+		h.heartbeat.PongTimeout = hb.PongTimeout
+	}
+	if hb.WriteTimeout > 0 {
+		h.heartbeat.WriteTimeout = hb.WriteTimeout
+	}
+}
+
+// Heartbeat returns the current keepalive settings
+func (h *Hub) Heartbeat() Heartbeat {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.heartbeat
+}
+
+// SetCompression changes the compression settings for connections started afterwards
+func (h *Hub) SetCompression(c Compression) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.compression = c
+}
+
+// Compression returns the current compression settings
+func (h *Hub) Compression() Compression {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.compression
+}
+
+// SetWelcome installs a function whose event is sent to every client as it
+// connects, before any replayed events, such as the server status. Like a
+// broadcast, it is not sequenced or buffered.
+func (h *Hub) SetWelcome(welcome func() Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.welcome = welcome
+}
+
+// Clients returns the number of connected clients
+func (h *Hub) Clients() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.clients)
+}
+
+// HubStats counts what the hub holds, for spotting clients or buffers that
+// are never released
+type HubStats struct {
+	Clients        int `json:"clients"`         // Connected clients, one per session
+	QueuedMessages int `json:"queued_messages"` // Messages waiting in client send buffers
+	Broadcasts     int `json:"broadcasts"`      // Broadcasts waiting for the hub loop
+	ReplayBuffers  int `json:"replay_buffers"`  // Sessions with buffered events
+	BufferedEvents int `json:"buffered_events"` // Events held for resuming clients
+	PendingAcks    int `json:"pending_acks"`    // Critical events awaiting acknowledgement
+}
+
+// Stats returns the current client and buffer counts
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := HubStats{
+		Clients:       len(h.clients),
+		Broadcasts:    len(h.broadcast),
+		ReplayBuffers: len(h.replay),
+	}
+	for _, client := range h.clients {
+		stats.QueuedMessages += len(client.Send)
+	}
+	for _, buf := range h.replay {
+		stats.BufferedEvents += len(buf.events)
+		stats.PendingAcks += len(buf.pending)
+	}
+	return stats
+}
+
 // Register registers a new client
 func (h *Hub) Register(client *Client) {
 	h.register <- client
@@ -88,75 +270,138 @@ func (h *Hub) Unregister(client *Client) {
 	h.unregister <- client
 }
 
-// EmitToSession sends a message to a specific session
-func (h *Hub) EmitToSession(sessionID, event string, payload any) {
-	h.mu.RLock()
-	client, exists := h.clients[sessionID]
-	h.mu.RUnlock()
+// EmitToSession sends a message to a specific session. The message is kept in
+// the session's replay buffer even when no client is connected, so a client
+// reconnecting shortly after can still receive it.
+func (h *Hub) EmitToSession(sessionID string, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
+	buf, ok := h.replay[sessionID]
+	if !ok {
+		buf = &replayBuffer{}
+		h.replay[sessionID] = buf
+	}
+	buf.seq++
+	now := time.Now()
+	message := newMessage(sessionID, event)
+	message.Seq = buf.seq
+	message.AckRequired = requiresAck(event)
+	buf.add(message, now, h.replaySize, h.replayWindow)
+
+	client, exists := h.clients[sessionID]
 	if !exists {
-		log.Printf("[Hub] Session not found: %s", sessionID)
+		log.Printf("[Hub] Session not connected, buffered event %d: %s", message.Seq, sessionID)
 		return
 	}
-
-	message := Message{
-		Event:   event,
-		Payload: payload,
+	if !client.wants(message.Event) {
+		return
+	}
+	if message.AckRequired && client.Acks {
+		// Tracked before sending: a full channel is retried like a lost event
+		h.track(buf, message, now)
 	}
 
-	select {
-	case client.Send <- message:
-	default:
+	if !h.deliver(client, message) {
 		log.Printf("[Hub] Failed to send message to session: %s", sessionID)
 	}
 }
 
-// EmitToAll broadcasts a message to all connected clients
-func (h *Hub) EmitToAll(event string, payload any) {
-	message := Message{
-		Event:   event,
-		Payload: payload,
+// SendToSession delivers an event to the session's connected client without
+// sequencing or buffering it, for periodic state where a newer event
+// supersedes any the client missed. It reports whether the event was queued.
+func (h *Hub) SendToSession(sessionID string, event Event) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client, exists := h.clients[sessionID]
+	if !exists {
+		return false
 	}
+	message := newMessage(sessionID, event)
+	if !client.wants(message.Event) {
+		return false
+	}
+	return h.deliver(client, message)
+}
 
-	h.broadcast <- message
+// EmitToAll broadcasts a message to all connected clients
+func (h *Hub) EmitToAll(event Event) {
+	h.broadcast <- newMessage("", event)
 }
 
-// ReadPump handles incoming messages from the client
+// ReadPump handles incoming commands from the client, one at a time. The
+// connection is dropped when nothing arrives within the pong timeout.
 func (c *Client) ReadPump(hub *Hub) {
 	defer func() {
 		hub.Unregister(c)
 		c.Conn.Close()
 	}()
 
+	hb := hub.Heartbeat()
+	c.Conn.SetReadLimit(maxInboundMessageSize)
+	extend := func() error {
+		return c.Conn.SetReadDeadline(time.Now().Add(hb.PongTimeout))
+	}
+	_ = extend()
+	c.Conn.SetPongHandler(func(string) error { return extend() })
+
 	for {
-		_, _, err := c.Conn.ReadMessage()
+		_, data, err := c.Conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				log.Printf("[Client] Heartbeat timeout for session %s", c.SessionID)
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("[Client] Read error: %v", err)
 			}
 			break
 		}
-		// Currently, we don't process incoming messages from clients
-		// All events are server-initiated
+		_ = extend()
+		hub.dispatch(c, data)
 	}
 }
 
-// WritePump handles outgoing messages to the client
-func (c *Client) WritePump() {
+// WritePump handles outgoing messages to the client, compressing large ones,
+// and pings it every ping interval so idle connections stay open through proxies
+func (c *Client) WritePump(hub *Hub) {
+	hb := hub.Heartbeat()
+	minCompressed := hub.Compression().MinSize
+	ticker := time.NewTicker(hb.PingInterval)
 	defer func() {
+		ticker.Stop()
 		c.Conn.Close()
 	}()
 
-	for message := range c.Send {
-		data, err := json.Marshal(message)
-		if err != nil {
-			log.Printf("[Client] Failed to marshal message: %v", err)
-			continue
-		}
+	for {
+		select {
+		case message, ok := <-c.Send:
+			_ = c.Conn.SetWriteDeadline(time.Now().Add(hb.WriteTimeout))
+			if !ok {
+				// The hub closed the channel (unregistered or replaced)
+				_ = c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
 
-		if err := c.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
-			log.Printf("[Client] Write error: %v", err)
-			return
+			data, err := json.Marshal(message)
+			if err != nil {
+				log.Printf("[Client] Failed to marshal message: %v", err)
+				continue
+			}
+
+			// Deflating small messages costs more than it saves; this is a no-op
+			// when the client did not negotiate compression
+			c.Conn.EnableWriteCompression(len(data) >= minCompressed)
+			if err := c.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				log.Printf("[Client] Write error: %v", err)
+				return
+			}
+
+		case <-ticker.C:
+			if err := c.Conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(hb.WriteTimeout)); err != nil {
+				log.Printf("[Client] Ping failed for session %s: %v", c.SessionID, err)
+				return
+			}
 		}
 	}
 }
@@ -0,0 +1,139 @@
+package websocket
+
+import "time"
+
+// Replay buffer defaults; see Hub.SetReplay
+const (
+	DefaultReplaySize   = 128
+	DefaultReplayWindow = 5 * time.Minute
+)
+
+// bufferedEvent is a session event kept for reconnecting clients
+type bufferedEvent struct {
+	message Message
+	at      time.Time
+}
+
+// replayBuffer holds the most recent events of one session. seq is the
+// sequence number of the last event emitted to the session.
+type replayBuffer struct {
+	seq    uint64
+	events []bufferedEvent
+
+	pending map[uint64]*pendingAck // Critical events awaiting acknowledgement, by seq
+}
+
+// add appends an event and drops events past the size limit or time window
+func (b *replayBuffer) add(message Message, now time.Time, size int, window time.Duration) {
+	b.events = append(b.events, bufferedEvent{message: message, at: now})
+
+	drop := 0
+	if len(b.events) > size {
+		drop = len(b.events) - size
+	}
+	for drop < len(b.events) && now.Sub(b.events[drop].at) > window {
+		drop++
+	}
+	if drop > 0 {
+		b.events = append(b.events[:0:0], b.events[drop:]...)
+	}
+}
+
+// since returns the buffered events after seq, and whether they are all the
+// client missed. A client ahead of the buffer (e.g. after a server restart)
+// gets every buffered event and complete=false.
+func (b *replayBuffer) since(seq uint64) ([]Message, bool) {
+	if b == nil {
+		return nil, seq == 0
+	}
+	if seq > b.seq {
+		out := make([]Message, 0, len(b.events))
+		for _, e := range b.events {
+			out = append(out, e.message)
+		}
+		return out, false
+	}
+
+	complete := true
+	if len(b.events) == 0 {
+		complete = seq == b.seq
+	} else if first := b.events[0].message.Seq; seq+1 < first {
+		complete = false
+	}
+
+	var out []Message
+	for _, e := range b.events {
+		if e.message.Seq > seq {
+			out = append(out, e.message)
+		}
+	}
+	return out, complete
+}
+
+// SetReplay configures how many recent events per session (size) and for how
+// long (window) are kept for reconnecting clients. Zero values keep the
+// current setting.
+func (h *Hub) SetReplay(size int, window time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if size > 0 {
+		h.replaySize = size
+	}
+	if window > 0 {
+		h.replayWindow = window
+	}
+}
+
+// ReplaySize returns the per-session replay buffer size
+func (h *Hub) ReplaySize() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.replaySize
+}
+
+// ForgetSession drops the buffered events of a session (e.g. when it is deleted)
+func (h *Hub) ForgetSession(sessionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.replay, sessionID)
+	delete(h.drops, sessionID)
+}
+
+// replayTo queues the events a resuming client missed, followed by a
+// ws://replayed summary. Callers hold h.mu.
+func (h *Hub) replayTo(client *Client) {
+	missed, complete := h.replay[client.SessionID].since(client.Since)
+
+	buf := h.replay[client.SessionID]
+	now := time.Now()
+	replayed, skipped := 0, 0
+	for _, message := range missed {
+		if !client.wants(message.Event) {
+			skipped++
+			continue
+		}
+		if message.AckRequired && client.Acks {
+			if _, tracked := buf.pending[message.Seq]; !tracked {
+				h.track(buf, message, now)
+			}
+		}
+		select {
+		case client.Send <- message:
+			replayed++
+		default:
+			complete = false
+		}
+	}
+
+	select {
+	case client.Send <- newMessage(client.SessionID, Replayed{
+		Since:    client.Since,
+		Replayed: replayed,
+		Complete: complete && replayed+skipped == len(missed),
+	}):
+	default:
+	}
+}
@@ -0,0 +1,90 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ServeSSE streams the client's events as Server-Sent Events until the request
+// ends or the hub drops the client, then unregisters it. The client must be
+// registered and have no Conn. Events carry their sequence number as the SSE
+// id, so a reconnecting EventSource resumes through Last-Event-ID. A comment
+// line is sent every ping interval to keep proxies from closing idle streams.
+func (c *Client) ServeSSE(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	defer hub.Unregister(c)
+
+	hb := hub.Heartbeat()
+	rc := http.NewResponseController(w)
+	ticker := time.NewTicker(hb.PingInterval)
+	defer ticker.Stop()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // Disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+
+	write := func(frame []byte) bool {
+		if err := rc.SetWriteDeadline(time.Now().Add(hb.WriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return false
+		}
+		if _, err := w.Write(frame); err != nil {
+			log.Printf("[SSE] Write error for session %s: %v", c.SessionID, err)
+			return false
+		}
+		if err := rc.Flush(); err != nil {
+			log.Printf("[SSE] Flush error for session %s: %v", c.SessionID, err)
+			return false
+		}
+		return true
+	}
+
+	// Tell EventSource how long to wait before reconnecting
+	if !write([]byte("retry: 2000\n\n")) {
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case message, ok := <-c.Send:
+			if !ok {
+				// The hub closed the channel (unregistered or replaced)
+				return
+			}
+			data, err := json.Marshal(message)
+			if err != nil {
+				log.Printf("[SSE] Failed to marshal message: %v", err)
+				continue
+			}
+			if !write(sseFrame(message, data)) {
+				return
+			}
+
+		case <-ticker.C:
+			if !write([]byte(": ping\n\n")) {
+				return
+			}
+		}
+	}
+}
+
+// sseFrame formats one event. The frame has no "event:" field, so an
+// EventSource's onmessage sees every event; the envelope names it. JSON has
+// no raw newlines, so the data fits on one line.
+func sseFrame(message Message, data []byte) []byte {
+	var frame []byte
+	if message.Seq > 0 {
+		frame = fmt.Appendf(frame, "id: %d\n", message.Seq)
+	}
+	frame = append(frame, "data: "...)
+	frame = append(frame, data...)
+	return append(frame, '\n', '\n')
+}
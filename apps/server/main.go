@@ -1,31 +1,58 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/grpc-bridge/server/internal/audit"
+	"github.com/grpc-bridge/server/internal/auth"
+	"github.com/grpc-bridge/server/internal/callstats"
+	"github.com/grpc-bridge/server/internal/collection"
+	"github.com/grpc-bridge/server/internal/config"
+	"github.com/grpc-bridge/server/internal/database"
+	"github.com/grpc-bridge/server/internal/gitsource"
 	"github.com/grpc-bridge/server/internal/grpc"
 	"github.com/grpc-bridge/server/internal/handler"
+	"github.com/grpc-bridge/server/internal/history"
 	"github.com/grpc-bridge/server/internal/middleware"
+	"github.com/grpc-bridge/server/internal/objectstore"
+	"github.com/grpc-bridge/server/internal/payload"
+	"github.com/grpc-bridge/server/internal/proto"
+	"github.com/grpc-bridge/server/internal/secret"
 	"github.com/grpc-bridge/server/internal/session"
 	"github.com/grpc-bridge/server/internal/static"
+	"github.com/grpc-bridge/server/internal/tlsconfig"
+	"github.com/grpc-bridge/server/internal/tracing"
 	"github.com/grpc-bridge/server/internal/websocket"
 )
 
+// version is reported to clients; set it at build time with
+// -ldflags "-X main.version=1.2.3"
+var version = "dev"
+
+// shutdownNoticeDelay gives sockets time to deliver the shutdown notice
+const shutdownNoticeDelay = time.Second
+
 func main() {
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8800"
-	}
+	runCommand(os.Args[1:])
+}
+
+// serve runs the bridge with the loaded configuration until it is stopped
+func serve(cfg *config.Config) {
+	port := strconv.Itoa(cfg.Server.Port)
 
 	// Initialize upload directory (use absolute path)
-	uploadDir := "./uploads"
-	if dir := os.Getenv("UPLOAD_DIR"); dir != "" {
-		uploadDir = dir
-	}
-	
+	uploadDir := cfg.Uploads.Dir
+
 	// Convert to absolute path
 	absUploadDir, err := os.Getwd()
 	if err != nil {
@@ -34,77 +61,749 @@ func main() {
 	if uploadDir[0] == '.' {
 		uploadDir = absUploadDir + uploadDir[1:]
 	}
-	
+
 	// Ensure upload directory exists
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
 		log.Fatalf("Failed to create upload directory: %v", err)
 	}
-	
+
 	log.Printf("Upload directory: %s", uploadDir)
 
+	// Readiness checks are registered as the dependencies they cover are set up
+	healthHandler := handler.NewHealthHandler(version)
+	healthHandler.AddCheck("upload_dir", handler.CheckWritableDir(uploadDir))
+
 	// Initialize services
 	sessionManager := session.NewManager(uploadDir)
-	grpcProxy := grpc.NewProxy()
+
+	// Encrypt stored credentials (sensitive metadata and variables) with
+	// SECRET_KEY, or the key in SECRET_KEY_FILE as mounted by a KMS or secret
+	// manager: 64 hex characters or base64 of 32 bytes
+	secretKey := cfg.Sessions.SecretKey
+	if path := cfg.Sessions.SecretKeyFile; path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read SECRET_KEY_FILE: %v", err)
+		}
+		secretKey = string(data)
+	}
+	var cipher *secret.Cipher
+	if secretKey != "" {
+		key, err := secret.ParseKey(secretKey)
+		if err != nil {
+			log.Fatalf("Invalid secret key: %v", err)
+		}
+		if cipher, err = secret.NewCipher(key); err != nil {
+			log.Fatalf("Invalid secret key: %v", err)
+		}
+		sessionManager.SetCipher(cipher)
+		log.Printf("Stored credentials: encrypted")
+	}
+
+	// Persist session records so uploaded trees survive restarts. SESSION_STORE_FILE
+	// overrides the location; "off" keeps sessions in memory only.
+	storeFile := cfg.Sessions.StoreFile
+	if storeFile == "" {
+		storeFile = filepath.Join(uploadDir, "sessions.json")
+	}
+	if storeFile != "off" {
+		restored, err := sessionManager.UseStore(session.NewFileStore(storeFile))
+		if err != nil {
+			log.Fatalf("Failed to load sessions: %v", err)
+		}
+		log.Printf("Session store: %s (%d session(s) restored)", storeFile, restored)
+	}
+
+	// Sessions expire after SESSION_IDLE_TIMEOUT without use (Go duration, default 24h)
+	sessionManager.SetIdleTimeout(cfg.Sessions.IdleTimeout.Std())
+
+	// Archive expired sessions to object storage instead of deleting them:
+	// ARCHIVE_URL is s3://bucket/prefix, gs://bucket/prefix or a local directory
+	if archive := cfg.Storage.Archive; archive.URL != "" {
+		archiveStore, err := objectstore.Open(archive.URL, objectstore.Options{
+			Endpoint: archive.Endpoint,
+			Region:   archive.Region,
+			Credentials: objectstore.Credentials{
+				AccessKey: archive.AccessKey,
+				SecretKey: archive.SecretKey,
+			},
+		})
+		if err != nil {
+			log.Fatalf("Invalid ARCHIVE_URL: %v", err)
+		}
+		sessionManager.SetArchiveStore(archiveStore)
+		healthHandler.AddCheck("archive", func(ctx context.Context) error {
+			return objectstore.Ping(ctx, archiveStore)
+		})
+		log.Printf("Session archive: %s", archiveStore)
+	}
+
+	// Per-session storage limits; 0 disables a limit
+	sessionManager.SetQuota(session.Quota{
+		MaxFiles: cfg.Sessions.MaxFiles,
+		MaxBytes: cfg.Sessions.MaxBytes,
+	})
+
+	// Global storage quota: when the upload directory grows past
+	// STORAGE_MAX_BYTES, the least recently used sessions are evicted, checked
+	// every STORAGE_CHECK_INTERVAL (default 5m)
+	sessionManager.SetStorageQuota(cfg.Storage.MaxBytes)
+	if maxStorage := sessionManager.StorageQuota(); maxStorage > 0 {
+		interval := cfg.Storage.CheckInterval.Std()
+		sessionManager.WatchStorageQuota(interval)
+		log.Printf("Storage quota: %d bytes, checked every %s", maxStorage, interval)
+	}
+
+	grpcClient, err := grpc.NewClient(cfg.GRPC.Backend)
+	if err != nil {
+		log.Fatalf("Failed to initialize gRPC backend: %v", err)
+	}
+	log.Printf("gRPC backend: %T", grpcClient)
+
+	// Compiled descriptors are cached for the DESCRIPTOR_CACHE_SIZE most
+	// recently used sessions (default 64, 0 for unlimited)
+	grpc.SetDescriptorCacheSize(cfg.GRPC.DescriptorCacheSize)
+
+	// Target connections are shared between calls and closed after
+	// GRPC_CONN_IDLE_TIMEOUT without use (default 5m, 0 to close them after
+	// each call); at most GRPC_CONN_CACHE_SIZE are kept (default 256)
+	grpc.SetConnIdleTimeout(cfg.GRPC.ConnIdleTimeout.Std())
+	grpc.SetConnCacheSize(cfg.GRPC.ConnCacheSize)
+	if grpcurl, ok := grpcClient.(*grpc.GrpcurlClient); ok {
+		healthHandler.AddCheck("grpcurl", func(context.Context) error {
+			return grpcurl.Ready()
+		})
+	}
+
+	// Restrict the targets the bridge may dial: GRPC_TARGET_ALLOW and
+	// GRPC_TARGET_DENY are comma-separated hosts, *.domains, IPs or CIDRs,
+	// optionally with a port; GRPC_TARGET_BLOCK_PRIVATE=true denies private
+	// and loopback addresses that are not explicitly allowed
+	blockPrivate := cfg.GRPC.BlockPrivateTargets
+	targetPolicy, err := grpc.NewTargetPolicy(cfg.GRPC.TargetAllow, cfg.GRPC.TargetDeny, blockPrivate)
+	if err != nil {
+		log.Fatalf("Invalid gRPC target rules: %v", err)
+	}
+	if targetPolicy.Restricted() {
+		grpc.SetTargetPolicy(targetPolicy)
+		log.Printf("gRPC targets: restricted (allow: %q, deny: %q, block private: %t)", strings.Join(cfg.GRPC.TargetAllow, ","), strings.Join(cfg.GRPC.TargetDeny, ","), blockPrivate)
+	}
+
+	// Restrict the repositories proto imports may clone the same way:
+	// GIT_HOST_ALLOW, GIT_HOST_DENY and GIT_BLOCK_PRIVATE take the same rules
+	// as the gRPC targets, GIT_ALLOW_SSH=true also accepts ssh:// urls and
+	// GIT_MAX_CHECKOUT_SIZE stops clones growing past it
+	gitHosts, err := grpc.NewTargetPolicy(cfg.Git.HostAllow, cfg.Git.HostDeny, cfg.Git.BlockPrivate)
+	if err != nil {
+		log.Fatalf("Invalid git host rules: %v", err)
+	}
+	gitPolicy := gitsource.Policy{AllowSSH: cfg.Git.AllowSSH, MaxCheckoutSize: cfg.Git.MaxCheckoutSize}
+	if gitHosts.Restricted() {
+		gitPolicy.Hosts = gitHosts
+		log.Printf("Git hosts: restricted (allow: %q, deny: %q, block private: %t)", strings.Join(cfg.Git.HostAllow, ","), strings.Join(cfg.Git.HostDeny, ","), cfg.Git.BlockPrivate)
+	}
+	gitsource.SetPolicy(gitPolicy)
+
+	// GRPC_FORBID_PLAINTEXT=true rejects plaintext calls, so only TLS targets
+	// are ever contacted
+	if cfg.GRPC.ForbidPlaintext {
+		grpc.ForbidPlaintext(true)
+		log.Printf("gRPC plaintext calls: forbidden")
+	}
+
+	// Call history and collections live in an embedded SQLite database, so
+	// they survive restarts. DATABASE_FILE overrides the location; "off" keeps
+	// them in memory only.
+	var historyStore history.Store = history.NewMemoryStore(1000)
+	var collectionStore collection.Store = collection.NewMemoryStore()
+	databaseFile := cfg.Storage.DatabaseFile
+	if databaseFile == "off" {
+		log.Printf("Database: off; history and collections are kept in memory")
+	} else {
+		if databaseFile == "" {
+			databaseFile = filepath.Join(uploadDir, "grpc-bridge.db")
+		}
+		db, err := database.Open(databaseFile)
+		if err != nil {
+			log.Fatalf("Failed to open database: %v", err)
+		}
+		sqlHistory := history.NewSQLStore(db, 1000)
+		sqlHistory.SetCipher(cipher)
+		historyStore = sqlHistory
+		sqlCollections := collection.NewSQLStore(db)
+		sqlCollections.SetCipher(cipher)
+		collectionStore = sqlCollections
+		healthHandler.AddCheck("database", db.PingContext)
+		log.Printf("Database: %s", databaseFile)
+	}
+	auditStore := audit.NewMemoryStore(1000)
+
+	// Drop per-session state held outside the session manager when a session is deleted or expires
+	sessionManager.OnDelete(grpcClient.ClearCache)
+	sessionManager.OnProtoChange(grpcClient.ClearCache)
+	sessionManager.OnDelete(func(sessionID string) {
+		_ = historyStore.Clear(sessionID)
+		_ = auditStore.Clear(sessionID)
+		_ = collectionStore.DeleteSession(sessionID)
+	})
+
 	wsHub := websocket.NewHub()
 
-	// Create Gin router
-	router := gin.Default()
+	// Push descriptor compilation progress to the compiling session
+	grpc.OnParseProgress(func(sessionID string, p grpc.ParseProgress) {
+		wsHub.EmitToSession(sessionID, handler.ParseProgress{ParseProgress: p})
+	})
+
+	// Server status: every client is greeted with the version and maintenance
+	// state, and told when the server stops
+	statusHandler := handler.NewStatusHandler(wsHub, version)
+	wsHub.SetWelcome(statusHandler.Status)
+
+	// OpenTelemetry tracing: spans are exported over OTLP when
+	// OTEL_EXPORTER_OTLP_ENDPOINT (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) is set
+	shutdownTracing, tracingEnabled, err := tracing.Setup(context.Background(), version)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	if tracingEnabled {
+		log.Printf("Exporting traces over OTLP")
+	}
+
+	// On SIGINT/SIGTERM, announce the shutdown and write pending session changes
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-signals
+		log.Printf("Received %s, shutting down", sig)
+		healthHandler.Drain()
+		statusHandler.AnnounceShutdown(sig.String())
+		time.Sleep(shutdownNoticeDelay)
+		if err := sessionManager.Flush(); err != nil {
+			log.Printf("Failed to persist sessions: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+		cancel()
+		os.Exit(0)
+	}()
+
+	// Per-endpoint call statistics cover the last CALL_STATS_WINDOW (default
+	// 5m) and are pushed as stats://update events every CALL_STATS_INTERVAL
+	// (default 10s) while they change
+	callStats := callstats.NewTracker(cfg.GRPC.CallStatsWindow.Std())
+	callStats.Run(cfg.GRPC.CallStatsInterval.Std(), func(sessionID string, snap callstats.Snapshot) {
+		wsHub.SendToSession(sessionID, handler.StatsUpdate{Snapshot: snap})
+	})
+	sessionManager.OnDelete(callStats.Forget)
+
+	// WebSocket keepalive: the server pings every WS_PING_INTERVAL (default 30s)
+	// and drops clients silent for WS_PONG_TIMEOUT (default 60s)
+	heartbeat := wsHub.Heartbeat()
+	heartbeat.PingInterval = cfg.WebSocket.PingInterval.Std()
+	heartbeat.PongTimeout = cfg.WebSocket.PongTimeout.Std()
+	wsHub.SetHeartbeat(heartbeat)
+
+	// Missed-event replay: the last WS_REPLAY_BUFFER events (default 128) of
+	// each session, up to WS_REPLAY_WINDOW old (default 5m), are kept for
+	// clients reconnecting with ?since=<seq>
+	wsHub.SetReplay(cfg.WebSocket.ReplayBuffer, cfg.WebSocket.ReplayWindow.Std())
+	sessionManager.OnDelete(wsHub.ForgetSession)
+
+	// permessage-deflate: offered to clients unless WS_COMPRESSION=false;
+	// messages from WS_COMPRESSION_MIN_SIZE bytes (default 1024) are compressed
+	// at WS_COMPRESSION_LEVEL (1-9, default 1)
+	compression := wsHub.Compression()
+	compression.Enabled = cfg.WebSocket.Compression
+	compression.Level = cfg.WebSocket.CompressionLevel
+	compression.MinSize = cfg.WebSocket.CompressionMinSize
+	wsHub.SetCompression(compression)
+
+	// Critical events sent to clients connected with ?acks=true are resent
+	// every WS_ACK_TIMEOUT (default 2s) until acknowledged, up to
+	// WS_ACK_RETRIES sends in total (default 5)
+	wsHub.SetAcks(cfg.WebSocket.AckTimeout.Std(), cfg.WebSocket.AckRetries)
+
+	// Slow clients whose send buffer is full: WS_BACKPRESSURE=drop-oldest
+	// (default) discards their oldest queued message, disconnect drops them
+	backpressure, err := websocket.ParseBackpressurePolicy(cfg.WebSocket.Backpressure)
+	if err != nil {
+		log.Fatalf("Invalid WS_BACKPRESSURE: %v", err)
+	}
+	wsHub.SetBackpressure(backpressure)
+
+	// Shared proto libraries: COMMON_PROTO_DIRS is a comma-separated list of
+	// directories, optionally prefixed with "name=" (e.g. "acme=/srv/protos/common")
+	stdlibManager := proto.NewStdlibManager()
+	var commonProtoDirs []string
+	for _, entry := range cfg.GRPC.CommonProtoDirs {
+		name, dir, found := strings.Cut(entry, "=")
+		if !found {
+			name, dir = "", entry
+		}
+		lib, err := stdlibManager.AddSharedLibrary(name, dir)
+		if err != nil {
+			log.Fatalf("Failed to register shared proto library: %v", err)
+		}
+		commonProtoDirs = append(commonProtoDirs, lib.Path)
+		log.Printf("Shared proto library %q: %s (%d files)", lib.Name, lib.Path, len(lib.Files))
+	}
+
+	// Shared stdlib tree: the embedded standard library is extracted once to
+	// STDLIB_DIR (default <UPLOAD_DIR>/.stdlib), kept read-only and used as an
+	// import path instead of being copied into every session. "off" copies it
+	// into each session directory.
+	stdlibDir := cfg.Storage.StdlibDir
+	if stdlibDir == "" {
+		stdlibDir = filepath.Join(uploadDir, ".stdlib")
+	}
+	if stdlibDir != "off" {
+		dir, err := stdlibManager.ExtractShared(stdlibDir)
+		if err != nil {
+			log.Fatalf("Failed to extract standard library protos: %v", err)
+		}
+		proto.SetStdlibDir(dir)
+		log.Printf("Shared stdlib: %s", dir)
+	}
+
+	// Upload directories without a session are adopted when sessions are
+	// persisted, or removed once older than ORPHAN_GRACE_PERIOD (default 1h).
+	// Copied stdlib and shared library files are not listed in adopted sessions.
+	orphanOptions := session.OrphanOptions{Grace: cfg.Sessions.OrphanGracePeriod.Std()}
+	orphanOptions.SharedFiles = stdlibManager.ListAvailableFiles
+	sessionManager.ReconcileOrphans(orphanOptions)
+
+	// Stdlib copies made before the shared tree existed are garbage collected
+	if proto.StdlibDir() != "" {
+		pruned := 0
+		for root, files := range sessionManager.RootFiles() {
+			n, err := stdlibManager.PruneCopies(root, files)
+			if err != nil {
+				log.Printf("Failed to prune stdlib copies in %s: %v", root, err)
+			}
+			pruned += n
+		}
+		if pruned > 0 {
+			log.Printf("Removed %d stdlib copies from session directories", pruned)
+		}
+	}
+
+	// Remote sources for fetching missing well-known imports: REMOTE_PROTO_SOURCES
+	// is a comma-separated list of "prefix=baseURL" entries that extend or
+	// override the defaults (e.g. a googleapis mirror)
+	remoteSources := append([]proto.RemoteSource{}, proto.DefaultRemoteSources...)
+	for _, entry := range cfg.GRPC.RemoteProtoSources {
+		prefix, baseURL, found := strings.Cut(entry, "=")
+		if !found || prefix == "" || baseURL == "" {
+			log.Fatalf("Invalid REMOTE_PROTO_SOURCES entry %q (expected prefix=baseURL)", entry)
+		}
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		remoteSources = append([]proto.RemoteSource{{Prefix: prefix, BaseURL: baseURL}}, remoteSources...)
+	}
+	importFetcher := proto.NewImportFetcher(remoteSources)
+
+	// API authentication: AUTH_MODE is none (default), basic or token. Basic
+	// mode reads "user:password" pairs from AUTH_USERS, token mode reads
+	// "token=user" pairs from AUTH_TOKENS (both comma-separated). Sessions
+	// created by an authenticated user are only visible to that user.
+	users, err := auth.ParseCredentials(strings.Join(cfg.Auth.Users, ","), ":")
+	if err != nil {
+		log.Fatalf("Invalid AUTH_USERS: %v", err)
+	}
+	tokens, err := auth.ParseCredentials(strings.Join(cfg.Auth.Tokens, ","), "=")
+	if err != nil {
+		log.Fatalf("Invalid AUTH_TOKENS: %v", err)
+	}
+	authenticator, err := auth.New(auth.Mode(strings.ToLower(cfg.Auth.Mode)), users, tokens)
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
+
+	// OIDC login (AUTH_MODE=oidc): OIDC_ISSUER_URL, OIDC_CLIENT_ID and
+	// OIDC_CLIENT_SECRET identify the client at the provider, OIDC_REDIRECT_URL
+	// is this server's /auth/callback as registered there. The frontend and
+	// API then require a login; users are named by the OIDC_USER_CLAIM claim.
+	if authenticator.Mode() == auth.ModeOIDC {
+		oidc := cfg.Auth.OIDC
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		provider, err := auth.NewOIDCProvider(ctx, auth.OIDCConfig{
+			IssuerURL:    oidc.IssuerURL,
+			ClientID:     oidc.ClientID,
+			ClientSecret: oidc.ClientSecret,
+			RedirectURL:  oidc.RedirectURL,
+			Scopes:       strings.Fields(strings.Join(oidc.Scopes, " ")),
+			UserClaim:    oidc.UserClaim,
+		})
+		cancel()
+		if err != nil {
+			log.Fatalf("Failed to configure OIDC: %v", err)
+		}
+		authenticator.SetOIDC(provider, auth.NewCookieSigner([]byte(oidc.CookieSecret)), oidc.SessionTTL.Std())
+		log.Printf("OIDC issuer: %s", provider.Issuer())
+	}
+
+	// API keys for scripted access (X-API-Key header): API_KEYS is a
+	// comma-separated list of keys, each optionally bound to a user as "key=user"
+	if apiKeys := auth.ParseAPIKeys(strings.Join(cfg.Auth.APIKeys, ",")); len(apiKeys) > 0 {
+		authenticator.SetAPIKeys(apiKeys)
+		log.Printf("API keys: %d configured", len(apiKeys))
+	}
+	log.Printf("Authentication: %s", authenticator.Mode())
+
+	// Debug endpoints (/debug/pprof and /debug/runtime) are served when
+	// DEBUG_ENDPOINTS is true. With authentication on, only the users listed
+	// in ADMIN_USERS (comma-separated) may use them and the /api/admin routes.
+	debugEndpoints := cfg.Server.DebugEndpoints
+	adminUsers := cfg.Auth.AdminUsers
+	if authenticator.Enabled() && len(adminUsers) == 0 {
+		log.Printf("[Warning] ADMIN_USERS is empty; admin routes and debug endpoints will refuse every request")
+	}
+
+	// Event stream tokens: /api/ws and /api/events need a token minted for the
+	// session (or an API key). WS_TOKEN_SECRET signs them; set it when several
+	// instances serve the same sessions. Tokens expire after WS_TOKEN_TTL
+	// (default 5m).
+	sessionTokens := auth.NewSessionTokens([]byte(cfg.Auth.WSTokenSecret), cfg.Auth.WSTokenTTL.Std())
+
+	// Signed download URLs fetch session files with a plain GET, without
+	// credentials. DOWNLOAD_URL_SECRET signs them (set it when several
+	// instances serve the same sessions); they live at most DOWNLOAD_URL_TTL
+	// (default 15m).
+	urlSigner := auth.NewURLSigner([]byte(cfg.Auth.DownloadURLSecret), cfg.Auth.DownloadURLTTL.Std())
+	authenticator.SetURLSigner(urlSigner)
+
+	// Rate limits for calls and uploads: RATE_LIMIT_SESSION and RATE_LIMIT_IP
+	// are requests per second per session and per client IP (0, the default,
+	// disables a limit); RATE_LIMIT_BURST is the burst size (default: the rate)
+	var sessionLimit, ipLimit *middleware.RateLimiter
+	if rate := cfg.RateLimit.Session; rate > 0 {
+		sessionLimit = middleware.NewRateLimiter(rate, cfg.RateLimit.Burst)
+		log.Printf("Rate limit: RATE_LIMIT_SESSION=%g/s", rate)
+	}
+	if rate := cfg.RateLimit.IP; rate > 0 {
+		ipLimit = middleware.NewRateLimiter(rate, cfg.RateLimit.Burst)
+		log.Printf("Rate limit: RATE_LIMIT_IP=%g/s", rate)
+	}
+	rateLimit := middleware.RateLimit(sessionLimit, ipLimit,
+		"/api/grpc/", "/api/proto/upload-", "/api/proto/import-git", "/api/proto/save-reflection", "/api/sessions/import")
+
+	// Upload size limits: UPLOAD_MAX_FILE_SIZE caps each proto file of a
+	// directory upload, UPLOAD_MAX_REQUEST_SIZE the whole upload request, and
+	// multipart parts beyond UPLOAD_MAX_MEMORY bytes are buffered on disk
+	uploadLimits := handler.UploadLimits{
+		MaxFileSize:    cfg.Uploads.MaxFileSize,
+		MaxRequestSize: cfg.Uploads.MaxRequestSize,
+	}
+	multipartMemory := cfg.Uploads.MaxMemory
+
+	// Chunked uploads idle for longer than UPLOAD_CHUNK_TTL (default 1h) are discarded
+	chunkedUploadTTL := cfg.Uploads.ChunkTTL.Std()
+
+	// HTTP connections are counted for the runtime statistics
+	httpConns := &handler.HTTPConns{}
+
+	// Access log: ACCESS_LOG_FORMAT is text (default), json, combined or off;
+	// ACCESS_LOG_FIELDS picks the fields of json lines (default: all), and
+	// ACCESS_LOG_SAMPLE_RATE logs that share of successful requests (errors
+	// are always logged). json and combined lines go to stdout.
+	accessLogFields, err := middleware.ParseAccessLogFields(strings.Join(cfg.AccessLog.Fields, ","))
+	if err != nil {
+		log.Fatalf("Invalid ACCESS_LOG_FIELDS: %v", err)
+	}
+	accessLog := middleware.AccessLogConfig{
+		Format:     cfg.AccessLog.Format,
+		Fields:     accessLogFields,
+		SampleRate: cfg.AccessLog.SampleRate,
+		Output:     os.Stdout,
+	}
+
+	// Create Gin router; the access log replaces Gin's default logger
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.MaxMultipartMemory = multipartMemory
 
 	// Apply middleware
 	router.SetTrustedProxies([]string{"127.0.0.1"})
-	router.Use(middleware.CORS())
-	router.Use(middleware.Logger())
+	// Browser origins allowed to call the API: CORS_ALLOWED_ORIGINS is a
+	// comma-separated list of origins (default "*", any origin)
+	router.Use(middleware.CORS(cfg.CORS.AllowedOrigins))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.AccessLog(accessLog))
+	router.Use(middleware.Tracing())
+
+	// OIDC login routes
+	authHandler := handler.NewAuthHandler(authenticator)
+	router.GET(auth.LoginPath, authHandler.Login)
+	router.GET(auth.CallbackPath, authHandler.Callback)
+	router.POST(auth.LogoutPath, authHandler.Logout)
 
 	// API routes
-	api := router.Group("/api")
+	api := router.Group("/api", middleware.BodyLimit(uploadLimits.MaxRequestSize, "/api/proto/upload-", "/api/proto/import-git", "/api/proto/save-reflection", "/api/sessions/import"), middleware.Auth(authenticator, "/api/health", "/api/health/live", "/api/health/ready", "/api/openapi.json", "/api/openapi.yaml"), middleware.SessionAccess(sessionManager), rateLimit, middleware.SessionActivity(sessionManager), middleware.Audit(auditStore, sessionManager))
 	{
-		// Health check
-		api.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"status":  "ok",
-				"service": "grpc-bridge-web-api",
-			})
-		})
+		// Health checks: /health and /health/live report that the process is
+		// up, /health/ready that its dependencies are usable
+		api.GET("/health", healthHandler.Live)
+		api.GET("/health/live", healthHandler.Live)
+		api.GET("/health/ready", healthHandler.Ready)
+
+		// OpenAPI document of this API, for generating clients
+		apiDocHandler := handler.NewAPIDocHandler(version, authenticator.Enabled(), router.Routes)
+		api.GET("/openapi.json", apiDocHandler.GetOpenAPIJSON)
+		api.GET("/openapi.yaml", apiDocHandler.GetOpenAPIYAML)
+
+		// Current user
+		api.GET("/me", authHandler.Me)
 
 		// WebSocket route
-		wsHandler := handler.NewWebSocketHandler(wsHub)
+		wsHandler := handler.NewWebSocketHandler(wsHub, authenticator, sessionTokens)
 		api.GET("/ws", wsHandler.HandleConnection)
+		api.GET("/events", wsHandler.HandleEvents) // Server-Sent Events fallback
 
 		// Session routes
-		sessionHandler := handler.NewSessionHandler(sessionManager)
+		sessionHandler := handler.NewSessionHandler(sessionManager, sessionTokens)
 		api.POST("/sessions", sessionHandler.CreateSession)
 		api.GET("/sessions/:sessionId", sessionHandler.GetSession)
+		api.PATCH("/sessions/:sessionId", sessionHandler.UpdateSession)
 		api.DELETE("/sessions/:sessionId", sessionHandler.DeleteSession)
+		api.POST("/sessions/bulk-delete", middleware.RequireAdmin(authenticator, adminUsers), sessionHandler.BulkDeleteSessions)
+		api.POST("/sessions/:sessionId/restore", sessionHandler.RestoreSession)
+		api.POST("/sessions/:sessionId/ws-token", sessionHandler.IssueWSToken)
+
+		// Session variable routes (used for {{var}} templating)
+		variableHandler := handler.NewVariableHandler(sessionManager)
+		api.GET("/sessions/:sessionId/variables", variableHandler.ListVariables)
+		api.PUT("/sessions/:sessionId/variables", variableHandler.ReplaceVariables)
+		api.PUT("/sessions/:sessionId/variables/:name", variableHandler.SetVariable)
+		api.DELETE("/sessions/:sessionId/variables/:name", variableHandler.DeleteVariable)
+
+		// Session default metadata routes (merged into every call)
+		metadataHandler := handler.NewMetadataHandler(sessionManager)
+		api.GET("/sessions/:sessionId/metadata", metadataHandler.ListMetadata)
+		api.PUT("/sessions/:sessionId/metadata", metadataHandler.ReplaceMetadata)
+		api.PUT("/sessions/:sessionId/metadata/:key", metadataHandler.SetMetadata)
+		api.DELETE("/sessions/:sessionId/metadata/:key", metadataHandler.DeleteMetadata)
+
+		// Session environment routes (per-environment target, metadata and variables)
+		environmentHandler := handler.NewEnvironmentHandler(sessionManager)
+		api.GET("/sessions/:sessionId/environments", environmentHandler.ListEnvironments)
+		api.GET("/sessions/:sessionId/environments/:name", environmentHandler.GetEnvironment)
+		api.PUT("/sessions/:sessionId/environments/:name", environmentHandler.PutEnvironment)
+		api.DELETE("/sessions/:sessionId/environments/:name", environmentHandler.DeleteEnvironment)
+
+		// Target preset routes (saved server addresses and transport settings)
+		targetHandler := handler.NewTargetHandler(sessionManager)
+		api.GET("/sessions/:sessionId/targets", targetHandler.ListTargets)
+		api.POST("/sessions/:sessionId/targets", targetHandler.CreateTarget)
+		api.GET("/sessions/:sessionId/targets/:targetId", targetHandler.GetTarget)
+		api.PUT("/sessions/:sessionId/targets/:targetId", targetHandler.UpdateTarget)
+		api.DELETE("/sessions/:sessionId/targets/:targetId", targetHandler.DeleteTarget)
+
+		// Import path mapping routes
+		importMappingHandler := handler.NewImportMappingHandler(sessionManager)
+		api.GET("/sessions/:sessionId/import-mappings", importMappingHandler.ListImportMappings)
+		api.PUT("/sessions/:sessionId/import-mappings", importMappingHandler.ReplaceImportMappings)
+
+		// Call history routes
+		historyHandler := handler.NewHistoryHandler(sessionManager, historyStore, version)
+		api.GET("/sessions/:sessionId/history", historyHandler.ListHistory)
+		api.GET("/sessions/:sessionId/history/export", historyHandler.ExportHistory)
+		api.GET("/sessions/:sessionId/history/:historyId", historyHandler.GetHistoryEntry)
+		api.GET("/sessions/:sessionId/history/:historyId/grpcurl", historyHandler.ExportGrpcurl)
+		api.DELETE("/sessions/:sessionId/history", historyHandler.ClearHistory)
+		api.DELETE("/sessions/:sessionId/history/:historyId", historyHandler.DeleteHistoryEntry)
+
+		// Audit trail routes
+		auditHandler := handler.NewAuditHandler(sessionManager, auditStore)
+		api.GET("/sessions/:sessionId/audit", auditHandler.ListAudit)
+
+		// Saved request collection routes
+		collectionHandler := handler.NewCollectionHandler(sessionManager, collectionStore, adminUsers)
+		api.GET("/collections", collectionHandler.ListCollections)
+		api.POST("/collections", collectionHandler.CreateCollection)
+		api.GET("/collections/:collectionId", collectionHandler.GetCollection)
+		api.PUT("/collections/:collectionId", collectionHandler.UpdateCollection)
+		api.DELETE("/collections/:collectionId", collectionHandler.DeleteCollection)
+		api.POST("/collections/:collectionId/requests", collectionHandler.AddRequest)
+		api.PUT("/collections/:collectionId/requests/:requestId", collectionHandler.UpdateRequest)
+		api.DELETE("/collections/:collectionId/requests/:requestId", collectionHandler.DeleteRequest)
 
 		// Proto file routes (directory structure)
-		protoHandler := handler.NewProtoHandler(sessionManager, wsHub, uploadDir)
+		protoHandler := handler.NewProtoHandler(sessionManager, wsHub, uploadDir, stdlibManager, importFetcher)
+		protoHandler.SetUploadLimits(uploadLimits)
 		api.POST("/proto/upload-structure", protoHandler.UploadStructure)
+		api.POST("/proto/upload-archive", protoHandler.UploadArchive)
+		api.POST("/proto/import-git", protoHandler.ImportGit)
+		api.POST("/proto/upload-protoset", protoHandler.UploadProtoset)
+		api.POST("/proto/save-reflection", protoHandler.SaveReflection)
 		api.GET("/sessions/:sessionId/files", protoHandler.ListFiles)
 		api.GET("/sessions/:sessionId/file-content", protoHandler.GetFileContent)
+		api.PUT("/sessions/:sessionId/file-content", protoHandler.UpdateFileContent)
+		api.GET("/sessions/:sessionId/search", protoHandler.SearchFiles)
+		api.GET("/sessions/:sessionId/download", protoHandler.DownloadSession)
+		api.GET("/sessions/:sessionId/file-download", protoHandler.DownloadFile)
 		api.GET("/sessions/:sessionId/analyze", protoHandler.AnalyzeDependencies)
+		api.POST("/sessions/:sessionId/validate", protoHandler.ValidateSession)
+		api.GET("/sessions/:sessionId/lint", protoHandler.LintSession)
+		api.GET("/sessions/:sessionId/breaking", protoHandler.DetectBreakingChanges)
 		api.GET("/proto/stdlib", protoHandler.ListStdlibFiles)
 		api.GET("/proto/stdlib-content", protoHandler.GetStdlibFileContent)
 
+		// Chunked, resumable archive uploads
+		chunkedUploadHandler := handler.NewChunkedUploadHandler(protoHandler, chunkedUploadTTL)
+		sessionManager.OnDelete(chunkedUploadHandler.DiscardSession)
+		api.POST("/sessions/:sessionId/uploads", chunkedUploadHandler.CreateUpload)
+		api.GET("/sessions/:sessionId/uploads/:uploadId", chunkedUploadHandler.GetUpload)
+		api.PUT("/sessions/:sessionId/uploads/:uploadId", chunkedUploadHandler.PutChunk)
+		api.POST("/sessions/:sessionId/uploads/:uploadId/complete", chunkedUploadHandler.CompleteUpload)
+		api.DELETE("/sessions/:sessionId/uploads/:uploadId", chunkedUploadHandler.AbortUpload)
+
+		// Session bundle routes (export/import of a whole session)
+		bundleHandler := handler.NewBundleHandler(protoHandler, collectionStore)
+		api.GET("/sessions/:sessionId/bundle", bundleHandler.ExportBundle)
+		api.POST("/sessions/import", bundleHandler.ImportBundle)
+
+		// Signed download URLs for files, proto archives and bundles
+		downloadURLHandler := handler.NewDownloadURLHandler(sessionManager, urlSigner)
+		api.POST("/sessions/:sessionId/download-url", downloadURLHandler.CreateDownloadURL)
+
+		// Admin routes, for the users listed in ADMIN_USERS only
+		admin := api.Group("/admin", middleware.RequireAdmin(authenticator, adminUsers))
+
+		// Shared proto libraries
+		commonProtosHandler := handler.NewCommonProtosHandler(stdlibManager)
+		// Libraries registered through the API must be inside COMMON_PROTO_ROOTS
+		// or one of the COMMON_PROTO_DIRS directories
+		commonProtosHandler.SetAllowedRoots(append(append([]string{}, cfg.GRPC.CommonProtoRoots...), commonProtoDirs...))
+		admin.GET("/common-protos", commonProtosHandler.ListLibraries)
+		admin.POST("/common-protos", commonProtosHandler.RegisterLibrary)
+		admin.DELETE("/common-protos/:name", commonProtosHandler.DeleteLibrary)
+
+		// Server status and broadcasts to every connected client
+		admin.GET("/status", statusHandler.GetStatus)
+		admin.PUT("/maintenance", statusHandler.SetMaintenance)
+		admin.POST("/notices", statusHandler.BroadcastNotice)
+
+		// Upload directory maintenance
+		orphanHandler := handler.NewOrphanHandler(sessionManager, orphanOptions)
+		admin.POST("/orphans/reconcile", orphanHandler.Reconcile)
+		storageHandler := handler.NewStorageHandler(sessionManager)
+		admin.GET("/storage", storageHandler.GetStorage)
+		admin.POST("/storage/cleanup", storageHandler.Cleanup)
+
+		// Offline schema introspection routes (no target connection needed)
+		schemaHandler := handler.NewSchemaHandler(sessionManager, grpcClient)
+		api.GET("/sessions/:sessionId/describe", schemaHandler.DescribeService)
+		api.GET("/sessions/:sessionId/types/:typeName", schemaHandler.GetType)
+		api.GET("/sessions/:sessionId/enums", schemaHandler.ListEnums)
+		api.GET("/sessions/:sessionId/http-rules", schemaHandler.ListHTTPRules)
+		api.GET("/sessions/:sessionId/openapi", schemaHandler.GenerateOpenAPI)
+
 		// gRPC proxy routes
-		grpcHandler := handler.NewGRPCHandler(sessionManager, grpcProxy, wsHub)
+		grpcHandler := handler.NewGRPCHandler(sessionManager, grpcClient, wsHub, historyStore, callStats)
+		// Responses over WS_PREVIEW_SIZE bytes of JSON (default 256 KiB) are
+		// sent in events as a preview; the full JSON is kept for
+		// WS_PREVIEW_RETENTION (default 10m) and fetched in pages
+		responsePayloads := payload.NewStore(cfg.WebSocket.PreviewRetention.Std(), payload.DefaultMaxBytes)
+		grpcHandler.SetResponsePreview(cfg.WebSocket.PreviewSize, responsePayloads)
+		sessionManager.OnDelete(responsePayloads.Forget)
+		// Unary calls run on CALL_WORKERS workers (default 64); up to
+		// CALL_QUEUE_SIZE more (default 1024), CALL_QUEUE_SESSION_SIZE per
+		// session (default 64), wait for one and are told their position
+		grpcHandler.SetCallPool(cfg.GRPC.CallWorkers, cfg.GRPC.CallQueueSize, cfg.GRPC.CallQueueSessionSize)
+		sessionManager.OnDelete(grpcHandler.CancelSessionCalls)
+		// Calls can also be driven over the session's WebSocket
+		wsHub.SetCommandHandler(grpcHandler.HandleCommand)
 		api.POST("/grpc/call", grpcHandler.CallGRPC)
+		api.POST("/grpc/replay/:historyId", grpcHandler.ReplayCall)
+		api.POST("/grpc/chain", grpcHandler.CallChain)
+		api.POST("/grpc/export/grpcurl", grpcHandler.ExportGrpcurl)
+		api.POST("/grpc/loadtest", grpcHandler.LoadTest)
 		api.POST("/grpc/services", grpcHandler.ListServices)
 		api.POST("/grpc/describe", grpcHandler.DescribeService)
+		api.POST("/grpc/describe-method", grpcHandler.DescribeMethod)
+		api.POST("/grpc/describe-message", grpcHandler.DescribeMessage)
+		api.GET("/grpc/skeleton", grpcHandler.GetSkeleton)
+		api.GET("/sessions/:sessionId/stats", grpcHandler.GetCallStats)
+		api.GET("/sessions/:sessionId/responses/:responseId", grpcHandler.GetResponse)
+
+		// Profiling and runtime statistics, for admins only
+		if debugEndpoints {
+			debugHandler := handler.NewDebugHandler(sessionManager, grpcHandler, wsHub, httpConns)
+			debug := router.Group("/debug", middleware.Auth(authenticator), middleware.RequireAdmin(authenticator, adminUsers))
+			debug.GET("/runtime", debugHandler.GetRuntime)
+			debug.GET("/pprof/*name", debugHandler.Pprof)
+			debug.POST("/pprof/*name", debugHandler.Pprof)
+			log.Println("Debug endpoints enabled at /debug/pprof/ and /debug/runtime")
+		}
 	}
 
-	// Serve static files (embedded frontend)
+	// Serve static files (embedded frontend); unknown API routes get a JSON 404
+	apiNotFound := middleware.APINotFound("/api/")
 	staticHandler, err := static.GetFileServer()
 	if err != nil {
 		log.Printf("[Warning] Failed to load embedded static files: %v", err)
 		log.Println("[Warning] Static file serving disabled")
+		router.NoRoute(apiNotFound)
 	} else {
 		// Serve index.html for SPA routes
-		router.NoRoute(gin.WrapH(staticHandler))
+		router.NoRoute(apiNotFound, middleware.RequireLogin(authenticator), gin.WrapH(staticHandler))
 		log.Println("[Static] Serving embedded frontend from /")
 	}
 
-	log.Printf("Starting gRPC Bridge Web API on port %s", port)
-	if err := router.Run(":" + port); err != nil {
+	// Native HTTPS: TLS_CERT_FILE and TLS_KEY_FILE name a PEM certificate and
+	// key, or TLS_AUTOCERT_DOMAINS (comma-separated) obtains certificates from
+	// Let's Encrypt, cached in TLS_AUTOCERT_CACHE. HTTP_REDIRECT_PORT
+	// additionally serves plain HTTP that redirects to HTTPS (and answers
+	// ACME challenges).
+	tlsOptions := tlsconfig.Options{
+		CertFile:        cfg.TLS.CertFile,
+		KeyFile:         cfg.TLS.KeyFile,
+		AutocertDomains: cfg.TLS.AutocertDomains,
+		AutocertCache:   cfg.TLS.AutocertCache,
+		AutocertEmail:   cfg.TLS.AutocertEmail,
+	}
+	if tlsOptions.AutocertCache == "" {
+		tlsOptions.AutocertCache = filepath.Join(uploadDir, ".autocert")
+	}
+	tlsSetup, err := tlsconfig.New(tlsOptions)
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: 30 * time.Second,
+		ConnState:         httpConns.Track,
+	}
+	if tlsSetup == nil {
+		log.Printf("Starting gRPC Bridge Web API on port %s", port)
+		if err := server.ListenAndServe(); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+		return
+	}
+
+	if cfg.TLS.HTTPRedirectPort != 0 {
+		redirectPort := strconv.Itoa(cfg.TLS.HTTPRedirectPort)
+		redirect := tlsconfig.RedirectToHTTPS(port)
+		if tlsSetup.Challenge != nil {
+			redirect = tlsSetup.Challenge(redirect)
+		}
+		go func() {
+			log.Printf("Redirecting HTTP on port %s to HTTPS", redirectPort)
+			redirectServer := &http.Server{Addr: ":" + redirectPort, Handler: redirect, ReadHeaderTimeout: 30 * time.Second}
+			if err := redirectServer.ListenAndServe(); err != nil {
+				log.Fatalf("Failed to start HTTP redirect server: %v", err)
+			}
+		}()
+	}
+	server.TLSConfig = tlsSetup.Config
+	log.Printf("TLS: %s", tlsSetup.Description)
+	log.Printf("Starting gRPC Bridge Web API on port %s (HTTPS)", port)
+	if err := server.ListenAndServeTLS("", ""); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
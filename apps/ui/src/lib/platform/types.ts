@@ -122,6 +122,7 @@ export interface GRPCError {
   exit_code?: number;
   took_ms?: number;
   kind?: string;
+  code?: string; // Stable error code from the web server (e.g. TARGET_UNREACHABLE)
 }
 
 export interface GRPCManager {
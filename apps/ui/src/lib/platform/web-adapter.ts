@@ -82,13 +82,16 @@ class WebEventManager implements EventManager {
   private maxReconnectAttempts = 5;
 
   constructor() {
-    this.connect();
+    void this.connect();
   }
 
-  private connect(): void {
+  private async connect(): Promise<void> {
     try {
       const sessionId = this.getSessionId();
-      this.ws = new WebSocket(`${WS_BASE_URL}/api/ws?sessionId=${sessionId}`);
+      const token = await this.fetchToken(sessionId);
+      this.ws = new WebSocket(
+        `${WS_BASE_URL}/api/ws?sessionId=${encodeURIComponent(sessionId)}&token=${encodeURIComponent(token)}`
+      );
 
       this.ws.onopen = () => {
         console.log('[WebEventManager] WebSocket connected');
@@ -120,6 +123,19 @@ class WebEventManager implements EventManager {
     }
   }
 
+  // The server only accepts WebSocket upgrades carrying a short-lived token
+  // for the session, so a fresh one is fetched before every (re)connect.
+  private async fetchToken(sessionId: string): Promise<string> {
+    const response = await fetch(`${API_BASE_URL}/api/sessions/${encodeURIComponent(sessionId)}/ws-token`, {
+      method: 'POST',
+    });
+    if (!response.ok) {
+      throw new Error(`Failed to get WebSocket token: HTTP ${response.status}`);
+    }
+    const { token } = (await response.json()) as { token: string };
+    return token;
+  }
+
   private scheduleReconnect(): void {
     if (this.reconnectAttempts >= this.maxReconnectAttempts) {
       console.error('[WebEventManager] Max reconnect attempts reached');
@@ -135,7 +151,7 @@ class WebEventManager implements EventManager {
 
     console.log(`[WebEventManager] Reconnecting in ${delay}ms (attempt ${this.reconnectAttempts})`);
     this.reconnectTimer = setTimeout(() => {
-      this.connect();
+      void this.connect();
     }, delay);
   }
 
@@ -29,7 +29,7 @@ echo -e "${GREEN}✓ Frontend copied to apps/server/internal/static/dist/${NC}"
 # Step 3: Build Go server
 echo -e "${BLUE}[3/3] Building Go server with embedded frontend...${NC}"
 cd apps/server
-go build -o ../../dist/bin/grpc-bridge-server ./main.go
+go build -o ../../dist/bin/grpc-bridge-server .
 
 echo ""
 echo -e "${GREEN}=========================================${NC}"